@@ -1,10 +1,13 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
+	"os"
 
+	"github.com/akrovv/warehouse/internal/adapters/memory"
 	"github.com/akrovv/warehouse/internal/adapters/postgresql"
 	"github.com/akrovv/warehouse/internal/config"
 	"github.com/akrovv/warehouse/internal/handlers/jsonrpc"
@@ -20,6 +23,11 @@ const (
 	openConns  = 10
 )
 
+const (
+	driverPostgres = "postgres"
+	driverMemory   = "memory"
+)
+
 func main() {
 	logger, err := logger.NewLogger()
 	if err != nil {
@@ -38,31 +46,79 @@ func main() {
 		cfg.Database.User, cfg.Database.Password,
 		cfg.Database.Name, cfg.Database.SslMode)
 
-	db, err := sql.Open("postgres", dsn)
-	if err != nil {
-		logger.Fatalf("can't open database, %w", err)
-		return
-	}
-	defer db.Close()
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if cfg.Storage.Driver == driverMemory {
+			logger.Fatalf("storage driver %q has no schema to migrate", cfg.Storage.Driver)
+			return
+		}
 
-	db.SetMaxOpenConns(openConns)
+		if err = migrate(dsn, os.Args[2:]); err != nil {
+			logger.Fatalf("can't migrate, %w", err)
+		}
 
-	if err = db.Ping(); err != nil {
-		logger.Fatalf("can't connect to database, %w", err)
 		return
 	}
 
 	var (
-		productStorage   = postgresql.NewProductStorage(db)
-		warehouseStorage = postgresql.NewWarehouseStorage(db)
+		productStorage   services.ProductStorage
+		warehouseStorage services.WarehouseStorage
+		movementStorage  services.MovementStorage
+		purchaseStorage  services.PurchaseStorage
+		returnStorage    services.ReturnStorage
+		countStorage     services.CountStorage
 	)
 
+	switch cfg.Storage.Driver {
+	case driverPostgres, "":
+		db, err := openPostgres(dsn)
+		if err != nil {
+			logger.Fatalf("can't connect to database, %w", err)
+			return
+		}
+		defer db.Close()
+
+		if err = checkSchema(db); err != nil {
+			logger.Fatalf("can't start, %w", err)
+			return
+		}
+
+		productStorage = postgresql.NewProductStorage(db)
+		warehouseStorage = postgresql.NewWarehouseStorage(db)
+		movementStorage = postgresql.NewMovementStorage(db)
+		purchaseStorage = postgresql.NewPurchaseStorage(db)
+		returnStorage = postgresql.NewReturnStorage(db)
+		countStorage = postgresql.NewCountStorage(db)
+	case driverMemory:
+		db := memory.NewDB()
+
+		productStorage = memory.NewProductStorage(db)
+		warehouseStorage = memory.NewWarehouseStorage(db)
+		movementStorage = memory.NewMovementStorage(db)
+		purchaseStorage = memory.NewPurchaseStorage(db)
+		returnStorage = memory.NewReturnStorage(db)
+		countStorage = memory.NewCountStorage(db)
+	default:
+		logger.Fatalf("unknown storage driver %q", cfg.Storage.Driver)
+		return
+	}
+
 	var (
-		productService   = services.NewProductService(productStorage)
+		productService   = services.NewProductService(productStorage, cfg.Reservations.TTL, cfg.Adjustments.ApprovalThreshold)
 		warehouseService = services.NewWarehouseService(warehouseStorage)
+		movementService  = services.NewMovementService(movementStorage)
+		purchaseService  = services.NewPurchaseService(purchaseStorage)
+		returnService    = services.NewReturnService(returnStorage)
+		countService     = services.NewCountService(countStorage, cfg.Adjustments.ApprovalThreshold)
 	)
 
-	server, err := jsonrpc.NewServer(productService, warehouseService, logger)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sweeper := services.NewReservationSweeper(productStorage, cfg.Reservations.SweepInterval, logger)
+	go sweeper.Run(ctx)
+
+	server, err := jsonrpc.NewServer(productService, warehouseService, movementService, purchaseService, returnService,
+		countService, logger, cfg.Server.RequestTimeout)
 
 	if err != nil {
 		return
@@ -74,3 +130,30 @@ func main() {
 		return
 	}
 }
+
+// checkSchema refuses to serve a database migrated to an older version than
+// this build needs.
+func checkSchema(db *sql.DB) error {
+	migrator, err := postgresql.NewMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	return migrator.Check(context.Background())
+}
+
+func openPostgres(dsn string) (*sql.DB, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	db.SetMaxOpenConns(openConns)
+
+	if err = db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
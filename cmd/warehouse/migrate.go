@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/akrovv/warehouse/internal/adapters/postgresql"
+)
+
+var errMigrateUsage = errors.New("usage: migrate up|down|status")
+
+// migrate runs the migrate subcommand against the PostgreSQL database.
+func migrate(dsn string, args []string) error {
+	if len(args) != 1 {
+		return errMigrateUsage
+	}
+
+	db, err := openPostgres(dsn)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	migrator, err := postgresql.NewMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, m := range applied {
+			fmt.Printf("applied %04d_%s\n", m.Version, m.Name)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("schema is at version %d\n", migrator.Version())
+	case "down":
+		reverted, err := migrator.Down(ctx)
+		if err != nil {
+			return err
+		}
+
+		if reverted == nil {
+			fmt.Println("nothing to revert")
+			return nil
+		}
+
+		fmt.Printf("reverted %04d_%s\n", reverted.Version, reverted.Name)
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, s := range statuses {
+			state := "pending"
+			if s.AppliedAt != nil {
+				state = "applied at " + s.AppliedAt.Format(time.RFC3339)
+			}
+
+			fmt.Printf("%04d_%s\t%s\n", s.Version, s.Name, state)
+		}
+	default:
+		return errMigrateUsage
+	}
+
+	return nil
+}
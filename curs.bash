@@ -10,7 +10,8 @@ show_menu() {
     echo "6. Products.Add"
     echo "7. Products.Transfer" 
     echo "8. Products.Delete"
-    echo "9. Выйти"
+    echo "9. Products.Fulfill"
+    echo "10. Выйти"
 }
 
 create_warehouse() {
@@ -54,8 +55,9 @@ reserve_products() {
     read -p "ID склада: " warehouse_id
     read -p "Количество продуктов для резервирования: " quantity
     read -p "Код продукта для резервирования: " code
+    read -p "Ссылка на заказ: " order_ref
 
-    json_data='{"jsonrpc":"2.0", "id": 1, "method": "Products.Reserve", "params": [[{"warehouse_id": '$warehouse_id', "quantity": '$quantity', "code": "'"$code"'"}]]}'
+    json_data='{"jsonrpc":"2.0", "id": 1, "method": "Products.Reserve", "params": [[{"warehouse_id": '$warehouse_id', "quantity": '$quantity', "code": "'"$code"'", "order_ref": "'"$order_ref"'"}]]}'
 
     curl_response=$(curl -s -X POST -H "Content-Type: application/json" -d "$json_data" http://localhost:8080/)
     echo "Ответ сервера:"
@@ -64,11 +66,20 @@ reserve_products() {
 }
 
 cancel_reservation() {
-    read -p "ID склада: " warehouse_id
-    read -p "Количество продуктов для отмены резервирования: " quantity
-    read -p "Код продукта для отмены резервирования: " code
+    read -p "ID резерва: " reservation_id
+
+    json_data='{"jsonrpc":"2.0", "id": 1, "method": "Products.CancelReservation", "params": [[{"reservation_id": '$reservation_id'}]]}'
+
+    curl_response=$(curl -s -X POST -H "Content-Type: application/json" -d "$json_data" http://localhost:8080/)
+    echo "Ответ сервера:"
+    echo "$curl_response"
+    read -n 1 -s -r -p "enter, чтобы продолжить..."
+}
+
+fulfill_reservations() {
+    read -p "ID резервов через запятую: " reservation_ids
 
-    json_data='{"jsonrpc":"2.0", "id": 1, "method": "Products.CancelReservation", "params": [[{"warehouse_id": '$warehouse_id', "quantity": '$quantity', "code": "'"$code"'"}]]}'
+    json_data='{"jsonrpc":"2.0", "id": 1, "method": "Products.Fulfill", "params": [{"reservation_ids": ['$reservation_ids']}]}'
 
     curl_response=$(curl -s -X POST -H "Content-Type: application/json" -d "$json_data" http://localhost:8080/)
     echo "Ответ сервера:"
@@ -126,7 +137,8 @@ handle_choice() {
         6) add_products;;
         7) transfer_products;;
         8) delete_products;;
-        9) exit;;
+        9) fulfill_reservations;;
+        10) exit;;
         *) echo "Некорректный выбор";;
     esac
 }
@@ -0,0 +1,185 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/akrovv/warehouse/internal/domain"
+)
+
+func (s *productStorage) Adjust(ctx context.Context, a *domain.Adjustment) error {
+	code, err := parseCode(a.Code)
+	if err != nil {
+		return err
+	}
+
+	a.Code = code
+
+	return s.db.withTx(ctx, func(st *state) error {
+		if _, ok := st.products[code]; !ok {
+			return fmt.Errorf("%w: product %s", domain.ErrNotFound, code)
+		}
+
+		if _, ok := st.warehouses[a.WarehouseID]; !ok {
+			return fmt.Errorf("%w: warehouse %d", domain.ErrNotFound, a.WarehouseID)
+		}
+
+		if a.Status == domain.AdjustmentApplied {
+			if err := st.adjust(a); err != nil {
+				return err
+			}
+		}
+
+		st.insertAdjustment(a)
+
+		return nil
+	})
+}
+
+func (s *productStorage) ApproveAdjustment(ctx context.Context, d *domain.DecideAdjustment) (*domain.Adjustment, error) {
+	return s.decideAdjustment(ctx, d, true)
+}
+
+func (s *productStorage) RejectAdjustment(ctx context.Context, d *domain.DecideAdjustment) (*domain.Adjustment, error) {
+	return s.decideAdjustment(ctx, d, false)
+}
+
+// decideAdjustment is decideAdjustment of the PostgreSQL storage.
+func (s *productStorage) decideAdjustment(ctx context.Context, d *domain.DecideAdjustment, approve bool) (*domain.Adjustment, error) {
+	a := domain.Adjustment{}
+
+	err := s.db.withTx(ctx, func(st *state) error {
+		var ok bool
+		if a, ok = st.adjustments[d.ID]; !ok {
+			return fmt.Errorf("%w: adjustment %d", domain.ErrNotFound, d.ID)
+		}
+
+		if err := a.Decide(d, approve); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		a.DecidedAt = &now
+		st.adjustments[a.ID] = a
+
+		if a.Status == domain.AdjustmentApplied {
+			return st.adjust(&a)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &a, nil
+}
+
+func (s *productStorage) ListAdjustments(ctx context.Context, f *domain.AdjustmentFilter) ([]domain.Adjustment, error) {
+	code := f.Code
+	if code != "" {
+		var err error
+		if code, err = parseCode(code); err != nil {
+			return nil, err
+		}
+	}
+
+	adjustments := make([]domain.Adjustment, 0, domain.BasicSliceLength)
+
+	err := s.db.read(ctx, func(st *state) error {
+		for _, a := range st.adjustments {
+			switch {
+			case a.Status != f.Status,
+				f.WarehouseID != 0 && a.WarehouseID != f.WarehouseID,
+				code != "" && a.Code != code:
+				continue
+			}
+
+			adjustments = append(adjustments, a)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(adjustments, func(i, j int) bool { return adjustments[i].ID < adjustments[j].ID })
+
+	return page(adjustments, f.Limit, f.Offset), nil
+}
+
+func (s *state) insertAdjustment(a *domain.Adjustment) {
+	s.adjustmentSeq++
+	a.ID = s.adjustmentSeq
+	a.CreatedAt = time.Now()
+
+	stored := *a
+	stored.Lots, stored.Bins = nil, nil
+	s.adjustments[a.ID] = stored
+}
+
+// adjust is adjust of the PostgreSQL storage.
+func (s *state) adjust(a *domain.Adjustment) error {
+	p, ok := s.products[a.Code]
+	if !ok {
+		return fmt.Errorf("%w: product %s", domain.ErrNotFound, a.Code)
+	}
+
+	c := stockChange{
+		movementType: domain.MovementAdjust,
+		reason:       a.Reason,
+		requestID:    a.RequestID,
+		warehouseID:  a.WarehouseID,
+		code:         a.Code,
+	}
+
+	switch {
+	case a.Bucket != domain.BucketAvailable:
+		if err := s.changeBucket(c, a.Bucket, a.Quantity); err != nil {
+			return err
+		}
+	case a.Quantity < 0:
+		c.available = a.Quantity
+		if err := s.changeStock(c); err != nil {
+			return err
+		}
+
+		quantity := uint64(-a.Quantity)
+
+		lots, err := s.takeLots(lotPick{
+			warehouseID: a.WarehouseID,
+			code:        a.Code,
+			quantity:    quantity,
+			number:      a.Lot,
+		})
+
+		if err != nil {
+			return err
+		}
+
+		a.Lots = lots
+		a.Bins = s.takeBins(a.WarehouseID, a.Code, quantity, false)
+	default:
+		c.available = a.Quantity
+		if err := s.receiveStock(c); err != nil {
+			return err
+		}
+
+		key, err := s.receiveLot(a.Code, nil)
+		if err != nil {
+			return err
+		}
+
+		s.changeLot(a.WarehouseID, key, a.Quantity, 0)
+	}
+
+	p.Quantity = uint64(int64(p.Quantity) + a.Quantity)
+	s.products[a.Code] = p
+
+	return nil
+}
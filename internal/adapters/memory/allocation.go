@@ -0,0 +1,70 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/akrovv/warehouse/internal/domain"
+)
+
+func (s *productStorage) Allocate(ctx context.Context, a *domain.Allocation) ([]domain.Reservation, error) {
+	code, err := parseCode(a.Code)
+	if err != nil {
+		return nil, err
+	}
+
+	var reservations []domain.Reservation
+
+	err = s.db.withTx(ctx, func(st *state) error {
+		wps, err := a.Plan(st.reservableStock(code))
+		if err != nil {
+			return err
+		}
+
+		reservations = make([]domain.Reservation, 0, len(wps))
+		for i := range wps {
+			r := domain.NewReservation(&wps[i], a.ExpiresAt)
+			if err = st.reserve(&r); err != nil {
+				return err
+			}
+
+			reservations = append(reservations, r)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return reservations, nil
+}
+
+// reservableStock is reservableStock of the PostgreSQL storage.
+func (s *state) reservableStock(code string) []domain.WarehouseStock {
+	now := time.Now()
+	stock := make([]domain.WarehouseStock, 0, domain.BasicSliceLength)
+
+	for key := range s.stock {
+		switch {
+		case key.code != code,
+			!s.warehouses[key.warehouseID].Availability:
+			continue
+		}
+
+		ws := domain.WarehouseStock{WarehouseID: key.warehouseID}
+		for _, lot := range s.availableLots(key.warehouseID, code) {
+			if !lot.Expired(now) {
+				ws.Available += lot.Quantity
+			}
+		}
+
+		stock = append(stock, ws)
+	}
+
+	sort.Slice(stock, func(i, j int) bool { return stock[i].WarehouseID < stock[j].WarehouseID })
+
+	return stock
+}
@@ -0,0 +1,193 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/akrovv/warehouse/internal/domain"
+)
+
+type binStockKey struct {
+	binID int64
+	code  string
+}
+
+func (s *warehouseStorage) CreateBin(ctx context.Context, bin *domain.Bin) error {
+	return s.db.withTx(ctx, func(st *state) error {
+		if _, ok := st.warehouses[bin.WarehouseID]; !ok {
+			return fmt.Errorf("%w: warehouse %d", domain.ErrNotFound, bin.WarehouseID)
+		}
+
+		for _, b := range st.bins {
+			if b.WarehouseID == bin.WarehouseID && b.Zone == bin.Zone && b.Aisle == bin.Aisle &&
+				b.Rack == bin.Rack && b.Bin == bin.Bin {
+				return fmt.Errorf("%w: bin %+v already exists", domain.ErrDuplicateCode, *bin)
+			}
+		}
+
+		st.binSeq++
+		bin.ID = st.binSeq
+		st.bins[bin.ID] = *bin
+
+		return nil
+	})
+}
+
+func (s *warehouseStorage) ListBins(ctx context.Context, lb *domain.ListBins) ([]domain.Bin, error) {
+	bins := make([]domain.Bin, 0, domain.BasicSliceLength)
+
+	err := s.db.read(ctx, func(st *state) error {
+		bins = st.sortedBins(lb.WarehouseID)
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return bins, nil
+}
+
+func (s *productStorage) Relocate(ctx context.Context, rl *domain.Relocation) error {
+	code, err := parseCode(rl.Code)
+	if err != nil {
+		return err
+	}
+
+	return s.db.withTx(ctx, func(st *state) error {
+		wp, ok := st.stock[stockKey{warehouseID: rl.WarehouseID, code: code}]
+		if !ok {
+			return fmt.Errorf("%w: product %s in warehouse %d", domain.ErrNotFound, code, rl.WarehouseID)
+		}
+
+		if w := st.warehouses[rl.WarehouseID]; !w.Availability {
+			return fmt.Errorf("%w: relocation in no available warehouse %d", domain.ErrWarehouseUnavailable, rl.WarehouseID)
+		}
+
+		if err := st.checkBins(rl.WarehouseID, rl.FromBinID, rl.ToBinID); err != nil {
+			return err
+		}
+
+		fromAvailable := st.binStock[binStockKey{binID: rl.FromBinID, code: code}].available
+		if rl.FromBinID == 0 {
+			fromAvailable = wp.available - st.placed(rl.WarehouseID, code)
+		}
+
+		if fromAvailable < rl.Quantity {
+			return fmt.Errorf("bin %d: %w", rl.FromBinID, &domain.InsufficientStockError{
+				WarehouseID: rl.WarehouseID,
+				Code:        code,
+				Bucket:      domain.BucketAvailable,
+				Requested:   rl.Quantity,
+				Available:   fromAvailable,
+			})
+		}
+
+		if rl.FromBinID != 0 {
+			st.changeBin(rl.FromBinID, code, -int64(rl.Quantity), 0)
+		}
+
+		if rl.ToBinID != 0 {
+			st.changeBin(rl.ToBinID, code, int64(rl.Quantity), 0)
+		}
+
+		return nil
+	})
+}
+
+// checkBins returns ErrNotFound unless every bin id but 0 is a bin of the
+// warehouse.
+func (s *state) checkBins(warehouseID int64, binIDs ...int64) error {
+	for _, id := range binIDs {
+		if b, ok := s.bins[id]; id != 0 && (!ok || b.WarehouseID != warehouseID) {
+			return fmt.Errorf("%w: bin %d in warehouse %d", domain.ErrNotFound, id, warehouseID)
+		}
+	}
+
+	return nil
+}
+
+// placed is available stock of the product put into bins of the warehouse.
+func (s *state) placed(warehouseID int64, code string) uint64 {
+	placed := uint64(0)
+	for _, b := range s.sortedBins(warehouseID) {
+		placed += s.binStock[binStockKey{binID: b.ID, code: code}].available
+	}
+
+	return placed
+}
+
+// takeBins is takeBins of the PostgreSQL storage.
+func (s *state) takeBins(warehouseID int64, code string, quantity uint64, reserve bool) []domain.BinQuantity {
+	bins := make([]domain.BinQuantity, 0, domain.BasicSliceLength)
+	for _, b := range s.sortedBins(warehouseID) {
+		if st := s.binStock[binStockKey{binID: b.ID, code: code}]; st.available > 0 {
+			bins = append(bins, domain.BinQuantity{Bin: b, Quantity: st.available})
+		}
+	}
+
+	picked := domain.PickBins(bins, quantity)
+	for _, bin := range picked {
+		reserved := int64(0)
+		if reserve {
+			reserved = int64(bin.Quantity)
+		}
+
+		s.changeBin(bin.ID, code, -int64(bin.Quantity), reserved)
+	}
+
+	return picked
+}
+
+func (s *state) changeBin(binID int64, code string, available, reserved int64) {
+	key := binStockKey{binID: binID, code: code}
+	st := s.binStock[key]
+
+	s.binStock[key] = stock{
+		available: uint64(int64(st.available) + available),
+		reserved:  uint64(int64(st.reserved) + reserved),
+	}
+}
+
+// stockByBin returns stock of the product in bins of the warehouse, bins
+// that hold neither available nor reserved units are left out.
+func (s *state) stockByBin(warehouseID int64, code string) []domain.BinStock {
+	bins := make([]domain.BinStock, 0, domain.BasicSliceLength)
+	for _, b := range s.sortedBins(warehouseID) {
+		st := s.binStock[binStockKey{binID: b.ID, code: code}]
+		if st.available > 0 || st.reserved > 0 {
+			bins = append(bins, domain.BinStock{Bin: b, Available: st.available, Reserved: st.reserved})
+		}
+	}
+
+	return bins
+}
+
+// sortedBins returns bins of the warehouse in the order pickers walk it.
+func (s *state) sortedBins(warehouseID int64) []domain.Bin {
+	bins := make([]domain.Bin, 0, domain.BasicSliceLength)
+	for _, b := range s.bins {
+		if b.WarehouseID == warehouseID {
+			bins = append(bins, b)
+		}
+	}
+
+	sort.Slice(bins, func(i, j int) bool {
+		a, b := bins[i], bins[j]
+		switch {
+		case a.Zone != b.Zone:
+			return a.Zone < b.Zone
+		case a.Aisle != b.Aisle:
+			return a.Aisle < b.Aisle
+		case a.Rack != b.Rack:
+			return a.Rack < b.Rack
+		case a.Bin != b.Bin:
+			return a.Bin < b.Bin
+		}
+
+		return a.ID < b.ID
+	})
+
+	return bins
+}
@@ -0,0 +1,73 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/akrovv/warehouse/internal/domain"
+)
+
+func (s *productStorage) MoveStock(ctx context.Context, m *domain.BucketMove) error {
+	code, err := parseCode(m.Code)
+	if err != nil {
+		return err
+	}
+
+	return s.db.withTx(ctx, func(st *state) error {
+		return st.moveStock(m, code)
+	})
+}
+
+// moveStock is moveStock of the PostgreSQL storage.
+func (s *state) moveStock(m *domain.BucketMove, code string) error {
+	c := stockChange{
+		movementType: domain.MovementBucketMove,
+		reason:       m.Reason,
+		requestID:    m.RequestID,
+		warehouseID:  m.WarehouseID,
+		code:         code,
+	}
+
+	if m.From != domain.BucketAvailable {
+		if err := s.changeBucket(c, m.From, -int64(m.Quantity)); err != nil {
+			return err
+		}
+	} else {
+		out := c
+		out.available = -int64(m.Quantity)
+		if err := s.changeStock(out); err != nil {
+			return err
+		}
+
+		lots, err := s.takeLots(lotPick{
+			warehouseID: m.WarehouseID,
+			code:        code,
+			quantity:    m.Quantity,
+			number:      m.Lot,
+		})
+
+		if err != nil {
+			return err
+		}
+
+		m.Lots = lots
+		m.Bins = s.takeBins(m.WarehouseID, code, m.Quantity, false)
+	}
+
+	if m.To != domain.BucketAvailable {
+		return s.changeBucket(c, m.To, int64(m.Quantity))
+	}
+
+	c.available = int64(m.Quantity)
+	if err := s.changeStock(c); err != nil {
+		return err
+	}
+
+	key, err := s.receiveLot(code, nil)
+	if err != nil {
+		return err
+	}
+
+	s.changeLot(m.WarehouseID, key, int64(m.Quantity), 0)
+
+	return nil
+}
@@ -0,0 +1,23 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/akrovv/warehouse/internal/adapters/storagetest"
+)
+
+func TestConformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storagetest.Storages {
+		db := NewDB()
+
+		return storagetest.Storages{
+			Products:                NewProductStorage(db),
+			Warehouses:              NewWarehouseStorage(db),
+			Movements:               NewMovementStorage(db),
+			Purchases:               NewPurchaseStorage(db),
+			Returns:                 NewReturnStorage(db),
+			Counts:                  NewCountStorage(db),
+			InsertWarehouseProducts: db.InsertWarehouseProducts,
+		}
+	})
+}
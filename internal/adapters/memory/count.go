@@ -0,0 +1,234 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/akrovv/warehouse/internal/domain"
+)
+
+type countStorage struct {
+	db *DB
+}
+
+func NewCountStorage(db *DB) *countStorage {
+	return &countStorage{
+		db: db,
+	}
+}
+
+func (s *countStorage) Open(ctx context.Context, oc *domain.OpenCount) (*domain.CountSession, error) {
+	codes := make([]string, 0, len(oc.Codes))
+	for _, code := range oc.Codes {
+		code, err := parseCode(code)
+		if err != nil {
+			return nil, err
+		}
+
+		codes = append(codes, code)
+	}
+
+	oc = &domain.OpenCount{WarehouseID: oc.WarehouseID, Codes: codes}
+	session := domain.CountSession{}
+
+	err := s.db.withTx(ctx, func(st *state) error {
+		if _, ok := st.warehouses[oc.WarehouseID]; !ok {
+			return fmt.Errorf("%w: warehouse %d", domain.ErrNotFound, oc.WarehouseID)
+		}
+
+		for _, c := range st.counts {
+			if c.WarehouseID == oc.WarehouseID && c.Status == domain.CountOpen {
+				return fmt.Errorf("%w: warehouse %d has open count %d", domain.ErrValidationFailed, oc.WarehouseID, c.ID)
+			}
+		}
+
+		for _, code := range codes {
+			if _, ok := st.products[code]; !ok {
+				return fmt.Errorf("%w: product %s", domain.ErrNotFound, code)
+			}
+		}
+
+		expected := make(map[string]uint64, domain.BasicSliceLength)
+		for key, stock := range st.stock {
+			if key.warehouseID == oc.WarehouseID && (len(codes) == 0 || slices.Contains(codes, key.code)) {
+				expected[key.code] = stock.available + stock.reserved
+			}
+		}
+
+		session = domain.NewCountSession(oc, expected)
+
+		st.countSeq++
+		session.ID = st.countSeq
+		session.CreatedAt = time.Now()
+		session.MovementID = st.movementSeq
+		st.counts[session.ID] = session
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+func (s *countStorage) Get(ctx context.Context, gc *domain.GetCount) (*domain.CountSession, error) {
+	session := domain.CountSession{}
+
+	err := s.db.read(ctx, func(st *state) error {
+		var ok bool
+		if session, ok = st.counts[gc.ID]; !ok {
+			return fmt.Errorf("%w: count %d", domain.ErrNotFound, gc.ID)
+		}
+
+		// Review by the caller sets differences in the lines.
+		session.Lines = slices.Clone(session.Lines)
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+func (s *countStorage) Submit(ctx context.Context, cs *domain.CountSubmission) (*domain.CountSession, error) {
+	lines := make([]domain.CountedQuantity, 0, len(cs.Lines))
+	for _, line := range cs.Lines {
+		code, err := parseCode(line.Code)
+		if err != nil {
+			return nil, err
+		}
+
+		lines = append(lines, domain.CountedQuantity{Code: code, Quantity: line.Quantity})
+	}
+
+	cs = &domain.CountSubmission{SessionID: cs.SessionID, Lines: lines, Replace: cs.Replace}
+	session := domain.CountSession{}
+
+	err := s.db.withTx(ctx, func(st *state) error {
+		var err error
+		if session, err = st.count(cs.SessionID); err != nil {
+			return err
+		}
+
+		for _, line := range cs.Lines {
+			if _, ok := st.products[line.Code]; !ok {
+				return fmt.Errorf("%w: product %s", domain.ErrNotFound, line.Code)
+			}
+		}
+
+		if _, err = session.Submit(cs, st.movedStock(&session)); err != nil {
+			return err
+		}
+
+		st.counts[session.ID] = session
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+// Post is Post of the PostgreSQL storage.
+func (s *countStorage) Post(ctx context.Context, pc *domain.PostCount) (*domain.CountSession, error) {
+	session := domain.CountSession{}
+
+	err := s.db.withTx(ctx, func(st *state) error {
+		var err error
+		if session, err = st.count(pc.SessionID); err != nil {
+			return err
+		}
+
+		adjustments, err := session.Post(pc)
+		if err != nil {
+			return err
+		}
+
+		for i := range adjustments {
+			if adjustments[i].Status == domain.AdjustmentApplied {
+				if err = st.adjust(&adjustments[i]); err != nil {
+					return fmt.Errorf("product %s: %w", adjustments[i].Code, err)
+				}
+			}
+
+			st.insertAdjustment(&adjustments[i])
+		}
+
+		st.closeCount(&session)
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+func (s *countStorage) Cancel(ctx context.Context, cc *domain.CancelCount) (*domain.CountSession, error) {
+	session := domain.CountSession{}
+
+	err := s.db.withTx(ctx, func(st *state) error {
+		var err error
+		if session, err = st.count(cc.SessionID); err != nil {
+			return err
+		}
+
+		if err = session.Cancel(); err != nil {
+			return err
+		}
+
+		st.closeCount(&session)
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+// count returns the session with lines that can be changed, they are shared
+// with the committed state otherwise.
+func (s *state) count(id int64) (domain.CountSession, error) {
+	session, ok := s.counts[id]
+	if !ok {
+		return domain.CountSession{}, fmt.Errorf("%w: count %d", domain.ErrNotFound, id)
+	}
+
+	session.Lines = slices.Clone(session.Lines)
+
+	return session, nil
+}
+
+// movedStock is movedStock of the PostgreSQL storage.
+func (s *state) movedStock(session *domain.CountSession) map[string]int64 {
+	moved := make(map[string]int64, domain.BasicSliceLength)
+	for _, m := range s.movements {
+		if m.WarehouseID == session.WarehouseID && m.ID > session.MovementID &&
+			(m.Bucket == domain.BucketAvailable || m.Bucket == domain.BucketReserved) {
+			moved[m.Code] += m.Delta
+		}
+	}
+
+	return moved
+}
+
+func (s *state) closeCount(session *domain.CountSession) {
+	now := time.Now()
+	session.ClosedAt = &now
+	s.counts[session.ID] = *session
+}
@@ -0,0 +1,369 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/akrovv/warehouse/internal/domain"
+)
+
+type stockKey struct {
+	warehouseID int64
+	code        string
+}
+
+type stock struct {
+	available   uint64
+	reserved    uint64
+	quarantined uint64
+	damaged     uint64
+}
+
+// bucket returns the quantity of a bucket other than available and reserved,
+// nil if there is no such bucket.
+func (st *stock) bucket(name string) *uint64 {
+	switch name {
+	case domain.BucketQuarantined:
+		return &st.quarantined
+	case domain.BucketDamaged:
+		return &st.damaged
+	default:
+		return nil
+	}
+}
+
+// product keeps the insertion order, lists are sorted by id like in the
+// products table.
+type product struct {
+	id int64
+	domain.Product
+}
+
+type state struct {
+	products     map[string]product
+	warehouses   map[int64]domain.Warehouse
+	stock        map[stockKey]stock
+	reservations map[int64]domain.Reservation
+	movements    []domain.Movement
+	lots         map[lotKey]lot
+	stockLots    map[stockLotKey]stock
+	bins         map[int64]domain.Bin
+	binStock     map[binStockKey]stock
+	thresholds   map[stockKey]domain.StockThreshold
+	lowStock     []domain.LowStockEvent
+	transfers    map[int64]domain.TransitTransfer
+	suppliers    map[int64]domain.Supplier
+	orders       map[int64]domain.PurchaseOrder
+	shipments    map[int64]domain.Shipment
+	returns      map[int64]domain.Return
+	adjustments  map[int64]domain.Adjustment
+	counts       map[int64]domain.CountSession
+
+	lotSeq         int64
+	binSeq         int64
+	productSeq     int64
+	warehouseSeq   int64
+	reservationSeq int64
+	shipmentSeq    int64
+	movementSeq    int64
+	lowStockSeq    int64
+	transferSeq    int64
+	supplierSeq    int64
+	orderSeq       int64
+	orderLineSeq   int64
+	returnSeq      int64
+	returnLineSeq  int64
+	adjustmentSeq  int64
+	countSeq       int64
+}
+
+func (s *state) clone() *state {
+	c := *s
+
+	c.products = make(map[string]product, len(s.products))
+	for k, v := range s.products {
+		c.products[k] = v
+	}
+
+	c.warehouses = make(map[int64]domain.Warehouse, len(s.warehouses))
+	for k, v := range s.warehouses {
+		c.warehouses[k] = v
+	}
+
+	c.stock = make(map[stockKey]stock, len(s.stock))
+	for k, v := range s.stock {
+		c.stock[k] = v
+	}
+
+	c.reservations = make(map[int64]domain.Reservation, len(s.reservations))
+	for k, v := range s.reservations {
+		c.reservations[k] = v
+	}
+
+	c.lots = make(map[lotKey]lot, len(s.lots))
+	for k, v := range s.lots {
+		c.lots[k] = v
+	}
+
+	c.stockLots = make(map[stockLotKey]stock, len(s.stockLots))
+	for k, v := range s.stockLots {
+		c.stockLots[k] = v
+	}
+
+	c.bins = make(map[int64]domain.Bin, len(s.bins))
+	for k, v := range s.bins {
+		c.bins[k] = v
+	}
+
+	c.binStock = make(map[binStockKey]stock, len(s.binStock))
+	for k, v := range s.binStock {
+		c.binStock[k] = v
+	}
+
+	c.thresholds = make(map[stockKey]domain.StockThreshold, len(s.thresholds))
+	for k, v := range s.thresholds {
+		c.thresholds[k] = v
+	}
+
+	c.transfers = make(map[int64]domain.TransitTransfer, len(s.transfers))
+	for k, v := range s.transfers {
+		c.transfers[k] = v
+	}
+
+	c.suppliers = make(map[int64]domain.Supplier, len(s.suppliers))
+	for k, v := range s.suppliers {
+		c.suppliers[k] = v
+	}
+
+	c.orders = make(map[int64]domain.PurchaseOrder, len(s.orders))
+	for k, v := range s.orders {
+		c.orders[k] = v
+	}
+
+	c.shipments = make(map[int64]domain.Shipment, len(s.shipments))
+	for k, v := range s.shipments {
+		c.shipments[k] = v
+	}
+
+	c.returns = make(map[int64]domain.Return, len(s.returns))
+	for k, v := range s.returns {
+		c.returns[k] = v
+	}
+
+	c.adjustments = make(map[int64]domain.Adjustment, len(s.adjustments))
+	for k, v := range s.adjustments {
+		c.adjustments[k] = v
+	}
+
+	c.counts = make(map[int64]domain.CountSession, len(s.counts))
+	for k, v := range s.counts {
+		c.counts[k] = v
+	}
+
+	// movements and low stock events are append-only, a rolled back
+	// transaction leaves the committed slice headers untouched.
+	return &c
+}
+
+// DB is an in-memory replacement of the PostgreSQL schema. Every write runs
+// on a copy of the state that replaces it only if the write succeeds, so a
+// failed operation is rolled back as a whole, like a transaction.
+type DB struct {
+	mu    sync.RWMutex
+	state *state
+}
+
+func NewDB() *DB {
+	return &DB{
+		state: &state{
+			products:     make(map[string]product),
+			warehouses:   make(map[int64]domain.Warehouse),
+			stock:        make(map[stockKey]stock),
+			reservations: make(map[int64]domain.Reservation),
+			movements:    make([]domain.Movement, 0, domain.BasicSliceLength),
+			lots:         make(map[lotKey]lot),
+			stockLots:    make(map[stockLotKey]stock),
+			bins:         make(map[int64]domain.Bin),
+			binStock:     make(map[binStockKey]stock),
+			thresholds:   make(map[stockKey]domain.StockThreshold),
+			lowStock:     make([]domain.LowStockEvent, 0, domain.BasicSliceLength),
+			transfers:    make(map[int64]domain.TransitTransfer),
+			suppliers:    make(map[int64]domain.Supplier),
+			orders:       make(map[int64]domain.PurchaseOrder),
+			shipments:    make(map[int64]domain.Shipment),
+			returns:      make(map[int64]domain.Return),
+			adjustments:  make(map[int64]domain.Adjustment),
+			counts:       make(map[int64]domain.CountSession),
+		},
+	}
+}
+
+// InsertWarehouseProducts is the insertWarehouseProducts procedure: the
+// product appears in the warehouse with its whole quantity available in the
+// default lot.
+func (db *DB) InsertWarehouseProducts(ctx context.Context, warehouseID int64, code string) error {
+	return db.withTx(ctx, func(s *state) error {
+		code, err := parseCode(code)
+		if err != nil {
+			return err
+		}
+
+		p, ok := s.products[code]
+		if !ok {
+			return fmt.Errorf("%w: product %s", domain.ErrNotFound, code)
+		}
+
+		if err = s.insertStock(warehouseID, code, stock{available: p.Quantity}); err != nil {
+			return err
+		}
+
+		key, err := s.receiveLot(code, nil)
+		if err != nil {
+			return err
+		}
+
+		s.changeLot(warehouseID, key, int64(p.Quantity), 0)
+
+		return nil
+	})
+}
+
+// read and withTx check ctx after the lock is taken too, a caller could have
+// given up while waiting for it.
+func (db *DB) read(ctx context.Context, fn func(s *state) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return fn(db.state)
+}
+
+func (db *DB) withTx(ctx context.Context, fn func(s *state) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	tx := db.state.clone()
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	db.state = tx
+
+	return nil
+}
+
+func (s *state) insertStock(warehouseID int64, code string, st stock) error {
+	if err := s.checkAvailability(warehouseID); err != nil {
+		return err
+	}
+
+	if _, ok := s.warehouses[warehouseID]; !ok {
+		return fmt.Errorf("%w: warehouse %d", domain.ErrNotFound, warehouseID)
+	}
+
+	if _, ok := s.products[code]; !ok {
+		return fmt.Errorf("%w: product %s", domain.ErrNotFound, code)
+	}
+
+	key := stockKey{warehouseID: warehouseID, code: code}
+	if _, ok := s.stock[key]; ok {
+		return fmt.Errorf("%w: product %s is already in warehouse %d", domain.ErrDuplicateCode, code, warehouseID)
+	}
+
+	s.stock[key] = st
+
+	return nil
+}
+
+// checkAvailability is the tr_wareproducts_availability trigger, it runs
+// before any change of warehouse stock.
+func (s *state) checkAvailability(warehouseID int64) error {
+	w, ok := s.warehouses[warehouseID]
+	if ok && !w.Availability {
+		return fmt.Errorf("%w: insert/update/delete in no available warehouse %d",
+			domain.ErrWarehouseUnavailable, warehouseID)
+	}
+
+	return nil
+}
+
+func (s *state) sortedProducts() []product {
+	products := make([]product, 0, len(s.products))
+	for _, p := range s.products {
+		products = append(products, p)
+	}
+
+	sort.Slice(products, func(i, j int) bool { return products[i].id < products[j].id })
+
+	return products
+}
+
+func (s *state) sortedReservations() []domain.Reservation {
+	reservations := make([]domain.Reservation, 0, len(s.reservations))
+	for _, r := range s.reservations {
+		reservations = append(reservations, r)
+	}
+
+	sort.Slice(reservations, func(i, j int) bool { return reservations[i].ID < reservations[j].ID })
+
+	return reservations
+}
+
+// parseCode accepts the same UUID spellings as the code column does in the
+// common cases and returns the canonical lower case form.
+func parseCode(code string) (string, error) {
+	raw := strings.TrimSuffix(strings.TrimPrefix(code, "{"), "}")
+	if len(raw) == 36 {
+		for _, i := range []int{8, 13, 18, 23} {
+			if raw[i] != '-' {
+				return "", fmt.Errorf("%w: invalid input syntax for type uuid: %q", domain.ErrValidationFailed, code)
+			}
+		}
+
+		raw = strings.ReplaceAll(raw, "-", "")
+	}
+
+	if len(raw) != 32 {
+		return "", fmt.Errorf("%w: invalid input syntax for type uuid: %q", domain.ErrValidationFailed, code)
+	}
+
+	raw = strings.ToLower(raw)
+	for _, r := range raw {
+		if (r < '0' || r > '9') && (r < 'a' || r > 'f') {
+			return "", fmt.Errorf("%w: invalid input syntax for type uuid: %q", domain.ErrValidationFailed, code)
+		}
+	}
+
+	return raw[:8] + "-" + raw[8:12] + "-" + raw[12:16] + "-" + raw[16:20] + "-" + raw[20:], nil
+}
+
+func page[T any](items []T, limit, offset uint64) []T {
+	if offset >= uint64(len(items)) {
+		return items[:0]
+	}
+
+	items = items[offset:]
+	if limit < uint64(len(items)) {
+		items = items[:limit]
+	}
+
+	return items
+}
@@ -0,0 +1,150 @@
+package memory
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/akrovv/warehouse/internal/domain"
+)
+
+type lotKey struct {
+	code   string
+	number string
+}
+
+// lot keeps the insertion order, it breaks ties between lots that expire at
+// the same time like the id of the lots table.
+type lot struct {
+	id int64
+	domain.Lot
+}
+
+type stockLotKey struct {
+	warehouseID int64
+	lot         lotKey
+}
+
+// lotPick is the lotPick of the PostgreSQL storage.
+type lotPick struct {
+	warehouseID int64
+	code        string
+	quantity    uint64
+	number      string
+	reserve     bool
+}
+
+// receiveLot creates the lot on first receipt, a lot received again must have
+// the same dates.
+func (s *state) receiveLot(code string, received *domain.Lot) (lotKey, error) {
+	if received == nil {
+		received = &domain.Lot{}
+	}
+
+	key := lotKey{code: code, number: received.Number}
+
+	stored, ok := s.lots[key]
+	if !ok {
+		s.lotSeq++
+		s.lots[key] = lot{id: s.lotSeq, Lot: *received}
+
+		return key, nil
+	}
+
+	if !received.SameDates(&stored.Lot) {
+		return lotKey{}, fmt.Errorf("%w: lot %s of %s is already received with other dates",
+			domain.ErrValidationFailed, received.Number, code)
+	}
+
+	return key, nil
+}
+
+// takeLots takes available stock of lots first-expired-first-out, reserved
+// units skip expired lots.
+func (s *state) takeLots(p lotPick) ([]domain.LotQuantity, error) {
+	now := time.Now()
+
+	keys := make([]lotKey, 0, domain.BasicSliceLength)
+	lots := make([]domain.LotQuantity, 0, domain.BasicSliceLength)
+
+	for _, key := range s.sortedLots(p.code) {
+		l := s.lots[key]
+		st := s.stockLots[stockLotKey{warehouseID: p.warehouseID, lot: key}]
+
+		switch {
+		case st.available == 0,
+			p.number != "" && key.number != p.number,
+			p.reserve && l.Expired(now):
+			continue
+		}
+
+		keys = append(keys, key)
+		lots = append(lots, domain.LotQuantity{Lot: l.Lot, Quantity: st.available})
+	}
+
+	picked, err := domain.PickLots(p.warehouseID, p.code, lots, p.quantity)
+	if err != nil && p.number != "" {
+		return nil, fmt.Errorf("lot %s: %w", p.number, err)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	for i, lot := range picked {
+		reserved := int64(0)
+		if p.reserve {
+			reserved = int64(lot.Quantity)
+		}
+
+		s.changeLot(p.warehouseID, keys[i], -int64(lot.Quantity), reserved)
+	}
+
+	return picked, nil
+}
+
+// changeLot changes stock buckets of a lot in a warehouse, callers check the
+// buckets don't go below zero.
+func (s *state) changeLot(warehouseID int64, key lotKey, available, reserved int64) {
+	stockKey := stockLotKey{warehouseID: warehouseID, lot: key}
+	st := s.stockLots[stockKey]
+
+	s.stockLots[stockKey] = stock{
+		available: uint64(int64(st.available) + available),
+		reserved:  uint64(int64(st.reserved) + reserved),
+	}
+}
+
+// availableLots returns available stock of the warehouse by product code and
+// lot, first-expired-first-out.
+func (s *state) availableLots(warehouseID int64, code string) []domain.LotQuantity {
+	lots := make([]domain.LotQuantity, 0, domain.BasicSliceLength)
+	for _, key := range s.sortedLots(code) {
+		st := s.stockLots[stockLotKey{warehouseID: warehouseID, lot: key}]
+		if st.available > 0 {
+			lots = append(lots, domain.LotQuantity{Lot: s.lots[key].Lot, Quantity: st.available})
+		}
+	}
+
+	return lots
+}
+
+func (s *state) sortedLots(code string) []lotKey {
+	keys := make([]lotKey, 0, domain.BasicSliceLength)
+	for key := range s.lots {
+		if key.code == code {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := s.lots[keys[i]], s.lots[keys[j]]
+		if a.ExpiresBefore(&b.Lot) || b.ExpiresBefore(&a.Lot) {
+			return a.ExpiresBefore(&b.Lot)
+		}
+
+		return a.id < b.id
+	})
+
+	return keys
+}
@@ -0,0 +1,168 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/akrovv/warehouse/internal/domain"
+)
+
+func (s *warehouseStorage) SetThreshold(ctx context.Context, t *domain.StockThreshold) error {
+	code, err := parseCode(t.Code)
+	if err != nil {
+		return err
+	}
+
+	return s.db.withTx(ctx, func(st *state) error {
+		key := stockKey{warehouseID: t.WarehouseID, code: code}
+		if _, ok := st.stock[key]; !ok {
+			return fmt.Errorf("%w: product %s in warehouse %d", domain.ErrNotFound, code, t.WarehouseID)
+		}
+
+		if err := st.checkAvailability(t.WarehouseID); err != nil {
+			return err
+		}
+
+		st.thresholds[key] = domain.StockThreshold{
+			WarehouseID:  t.WarehouseID,
+			Code:         code,
+			MinQuantity:  t.MinQuantity,
+			ReorderPoint: t.ReorderPoint,
+		}
+
+		return nil
+	})
+}
+
+func (s *warehouseStorage) LowStock(ctx context.Context, f *domain.LowStockFilter) ([]domain.LowStockItem, error) {
+	items := make([]domain.LowStockItem, 0, domain.BasicSliceLength)
+
+	err := s.db.read(ctx, func(st *state) error {
+		for key, t := range st.thresholds {
+			stock := st.stock[key]
+			w := st.warehouses[key.warehouseID]
+
+			switch {
+			case f.WarehouseID != 0 && key.warehouseID != f.WarehouseID,
+				w.ArchivedAt != nil,
+				stock.available >= t.ReorderPoint:
+				continue
+			}
+
+			items = append(items, domain.LowStockItem{
+				StockThreshold: t,
+				Name:           st.products[key.code].Name,
+				Available:      stock.available,
+				Level:          t.Level(stock.available),
+			})
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].WarehouseID != items[j].WarehouseID {
+			return items[i].WarehouseID < items[j].WarehouseID
+		}
+
+		return items[i].Code < items[j].Code
+	})
+
+	return items, nil
+}
+
+func (s *warehouseStorage) LowStockEvents(ctx context.Context, f *domain.LowStockEventFilter) ([]domain.LowStockEvent, error) {
+	events := make([]domain.LowStockEvent, 0, domain.BasicSliceLength)
+
+	err := s.db.read(ctx, func(st *state) error {
+		for _, e := range st.lowStock {
+			switch {
+			case e.ID <= f.AfterID,
+				f.WarehouseID != 0 && e.WarehouseID != f.WarehouseID:
+				continue
+			}
+
+			events = append(events, e)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return page(events, f.Limit, 0), nil
+}
+
+// recordLowStock is recordLowStock of the PostgreSQL storage.
+func (s *state) recordLowStock(c stockChange, before, available uint64) {
+	t, ok := s.thresholds[stockKey{warehouseID: c.warehouseID, code: c.code}]
+	if !ok {
+		return
+	}
+
+	for _, level := range t.Crossed(before, available) {
+		s.lowStockSeq++
+		s.lowStock = append(s.lowStock, domain.LowStockEvent{
+			ID:          s.lowStockSeq,
+			WarehouseID: c.warehouseID,
+			Code:        c.code,
+			Level:       level,
+			Threshold:   t.Threshold(level),
+			Available:   available,
+			RequestID:   c.requestID,
+			CreatedAt:   time.Now(),
+		})
+	}
+}
+
+// StockLevels is StockLevels of the PostgreSQL storage.
+func (s *productStorage) StockLevels(ctx context.Context, sg *domain.SuggestTransfers) ([]domain.StockLevel, error) {
+	code := sg.Code
+	if code != "" {
+		var err error
+		if code, err = parseCode(code); err != nil {
+			return nil, err
+		}
+	}
+
+	levels := make([]domain.StockLevel, 0, domain.BasicSliceLength)
+
+	err := s.db.read(ctx, func(st *state) error {
+		for key, stock := range st.stock {
+			switch {
+			case code != "" && key.code != code,
+				!st.warehouses[key.warehouseID].Availability:
+				continue
+			}
+
+			t := st.thresholds[key]
+			t.WarehouseID, t.Code = key.warehouseID, key.code
+
+			levels = append(levels, domain.StockLevel{StockThreshold: t, Available: stock.available})
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(levels, func(i, j int) bool {
+		if levels[i].Code != levels[j].Code {
+			return levels[i].Code < levels[j].Code
+		}
+
+		return levels[i].WarehouseID < levels[j].WarehouseID
+	})
+
+	return levels, nil
+}
@@ -0,0 +1,175 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/akrovv/warehouse/internal/domain"
+)
+
+type movementStorage struct {
+	db *DB
+}
+
+func NewMovementStorage(db *DB) *movementStorage {
+	return &movementStorage{
+		db: db,
+	}
+}
+
+func (s *movementStorage) List(ctx context.Context, f *domain.MovementFilter) ([]domain.Movement, error) {
+	code := f.Code
+	if code != "" {
+		var err error
+		if code, err = parseCode(code); err != nil {
+			return nil, err
+		}
+	}
+
+	movements := make([]domain.Movement, 0, domain.BasicSliceLength)
+
+	err := s.db.read(ctx, func(st *state) error {
+		for _, m := range st.movements {
+			switch {
+			case code != "" && m.Code != code,
+				f.WarehouseID != 0 && m.WarehouseID != f.WarehouseID,
+				!f.From.IsZero() && m.CreatedAt.Before(f.From),
+				!f.To.IsZero() && !m.CreatedAt.Before(f.To):
+				continue
+			}
+
+			movements = append(movements, m)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return page(movements, f.Limit, 0), nil
+}
+
+// stockChange describes a change of warehouse stock buckets, every non zero
+// delta is written to the ledger together with the resulting balance.
+type stockChange struct {
+	movementType string
+	reason       string
+	requestID    string
+	warehouseID  int64
+	code         string
+	available    int64
+	reserved     int64
+}
+
+func (s *state) changeStock(c stockChange) error {
+	key := stockKey{warehouseID: c.warehouseID, code: c.code}
+
+	st, ok := s.stock[key]
+	if !ok {
+		return fmt.Errorf("%w: product %s in warehouse %d", domain.ErrNotFound, c.code, c.warehouseID)
+	}
+
+	err := domain.StockShortage(c.warehouseID, c.code, st.available, st.reserved, c.available, c.reserved)
+	if err != nil {
+		return err
+	}
+
+	if err = s.checkAvailability(c.warehouseID); err != nil {
+		return err
+	}
+
+	available := uint64(int64(st.available) + c.available)
+	reserved := uint64(int64(st.reserved) + c.reserved)
+
+	previous := st.available
+	st.available, st.reserved = available, reserved
+	s.stock[key] = st
+	s.recordMovements(c, available, reserved)
+	s.recordLowStock(c, previous, available)
+
+	return nil
+}
+
+// receiveStock is changeStock for incoming stock, the product appears in the
+// warehouse if it wasn't there yet.
+func (s *state) receiveStock(c stockChange) error {
+	key := stockKey{warehouseID: c.warehouseID, code: c.code}
+
+	if _, ok := s.stock[key]; !ok {
+		if err := s.insertStock(c.warehouseID, c.code, stock{}); err != nil {
+			return err
+		}
+	}
+
+	return s.changeStock(c)
+}
+
+// changeBucket is changeBucket of the PostgreSQL storage.
+func (s *state) changeBucket(c stockChange, bucket string, delta int64) error {
+	key := stockKey{warehouseID: c.warehouseID, code: c.code}
+
+	_, ok := s.stock[key]
+	switch {
+	case !ok && delta < 0:
+		return fmt.Errorf("%w: product %s in warehouse %d", domain.ErrNotFound, c.code, c.warehouseID)
+	case !ok:
+		if err := s.insertStock(c.warehouseID, c.code, stock{}); err != nil {
+			return err
+		}
+	default:
+		if err := s.checkAvailability(c.warehouseID); err != nil {
+			return err
+		}
+	}
+
+	st := s.stock[key]
+	balance := st.bucket(bucket)
+	if balance == nil {
+		return fmt.Errorf("%w: unknown stock bucket %s", domain.ErrValidationFailed, bucket)
+	}
+
+	if delta < 0 && uint64(-delta) > *balance {
+		return &domain.InsufficientStockError{
+			WarehouseID: c.warehouseID,
+			Code:        c.code,
+			Bucket:      bucket,
+			Requested:   uint64(-delta),
+			Available:   *balance,
+		}
+	}
+
+	*balance = uint64(int64(*balance) + delta)
+	s.stock[key] = st
+	s.recordMovement(c, bucket, delta, *balance)
+
+	return nil
+}
+
+func (s *state) recordMovements(c stockChange, available, reserved uint64) {
+	if c.available != 0 {
+		s.recordMovement(c, domain.BucketAvailable, c.available, available)
+	}
+
+	if c.reserved != 0 {
+		s.recordMovement(c, domain.BucketReserved, c.reserved, reserved)
+	}
+}
+
+func (s *state) recordMovement(c stockChange, bucket string, delta int64, balance uint64) {
+	s.movementSeq++
+	s.movements = append(s.movements, domain.Movement{
+		ID:          s.movementSeq,
+		Type:        c.movementType,
+		WarehouseID: c.warehouseID,
+		Code:        c.code,
+		Bucket:      bucket,
+		Delta:       delta,
+		Balance:     balance,
+		Reason:      c.reason,
+		RequestID:   c.requestID,
+		CreatedAt:   time.Now(),
+	})
+}
@@ -0,0 +1,638 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/akrovv/warehouse/internal/domain"
+)
+
+type productStorage struct {
+	db *DB
+}
+
+func NewProductStorage(db *DB) *productStorage {
+	return &productStorage{
+		db: db,
+	}
+}
+
+func (s *productStorage) Create(ctx context.Context, p *domain.Product) error {
+	code, err := parseCode(p.Code)
+	if err != nil {
+		return err
+	}
+
+	return s.db.withTx(ctx, func(st *state) error {
+		if _, ok := st.products[code]; ok {
+			return fmt.Errorf("%w: product %s already exists", domain.ErrDuplicateCode, code)
+		}
+
+		st.productSeq++
+		created := product{id: st.productSeq, Product: *p}
+		created.Code = code
+		st.products[code] = created
+
+		return nil
+	})
+}
+
+func (s *productStorage) Get(ctx context.Context, gp *domain.GetProduct) (*domain.Product, error) {
+	code, err := parseCode(gp.Code)
+	if err != nil {
+		return nil, err
+	}
+
+	p := domain.Product{}
+
+	err = s.db.read(ctx, func(st *state) error {
+		found, ok := st.products[code]
+		if !ok {
+			return fmt.Errorf("%w: product %s", domain.ErrNotFound, code)
+		}
+
+		p = found.Product
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &p, nil
+}
+
+func (s *productStorage) List(ctx context.Context, f *domain.ProductFilter) ([]domain.Product, error) {
+	code := f.Code
+	if code != "" {
+		var err error
+		if code, err = parseCode(code); err != nil {
+			return nil, err
+		}
+	}
+
+	name := strings.ToLower(f.Name)
+	products := make([]domain.Product, 0, domain.BasicSliceLength)
+
+	err := s.db.read(ctx, func(st *state) error {
+		for _, p := range st.sortedProducts() {
+			switch {
+			case name != "" && !strings.Contains(strings.ToLower(p.Name), name),
+				f.Size != "" && p.Size != f.Size,
+				code != "" && p.Code != code:
+				continue
+			}
+
+			products = append(products, p.Product)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return page(products, f.Limit, f.Offset), nil
+}
+
+func (s *productStorage) Update(ctx context.Context, up *domain.UpdateProduct) (*domain.Product, error) {
+	code, err := parseCode(up.Code)
+	if err != nil {
+		return nil, err
+	}
+
+	p := domain.Product{}
+
+	err = s.db.withTx(ctx, func(st *state) error {
+		found, ok := st.products[code]
+		if !ok {
+			return fmt.Errorf("%w: product %s", domain.ErrNotFound, code)
+		}
+
+		if up.Name != "" {
+			found.Name = up.Name
+		}
+
+		if up.Size != "" {
+			found.Size = up.Size
+		}
+
+		st.products[code] = found
+		p = found.Product
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &p, nil
+}
+
+func (s *productStorage) Reserve(ctx context.Context, r *domain.Reservation) error {
+	return s.db.withTx(ctx, func(st *state) error {
+		return st.reserve(r)
+	})
+}
+
+func (s *productStorage) ReserveBatch(ctx context.Context, rs []domain.Reservation) error {
+	return s.db.withTx(ctx, func(st *state) error {
+		for i := range rs {
+			if err := st.reserve(&rs[i]); err != nil {
+				return &domain.BatchError{Index: i, Err: err}
+			}
+		}
+
+		return nil
+	})
+}
+
+func (s *productStorage) CancelReservation(ctx context.Context, cr *domain.CancelReservation) (*domain.Reservation, error) {
+	reservation := domain.Reservation{}
+
+	err := s.db.withTx(ctx, func(st *state) error {
+		r, ok := st.reservations[cr.ReservationID]
+		if !ok || r.Status != domain.ReservationActive {
+			return fmt.Errorf("%w: active reservation %d", domain.ErrNotFound, cr.ReservationID)
+		}
+
+		r.Status = domain.ReservationCanceled
+		st.reservations[r.ID] = r
+
+		if err := st.release(&r, domain.MovementRelease, cr.RequestID); err != nil {
+			return err
+		}
+
+		reservation = r
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &reservation, nil
+}
+
+func (s *productStorage) Fulfill(ctx context.Context, f *domain.Fulfillment) (*domain.Shipment, error) {
+	shipment := domain.Shipment{}
+
+	err := s.db.withTx(ctx, func(st *state) error {
+		st.shipmentSeq++
+		shipment = domain.Shipment{
+			ID:        st.shipmentSeq,
+			CreatedAt: time.Now(),
+			Lines:     make([]domain.ShipmentLine, 0, len(f.ReservationIDs)),
+		}
+
+		for _, id := range f.ReservationIDs {
+			line, err := st.ship(id, f.RequestID, shipment.CreatedAt)
+			if err != nil {
+				return fmt.Errorf("can't ship reservation %d: %w", id, err)
+			}
+
+			shipment.Lines = append(shipment.Lines, *line)
+		}
+
+		st.shipments[shipment.ID] = shipment
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &shipment, nil
+}
+
+// ReleaseExpired leaves reservations in unavailable warehouses until the
+// warehouse is available again, as the PostgreSQL storage does.
+func (s *productStorage) ReleaseExpired(ctx context.Context, now time.Time) ([]domain.Reservation, error) {
+	reservations := make([]domain.Reservation, 0, domain.BasicSliceLength)
+
+	err := s.db.withTx(ctx, func(st *state) error {
+		for _, r := range st.sortedReservations() {
+			w := st.warehouses[r.WarehouseID]
+			if r.Status != domain.ReservationActive || r.ExpiresAt.After(now) || !w.Availability {
+				continue
+			}
+
+			r.Status = domain.ReservationExpired
+			st.reservations[r.ID] = r
+
+			if err := st.release(&r, domain.MovementExpire, domain.SweeperRequestID); err != nil {
+				return err
+			}
+
+			reservations = append(reservations, r)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return reservations, nil
+}
+
+func (s *productStorage) Transfer(ctx context.Context, td *domain.TransferProduct) error {
+	return s.db.withTx(ctx, func(st *state) error {
+		return st.transfer(td)
+	})
+}
+
+func (s *productStorage) TransferBatch(ctx context.Context, tds []domain.TransferProduct) error {
+	return s.db.withTx(ctx, func(st *state) error {
+		for i := range tds {
+			if err := st.transfer(&tds[i]); err != nil {
+				return &domain.BatchError{Index: i, Err: err}
+			}
+		}
+
+		return nil
+	})
+}
+
+func (s *productStorage) Add(ctx context.Context, ad *domain.AddProduct) error {
+	code, err := parseCode(ad.Code)
+	if err != nil {
+		return err
+	}
+
+	return s.db.withTx(ctx, func(st *state) error {
+		return st.add(ad, code, domain.MovementAdd)
+	})
+}
+
+// add is add of the PostgreSQL storage.
+func (s *state) add(ad *domain.AddProduct, code, movementType string) error {
+	p, ok := s.products[code]
+	if !ok {
+		return fmt.Errorf("%w: product %s", domain.ErrNotFound, code)
+	}
+
+	p.Quantity += ad.Quantity
+	s.products[code] = p
+
+	err := s.receiveStock(stockChange{
+		movementType: movementType,
+		requestID:    ad.RequestID,
+		warehouseID:  ad.WarehouseID,
+		code:         code,
+		available:    int64(ad.Quantity),
+	})
+
+	if err != nil {
+		return err
+	}
+
+	key, err := s.receiveLot(code, ad.Lot)
+	if err != nil {
+		return err
+	}
+
+	s.changeLot(ad.WarehouseID, key, int64(ad.Quantity), 0)
+
+	if ad.BinID == 0 {
+		return nil
+	}
+
+	if err = s.checkBins(ad.WarehouseID, ad.BinID); err != nil {
+		return err
+	}
+
+	s.changeBin(ad.BinID, code, int64(ad.Quantity), 0)
+
+	return nil
+}
+
+func (s *productStorage) Delete(ctx context.Context, dp *domain.DeleteProduct) (*domain.Product, error) {
+	code, err := parseCode(dp.Code)
+	if err != nil {
+		return nil, err
+	}
+
+	deleted := domain.Product{}
+
+	err = s.db.withTx(ctx, func(st *state) error {
+		transfers := make([]int64, 0, domain.BasicSliceLength)
+		for id, t := range st.transfers {
+			if t.Code == code && t.Status == domain.TransferInTransit {
+				transfers = append(transfers, id)
+			}
+		}
+
+		if len(transfers) > 0 {
+			sort.Slice(transfers, func(i, j int) bool { return transfers[i] < transfers[j] })
+
+			return fmt.Errorf("%w: product %s has transfers in transit %v",
+				domain.ErrValidationFailed, code, transfers)
+		}
+
+		keys := make([]stockKey, 0, domain.BasicSliceLength)
+		for key := range st.stock {
+			if key.code == code {
+				keys = append(keys, key)
+			}
+		}
+
+		sort.Slice(keys, func(i, j int) bool { return keys[i].warehouseID < keys[j].warehouseID })
+
+		for _, key := range keys {
+			if err := st.checkAvailability(key.warehouseID); err != nil {
+				return err
+			}
+
+			stock := st.stock[key]
+			delete(st.stock, key)
+			delete(st.thresholds, key)
+
+			st.recordMovements(stockChange{
+				movementType: domain.MovementDelete,
+				requestID:    dp.RequestID,
+				warehouseID:  key.warehouseID,
+				code:         code,
+				available:    -int64(stock.available),
+				reserved:     -int64(stock.reserved),
+			}, 0, 0)
+
+			for _, bucket := range []string{domain.BucketQuarantined, domain.BucketDamaged} {
+				if q := *stock.bucket(bucket); q != 0 {
+					st.recordMovement(stockChange{
+						movementType: domain.MovementDelete,
+						requestID:    dp.RequestID,
+						warehouseID:  key.warehouseID,
+						code:         code,
+					}, bucket, -int64(q), 0)
+				}
+			}
+		}
+
+		p, ok := st.products[code]
+		if !ok {
+			return fmt.Errorf("%w: product %s", domain.ErrNotFound, code)
+		}
+
+		for id, r := range st.reservations {
+			if r.Code == code {
+				delete(st.reservations, id)
+			}
+		}
+
+		for id, t := range st.transfers {
+			if t.Code == code {
+				delete(st.transfers, id)
+			}
+		}
+
+		for id, po := range st.orders {
+			if slices.ContainsFunc(po.Lines, func(l domain.PurchaseOrderLine) bool { return l.Code == code }) {
+				po.Lines = slices.DeleteFunc(slices.Clone(po.Lines),
+					func(l domain.PurchaseOrderLine) bool { return l.Code == code })
+				st.orders[id] = po
+			}
+		}
+
+		for id, ret := range st.returns {
+			if slices.ContainsFunc(ret.Lines, func(l domain.ReturnLine) bool { return l.Code == code }) {
+				ret.Lines = slices.DeleteFunc(slices.Clone(ret.Lines),
+					func(l domain.ReturnLine) bool { return l.Code == code })
+				st.returns[id] = ret
+			}
+		}
+
+		for id, a := range st.adjustments {
+			if a.Code == code {
+				delete(st.adjustments, id)
+			}
+		}
+
+		for id, session := range st.counts {
+			if slices.ContainsFunc(session.Lines, func(l domain.CountLine) bool { return l.Code == code }) {
+				session.Lines = slices.DeleteFunc(slices.Clone(session.Lines),
+					func(l domain.CountLine) bool { return l.Code == code })
+				st.counts[id] = session
+			}
+		}
+
+		for key := range st.stockLots {
+			if key.lot.code == code {
+				delete(st.stockLots, key)
+			}
+		}
+
+		for key := range st.lots {
+			if key.code == code {
+				delete(st.lots, key)
+			}
+		}
+
+		for key := range st.binStock {
+			if key.code == code {
+				delete(st.binStock, key)
+			}
+		}
+
+		delete(st.products, code)
+		deleted = p.Product
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &deleted, nil
+}
+
+func (s *state) reserve(r *domain.Reservation) error {
+	code, err := parseCode(r.Code)
+	if err != nil {
+		return err
+	}
+
+	err = s.changeStock(stockChange{
+		movementType: domain.MovementReserve,
+		requestID:    r.RequestID,
+		warehouseID:  r.WarehouseID,
+		code:         code,
+		available:    -int64(r.Quantity),
+		reserved:     int64(r.Quantity),
+	})
+
+	if err != nil {
+		return err
+	}
+
+	r.Lots, err = s.takeLots(lotPick{
+		warehouseID: r.WarehouseID,
+		code:        code,
+		quantity:    r.Quantity,
+		number:      r.Lot,
+		reserve:     true,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	r.Bins = s.takeBins(r.WarehouseID, code, r.Quantity, true)
+
+	s.reservationSeq++
+	r.ID = s.reservationSeq
+	r.CreatedAt = time.Now()
+
+	stored := *r
+	stored.Code = code
+	stored.RequestID = ""
+	s.reservations[r.ID] = stored
+
+	return nil
+}
+
+// release returns reserved units to available stock of the lots and bins
+// they were reserved from, even if the lots have expired since.
+func (s *state) release(r *domain.Reservation, movementType, requestID string) error {
+	err := s.changeStock(stockChange{
+		movementType: movementType,
+		requestID:    requestID,
+		warehouseID:  r.WarehouseID,
+		code:         r.Code,
+		available:    int64(r.Quantity),
+		reserved:     -int64(r.Quantity),
+	})
+
+	if err != nil {
+		return err
+	}
+
+	for _, lot := range r.Lots {
+		key := lotKey{code: r.Code, number: lot.Number}
+		s.changeLot(r.WarehouseID, key, int64(lot.Quantity), -int64(lot.Quantity))
+	}
+
+	for _, bin := range r.Bins {
+		s.changeBin(bin.ID, r.Code, int64(bin.Quantity), -int64(bin.Quantity))
+	}
+
+	return nil
+}
+
+func (s *state) transfer(td *domain.TransferProduct) error {
+	code, err := parseCode(td.Code)
+	if err != nil {
+		return err
+	}
+
+	err = s.changeStock(stockChange{
+		movementType: domain.MovementTransferOut,
+		requestID:    td.RequestID,
+		warehouseID:  td.WarehouseFromID,
+		code:         code,
+		available:    -int64(td.Quantity),
+	})
+
+	if err != nil {
+		return err
+	}
+
+	lots, err := s.takeLots(lotPick{
+		warehouseID: td.WarehouseFromID,
+		code:        code,
+		quantity:    td.Quantity,
+		number:      td.Lot,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	bins := s.takeBins(td.WarehouseFromID, code, td.Quantity, false)
+
+	err = s.receiveStock(stockChange{
+		movementType: domain.MovementTransferIn,
+		requestID:    td.RequestID,
+		warehouseID:  td.WarehouseToID,
+		code:         code,
+		available:    int64(td.Quantity),
+	})
+
+	if err != nil {
+		return err
+	}
+
+	for _, lot := range lots {
+		s.changeLot(td.WarehouseToID, lotKey{code: code, number: lot.Number}, int64(lot.Quantity), 0)
+	}
+
+	td.Lots = lots
+	td.Bins = bins
+
+	return nil
+}
+
+func (s *state) ship(reservationID int64, requestID string, now time.Time) (*domain.ShipmentLine, error) {
+	r, ok := s.reservations[reservationID]
+	if !ok || r.Status != domain.ReservationActive || !r.ExpiresAt.After(now) {
+		return nil, fmt.Errorf("%w: active reservation %d", domain.ErrNotFound, reservationID)
+	}
+
+	r.Status = domain.ReservationFulfilled
+	s.reservations[r.ID] = r
+
+	err := s.changeStock(stockChange{
+		movementType: domain.MovementFulfill,
+		requestID:    requestID,
+		warehouseID:  r.WarehouseID,
+		code:         r.Code,
+		reserved:     -int64(r.Quantity),
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, lot := range r.Lots {
+		s.changeLot(r.WarehouseID, lotKey{code: r.Code, number: lot.Number}, 0, -int64(lot.Quantity))
+	}
+
+	for _, bin := range r.Bins {
+		s.changeBin(bin.ID, r.Code, 0, -int64(bin.Quantity))
+	}
+
+	p, ok := s.products[r.Code]
+	if !ok {
+		return nil, fmt.Errorf("%w: product %s", domain.ErrNotFound, r.Code)
+	}
+
+	if p.Quantity < r.Quantity {
+		return nil, fmt.Errorf("%w: product %s quantity %d is less than %d",
+			domain.ErrInsufficientStock, r.Code, p.Quantity, r.Quantity)
+	}
+
+	p.Quantity -= r.Quantity
+
+	s.products[r.Code] = p
+
+	return &domain.ShipmentLine{
+		ReservationID: r.ID,
+		OrderRef:      r.OrderRef,
+		WarehouseID:   r.WarehouseID,
+		Code:          r.Code,
+		Quantity:      r.Quantity,
+		Lots:          r.Lots,
+		Bins:          r.Bins,
+	}, nil
+}
@@ -0,0 +1,140 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/akrovv/warehouse/internal/domain"
+)
+
+const (
+	testCode  = "8b4cd8c7-3a1d-4f5e-9b0a-2c6e1f7d9a10"
+	otherCode = "0f3c2a1b-5d6e-4a7b-8c9d-0e1f2a3b4c5d"
+)
+
+type stockTestCase struct {
+	name        string
+	reservation domain.Reservation
+	expectError error
+}
+
+// newTestDB returns two available warehouses, 1 and 2, with 10 units of
+// testCode in warehouse 1.
+func newTestDB(t *testing.T) *DB {
+	t.Helper()
+
+	db := NewDB()
+	warehouses := NewWarehouseStorage(db)
+	products := NewProductStorage(db)
+
+	for _, name := range []string{"first", "second"} {
+		if err := warehouses.Create(context.Background(), &domain.Warehouse{Name: name, Availability: true}); err != nil {
+			t.Fatalf("can't create warehouse: %s", err)
+		}
+	}
+
+	if err := products.Create(context.Background(), &domain.Product{Name: "shirt", Size: "M", Code: testCode, Quantity: 10}); err != nil {
+		t.Fatalf("can't create product: %s", err)
+	}
+
+	if err := db.InsertWarehouseProducts(context.Background(), 1, testCode); err != nil {
+		t.Fatalf("can't insert warehouse products: %s", err)
+	}
+
+	return db
+}
+
+func TestProductCreate(t *testing.T) {
+	storage := NewProductStorage(newTestDB(t))
+
+	testCases := []struct {
+		product     domain.Product
+		expectError error
+	}{
+		{
+			product: domain.Product{Name: "hat", Size: "L", Code: otherCode},
+		},
+		{
+			product:     domain.Product{Name: "hat", Size: "L", Code: "8B4CD8C7-3A1D-4F5E-9B0A-2C6E1F7D9A10"},
+			expectError: domain.ErrDuplicateCode,
+		},
+		{
+			product:     domain.Product{Name: "hat", Size: "L", Code: "not-a-uuid"},
+			expectError: domain.ErrValidationFailed,
+		},
+	}
+
+	for _, tc := range testCases {
+		err := storage.Create(context.Background(), &tc.product)
+		if !errors.Is(err, tc.expectError) {
+			t.Fatalf("expected: %v, got: %v", tc.expectError, err)
+		}
+	}
+}
+
+func TestProductReserve(t *testing.T) {
+	testCases := []stockTestCase{
+		{
+			name:        "reserved",
+			reservation: domain.Reservation{WarehouseID: 1, Code: testCode, Quantity: 10},
+		},
+		{
+			name:        "more than available",
+			reservation: domain.Reservation{WarehouseID: 1, Code: testCode, Quantity: 11},
+			expectError: domain.ErrInsufficientStock,
+		},
+		{
+			name:        "not in warehouse",
+			reservation: domain.Reservation{WarehouseID: 2, Code: testCode, Quantity: 1},
+			expectError: domain.ErrNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		db := newTestDB(t)
+		storage := NewProductStorage(db)
+
+		err := storage.Reserve(context.Background(), &tc.reservation)
+		if !errors.Is(err, tc.expectError) {
+			t.Fatalf("%s: expected: %v, got: %v", tc.name, tc.expectError, err)
+		}
+
+		movements, _ := NewMovementStorage(db).List(context.Background(), &domain.MovementFilter{Limit: 10})
+		if tc.expectError != nil && len(movements) != 0 {
+			t.Fatalf("%s: expected no movements after failure, got: %v", tc.name, movements)
+		}
+
+		if tc.expectError == nil && len(movements) != 2 {
+			t.Fatalf("%s: expected: 2 movements, got: %v", tc.name, movements)
+		}
+	}
+}
+
+func TestProductTransferBatch(t *testing.T) {
+	db := newTestDB(t)
+	storage := NewProductStorage(db)
+
+	err := storage.TransferBatch(context.Background(), []domain.TransferProduct{
+		{WarehouseFromID: 1, WarehouseToID: 2, Code: testCode, Quantity: 4},
+		{WarehouseFromID: 1, WarehouseToID: 2, Code: testCode, Quantity: 4},
+	})
+	if err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+
+	err = storage.TransferBatch(context.Background(), []domain.TransferProduct{
+		{WarehouseFromID: 2, WarehouseToID: 1, Code: testCode, Quantity: 8},
+		{WarehouseFromID: 1, WarehouseToID: 2, Code: testCode, Quantity: 11},
+	})
+
+	var batchErr *domain.BatchError
+	if !errors.As(err, &batchErr) || batchErr.Index != 1 || !errors.Is(err, domain.ErrInsufficientStock) {
+		t.Fatalf("expected insufficient stock at index 1, got: %v", err)
+	}
+
+	leftovers, err := NewWarehouseStorage(db).GetLeftOvers(context.Background(), &domain.GetFromWarehouse{WarehouseID: 2})
+	if err != nil || len(leftovers) != 1 || leftovers[0].Quantity != 8 {
+		t.Fatalf("expected 8 units in warehouse 2 after rollback, got: %v, %v", leftovers, err)
+	}
+}
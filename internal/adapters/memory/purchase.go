@@ -0,0 +1,220 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/akrovv/warehouse/internal/domain"
+)
+
+type purchaseStorage struct {
+	db *DB
+}
+
+func NewPurchaseStorage(db *DB) *purchaseStorage {
+	return &purchaseStorage{
+		db: db,
+	}
+}
+
+func (s *purchaseStorage) CreateSupplier(ctx context.Context, supplier *domain.Supplier) error {
+	return s.db.withTx(ctx, func(st *state) error {
+		st.supplierSeq++
+		supplier.ID = st.supplierSeq
+		st.suppliers[supplier.ID] = *supplier
+
+		return nil
+	})
+}
+
+func (s *purchaseStorage) ListSuppliers(ctx context.Context, f *domain.SupplierFilter) ([]domain.Supplier, error) {
+	suppliers := make([]domain.Supplier, 0, domain.BasicSliceLength)
+
+	err := s.db.read(ctx, func(st *state) error {
+		for _, supplier := range st.suppliers {
+			suppliers = append(suppliers, supplier)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(suppliers, func(i, j int) bool { return suppliers[i].ID < suppliers[j].ID })
+
+	return page(suppliers, f.Limit, f.Offset), nil
+}
+
+// CreateOrder checks the foreign keys of the purchase order tables.
+func (s *purchaseStorage) CreateOrder(ctx context.Context, po *domain.PurchaseOrder) error {
+	lines := slices.Clone(po.Lines)
+	for i := range lines {
+		code, err := parseCode(lines[i].Code)
+		if err != nil {
+			return err
+		}
+
+		lines[i].Code = code
+	}
+
+	return s.db.withTx(ctx, func(st *state) error {
+		if _, ok := st.suppliers[po.SupplierID]; !ok {
+			return fmt.Errorf("%w: supplier %d", domain.ErrNotFound, po.SupplierID)
+		}
+
+		for i, line := range lines {
+			if _, ok := st.warehouses[line.WarehouseID]; !ok {
+				return fmt.Errorf("%w: warehouse %d", domain.ErrNotFound, line.WarehouseID)
+			}
+
+			if _, ok := st.products[line.Code]; !ok {
+				return fmt.Errorf("%w: product %s", domain.ErrNotFound, line.Code)
+			}
+
+			st.orderLineSeq++
+			lines[i].ID = st.orderLineSeq
+		}
+
+		st.orderSeq++
+		po.ID = st.orderSeq
+		po.CreatedAt = time.Now()
+		po.Lines = lines
+		st.orders[po.ID] = *po
+
+		return nil
+	})
+}
+
+func (s *purchaseStorage) GetOrder(ctx context.Context, gp *domain.GetPurchaseOrder) (*domain.PurchaseOrder, error) {
+	var po domain.PurchaseOrder
+
+	err := s.db.read(ctx, func(st *state) error {
+		var ok bool
+		if po, ok = st.orders[gp.ID]; !ok {
+			return fmt.Errorf("%w: purchase order %d", domain.ErrNotFound, gp.ID)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &po, nil
+}
+
+// Receive is Receive of the PostgreSQL storage.
+func (s *purchaseStorage) Receive(ctx context.Context, pr *domain.PurchaseReceipt) (*domain.PurchaseOrder, error) {
+	var po domain.PurchaseOrder
+
+	err := s.db.withTx(ctx, func(st *state) error {
+		var ok bool
+		if po, ok = st.orders[pr.OrderID]; !ok {
+			return fmt.Errorf("%w: purchase order %d", domain.ErrNotFound, pr.OrderID)
+		}
+
+		// Receive changes the lines in place, they are shared with the
+		// committed state.
+		po.Lines = slices.Clone(po.Lines)
+
+		adds, err := po.Receive(pr)
+		if err != nil {
+			return err
+		}
+
+		for i := range adds {
+			if err = st.add(&adds[i], adds[i].Code, domain.MovementAdd); err != nil {
+				return err
+			}
+		}
+
+		st.orders[po.ID] = po
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &po, nil
+}
+
+func (s *purchaseStorage) CloseOrder(ctx context.Context, cp *domain.ClosePurchaseOrder) (*domain.PurchaseOrder, error) {
+	var po domain.PurchaseOrder
+
+	err := s.db.withTx(ctx, func(st *state) error {
+		var ok bool
+		if po, ok = st.orders[cp.OrderID]; !ok || po.Status != domain.PurchaseOrderOpen {
+			return fmt.Errorf("%w: open purchase order %d", domain.ErrNotFound, cp.OrderID)
+		}
+
+		now := time.Now()
+		po.Status = domain.PurchaseOrderClosed
+		po.ClosedAt = &now
+		st.orders[po.ID] = po
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &po, nil
+}
+
+// Outstanding is Outstanding of the PostgreSQL storage.
+func (s *purchaseStorage) Outstanding(ctx context.Context, f *domain.OutstandingFilter) ([]domain.OutstandingLine, error) {
+	code := f.Code
+	if code != "" {
+		var err error
+		if code, err = parseCode(code); err != nil {
+			return nil, err
+		}
+	}
+
+	lines := make([]domain.OutstandingLine, 0, domain.BasicSliceLength)
+
+	err := s.db.read(ctx, func(st *state) error {
+		for _, po := range st.orders {
+			switch {
+			case po.Status != domain.PurchaseOrderOpen,
+				f.SupplierID != 0 && po.SupplierID != f.SupplierID:
+				continue
+			}
+
+			for _, line := range po.Outstanding() {
+				switch {
+				case f.WarehouseID != 0 && line.WarehouseID != f.WarehouseID,
+					code != "" && line.Code != code:
+					continue
+				}
+
+				lines = append(lines, line)
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(lines, func(i, j int) bool {
+		if lines[i].OrderID != lines[j].OrderID {
+			return lines[i].OrderID < lines[j].OrderID
+		}
+
+		return lines[i].ID < lines[j].ID
+	})
+
+	return page(lines, f.Limit, f.Offset), nil
+}
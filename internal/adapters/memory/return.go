@@ -0,0 +1,171 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/akrovv/warehouse/internal/domain"
+)
+
+type returnStorage struct {
+	db *DB
+}
+
+func NewReturnStorage(db *DB) *returnStorage {
+	return &returnStorage{
+		db: db,
+	}
+}
+
+func (s *returnStorage) Create(ctx context.Context, cr *domain.CreateReturn) (*domain.Return, error) {
+	var ret domain.Return
+
+	err := s.db.withTx(ctx, func(st *state) error {
+		shipmentID, shipped := st.shippedLines(cr)
+
+		returned := make(map[int64]uint64, len(shipped))
+		for _, r := range st.returns {
+			for _, line := range r.Lines {
+				returned[line.ReservationID] += line.Quantity
+			}
+		}
+
+		var err error
+		if ret, err = domain.NewReturn(cr, shipmentID, shipped, returned); err != nil {
+			return err
+		}
+
+		for i := range ret.Lines {
+			if _, ok := st.products[ret.Lines[i].Code]; !ok {
+				return fmt.Errorf("%w: product %s", domain.ErrNotFound, ret.Lines[i].Code)
+			}
+
+			st.returnLineSeq++
+			ret.Lines[i].ID = st.returnLineSeq
+		}
+
+		st.returnSeq++
+		ret.ID = st.returnSeq
+		ret.CreatedAt = time.Now()
+		st.returns[ret.ID] = ret
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
+
+func (s *returnStorage) Get(ctx context.Context, gr *domain.GetReturn) (*domain.Return, error) {
+	var ret domain.Return
+
+	err := s.db.read(ctx, func(st *state) error {
+		var ok bool
+		if ret, ok = st.returns[gr.ID]; !ok {
+			return fmt.Errorf("%w: return %d", domain.ErrNotFound, gr.ID)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
+
+// Receive is Receive of the PostgreSQL storage.
+func (s *returnStorage) Receive(ctx context.Context, rr *domain.ReturnReceipt) (*domain.Return, error) {
+	var ret domain.Return
+
+	err := s.db.withTx(ctx, func(st *state) error {
+		var ok bool
+		if ret, ok = st.returns[rr.ReturnID]; !ok {
+			return fmt.Errorf("%w: return %d", domain.ErrNotFound, rr.ReturnID)
+		}
+
+		// Receive changes the lines in place, they are shared with the
+		// committed state.
+		ret.Lines = slices.Clone(ret.Lines)
+
+		stock, err := ret.Receive(rr)
+		if err != nil {
+			return err
+		}
+
+		for i := range stock {
+			if err = st.returnStock(&stock[i]); err != nil {
+				return err
+			}
+		}
+
+		st.returns[ret.ID] = ret
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
+
+// returnStock is returnStock of the PostgreSQL storage.
+func (s *state) returnStock(rs *domain.ReturnedStock) error {
+	if rs.Disposition == domain.DispositionRestock {
+		return s.add(&rs.AddProduct, rs.Code, domain.MovementReturn)
+	}
+
+	p, ok := s.products[rs.Code]
+	if !ok {
+		return fmt.Errorf("%w: product %s", domain.ErrNotFound, rs.Code)
+	}
+
+	c := stockChange{
+		movementType: domain.MovementReturn,
+		requestID:    rs.RequestID,
+		warehouseID:  rs.WarehouseID,
+		code:         rs.Code,
+	}
+
+	if err := s.changeBucket(c, domain.BucketQuarantined, int64(rs.Quantity)); err != nil {
+		return err
+	}
+
+	if rs.Disposition == domain.DispositionWriteOff {
+		c.movementType = domain.MovementWriteOff
+		return s.changeBucket(c, domain.BucketQuarantined, -int64(rs.Quantity))
+	}
+
+	p.Quantity += rs.Quantity
+	s.products[rs.Code] = p
+
+	return nil
+}
+
+// shippedLines returns the shipment lines cr returns and the shipment they
+// belong to.
+func (s *state) shippedLines(cr *domain.CreateReturn) (int64, []domain.ShipmentLine) {
+	if cr.ShipmentID != 0 {
+		shipment := s.shipments[cr.ShipmentID]
+		return shipment.ID, shipment.Lines
+	}
+
+	// A reservation is shipped once at most.
+	for _, shipment := range s.shipments {
+		for _, line := range shipment.Lines {
+			if line.ReservationID == cr.ReservationID {
+				return shipment.ID, []domain.ShipmentLine{line}
+			}
+		}
+	}
+
+	return 0, nil
+}
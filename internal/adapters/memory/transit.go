@@ -0,0 +1,219 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/akrovv/warehouse/internal/domain"
+)
+
+func (s *productStorage) Dispatch(ctx context.Context, td *domain.TransferProduct) (*domain.TransitTransfer, error) {
+	code, err := parseCode(td.Code)
+	if err != nil {
+		return nil, err
+	}
+
+	t := domain.NewTransitTransfer(td)
+	t.Code = code
+
+	err = s.db.withTx(ctx, func(st *state) error {
+		to, err := st.activeWarehouse(td.WarehouseToID)
+		if err != nil {
+			return err
+		}
+
+		if !to.Availability {
+			return fmt.Errorf("%w: destination warehouse %d", domain.ErrWarehouseUnavailable, to.ID)
+		}
+
+		err = st.changeStock(stockChange{
+			movementType: domain.MovementDispatch,
+			requestID:    td.RequestID,
+			warehouseID:  td.WarehouseFromID,
+			code:         code,
+			available:    -int64(td.Quantity),
+		})
+
+		if err != nil {
+			return err
+		}
+
+		lots, err := st.takeLots(lotPick{
+			warehouseID: td.WarehouseFromID,
+			code:        code,
+			quantity:    td.Quantity,
+			number:      td.Lot,
+		})
+
+		if err != nil {
+			return err
+		}
+
+		bins := st.takeBins(td.WarehouseFromID, code, td.Quantity, false)
+
+		if _, ok := st.warehouses[td.WarehouseToID]; !ok {
+			return fmt.Errorf("%w: warehouse %d", domain.ErrNotFound, td.WarehouseToID)
+		}
+
+		st.transferSeq++
+		t.ID = st.transferSeq
+		t.CreatedAt = time.Now()
+		t.Lots = lots
+		st.transfers[t.ID] = t
+
+		t.Bins = bins
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+// Receive is Receive of the PostgreSQL storage.
+func (s *productStorage) Receive(ctx context.Context, rt *domain.ReceiveTransfer) (*domain.TransitTransfer, error) {
+	t := domain.TransitTransfer{}
+
+	err := s.db.withTx(ctx, func(st *state) error {
+		var err error
+		if t, err = st.openTransfer(rt.TransferID); err != nil {
+			return err
+		}
+
+		quantity, err := t.Receive(rt.Quantity)
+		if err != nil {
+			return err
+		}
+
+		err = st.receiveStock(stockChange{
+			movementType: domain.MovementReceive,
+			requestID:    rt.RequestID,
+			warehouseID:  t.WarehouseToID,
+			code:         t.Code,
+			available:    int64(quantity),
+		})
+
+		if err != nil {
+			return err
+		}
+
+		picked, err := domain.PickLots(t.WarehouseToID, t.Code, t.Lots, quantity)
+		if err != nil {
+			return err
+		}
+
+		// The lots slice is shared with the committed state, it is replaced
+		// rather than changed in place.
+		lots := make([]domain.LotQuantity, 0, len(t.Lots))
+		for i, lot := range t.Lots {
+			if i < len(picked) {
+				st.changeLot(t.WarehouseToID, lotKey{code: t.Code, number: lot.Number}, int64(picked[i].Quantity), 0)
+				lot.Quantity -= picked[i].Quantity
+			}
+
+			if lot.Quantity > 0 {
+				lots = append(lots, lot)
+			}
+		}
+
+		t.Lots = lots
+		st.transfers[t.ID] = t
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+// CancelTransfer is CancelTransfer of the PostgreSQL storage.
+func (s *productStorage) CancelTransfer(ctx context.Context, ct *domain.CancelTransfer) (*domain.TransitTransfer, error) {
+	t := domain.TransitTransfer{}
+
+	err := s.db.withTx(ctx, func(st *state) error {
+		var err error
+		if t, err = st.openTransfer(ct.TransferID); err != nil {
+			return err
+		}
+
+		err = st.receiveStock(stockChange{
+			movementType: domain.MovementDispatchCancel,
+			requestID:    ct.RequestID,
+			warehouseID:  t.WarehouseFromID,
+			code:         t.Code,
+			available:    int64(t.InTransit()),
+		})
+
+		if err != nil {
+			return err
+		}
+
+		for _, lot := range t.Lots {
+			st.changeLot(t.WarehouseFromID, lotKey{code: t.Code, number: lot.Number}, int64(lot.Quantity), 0)
+		}
+
+		t.Status = domain.TransferCanceled
+		t.Lots = nil
+		st.transfers[t.ID] = t
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+func (s *productStorage) ListTransfers(ctx context.Context, f *domain.TransferFilter) ([]domain.TransitTransfer, error) {
+	code := f.Code
+	if code != "" {
+		var err error
+		if code, err = parseCode(code); err != nil {
+			return nil, err
+		}
+	}
+
+	transfers := make([]domain.TransitTransfer, 0, domain.BasicSliceLength)
+
+	err := s.db.read(ctx, func(st *state) error {
+		for _, t := range st.transfers {
+			switch {
+			case t.Status != domain.TransferInTransit,
+				f.WarehouseID != 0 && t.WarehouseFromID != f.WarehouseID && t.WarehouseToID != f.WarehouseID,
+				code != "" && t.Code != code:
+				continue
+			}
+
+			transfers = append(transfers, t)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(transfers, func(i, j int) bool { return transfers[i].ID < transfers[j].ID })
+
+	return page(transfers, f.Limit, f.Offset), nil
+}
+
+func (s *state) openTransfer(id int64) (domain.TransitTransfer, error) {
+	t, ok := s.transfers[id]
+	if !ok || t.Status != domain.TransferInTransit {
+		return domain.TransitTransfer{}, fmt.Errorf("%w: open transfer %d", domain.ErrNotFound, id)
+	}
+
+	return t, nil
+}
@@ -0,0 +1,346 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/akrovv/warehouse/internal/domain"
+)
+
+type warehouseStorage struct {
+	db *DB
+}
+
+func NewWarehouseStorage(db *DB) *warehouseStorage {
+	return &warehouseStorage{
+		db: db,
+	}
+}
+
+func (s *warehouseStorage) Create(ctx context.Context, warehouse *domain.Warehouse) error {
+	return s.db.withTx(ctx, func(st *state) error {
+		st.warehouseSeq++
+		warehouse.ID = st.warehouseSeq
+
+		created := *warehouse
+		created.ArchivedAt = nil
+		st.warehouses[created.ID] = created
+
+		return nil
+	})
+}
+
+func (s *warehouseStorage) Get(ctx context.Context, gw *domain.GetWarehouse) (*domain.Warehouse, error) {
+	warehouse := domain.Warehouse{}
+
+	err := s.db.read(ctx, func(st *state) error {
+		w, ok := st.warehouses[gw.ID]
+		if !ok {
+			return fmt.Errorf("%w: warehouse %d", domain.ErrNotFound, gw.ID)
+		}
+
+		warehouse = w
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &warehouse, nil
+}
+
+func (s *warehouseStorage) List(ctx context.Context, f *domain.WarehouseFilter) ([]domain.Warehouse, error) {
+	warehouses := make([]domain.Warehouse, 0, domain.BasicSliceLength)
+
+	err := s.db.read(ctx, func(st *state) error {
+		for _, w := range st.warehouses {
+			if w.ArchivedAt != nil && !f.IncludeArchived {
+				continue
+			}
+
+			warehouses = append(warehouses, w)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(warehouses, func(i, j int) bool { return warehouses[i].ID < warehouses[j].ID })
+
+	return page(warehouses, f.Limit, f.Offset), nil
+}
+
+func (s *warehouseStorage) Update(ctx context.Context, uw *domain.UpdateWarehouse) (*domain.Warehouse, error) {
+	warehouse := domain.Warehouse{}
+
+	err := s.db.withTx(ctx, func(st *state) error {
+		w, ok := st.warehouses[uw.ID]
+		if !ok {
+			return fmt.Errorf("%w: warehouse %d", domain.ErrNotFound, uw.ID)
+		}
+
+		w.Name = uw.Name
+		st.warehouses[w.ID] = w
+		warehouse = w
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &warehouse, nil
+}
+
+func (s *warehouseStorage) SetAvailability(ctx context.Context, sa *domain.SetAvailability) (*domain.AvailabilityChange, error) {
+	change := domain.AvailabilityChange{}
+
+	err := s.db.withTx(ctx, func(st *state) error {
+		w, err := st.activeWarehouse(sa.ID)
+		if err != nil {
+			return err
+		}
+
+		change.Warehouse = w
+
+		if !sa.Availability {
+			change.OpenReservations = st.openReservations(sa.ID)
+			if len(change.OpenReservations) > 0 && !sa.Force {
+				return nil
+			}
+		}
+
+		w.Availability = sa.Availability
+		st.warehouses[w.ID] = w
+
+		change.Warehouse = w
+		change.Applied = true
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &change, nil
+}
+
+func (s *warehouseStorage) GetLeftOvers(ctx context.Context, gw *domain.GetFromWarehouse) ([]domain.Product, error) {
+	products := make([]domain.Product, 0, domain.BasicSliceLength)
+
+	err := s.db.read(ctx, func(st *state) error {
+		if w := st.warehouses[gw.WarehouseID]; !w.Availability {
+			return nil
+		}
+
+		for _, p := range st.sortedProducts() {
+			stock, ok := st.stock[stockKey{warehouseID: gw.WarehouseID, code: p.Code}]
+			switch {
+			case !ok,
+				stock.available == 0 && !gw.AllBuckets,
+				stock.available+stock.reserved+stock.quarantined+stock.damaged == 0:
+				continue
+			}
+
+			p.Quantity = stock.available
+			if gw.AllBuckets {
+				p.Buckets = &domain.StockBuckets{
+					Available:   stock.available,
+					Reserved:    stock.reserved,
+					Quarantined: stock.quarantined,
+					Damaged:     stock.damaged,
+				}
+			}
+			if gw.ByLot {
+				p.Lots = st.availableLots(gw.WarehouseID, p.Code)
+			}
+
+			if gw.ByBin {
+				p.Bins = st.stockByBin(gw.WarehouseID, p.Code)
+			}
+
+			products = append(products, p.Product)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(products) == 0 {
+		return nil, fmt.Errorf("%w: warehouse %d has no available products", domain.ErrNotFound, gw.WarehouseID)
+	}
+
+	return products, nil
+}
+
+func (s *warehouseStorage) Decommission(ctx context.Context, d *domain.Decommission) (*domain.DecommissionResult, error) {
+	result := domain.DecommissionResult{
+		Transfers: make([]domain.TransferProduct, 0, domain.BasicSliceLength),
+	}
+
+	err := s.db.withTx(ctx, func(st *state) error {
+		w, err := st.activeWarehouse(d.WarehouseID)
+		if err != nil {
+			return err
+		}
+
+		if !w.Availability {
+			return fmt.Errorf("%w: warehouse %d must be available to move its stock out",
+				domain.ErrWarehouseUnavailable, d.WarehouseID)
+		}
+
+		if reservations := st.openReservations(d.WarehouseID); len(reservations) > 0 {
+			return fmt.Errorf("%w: warehouse %d has %d active reservations",
+				domain.ErrOpenReservations, d.WarehouseID, len(reservations))
+		}
+
+		if err = st.decommissionBlockers(d.WarehouseID).Err(d.WarehouseID); err != nil {
+			return err
+		}
+
+		var quarantined, damaged uint64
+		for key, stock := range st.stock {
+			if key.warehouseID == d.WarehouseID {
+				quarantined += stock.quarantined
+				damaged += stock.damaged
+			}
+		}
+
+		if quarantined > 0 || damaged > 0 {
+			return fmt.Errorf("%w: warehouse %d holds %d quarantined and %d damaged items",
+				domain.ErrValidationFailed, d.WarehouseID, quarantined, damaged)
+		}
+
+		for i := range d.Plan {
+			td := d.Plan[i].Transfer(d)
+			if err = st.transfer(&td); err != nil {
+				return fmt.Errorf("plan line %d: %w", i, err)
+			}
+
+			result.Transfers = append(result.Transfers, td)
+		}
+
+		for _, wp := range st.availableStock(d.WarehouseID) {
+			if d.TargetID == 0 {
+				return fmt.Errorf("%w: %d of %s are not in the plan and there is no target_id",
+					domain.ErrValidationFailed, wp.Quantity, wp.Code)
+			}
+
+			td := domain.TransferProduct{
+				WarehouseFromID: d.WarehouseID,
+				WarehouseToID:   d.TargetID,
+				Code:            wp.Code,
+				Quantity:        wp.Quantity,
+				RequestID:       d.RequestID,
+			}
+
+			if err = st.transfer(&td); err != nil {
+				return err
+			}
+
+			result.Transfers = append(result.Transfers, td)
+		}
+
+		archivedAt := time.Now()
+		w.Availability = false
+		w.ArchivedAt = &archivedAt
+		st.warehouses[w.ID] = w
+		result.Warehouse = w
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// activeWarehouse returns a warehouse that is not archived.
+func (s *state) activeWarehouse(id int64) (domain.Warehouse, error) {
+	w, ok := s.warehouses[id]
+	if !ok || w.ArchivedAt != nil {
+		return domain.Warehouse{}, fmt.Errorf("%w: warehouse %d", domain.ErrNotFound, id)
+	}
+
+	return w, nil
+}
+
+func (s *state) openReservations(warehouseID int64) []domain.Reservation {
+	reservations := make([]domain.Reservation, 0, domain.BasicSliceLength)
+	for _, r := range s.sortedReservations() {
+		if r.WarehouseID == warehouseID && r.Status == domain.ReservationActive {
+			reservations = append(reservations, r)
+		}
+	}
+
+	return reservations
+}
+
+func (s *state) availableStock(warehouseID int64) []domain.WarehouseProduct {
+	stock := make([]domain.WarehouseProduct, 0, domain.BasicSliceLength)
+	for key, st := range s.stock {
+		if key.warehouseID == warehouseID && st.available > 0 {
+			stock = append(stock, domain.WarehouseProduct{
+				WarehouseID: warehouseID,
+				Code:        key.code,
+				Quantity:    st.available,
+			})
+		}
+	}
+
+	sort.Slice(stock, func(i, j int) bool { return stock[i].Code < stock[j].Code })
+
+	return stock
+}
+
+// decommissionBlockers is decommissionBlockers of the PostgreSQL storage.
+func (s *state) decommissionBlockers(warehouseID int64) *domain.DecommissionBlockers {
+	b := domain.DecommissionBlockers{}
+
+	for id, t := range s.transfers {
+		if t.Status == domain.TransferInTransit && (t.WarehouseFromID == warehouseID || t.WarehouseToID == warehouseID) {
+			b.Transfers = append(b.Transfers, id)
+		}
+	}
+
+	for id, po := range s.orders {
+		if po.Status == domain.PurchaseOrderOpen && slices.ContainsFunc(po.Lines, func(l domain.PurchaseOrderLine) bool {
+			return l.WarehouseID == warehouseID && l.Received < l.Ordered
+		}) {
+			b.PurchaseOrders = append(b.PurchaseOrders, id)
+		}
+	}
+
+	for id, a := range s.adjustments {
+		if a.WarehouseID == warehouseID && a.Status == domain.AdjustmentPending {
+			b.Adjustments = append(b.Adjustments, id)
+		}
+	}
+
+	for id, c := range s.counts {
+		if c.WarehouseID == warehouseID && c.Status == domain.CountOpen {
+			b.Counts = append(b.Counts, id)
+		}
+	}
+
+	for _, ids := range [][]int64{b.Transfers, b.PurchaseOrders, b.Adjustments, b.Counts} {
+		slices.Sort(ids)
+	}
+
+	return &b
+}
@@ -0,0 +1,75 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/akrovv/warehouse/internal/domain"
+)
+
+type availabilityTestCase struct {
+	sa            domain.SetAvailability
+	reservations  int
+	expectApplied bool
+}
+
+func TestWarehouseSetAvailability(t *testing.T) {
+	db := newTestDB(t)
+	storage := NewWarehouseStorage(db)
+
+	wp := domain.WarehouseProduct{WarehouseID: 1, Code: testCode, Quantity: 1}
+	r := domain.NewReservation(&wp, time.Now().Add(time.Hour))
+	if err := NewProductStorage(db).Reserve(context.Background(), &r); err != nil {
+		t.Fatalf("can't reserve: %s", err)
+	}
+
+	testCases := []availabilityTestCase{
+		{sa: domain.SetAvailability{ID: 1}, reservations: 1, expectApplied: false},
+		{sa: domain.SetAvailability{ID: 1, Force: true}, reservations: 1, expectApplied: true},
+		{sa: domain.SetAvailability{ID: 2}, reservations: 0, expectApplied: true},
+	}
+
+	for _, tc := range testCases {
+		change, err := storage.SetAvailability(context.Background(), &tc.sa)
+		if err != nil {
+			t.Fatalf("expected: %v, got: %v", nil, err)
+		}
+
+		if change.Applied != tc.expectApplied || len(change.OpenReservations) != tc.reservations {
+			t.Fatalf("expected: %v, %d, got: %+v", tc.expectApplied, tc.reservations, change)
+		}
+	}
+}
+
+func TestWarehouseDecommission(t *testing.T) {
+	db := newTestDB(t)
+	storage := NewWarehouseStorage(db)
+
+	_, err := storage.Decommission(context.Background(), &domain.Decommission{WarehouseID: 1, Plan: []domain.DecommissionLine{
+		{WarehouseToID: 2, Code: testCode, Quantity: 4},
+	}})
+	if !errors.Is(err, domain.ErrValidationFailed) {
+		t.Fatalf("expected: %v, got: %v", domain.ErrValidationFailed, err)
+	}
+
+	result, err := storage.Decommission(context.Background(), &domain.Decommission{WarehouseID: 1, TargetID: 2})
+	if err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+
+	if result.Warehouse.ArchivedAt == nil || result.Warehouse.Availability || len(result.Transfers) != 1 {
+		t.Fatalf("expected archived warehouse with one transfer, got: %+v", result)
+	}
+
+	leftovers, err := storage.GetLeftOvers(context.Background(), &domain.GetFromWarehouse{WarehouseID: 2})
+	if err != nil || leftovers[0].Quantity != 10 {
+		t.Fatalf("expected 10 units in warehouse 2, got: %v, %v", leftovers, err)
+	}
+
+	_, err = storage.GetLeftOvers(context.Background(), &domain.GetFromWarehouse{WarehouseID: 1})
+	if !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("expected: %v, got: %v", domain.ErrNotFound, err)
+	}
+}
@@ -0,0 +1,197 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/akrovv/warehouse/internal/domain"
+)
+
+const adjustmentColumns = `id, warehouse_id, product_code, bucket, quantity, reason, lot_number, status,
+	requested_by, decided_by, request_id, created_at, decided_at`
+
+func adjustmentFields(a *domain.Adjustment) []any {
+	return []any{&a.ID, &a.WarehouseID, &a.Code, &a.Bucket, &a.Quantity, &a.Reason, &a.Lot, &a.Status,
+		&a.RequestedBy, &a.DecidedBy, &a.RequestID, &a.CreatedAt, &a.DecidedAt}
+}
+
+// Adjust records the adjustment and applies it unless it is pending.
+func (s *productStorage) Adjust(ctx context.Context, a *domain.Adjustment) error {
+	return withTx(ctx, s.db, func(tx *sql.Tx) error {
+		if a.Status == domain.AdjustmentApplied {
+			if err := adjust(ctx, tx, a); err != nil {
+				return err
+			}
+		}
+
+		return insertAdjustment(ctx, tx, a)
+	})
+}
+
+func (s *productStorage) ApproveAdjustment(ctx context.Context, d *domain.DecideAdjustment) (*domain.Adjustment, error) {
+	return s.decideAdjustment(ctx, d, true)
+}
+
+func (s *productStorage) RejectAdjustment(ctx context.Context, d *domain.DecideAdjustment) (*domain.Adjustment, error) {
+	return s.decideAdjustment(ctx, d, false)
+}
+
+// decideAdjustment locks the adjustment, so it is applied once at most. If
+// the stock is not there anymore the approval fails and the adjustment stays
+// pending.
+func (s *productStorage) decideAdjustment(ctx context.Context, d *domain.DecideAdjustment, approve bool) (*domain.Adjustment, error) {
+	a := domain.Adjustment{}
+
+	err := withTx(ctx, s.db, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, `SELECT `+adjustmentColumns+` FROM adjustments WHERE id = $1 FOR UPDATE`, d.ID).
+			Scan(adjustmentFields(&a)...)
+
+		if err != nil {
+			return fmt.Errorf("db.QueryRow with command SELECT to adjustments returned: %w", mapError(err))
+		}
+
+		if err = a.Decide(d, approve); err != nil {
+			return err
+		}
+
+		if a.Status == domain.AdjustmentApplied {
+			if err = adjust(ctx, tx, &a); err != nil {
+				return err
+			}
+		}
+
+		err = tx.QueryRowContext(ctx, `
+			UPDATE adjustments SET status = $2, decided_by = $3, decided_at = NOW() WHERE id = $1
+			RETURNING decided_at`,
+			a.ID, a.Status, a.DecidedBy).
+			Scan(&a.DecidedAt)
+
+		if err != nil {
+			return fmt.Errorf("db.QueryRow with command UPDATE to adjustments returned: %w", mapError(err))
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &a, nil
+}
+
+func (s *productStorage) ListAdjustments(ctx context.Context, f *domain.AdjustmentFilter) ([]domain.Adjustment, error) {
+	c := conditions{}
+	c.add("status = $%d", f.Status)
+
+	if f.WarehouseID != 0 {
+		c.add("warehouse_id = $%d", f.WarehouseID)
+	}
+
+	if f.Code != "" {
+		c.add("product_code = $%d", f.Code)
+	}
+
+	query := `SELECT ` + adjustmentColumns + ` FROM adjustments` + c.where() + " ORDER BY id" + c.page(f.Limit, f.Offset)
+
+	rows, err := s.db.QueryContext(ctx, query, c.args...)
+	if err != nil {
+		return nil, fmt.Errorf("db.Query with command SELECT to adjustments returned: %w", mapError(err))
+	}
+	defer rows.Close()
+
+	adjustments := make([]domain.Adjustment, 0, domain.BasicSliceLength)
+	for rows.Next() {
+		a := domain.Adjustment{}
+		if err = rows.Scan(adjustmentFields(&a)...); err != nil {
+			return nil, fmt.Errorf("row scan returned: %w", err)
+		}
+
+		adjustments = append(adjustments, a)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err() returned: %w", err)
+	}
+
+	return adjustments, nil
+}
+
+func insertAdjustment(ctx context.Context, ex executor, a *domain.Adjustment) error {
+	err := ex.QueryRowContext(ctx, `
+		INSERT INTO adjustments (warehouse_id, product_code, bucket, quantity, reason, lot_number, status,
+			requested_by, request_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, created_at`,
+		a.WarehouseID, a.Code, a.Bucket, a.Quantity, a.Reason, a.Lot, a.Status, a.RequestedBy, a.RequestID).
+		Scan(&a.ID, &a.CreatedAt)
+
+	if err != nil {
+		return fmt.Errorf("db.QueryRow with command INSERT to adjustments returned: %w", mapError(err))
+	}
+
+	return nil
+}
+
+// adjust changes the stock bucket and the quantity of the product by the
+// adjustment. Written off available stock leaves its lots and bins like with
+// a transfer, added stock goes unplaced into the default lot.
+func adjust(ctx context.Context, ex executor, a *domain.Adjustment) error {
+	c := stockChange{
+		movementType: domain.MovementAdjust,
+		reason:       a.Reason,
+		requestID:    a.RequestID,
+		warehouseID:  a.WarehouseID,
+		code:         a.Code,
+	}
+
+	switch {
+	case a.Bucket != domain.BucketAvailable:
+		if err := changeBucket(ctx, ex, c, a.Bucket, a.Quantity); err != nil {
+			return err
+		}
+	case a.Quantity < 0:
+		c.available = a.Quantity
+		if err := changeStock(ctx, ex, c); err != nil {
+			return err
+		}
+
+		quantity := uint64(-a.Quantity)
+
+		lots, err := takeLots(ctx, ex, lotPick{
+			warehouseID: a.WarehouseID,
+			code:        a.Code,
+			quantity:    quantity,
+			number:      a.Lot,
+		})
+
+		if err != nil {
+			return err
+		}
+
+		bins, err := takeBins(ctx, ex, a.WarehouseID, a.Code, quantity, false)
+		if err != nil {
+			return err
+		}
+
+		a.Lots = lotQuantities(lots)
+		a.Bins = bins
+	default:
+		c.available = a.Quantity
+		if err := receiveStock(ctx, ex, c); err != nil {
+			return err
+		}
+
+		lotID, err := receiveLot(ctx, ex, a.Code, nil)
+		if err != nil {
+			return err
+		}
+
+		if err = changeLot(ctx, ex, a.WarehouseID, lotID, a.Quantity, 0); err != nil {
+			return err
+		}
+	}
+
+	return changeQuantity(ctx, ex, a.Code, a.Quantity)
+}
@@ -0,0 +1,130 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/akrovv/warehouse/internal/domain"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+var adjustmentColumnNames = []string{"id", "warehouse_id", "product_code", "bucket", "quantity", "reason", "lot_number",
+	"status", "requested_by", "decided_by", "request_id", "created_at", "decided_at"}
+
+func expectAdjustMovement(mock sqlmock.Sqlmock, reason string, delta int64, balance uint64) {
+	mock.ExpectExec("INSERT INTO movements").
+		WithArgs(domain.MovementAdjust, 1, "test-1", domain.BucketAvailable, delta, balance, reason, "").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+}
+
+func TestProductAdjust(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("can't create mock: %s", err)
+	}
+	defer db.Close()
+
+	storage := NewProductStorage(db)
+	a := domain.Adjustment{WarehouseID: 1, Code: "test-1", Bucket: domain.BucketAvailable, Quantity: -3,
+		Reason: domain.ReasonDamage, Status: domain.AdjustmentApplied, RequestedBy: "alice"}
+
+	mock.ExpectBegin()
+	expectChangeStock(mock, 1, "test-1", -3, 0).WillReturnRows(changedRows(7, 0))
+	expectAdjustMovement(mock, domain.ReasonDamage, -3, 7)
+	expectTakeLots(mock, 1, "test-1", "", false, defaultLotRows(10))
+	expectChangeLot(mock, 1, 1, -3, 0)
+	expectTakeBins(mock, 1, "test-1", noBinRows())
+	mock.ExpectExec("UPDATE products SET quantity").
+		WithArgs(-3, "test-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("INSERT INTO adjustments").
+		WithArgs(1, "test-1", domain.BucketAvailable, -3, domain.ReasonDamage, "", domain.AdjustmentApplied, "alice", "").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(5, time.Now()))
+	mock.ExpectCommit()
+
+	if err = storage.Adjust(context.Background(), &a); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if a.ID != 5 || len(a.Lots) != 1 || a.Lots[0].Quantity != 3 {
+		t.Fatalf("expected adjustment 5 of three units of the default lot, got: %+v", a)
+	}
+
+	// A pending adjustment changes no stock.
+	a.Status = domain.AdjustmentPending
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO adjustments").
+		WithArgs(1, "test-1", domain.BucketAvailable, -3, domain.ReasonDamage, "", domain.AdjustmentPending, "alice", "").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(6, time.Now()))
+	mock.ExpectCommit()
+
+	if err = storage.Adjust(context.Background(), &a); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestProductApproveAdjustment(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("can't create mock: %s", err)
+	}
+	defer db.Close()
+
+	storage := NewProductStorage(db)
+	pending := func() *sqlmock.Rows {
+		return sqlmock.NewRows(adjustmentColumnNames).
+			AddRow(6, 1, "test-1", domain.BucketAvailable, 4, domain.ReasonCountCorrection, "", domain.AdjustmentPending,
+				"alice", "", "", time.Now(), nil)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT (.+) FROM adjustments WHERE id = (.+) FOR UPDATE").
+		WithArgs(6).
+		WillReturnRows(pending())
+	mock.ExpectQuery("INSERT INTO warehouse_products").
+		WithArgs(1, "test-1", 4).
+		WillReturnRows(sqlmock.NewRows([]string{"available_quantity", "reserved_quantity"}).AddRow(14, 0))
+	expectAdjustMovement(mock, domain.ReasonCountCorrection, 4, 14)
+	mock.ExpectQuery("INSERT INTO lots").
+		WithArgs("test-1", "", nil, nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "manufactured_at", "expires_at"}).AddRow(1, nil, nil))
+	expectChangeLot(mock, 1, 1, 4, 0)
+	mock.ExpectExec("UPDATE products SET quantity").
+		WithArgs(4, "test-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("UPDATE adjustments").
+		WithArgs(6, domain.AdjustmentApplied, "bob").
+		WillReturnRows(sqlmock.NewRows([]string{"decided_at"}).AddRow(time.Now()))
+	mock.ExpectCommit()
+
+	a, err := storage.ApproveAdjustment(context.Background(), &domain.DecideAdjustment{ID: 6, User: "bob"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if a.Status != domain.AdjustmentApplied || a.DecidedBy != "bob" || a.DecidedAt == nil {
+		t.Fatalf("expected the adjustment approved by bob, got: %+v", a)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT (.+) FROM adjustments WHERE id = (.+) FOR UPDATE").
+		WithArgs(6).
+		WillReturnRows(pending())
+	mock.ExpectRollback()
+
+	_, err = storage.ApproveAdjustment(context.Background(), &domain.DecideAdjustment{ID: 6, User: "alice"})
+	if !errors.Is(err, domain.ErrValidationFailed) {
+		t.Fatalf("expected: %v, got: %v", domain.ErrValidationFailed, err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,82 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/akrovv/warehouse/internal/domain"
+)
+
+// Allocate plans the allocation on stock it keeps locked until the
+// reservations are made.
+func (s *productStorage) Allocate(ctx context.Context, a *domain.Allocation) ([]domain.Reservation, error) {
+	var reservations []domain.Reservation
+
+	err := withTx(ctx, s.db, func(tx *sql.Tx) error {
+		stock, err := reservableStock(ctx, tx, a.Code)
+		if err != nil {
+			return err
+		}
+
+		wps, err := a.Plan(stock)
+		if err != nil {
+			return err
+		}
+
+		reservations = make([]domain.Reservation, 0, len(wps))
+		for i := range wps {
+			r := domain.NewReservation(&wps[i], a.ExpiresAt)
+			if err = reserve(ctx, tx, &r); err != nil {
+				return err
+			}
+
+			reservations = append(reservations, r)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return reservations, nil
+}
+
+// reservableStock locks stock of the product in available warehouses in the
+// order of their ids, like lockStock, and leaves expired lots out of it.
+func reservableStock(ctx context.Context, ex executor, code string) ([]domain.WarehouseStock, error) {
+	rows, err := ex.QueryContext(ctx, `
+		SELECT wp.warehouse_id, wp.available_quantity - COALESCE((
+			SELECT SUM(sl.available_quantity)
+			FROM stock_lots sl JOIN lots l ON l.id = sl.lot_id
+			WHERE sl.warehouse_id = wp.warehouse_id AND l.product_code = wp.product_code
+				AND l.expires_at <= NOW()), 0)
+		FROM warehouse_products wp JOIN warehouses w ON w.id = wp.warehouse_id
+		WHERE wp.product_code = $1 AND w.availability = true
+		ORDER BY wp.warehouse_id
+		FOR UPDATE OF wp`,
+		code)
+
+	if err != nil {
+		return nil, fmt.Errorf("db.Query with command SELECT to warehouse_products returned: %w", mapError(err))
+	}
+	defer rows.Close()
+
+	ws := domain.WarehouseStock{}
+	stock := make([]domain.WarehouseStock, 0, domain.BasicSliceLength)
+	for rows.Next() {
+		if err = rows.Scan(&ws.WarehouseID, &ws.Available); err != nil {
+			return nil, fmt.Errorf("row scan returned: %w", err)
+		}
+
+		stock = append(stock, ws)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err() returned: %w", err)
+	}
+
+	return stock, nil
+}
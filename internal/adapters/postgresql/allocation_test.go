@@ -0,0 +1,85 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/akrovv/warehouse/internal/domain"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+// expectReserve is the reserve of a warehouse without lots and bins.
+func expectReserve(mock sqlmock.Sqlmock, r domain.Reservation, id int64) {
+	quantity := int64(r.Quantity)
+
+	expectChangeStock(mock, r.WarehouseID, r.Code, -quantity, quantity).
+		WillReturnRows(changedRows(0, r.Quantity))
+	expectMovement(mock, domain.MovementReserve, r.WarehouseID, r.Code, domain.BucketAvailable, -quantity, 0)
+	expectMovement(mock, domain.MovementReserve, r.WarehouseID, r.Code, domain.BucketReserved, quantity, r.Quantity)
+	expectTakeLots(mock, r.WarehouseID, r.Code, "", true, defaultLotRows(r.Quantity))
+	expectChangeLot(mock, r.WarehouseID, 1, -quantity, quantity)
+	expectTakeBins(mock, r.WarehouseID, r.Code, noBinRows())
+	mock.ExpectQuery("INSERT INTO reservations").
+		WithArgs(r.OrderRef, r.WarehouseID, r.Code, r.Quantity, r.Status, r.ExpiresAt).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(id, r.ExpiresAt))
+	mock.ExpectExec("INSERT INTO reservation_lots").
+		WithArgs(id, 1, quantity).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+func expectReservableStock(mock sqlmock.Sqlmock, code string) *sqlmock.ExpectedQuery {
+	return mock.ExpectQuery("SELECT (.+) FROM warehouse_products wp (.+) FOR UPDATE OF wp").
+		WithArgs(code)
+}
+
+func TestProductAllocate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("can't create mock: %s", err)
+	}
+	defer db.Close()
+
+	storage := NewProductStorage(db)
+	expiresAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	stockColumns := []string{"warehouse_id", "available"}
+
+	a := domain.Allocation{Code: "test-1", Quantity: 12, Strategy: domain.AllocateSingleFirst, ExpiresAt: expiresAt}
+
+	mock.ExpectBegin()
+	expectReservableStock(mock, a.Code).
+		WillReturnRows(sqlmock.NewRows(stockColumns).AddRow(1, 4).AddRow(2, 10))
+
+	for i, wp := range []domain.WarehouseProduct{{WarehouseID: 1, Quantity: 4}, {WarehouseID: 2, Quantity: 8}} {
+		wp.Code = a.Code
+		expectReserve(mock, domain.NewReservation(&wp, expiresAt), int64(i+1))
+	}
+
+	mock.ExpectCommit()
+
+	reservations, err := storage.Allocate(context.Background(), &a)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(reservations) != 2 || reservations[1].ID != 2 || reservations[1].Quantity != 8 {
+		t.Fatalf("expected 4 and 8 reserved, got: %+v", reservations)
+	}
+
+	a.Quantity = 15
+
+	mock.ExpectBegin()
+	expectReservableStock(mock, a.Code).
+		WillReturnRows(sqlmock.NewRows(stockColumns).AddRow(1, 4).AddRow(2, 10))
+	mock.ExpectRollback()
+
+	var shortage *domain.InsufficientStockError
+	if _, err = storage.Allocate(context.Background(), &a); !errors.As(err, &shortage) || shortage.Available != 14 {
+		t.Fatalf("expected: %v, got: %v", domain.ErrInsufficientStock, err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,300 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/akrovv/warehouse/internal/domain"
+	"github.com/lib/pq"
+)
+
+const binColumns = "b.id, b.warehouse_id, b.zone, b.aisle, b.rack, b.bin"
+
+// binOrder is the order pickers walk the warehouse in, bins are taken in it.
+const binOrder = "b.zone, b.aisle, b.rack, b.bin, b.id"
+
+func binFields(b *domain.Bin) []any {
+	return []any{&b.ID, &b.WarehouseID, &b.Zone, &b.Aisle, &b.Rack, &b.Bin}
+}
+
+func (s *warehouseStorage) CreateBin(ctx context.Context, bin *domain.Bin) error {
+	err := s.db.QueryRowContext(ctx, `INSERT INTO bins (warehouse_id, zone, aisle, rack, bin)
+					   VALUES ($1, $2, $3, $4, $5) RETURNING id`,
+		bin.WarehouseID, bin.Zone, bin.Aisle, bin.Rack, bin.Bin).
+		Scan(&bin.ID)
+
+	if err != nil {
+		return fmt.Errorf("db.QueryRow with command INSERT to bins returned: %w", mapError(err))
+	}
+
+	return nil
+}
+
+func (s *warehouseStorage) ListBins(ctx context.Context, lb *domain.ListBins) ([]domain.Bin, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+binColumns+` FROM bins b WHERE b.warehouse_id = $1
+						   ORDER BY `+binOrder,
+		lb.WarehouseID)
+
+	if err != nil {
+		return nil, fmt.Errorf("db.Query with command SELECT to bins returned: %w", mapError(err))
+	}
+	defer rows.Close()
+
+	bin := domain.Bin{}
+	bins := make([]domain.Bin, 0, domain.BasicSliceLength)
+	for rows.Next() {
+		if err = rows.Scan(binFields(&bin)...); err != nil {
+			return nil, fmt.Errorf("row scan returned: %w", err)
+		}
+
+		bins = append(bins, bin)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err() returned: %w", err)
+	}
+
+	return bins, nil
+}
+
+// Relocate moves available stock between bins, the row lock of the
+// aggregated stock serializes it with reserves and transfers of the product.
+func (s *productStorage) Relocate(ctx context.Context, rl *domain.Relocation) error {
+	return withTx(ctx, s.db, func(tx *sql.Tx) error {
+		var (
+			available    uint64
+			availability bool
+		)
+
+		err := tx.QueryRowContext(ctx, `
+			SELECT wp.available_quantity, w.availability
+			FROM warehouse_products wp JOIN warehouses w ON w.id = wp.warehouse_id
+			WHERE wp.warehouse_id = $1 AND wp.product_code = $2
+			FOR UPDATE OF wp`,
+			rl.WarehouseID, rl.Code).
+			Scan(&available, &availability)
+
+		if err != nil {
+			return fmt.Errorf("db.QueryRow with command SELECT to warehouse_products returned: %w", mapError(err))
+		}
+
+		if !availability {
+			return fmt.Errorf("%w: relocation in no available warehouse %d", domain.ErrWarehouseUnavailable, rl.WarehouseID)
+		}
+
+		if err = checkBins(ctx, tx, rl.WarehouseID, rl.FromBinID, rl.ToBinID); err != nil {
+			return err
+		}
+
+		fromAvailable, err := binAvailable(ctx, tx, rl.WarehouseID, rl.Code, rl.FromBinID)
+		if err != nil {
+			return err
+		}
+
+		if rl.FromBinID == 0 {
+			fromAvailable = available - fromAvailable
+		}
+
+		if fromAvailable < rl.Quantity {
+			return fmt.Errorf("bin %d: %w", rl.FromBinID, &domain.InsufficientStockError{
+				WarehouseID: rl.WarehouseID,
+				Code:        rl.Code,
+				Bucket:      domain.BucketAvailable,
+				Requested:   rl.Quantity,
+				Available:   fromAvailable,
+			})
+		}
+
+		if rl.FromBinID != 0 {
+			if err = changeBin(ctx, tx, rl.FromBinID, rl.Code, -int64(rl.Quantity), 0); err != nil {
+				return err
+			}
+		}
+
+		if rl.ToBinID != 0 {
+			return changeBin(ctx, tx, rl.ToBinID, rl.Code, int64(rl.Quantity), 0)
+		}
+
+		return nil
+	})
+}
+
+// checkBins returns ErrNotFound unless every bin id but 0 is a bin of the
+// warehouse.
+func checkBins(ctx context.Context, ex executor, warehouseID int64, binIDs ...int64) error {
+	ids := make([]int64, 0, len(binIDs))
+	for _, id := range binIDs {
+		if id != 0 {
+			ids = append(ids, id)
+		}
+	}
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	var found int
+	err := ex.QueryRowContext(ctx, `SELECT COUNT(*) FROM bins WHERE warehouse_id = $1 AND id = ANY($2)`,
+		warehouseID, pq.Array(ids)).
+		Scan(&found)
+
+	if err != nil {
+		return fmt.Errorf("db.QueryRow with command SELECT to bins returned: %w", mapError(err))
+	}
+
+	if found != len(ids) {
+		return fmt.Errorf("%w: bins %v in warehouse %d", domain.ErrNotFound, ids, warehouseID)
+	}
+
+	return nil
+}
+
+// binAvailable is available stock of the product in the bin, with bin id 0 it
+// is the stock put into all bins of the warehouse.
+func binAvailable(ctx context.Context, ex executor, warehouseID int64, code string, binID int64) (uint64, error) {
+	var available uint64
+
+	err := ex.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(bs.available_quantity), 0)
+		FROM bin_stock bs JOIN bins b ON b.id = bs.bin_id
+		WHERE b.warehouse_id = $1 AND bs.product_code = $2 AND ($3 = 0 OR b.id = $3)`,
+		warehouseID, code, binID).
+		Scan(&available)
+
+	if err != nil {
+		return 0, fmt.Errorf("db.QueryRow with command SELECT to bin_stock returned: %w", mapError(err))
+	}
+
+	return available, nil
+}
+
+// takeBins takes quantity of available stock from bins in binOrder, the rest
+// is taken from unplaced stock. It runs after the aggregated stock is
+// changed, so bins always have enough.
+func takeBins(ctx context.Context, ex executor, warehouseID int64, code string, quantity uint64, reserve bool) ([]domain.BinQuantity, error) {
+	rows, err := ex.QueryContext(ctx, `
+		SELECT `+binColumns+`, bs.available_quantity
+		FROM bin_stock bs JOIN bins b ON b.id = bs.bin_id
+		WHERE b.warehouse_id = $1 AND bs.product_code = $2 AND bs.available_quantity > 0
+		ORDER BY `+binOrder,
+		warehouseID, code)
+
+	if err != nil {
+		return nil, fmt.Errorf("db.Query with command SELECT to bin_stock returned: %w", mapError(err))
+	}
+	defer rows.Close()
+
+	bins, err := scanBinQuantities(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	picked := domain.PickBins(bins, quantity)
+	for _, bin := range picked {
+		reserved := int64(0)
+		if reserve {
+			reserved = int64(bin.Quantity)
+		}
+
+		if err = changeBin(ctx, ex, bin.ID, code, -int64(bin.Quantity), reserved); err != nil {
+			return nil, err
+		}
+	}
+
+	return picked, nil
+}
+
+// changeBin is changeLot for bins.
+func changeBin(ctx context.Context, ex executor, binID int64, code string, available, reserved int64) error {
+	query := `UPDATE bin_stock
+		SET available_quantity = available_quantity + $3, reserved_quantity = reserved_quantity + $4
+		WHERE bin_id = $1 AND product_code = $2`
+
+	if available >= 0 && reserved >= 0 {
+		query = `INSERT INTO bin_stock (bin_id, product_code, available_quantity, reserved_quantity)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (bin_id, product_code) DO UPDATE
+		SET available_quantity = bin_stock.available_quantity + EXCLUDED.available_quantity,
+			reserved_quantity = bin_stock.reserved_quantity + EXCLUDED.reserved_quantity`
+	}
+
+	if _, err := ex.ExecContext(ctx, query, binID, code, available, reserved); err != nil {
+		return fmt.Errorf("db.Exec with command INSERT/UPDATE to bin_stock returned: %w", mapError(err))
+	}
+
+	return nil
+}
+
+// reservationBins returns the bins the reservation holds units in.
+func reservationBins(ctx context.Context, ex executor, reservationID int64) ([]domain.BinQuantity, error) {
+	rows, err := ex.QueryContext(ctx, `
+		SELECT `+binColumns+`, rb.quantity
+		FROM reservation_bins rb JOIN bins b ON b.id = rb.bin_id
+		WHERE rb.reservation_id = $1
+		ORDER BY `+binOrder,
+		reservationID)
+
+	if err != nil {
+		return nil, fmt.Errorf("db.Query with command SELECT to reservation_bins returned: %w", mapError(err))
+	}
+	defer rows.Close()
+
+	return scanBinQuantities(rows)
+}
+
+func scanBinQuantities(rows *sql.Rows) ([]domain.BinQuantity, error) {
+	bins := make([]domain.BinQuantity, 0, domain.BasicSliceLength)
+	for rows.Next() {
+		bin := domain.BinQuantity{}
+
+		if err := rows.Scan(append(binFields(&bin.Bin), &bin.Quantity)...); err != nil {
+			return nil, fmt.Errorf("row scan returned: %w", err)
+		}
+
+		bins = append(bins, bin)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err() returned: %w", err)
+	}
+
+	return bins, nil
+}
+
+// binStock returns stock of the warehouse by product code and bin, bins that
+// hold neither available nor reserved units are left out.
+func binStock(ctx context.Context, ex executor, warehouseID int64) (map[string][]domain.BinStock, error) {
+	rows, err := ex.QueryContext(ctx, `
+		SELECT bs.product_code, `+binColumns+`, bs.available_quantity, bs.reserved_quantity
+		FROM bin_stock bs JOIN bins b ON b.id = bs.bin_id
+		WHERE b.warehouse_id = $1 AND (bs.available_quantity > 0 OR bs.reserved_quantity > 0)
+		ORDER BY `+binOrder,
+		warehouseID)
+
+	if err != nil {
+		return nil, fmt.Errorf("db.Query with command SELECT to bin_stock returned: %w", mapError(err))
+	}
+	defer rows.Close()
+
+	bins := make(map[string][]domain.BinStock, domain.BasicSliceLength)
+	for rows.Next() {
+		var (
+			code string
+			bin  domain.BinStock
+		)
+
+		fields := append([]any{&code}, binFields(&bin.Bin)...)
+		if err = rows.Scan(append(fields, &bin.Available, &bin.Reserved)...); err != nil {
+			return nil, fmt.Errorf("row scan returned: %w", err)
+		}
+
+		bins[code] = append(bins[code], bin)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err() returned: %w", err)
+	}
+
+	return bins, nil
+}
@@ -0,0 +1,155 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/akrovv/warehouse/internal/domain"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+var binColumnNames = []string{"id", "warehouse_id", "zone", "aisle", "rack", "bin", "quantity"}
+
+// noBinRows are rows of a warehouse that keeps all its stock unplaced.
+func noBinRows() *sqlmock.Rows {
+	return sqlmock.NewRows(binColumnNames)
+}
+
+func expectTakeBins(mock sqlmock.Sqlmock, warehouseID int64, code string, rows *sqlmock.Rows) {
+	mock.ExpectQuery("SELECT (.+) FROM bin_stock").
+		WithArgs(warehouseID, code).
+		WillReturnRows(rows)
+}
+
+func expectChangeBin(mock sqlmock.Sqlmock, binID int64, code string, available, reserved int64) {
+	query := "UPDATE bin_stock"
+	if available >= 0 && reserved >= 0 {
+		query = "INSERT INTO bin_stock"
+	}
+
+	mock.ExpectExec(query).
+		WithArgs(binID, code, available, reserved).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+func expectReservationBins(mock sqlmock.Sqlmock, reservationID int64, rows *sqlmock.Rows) {
+	mock.ExpectQuery("SELECT (.+) FROM reservation_bins").
+		WithArgs(reservationID).
+		WillReturnRows(rows)
+}
+
+type relocateTestCase struct {
+	rl          domain.Relocation
+	available   uint64
+	bins        int
+	binStock    uint64
+	expectError error
+}
+
+func TestProductRelocate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("can't create mock: %s", err)
+	}
+	defer db.Close()
+
+	storage := NewProductStorage(db)
+
+	testCases := []relocateTestCase{
+		{
+			rl:        domain.Relocation{WarehouseID: 1, Code: "test-1", Quantity: 4, ToBinID: 3},
+			available: 10,
+			bins:      1,
+			binStock:  6,
+		},
+		{
+			rl:          domain.Relocation{WarehouseID: 1, Code: "test-1", Quantity: 5, ToBinID: 3},
+			available:   10,
+			bins:        1,
+			binStock:    6,
+			expectError: domain.ErrInsufficientStock,
+		},
+		{
+			rl:        domain.Relocation{WarehouseID: 1, Code: "test-1", Quantity: 6, FromBinID: 3, ToBinID: 4},
+			available: 10,
+			bins:      2,
+			binStock:  6,
+		},
+		{
+			rl:          domain.Relocation{WarehouseID: 1, Code: "test-1", Quantity: 6, FromBinID: 3, ToBinID: 4},
+			bins:        1,
+			expectError: domain.ErrNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT wp.available_quantity, w.availability FROM warehouse_products").
+			WithArgs(tc.rl.WarehouseID, tc.rl.Code).
+			WillReturnRows(sqlmock.NewRows([]string{"available_quantity", "availability"}).AddRow(tc.available, true))
+		mock.ExpectQuery("SELECT COUNT").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(tc.bins))
+
+		if !errors.Is(tc.expectError, domain.ErrNotFound) {
+			mock.ExpectQuery("SELECT COALESCE").
+				WithArgs(tc.rl.WarehouseID, tc.rl.Code, tc.rl.FromBinID).
+				WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(tc.binStock))
+		}
+
+		if tc.expectError == nil {
+			if tc.rl.FromBinID != 0 {
+				expectChangeBin(mock, tc.rl.FromBinID, tc.rl.Code, -int64(tc.rl.Quantity), 0)
+			}
+
+			expectChangeBin(mock, tc.rl.ToBinID, tc.rl.Code, int64(tc.rl.Quantity), 0)
+			mock.ExpectCommit()
+		} else {
+			mock.ExpectRollback()
+		}
+
+		err = storage.Relocate(context.Background(), &tc.rl)
+		if !errors.Is(err, tc.expectError) {
+			t.Fatalf("expected: %v, got: %v", tc.expectError, err)
+		}
+
+		if err = mock.ExpectationsWereMet(); err != nil {
+			t.Fatalf("there were unfulfilled expectations: %s", err)
+		}
+	}
+}
+
+func TestWarehouseGetLeftOversByBin(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("can't create mock: %s", err)
+	}
+	defer db.Close()
+
+	storage := NewWarehouseStorage(db)
+
+	mock.ExpectQuery("SELECT p.name, size, code, available_quantity FROM warehouse_products").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"name", "size", "code", "available_quantity"}).
+			AddRow("test", "test", "test-1", 10).
+			AddRow("test", "test", "test-2", 5))
+	mock.ExpectQuery("SELECT (.+) FROM bin_stock").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"product_code", "id", "warehouse_id", "zone", "aisle", "rack", "bin",
+			"available_quantity", "reserved_quantity"}).
+			AddRow("test-1", 3, 1, "A", "01", "R1", "B1", 4, 2).
+			AddRow("test-1", 4, 1, "A", "01", "R1", "B2", 0, 3))
+
+	products, err := storage.GetLeftOvers(context.Background(), &domain.GetFromWarehouse{WarehouseID: 1, ByBin: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(products[0].Bins) != 2 || products[0].Bins[1].Reserved != 3 || len(products[1].Bins) != 0 {
+		t.Fatalf("unexpected bins: %+v", products)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,73 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/akrovv/warehouse/internal/domain"
+)
+
+func (s *productStorage) MoveStock(ctx context.Context, m *domain.BucketMove) error {
+	return withTx(ctx, s.db, func(tx *sql.Tx) error {
+		return moveStock(ctx, tx, m)
+	})
+}
+
+// moveStock takes stock out of available like transfer does, the ledger gets
+// both buckets with the reason of the move.
+func moveStock(ctx context.Context, ex executor, m *domain.BucketMove) error {
+	c := stockChange{
+		movementType: domain.MovementBucketMove,
+		reason:       m.Reason,
+		requestID:    m.RequestID,
+		warehouseID:  m.WarehouseID,
+		code:         m.Code,
+	}
+
+	if m.From != domain.BucketAvailable {
+		if err := changeBucket(ctx, ex, c, m.From, -int64(m.Quantity)); err != nil {
+			return err
+		}
+	} else {
+		out := c
+		out.available = -int64(m.Quantity)
+		if err := changeStock(ctx, ex, out); err != nil {
+			return err
+		}
+
+		lots, err := takeLots(ctx, ex, lotPick{
+			warehouseID: m.WarehouseID,
+			code:        m.Code,
+			quantity:    m.Quantity,
+			number:      m.Lot,
+		})
+
+		if err != nil {
+			return err
+		}
+
+		bins, err := takeBins(ctx, ex, m.WarehouseID, m.Code, m.Quantity, false)
+		if err != nil {
+			return err
+		}
+
+		m.Lots = lotQuantities(lots)
+		m.Bins = bins
+	}
+
+	if m.To != domain.BucketAvailable {
+		return changeBucket(ctx, ex, c, m.To, int64(m.Quantity))
+	}
+
+	c.available = int64(m.Quantity)
+	if err := changeStock(ctx, ex, c); err != nil {
+		return err
+	}
+
+	lotID, err := receiveLot(ctx, ex, m.Code, nil)
+	if err != nil {
+		return err
+	}
+
+	return changeLot(ctx, ex, m.WarehouseID, lotID, int64(m.Quantity), 0)
+}
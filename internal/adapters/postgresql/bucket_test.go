@@ -0,0 +1,87 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/akrovv/warehouse/internal/domain"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func expectBucketMovement(mock sqlmock.Sqlmock, bucket string, delta int64, balance uint64) {
+	mock.ExpectExec("INSERT INTO movements").
+		WithArgs(domain.MovementBucketMove, 1, "test-1", bucket, delta, balance, domain.ReasonQAHold, "").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+}
+
+func TestProductMoveStock(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("can't create mock: %s", err)
+	}
+	defer db.Close()
+
+	storage := NewProductStorage(db)
+	m := domain.BucketMove{WarehouseID: 1, Code: "test-1", From: domain.BucketAvailable,
+		To: domain.BucketQuarantined, Quantity: 3, Reason: domain.ReasonQAHold}
+
+	mock.ExpectBegin()
+	expectChangeStock(mock, 1, "test-1", -3, 0).WillReturnRows(changedRows(7, 0))
+	expectBucketMovement(mock, domain.BucketAvailable, -3, 7)
+	expectTakeLots(mock, 1, "test-1", "", false, defaultLotRows(10))
+	expectChangeLot(mock, 1, 1, -3, 0)
+	expectTakeBins(mock, 1, "test-1", noBinRows())
+	mock.ExpectQuery("INSERT INTO warehouse_products").
+		WithArgs(1, "test-1", 3).
+		WillReturnRows(sqlmock.NewRows([]string{"quarantined_quantity"}).AddRow(3))
+	expectBucketMovement(mock, domain.BucketQuarantined, 3, 3)
+	mock.ExpectCommit()
+
+	if err = storage.MoveStock(context.Background(), &m); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(m.Lots) != 1 || m.Lots[0].Quantity != 3 {
+		t.Fatalf("expected three units of the default lot, got: %+v", m.Lots)
+	}
+
+	m.From, m.To = domain.BucketQuarantined, domain.BucketAvailable
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("UPDATE warehouse_products SET quarantined_quantity").
+		WithArgs(1, "test-1", -3).
+		WillReturnRows(sqlmock.NewRows([]string{"quarantined_quantity"}).AddRow(0))
+	expectBucketMovement(mock, domain.BucketQuarantined, -3, 0)
+	expectChangeStock(mock, 1, "test-1", 3, 0).WillReturnRows(changedRows(10, 0))
+	expectBucketMovement(mock, domain.BucketAvailable, 3, 10)
+	mock.ExpectQuery("INSERT INTO lots").
+		WithArgs("test-1", "", nil, nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "manufactured_at", "expires_at"}).AddRow(1, nil, nil))
+	expectChangeLot(mock, 1, 1, 3, 0)
+	mock.ExpectCommit()
+
+	if err = storage.MoveStock(context.Background(), &m); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("UPDATE warehouse_products SET quarantined_quantity").
+		WithArgs(1, "test-1", -3).
+		WillReturnRows(sqlmock.NewRows([]string{"quarantined_quantity"}))
+	mock.ExpectQuery("SELECT quarantined_quantity FROM warehouse_products").
+		WithArgs(1, "test-1").
+		WillReturnRows(sqlmock.NewRows([]string{"quarantined_quantity"}).AddRow(1))
+	mock.ExpectRollback()
+
+	err = storage.MoveStock(context.Background(), &m)
+
+	var stockErr *domain.InsufficientStockError
+	if !errors.As(err, &stockErr) || stockErr.Bucket != domain.BucketQuarantined || stockErr.Available != 1 {
+		t.Fatalf("expected a shortage of quarantined stock, got: %v", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("there were unfulfilled expectations: %s", err)
+	}
+}
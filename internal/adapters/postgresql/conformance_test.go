@@ -0,0 +1,63 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/akrovv/warehouse/internal/adapters/storagetest"
+	_ "github.com/lib/pq"
+)
+
+// conformanceDSN points to a database the suite migrates up and truncates
+// before each test.
+const conformanceDSN = "WAREHOUSE_TEST_DSN"
+
+func TestConformance(t *testing.T) {
+	dsn := os.Getenv(conformanceDSN)
+	if dsn == "" {
+		t.Skipf("%s is not set", conformanceDSN)
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("can't open database: %s", err)
+	}
+	defer db.Close()
+
+	migrator, err := NewMigrator(db)
+	if err != nil {
+		t.Fatalf("can't load migrations: %s", err)
+	}
+
+	if _, err = migrator.Up(context.Background()); err != nil {
+		t.Fatalf("can't migrate: %s", err)
+	}
+
+	storagetest.Run(t, func(t *testing.T) storagetest.Storages {
+		// Every table is listed, low_stock_events and movements have no foreign
+		// keys and CASCADE doesn't reach them.
+		_, err := db.Exec(`TRUNCATE warehouses, products, warehouse_products, reservations, shipments,
+						   shipment_lines, movements, lots, stock_lots, reservation_lots, bins, bin_stock,
+						   reservation_bins, low_stock_events, transfers, transfer_lots, suppliers, purchase_orders,
+						   purchase_order_lines, returns, return_lines, adjustments, count_sessions, count_lines
+						   RESTART IDENTITY CASCADE`)
+		if err != nil {
+			t.Fatalf("can't truncate tables: %s", err)
+		}
+
+		return storagetest.Storages{
+			Products:   NewProductStorage(db),
+			Warehouses: NewWarehouseStorage(db),
+			Movements:  NewMovementStorage(db),
+			Purchases:  NewPurchaseStorage(db),
+			Returns:    NewReturnStorage(db),
+			Counts:     NewCountStorage(db),
+			InsertWarehouseProducts: func(ctx context.Context, warehouseID int64, code string) error {
+				_, err := db.ExecContext(ctx, `CALL insertWarehouseProducts($1, $2)`, warehouseID, code)
+				return mapError(err)
+			},
+		}
+	})
+}
@@ -0,0 +1,349 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/akrovv/warehouse/internal/domain"
+	"github.com/lib/pq"
+)
+
+const countColumns = "id, warehouse_id, limited, status, created_at, closed_at, movement_id"
+
+func countFields(s *domain.CountSession) []any {
+	return []any{&s.ID, &s.WarehouseID, &s.Limited, &s.Status, &s.CreatedAt, &s.ClosedAt, &s.MovementID}
+}
+
+type countStorage struct {
+	db *sql.DB
+}
+
+func NewCountStorage(db *sql.DB) *countStorage {
+	return &countStorage{
+		db: db,
+	}
+}
+
+// Open reads the expected quantities and the last movement of the ledger in
+// the transaction that creates the session, so the snapshot is what the
+// session starts from. The warehouse is locked, so concurrent opens wait for
+// each other, and the ledger is locked against writes, so the expected
+// quantities hold every movement up to the last one and none after it. Stock
+// changes don't lock the warehouse row and wait for the ledger only.
+func (s *countStorage) Open(ctx context.Context, oc *domain.OpenCount) (*domain.CountSession, error) {
+	session := domain.CountSession{}
+
+	err := withTx(ctx, s.db, func(tx *sql.Tx) error {
+		var openID int64
+
+		err := tx.QueryRowContext(ctx, `SELECT id FROM warehouses WHERE id = $1 FOR NO KEY UPDATE`, oc.WarehouseID).
+			Scan(&openID)
+
+		if err != nil {
+			return fmt.Errorf("db.QueryRow with command SELECT to warehouses returned: %w", mapError(err))
+		}
+
+		if _, err = tx.ExecContext(ctx, `LOCK TABLE movements IN SHARE MODE`); err != nil {
+			return fmt.Errorf("db.Exec with command LOCK of movements returned: %w", mapError(err))
+		}
+
+		err = tx.QueryRowContext(ctx, `SELECT id FROM count_sessions WHERE warehouse_id = $1 AND status = $2`,
+			oc.WarehouseID, domain.CountOpen).
+			Scan(&openID)
+
+		switch {
+		case err == nil:
+			return fmt.Errorf("%w: warehouse %d has open count %d", domain.ErrValidationFailed, oc.WarehouseID, openID)
+		case !errors.Is(err, sql.ErrNoRows):
+			return fmt.Errorf("db.QueryRow with command SELECT to count_sessions returned: %w", mapError(err))
+		}
+
+		expected, err := expectedStock(ctx, tx, oc)
+		if err != nil {
+			return err
+		}
+
+		session = domain.NewCountSession(oc, expected)
+
+		err = tx.QueryRowContext(ctx, `SELECT COALESCE(MAX(id), 0) FROM movements`).Scan(&session.MovementID)
+		if err != nil {
+			return fmt.Errorf("db.QueryRow with command SELECT to movements returned: %w", mapError(err))
+		}
+
+		err = tx.QueryRowContext(ctx, `
+			INSERT INTO count_sessions (warehouse_id, limited, status, movement_id) VALUES ($1, $2, $3, $4)
+			RETURNING `+countColumns,
+			session.WarehouseID, session.Limited, session.Status, session.MovementID).
+			Scan(countFields(&session)...)
+
+		if err != nil {
+			return fmt.Errorf("db.QueryRow with command INSERT to count_sessions returned: %w", mapError(err))
+		}
+
+		for _, line := range session.Lines {
+			if err = saveCountLine(ctx, tx, session.ID, &line); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+func (s *countStorage) Get(ctx context.Context, gc *domain.GetCount) (*domain.CountSession, error) {
+	session := domain.CountSession{}
+
+	if err := loadCount(ctx, s.db, gc.ID, false, &session); err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+// Submit locks the session, so counts of concurrent scanners add up. The
+// changed lines record the stock moved by the ledger since the session
+// opened.
+func (s *countStorage) Submit(ctx context.Context, cs *domain.CountSubmission) (*domain.CountSession, error) {
+	session := domain.CountSession{}
+
+	err := withTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := loadCount(ctx, tx, cs.SessionID, true, &session); err != nil {
+			return err
+		}
+
+		moved, err := movedStock(ctx, tx, &session)
+		if err != nil {
+			return err
+		}
+
+		codes, err := session.Submit(cs, moved)
+		if err != nil {
+			return err
+		}
+
+		for _, line := range session.Lines {
+			if !slices.Contains(codes, line.Code) {
+				continue
+			}
+
+			if err = saveCountLine(ctx, tx, session.ID, &line); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+// Post records every difference as an adjustment in the transaction that
+// closes the session, one failed adjustment leaves the session open.
+func (s *countStorage) Post(ctx context.Context, pc *domain.PostCount) (*domain.CountSession, error) {
+	session := domain.CountSession{}
+
+	err := withTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := loadCount(ctx, tx, pc.SessionID, true, &session); err != nil {
+			return err
+		}
+
+		adjustments, err := session.Post(pc)
+		if err != nil {
+			return err
+		}
+
+		for i := range adjustments {
+			if adjustments[i].Status == domain.AdjustmentApplied {
+				if err = adjust(ctx, tx, &adjustments[i]); err != nil {
+					return fmt.Errorf("product %s: %w", adjustments[i].Code, err)
+				}
+			}
+
+			if err = insertAdjustment(ctx, tx, &adjustments[i]); err != nil {
+				return err
+			}
+		}
+
+		return closeCount(ctx, tx, &session)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+func (s *countStorage) Cancel(ctx context.Context, cc *domain.CancelCount) (*domain.CountSession, error) {
+	session := domain.CountSession{}
+
+	err := withTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := loadCount(ctx, tx, cc.SessionID, true, &session); err != nil {
+			return err
+		}
+
+		if err := session.Cancel(); err != nil {
+			return err
+		}
+
+		return closeCount(ctx, tx, &session)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+// expectedStock returns the available and reserved units by code of the
+// products oc counts.
+func expectedStock(ctx context.Context, ex executor, oc *domain.OpenCount) (map[string]uint64, error) {
+	c := conditions{}
+	c.add("warehouse_id = $%d", oc.WarehouseID)
+
+	if len(oc.Codes) > 0 {
+		c.add("product_code = ANY($%d)", pq.Array(oc.Codes))
+	}
+
+	rows, err := ex.QueryContext(ctx, `SELECT product_code, available_quantity + reserved_quantity
+		FROM warehouse_products`+c.where(),
+		c.args...)
+
+	if err != nil {
+		return nil, fmt.Errorf("db.Query with command SELECT to warehouse_products returned: %w", mapError(err))
+	}
+	defer rows.Close()
+
+	expected := make(map[string]uint64, domain.BasicSliceLength)
+	for rows.Next() {
+		var (
+			code     string
+			quantity uint64
+		)
+
+		if err = rows.Scan(&code, &quantity); err != nil {
+			return nil, fmt.Errorf("row scan returned: %w", err)
+		}
+
+		expected[code] = quantity
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err() returned: %w", err)
+	}
+
+	return expected, nil
+}
+
+// movedStock returns the net change of the available and reserved units by
+// code that the ledger recorded in the warehouse of the session after its
+// last movement.
+func movedStock(ctx context.Context, ex executor, session *domain.CountSession) (map[string]int64, error) {
+	rows, err := ex.QueryContext(ctx, `SELECT product_code, SUM(delta) FROM movements
+		WHERE warehouse_id = $1 AND bucket IN ($2, $3) AND id > $4
+		GROUP BY product_code`,
+		session.WarehouseID, domain.BucketAvailable, domain.BucketReserved, session.MovementID)
+
+	if err != nil {
+		return nil, fmt.Errorf("db.Query with command SELECT to movements returned: %w", mapError(err))
+	}
+	defer rows.Close()
+
+	moved := make(map[string]int64, domain.BasicSliceLength)
+	for rows.Next() {
+		var (
+			code  string
+			delta int64
+		)
+
+		if err = rows.Scan(&code, &delta); err != nil {
+			return nil, fmt.Errorf("row scan returned: %w", err)
+		}
+
+		moved[code] = delta
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err() returned: %w", err)
+	}
+
+	return moved, nil
+}
+
+// loadCount reads the session with its lines, lock keeps it locked until the
+// transaction ends.
+func loadCount(ctx context.Context, ex executor, id int64, lock bool, session *domain.CountSession) error {
+	query := `SELECT ` + countColumns + ` FROM count_sessions WHERE id = $1`
+	if lock {
+		query += ` FOR UPDATE`
+	}
+
+	if err := ex.QueryRowContext(ctx, query, id).Scan(countFields(session)...); err != nil {
+		return fmt.Errorf("db.QueryRow with command SELECT to count_sessions returned: %w", mapError(err))
+	}
+
+	rows, err := ex.QueryContext(ctx, `
+		SELECT product_code, expected, moved, counted FROM count_lines WHERE session_id = $1 ORDER BY product_code`,
+		id)
+
+	if err != nil {
+		return fmt.Errorf("db.Query with command SELECT to count_lines returned: %w", mapError(err))
+	}
+	defer rows.Close()
+
+	session.Lines = make([]domain.CountLine, 0, domain.BasicSliceLength)
+	for rows.Next() {
+		line := domain.CountLine{}
+		if err = rows.Scan(&line.Code, &line.Expected, &line.Moved, &line.Counted); err != nil {
+			return fmt.Errorf("row scan returned: %w", err)
+		}
+
+		session.Lines = append(session.Lines, line)
+	}
+
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("rows.Err() returned: %w", err)
+	}
+
+	return nil
+}
+
+func saveCountLine(ctx context.Context, ex executor, sessionID int64, line *domain.CountLine) error {
+	_, err := ex.ExecContext(ctx, `
+		INSERT INTO count_lines (session_id, product_code, expected, moved, counted) VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (session_id, product_code) DO UPDATE SET moved = EXCLUDED.moved, counted = EXCLUDED.counted`,
+		sessionID, line.Code, line.Expected, line.Moved, line.Counted)
+
+	if err != nil {
+		return fmt.Errorf("db.Exec with command INSERT to count_lines returned: %w", mapError(err))
+	}
+
+	return nil
+}
+
+func closeCount(ctx context.Context, ex executor, session *domain.CountSession) error {
+	err := ex.QueryRowContext(ctx, `UPDATE count_sessions SET status = $2, closed_at = NOW() WHERE id = $1
+		RETURNING closed_at`,
+		session.ID, session.Status).
+		Scan(&session.ClosedAt)
+
+	if err != nil {
+		return fmt.Errorf("db.QueryRow with command UPDATE to count_sessions returned: %w", mapError(err))
+	}
+
+	return nil
+}
@@ -0,0 +1,214 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/akrovv/warehouse/internal/domain"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+var countRows = []string{"id", "warehouse_id", "limited", "status", "created_at", "closed_at", "movement_id"}
+
+// expectLoadCount loads count 3 of warehouse 1 opened after movement 12,
+// test-1 counted with moved units.
+func expectLoadCount(mock sqlmock.Sqlmock, status string, moved int64) {
+	mock.ExpectQuery("SELECT (.+) FROM count_sessions WHERE id = (.+) FOR UPDATE").
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows(countRows).
+			AddRow(3, 1, false, status, time.Now(), nil, 12))
+	mock.ExpectQuery("SELECT (.+) FROM count_lines").
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"product_code", "expected", "moved", "counted"}).
+			AddRow("test-1", 10, moved, 7).
+			AddRow("test-2", 4, 0, nil))
+}
+
+func expectMovedStock(mock sqlmock.Sqlmock, rows *sqlmock.Rows) {
+	mock.ExpectQuery("SELECT product_code, SUM(.+) FROM movements").
+		WithArgs(1, domain.BucketAvailable, domain.BucketReserved, 12).
+		WillReturnRows(rows)
+}
+
+func TestCountOpen(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("can't create mock: %s", err)
+	}
+	defer db.Close()
+
+	storage := NewCountStorage(db)
+
+	// The session starts from the last movement written before the stock is read.
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id FROM warehouses WHERE id = (.+) FOR NO KEY UPDATE").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectExec("LOCK TABLE movements IN SHARE MODE").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT id FROM count_sessions").
+		WithArgs(1, domain.CountOpen).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery("SELECT product_code, (.+) FROM warehouse_products").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"product_code", "quantity"}).AddRow("test-1", 10))
+	mock.ExpectQuery("SELECT COALESCE(.+) FROM movements").
+		WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(12))
+	mock.ExpectQuery("INSERT INTO count_sessions").
+		WithArgs(1, false, domain.CountOpen, 12).
+		WillReturnRows(sqlmock.NewRows(countRows).
+			AddRow(3, 1, false, domain.CountOpen, time.Now(), nil, 12))
+	mock.ExpectExec("INSERT INTO count_lines").
+		WithArgs(3, "test-1", 10, 0, nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	session, err := storage.Open(context.Background(), &domain.OpenCount{WarehouseID: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if session.ID != 3 || session.MovementID != 12 || len(session.Lines) != 1 || session.Lines[0].Expected != 10 {
+		t.Fatalf("expected count 3 of ten units after movement 12, got: %+v", session)
+	}
+
+	// A second count of the warehouse waits for the first one and is refused.
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id FROM warehouses WHERE id = (.+) FOR NO KEY UPDATE").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectExec("LOCK TABLE movements IN SHARE MODE").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT id FROM count_sessions").
+		WithArgs(1, domain.CountOpen).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+	mock.ExpectRollback()
+
+	_, err = storage.Open(context.Background(), &domain.OpenCount{WarehouseID: 1})
+	if !errors.Is(err, domain.ErrValidationFailed) {
+		t.Fatalf("expected: %v, got: %v", domain.ErrValidationFailed, err)
+	}
+
+	// A missing warehouse is not found before the ledger is locked.
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id FROM warehouses WHERE id = (.+) FOR NO KEY UPDATE").
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectRollback()
+
+	_, err = storage.Open(context.Background(), &domain.OpenCount{WarehouseID: 2})
+	if !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("expected: %v, got: %v", domain.ErrNotFound, err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestCountSubmit(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("can't create mock: %s", err)
+	}
+	defer db.Close()
+
+	storage := NewCountStorage(db)
+
+	// The line records the unit shipped before it was counted.
+	mock.ExpectBegin()
+	expectLoadCount(mock, domain.CountOpen, 0)
+	expectMovedStock(mock, sqlmock.NewRows([]string{"product_code", "sum"}).AddRow("test-1", -1))
+	mock.ExpectExec("INSERT INTO count_lines").
+		WithArgs(3, "test-1", 10, -1, 9).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	session, err := storage.Submit(context.Background(), &domain.CountSubmission{
+		SessionID: 3,
+		Lines:     []domain.CountedQuantity{{Code: "test-1", Quantity: 2}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if line := session.Lines[0]; line.Moved != -1 || *line.Counted != 9 || line.Difference != 0 {
+		t.Fatalf("expected nine units counted after one shipped, got: %+v", line)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestCountPost(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("can't create mock: %s", err)
+	}
+	defer db.Close()
+
+	storage := NewCountStorage(db)
+
+	// A unit shipped before the line was counted is not corrected by it once more.
+	mock.ExpectBegin()
+	expectLoadCount(mock, domain.CountOpen, -1)
+	expectChangeStock(mock, 1, "test-1", -2, 0).WillReturnRows(changedRows(7, 0))
+	expectAdjustMovement(mock, domain.ReasonCountCorrection, -2, 7)
+	expectTakeLots(mock, 1, "test-1", "", false, defaultLotRows(9))
+	expectChangeLot(mock, 1, 1, -2, 0)
+	expectTakeBins(mock, 1, "test-1", noBinRows())
+	mock.ExpectExec("UPDATE products SET quantity").
+		WithArgs(-2, "test-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("INSERT INTO adjustments").
+		WithArgs(1, "test-1", domain.BucketAvailable, -2, domain.ReasonCountCorrection, "", domain.AdjustmentApplied,
+			"alice", "").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(5, time.Now()))
+	mock.ExpectQuery("UPDATE count_sessions").
+		WithArgs(3, domain.CountPosted).
+		WillReturnRows(sqlmock.NewRows([]string{"closed_at"}).AddRow(time.Now()))
+	mock.ExpectCommit()
+
+	session, err := storage.Post(context.Background(), &domain.PostCount{SessionID: 3, User: "alice"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if session.Status != domain.CountPosted || session.ClosedAt == nil || session.Lines[0].Difference != -2 {
+		t.Fatalf("expected a posted count short of two units, got: %+v", session)
+	}
+
+	// A difference above the threshold is recorded pending, the stock stays.
+	mock.ExpectBegin()
+	expectLoadCount(mock, domain.CountOpen, 0)
+	mock.ExpectQuery("INSERT INTO adjustments").
+		WithArgs(1, "test-1", domain.BucketAvailable, -3, domain.ReasonCountCorrection, "", domain.AdjustmentPending,
+			"alice", "").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(6, time.Now()))
+	mock.ExpectQuery("UPDATE count_sessions").
+		WithArgs(3, domain.CountPosted).
+		WillReturnRows(sqlmock.NewRows([]string{"closed_at"}).AddRow(time.Now()))
+	mock.ExpectCommit()
+
+	_, err = storage.Post(context.Background(), &domain.PostCount{SessionID: 3, User: "alice", ApprovalThreshold: 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// A posted count is not posted twice.
+	mock.ExpectBegin()
+	expectLoadCount(mock, domain.CountPosted, 0)
+	mock.ExpectRollback()
+
+	_, err = storage.Post(context.Background(), &domain.PostCount{SessionID: 3, User: "alice"})
+	if !errors.Is(err, domain.ErrValidationFailed) {
+		t.Fatalf("expected: %v, got: %v", domain.ErrValidationFailed, err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("there were unfulfilled expectations: %s", err)
+	}
+}
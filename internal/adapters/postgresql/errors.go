@@ -0,0 +1,63 @@
+package postgresql
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/akrovv/warehouse/internal/domain"
+	"github.com/lib/pq"
+)
+
+const (
+	codeUniqueViolation           = "23505"
+	codeForeignKeyViolation       = "23503"
+	codeCheckViolation            = "23514"
+	codeInvalidTextRepresentation = "22P02"
+	codeSerializationFailure      = "40001"
+	codeDeadlockDetected          = "40P01"
+	codeWarehouseUnavailable      = "70001"
+	codeProductNotFound           = "70002"
+	codeUndefinedTable            = "42P01"
+)
+
+var errNoRowsAffected = fmt.Errorf("%w: affected 0 rows", domain.ErrNotFound)
+
+// mapError wraps driver errors with the domain sentinel matching them,
+// the original error is kept in the chain.
+func mapError(err error) error {
+	var pqErr *pq.Error
+
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return fmt.Errorf("%w: %w", domain.ErrNotFound, err)
+	case !errors.As(err, &pqErr):
+		return err
+	}
+
+	switch pqErr.Code {
+	case codeUniqueViolation:
+		return fmt.Errorf("%w: %w", domain.ErrDuplicateCode, err)
+	case codeForeignKeyViolation, codeProductNotFound:
+		return fmt.Errorf("%w: %w", domain.ErrNotFound, err)
+	case codeCheckViolation:
+		return fmt.Errorf("%w: %w", domain.ErrInsufficientStock, err)
+	case codeInvalidTextRepresentation:
+		return fmt.Errorf("%w: %w", domain.ErrValidationFailed, err)
+	case codeWarehouseUnavailable:
+		return fmt.Errorf("%w: %w", domain.ErrWarehouseUnavailable, err)
+	}
+
+	return err
+}
+
+// isRetryable reports whether the transaction failed only because of
+// concurrent transactions and may succeed if run again.
+func isRetryable(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+
+	return pqErr.Code == codeSerializationFailure || pqErr.Code == codeDeadlockDetected
+}
@@ -0,0 +1,60 @@
+package postgresql
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/akrovv/warehouse/internal/domain"
+	"github.com/lib/pq"
+)
+
+type mapErrorTestCase struct {
+	err    error
+	expect error
+}
+
+func TestMapError(t *testing.T) {
+	testCases := []mapErrorTestCase{
+		{
+			err:    sql.ErrNoRows,
+			expect: domain.ErrNotFound,
+		},
+		{
+			err:    &pq.Error{Code: codeUniqueViolation},
+			expect: domain.ErrDuplicateCode,
+		},
+		{
+			err:    &pq.Error{Code: codeForeignKeyViolation},
+			expect: domain.ErrNotFound,
+		},
+		{
+			err:    &pq.Error{Code: codeCheckViolation},
+			expect: domain.ErrInsufficientStock,
+		},
+		{
+			err:    &pq.Error{Code: codeInvalidTextRepresentation},
+			expect: domain.ErrValidationFailed,
+		},
+		{
+			err:    &pq.Error{Code: codeWarehouseUnavailable},
+			expect: domain.ErrWarehouseUnavailable,
+		},
+		{
+			err:    domain.ErrTest,
+			expect: domain.ErrTest,
+		},
+	}
+
+	for _, tc := range testCases {
+		err := mapError(tc.err)
+
+		if !errors.Is(err, tc.expect) {
+			t.Fatalf("expected: %v, got: %v", tc.expect, err)
+		}
+
+		if !errors.Is(err, tc.err) {
+			t.Fatalf("original error %v is lost in: %v", tc.err, err)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/akrovv/warehouse/internal/domain"
+)
+
+// stockLot is a lot quantity together with the id of the lot.
+type stockLot struct {
+	id int64
+	domain.LotQuantity
+}
+
+func lotQuantities(lots []stockLot) []domain.LotQuantity {
+	quantities := make([]domain.LotQuantity, 0, len(lots))
+	for _, lot := range lots {
+		quantities = append(quantities, lot.LotQuantity)
+	}
+
+	return quantities
+}
+
+// receiveLot returns the id of the lot, the lot is created on first receipt.
+// A lot received again must have the same dates.
+func receiveLot(ctx context.Context, ex executor, code string, lot *domain.Lot) (int64, error) {
+	if lot == nil {
+		lot = &domain.Lot{}
+	}
+
+	var (
+		id     int64
+		stored domain.Lot
+	)
+
+	err := ex.QueryRowContext(ctx, `
+		INSERT INTO lots (product_code, number, manufactured_at, expires_at) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (product_code, number) DO UPDATE SET number = EXCLUDED.number
+		RETURNING id, manufactured_at, expires_at`,
+		code, lot.Number, lot.ManufacturedAt, lot.ExpiresAt).
+		Scan(&id, &stored.ManufacturedAt, &stored.ExpiresAt)
+
+	if err != nil {
+		return 0, fmt.Errorf("db.QueryRow with command INSERT to lots returned: %w", mapError(err))
+	}
+
+	if !lot.SameDates(&stored) {
+		return 0, fmt.Errorf("%w: lot %s of %s is already received with other dates",
+			domain.ErrValidationFailed, lot.Number, code)
+	}
+
+	return id, nil
+}
+
+// lotPick takes quantity of available stock from lots first-expired-first-out,
+// number restricts it to one lot. Reserved units stay in the warehouse, so
+// expired lots are skipped for them, the rest leave available stock.
+type lotPick struct {
+	warehouseID int64
+	code        string
+	quantity    uint64
+	number      string
+	reserve     bool
+}
+
+// takeLots runs after the aggregated stock is changed, the row lock of the
+// aggregated stock serializes concurrent picks of the same product.
+func takeLots(ctx context.Context, ex executor, p lotPick) ([]stockLot, error) {
+	rows, err := ex.QueryContext(ctx, `
+		SELECT l.id, l.number, l.manufactured_at, l.expires_at, sl.available_quantity
+		FROM stock_lots sl JOIN lots l ON l.id = sl.lot_id
+		WHERE sl.warehouse_id = $1 AND l.product_code = $2 AND sl.available_quantity > 0
+			AND ($3 = '' OR l.number = $3)
+			AND (NOT $4 OR l.expires_at IS NULL OR l.expires_at > NOW())
+		ORDER BY l.expires_at ASC NULLS LAST, l.id`,
+		p.warehouseID, p.code, p.number, p.reserve)
+
+	if err != nil {
+		return nil, fmt.Errorf("db.Query with command SELECT to stock_lots returned: %w", mapError(err))
+	}
+	defer rows.Close()
+
+	lots := make([]stockLot, 0, domain.BasicSliceLength)
+	for rows.Next() {
+		lot := stockLot{}
+
+		err = rows.Scan(&lot.id, &lot.Number, &lot.ManufacturedAt, &lot.ExpiresAt, &lot.Quantity)
+		if err != nil {
+			return nil, fmt.Errorf("row scan returned: %w", err)
+		}
+
+		lots = append(lots, lot)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err() returned: %w", err)
+	}
+
+	picked, err := domain.PickLots(p.warehouseID, p.code, lotQuantities(lots), p.quantity)
+	if err != nil && p.number != "" {
+		return nil, fmt.Errorf("lot %s: %w", p.number, err)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	taken := make([]stockLot, 0, len(picked))
+	for i, lot := range picked {
+		quantity := int64(lot.Quantity)
+
+		reserved := int64(0)
+		if p.reserve {
+			reserved = quantity
+		}
+
+		if err = changeLot(ctx, ex, p.warehouseID, lots[i].id, -quantity, reserved); err != nil {
+			return nil, err
+		}
+
+		taken = append(taken, stockLot{id: lots[i].id, LotQuantity: lot})
+	}
+
+	return taken, nil
+}
+
+// changeLot changes stock buckets of a lot in a warehouse, the row appears
+// on first receipt. Stock is taken away with a plain update, an upsert would
+// fail the CHECK constraints on the row it proposes to insert.
+func changeLot(ctx context.Context, ex executor, warehouseID, lotID, available, reserved int64) error {
+	query := `UPDATE stock_lots
+		SET available_quantity = available_quantity + $3, reserved_quantity = reserved_quantity + $4
+		WHERE warehouse_id = $1 AND lot_id = $2`
+
+	if available >= 0 && reserved >= 0 {
+		query = `INSERT INTO stock_lots (warehouse_id, lot_id, available_quantity, reserved_quantity)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (warehouse_id, lot_id) DO UPDATE
+		SET available_quantity = stock_lots.available_quantity + EXCLUDED.available_quantity,
+			reserved_quantity = stock_lots.reserved_quantity + EXCLUDED.reserved_quantity`
+	}
+
+	if _, err := ex.ExecContext(ctx, query, warehouseID, lotID, available, reserved); err != nil {
+		return fmt.Errorf("db.Exec with command INSERT/UPDATE to stock_lots returned: %w", mapError(err))
+	}
+
+	return nil
+}
+
+// reservationLots returns the lots the reservation holds.
+func reservationLots(ctx context.Context, ex executor, reservationID int64) ([]stockLot, error) {
+	rows, err := ex.QueryContext(ctx, `
+		SELECT l.id, l.number, l.manufactured_at, l.expires_at, rl.quantity
+		FROM reservation_lots rl JOIN lots l ON l.id = rl.lot_id
+		WHERE rl.reservation_id = $1
+		ORDER BY l.id`,
+		reservationID)
+
+	if err != nil {
+		return nil, fmt.Errorf("db.Query with command SELECT to reservation_lots returned: %w", mapError(err))
+	}
+	defer rows.Close()
+
+	lots := make([]stockLot, 0, domain.BasicSliceLength)
+	for rows.Next() {
+		lot := stockLot{}
+
+		err = rows.Scan(&lot.id, &lot.Number, &lot.ManufacturedAt, &lot.ExpiresAt, &lot.Quantity)
+		if err != nil {
+			return nil, fmt.Errorf("row scan returned: %w", err)
+		}
+
+		lots = append(lots, lot)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err() returned: %w", err)
+	}
+
+	return lots, nil
+}
+
+// availableLots returns available stock of the warehouse by product code and
+// lot, first-expired-first-out.
+func availableLots(ctx context.Context, ex executor, warehouseID int64) (map[string][]domain.LotQuantity, error) {
+	rows, err := ex.QueryContext(ctx, `
+		SELECT l.product_code, l.number, l.manufactured_at, l.expires_at, sl.available_quantity
+		FROM stock_lots sl JOIN lots l ON l.id = sl.lot_id
+		WHERE sl.warehouse_id = $1 AND sl.available_quantity > 0
+		ORDER BY l.expires_at ASC NULLS LAST, l.id`,
+		warehouseID)
+
+	if err != nil {
+		return nil, fmt.Errorf("db.Query with command SELECT to stock_lots returned: %w", mapError(err))
+	}
+	defer rows.Close()
+
+	lots := make(map[string][]domain.LotQuantity, domain.BasicSliceLength)
+	for rows.Next() {
+		var (
+			code string
+			lot  domain.LotQuantity
+		)
+
+		err = rows.Scan(&code, &lot.Number, &lot.ManufacturedAt, &lot.ExpiresAt, &lot.Quantity)
+		if err != nil {
+			return nil, fmt.Errorf("row scan returned: %w", err)
+		}
+
+		lots[code] = append(lots[code], lot)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err() returned: %w", err)
+	}
+
+	return lots, nil
+}
@@ -0,0 +1,127 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/akrovv/warehouse/internal/domain"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+var lotColumns = []string{"id", "number", "manufactured_at", "expires_at", "quantity"}
+
+// defaultLotRows are rows of the default lot, its id is 1 in every test.
+func defaultLotRows(quantity uint64) *sqlmock.Rows {
+	return sqlmock.NewRows(lotColumns).AddRow(1, "", nil, nil, quantity)
+}
+
+func expectTakeLots(mock sqlmock.Sqlmock, warehouseID int64, code, number string, reserve bool, rows *sqlmock.Rows) {
+	mock.ExpectQuery("SELECT (.+) FROM stock_lots").
+		WithArgs(warehouseID, code, number, reserve).
+		WillReturnRows(rows)
+}
+
+func expectChangeLot(mock sqlmock.Sqlmock, warehouseID, lotID, available, reserved int64) {
+	query := "UPDATE stock_lots"
+	if available >= 0 && reserved >= 0 {
+		query = "INSERT INTO stock_lots"
+	}
+
+	mock.ExpectExec(query).
+		WithArgs(warehouseID, lotID, available, reserved).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+func expectReservationLots(mock sqlmock.Sqlmock, reservationID int64, rows *sqlmock.Rows) {
+	mock.ExpectQuery("SELECT (.+) FROM reservation_lots").
+		WithArgs(reservationID).
+		WillReturnRows(rows)
+}
+
+func TestTakeLots(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("can't create mock: %s", err)
+	}
+	defer db.Close()
+
+	soon := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	later := soon.AddDate(0, 1, 0)
+
+	expectTakeLots(mock, 1, "test-1", "", true, sqlmock.NewRows(lotColumns).
+		AddRow(2, "B", nil, soon, 3).
+		AddRow(3, "C", nil, later, 10).
+		AddRow(1, "", nil, nil, 5))
+	expectChangeLot(mock, 1, 2, -3, 3)
+	expectChangeLot(mock, 1, 3, -4, 4)
+
+	lots, err := takeLots(context.Background(), db, lotPick{warehouseID: 1, code: "test-1", quantity: 7, reserve: true})
+	if err != nil || len(lots) != 2 || lots[0].id != 2 || lots[1].Quantity != 4 {
+		t.Fatalf("expected lots B and C, got: %+v, %v", lots, err)
+	}
+
+	expectTakeLots(mock, 1, "test-1", "B", false, sqlmock.NewRows(lotColumns).AddRow(2, "B", nil, soon, 3))
+
+	_, err = takeLots(context.Background(), db, lotPick{warehouseID: 1, code: "test-1", quantity: 4, number: "B"})
+
+	var shortage *domain.InsufficientStockError
+	if !errors.As(err, &shortage) || shortage.Available != 3 || shortage.Requested != 4 {
+		t.Fatalf("expected: %v, got: %v", domain.ErrInsufficientStock, err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestReceiveLot(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("can't create mock: %s", err)
+	}
+	defer db.Close()
+
+	expiresAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	other := expiresAt.AddDate(0, 0, 1)
+
+	testCases := []struct {
+		lot         domain.Lot
+		stored      time.Time
+		expectError error
+	}{
+		{
+			lot:    domain.Lot{Number: "A", ExpiresAt: &expiresAt},
+			stored: expiresAt,
+		},
+		{
+			lot:    domain.Lot{Number: "A"},
+			stored: expiresAt,
+		},
+		{
+			lot:         domain.Lot{Number: "A", ExpiresAt: &other},
+			stored:      expiresAt,
+			expectError: domain.ErrValidationFailed,
+		},
+	}
+
+	for _, tc := range testCases {
+		mock.ExpectQuery("INSERT INTO lots").
+			WithArgs("test-1", tc.lot.Number, tc.lot.ManufacturedAt, tc.lot.ExpiresAt).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "manufactured_at", "expires_at"}).AddRow(4, nil, tc.stored))
+
+		id, err := receiveLot(context.Background(), db, "test-1", &tc.lot)
+		if !errors.Is(err, tc.expectError) {
+			t.Fatalf("expected: %v, got: %v", tc.expectError, err)
+		}
+
+		if err == nil && id != 4 {
+			t.Fatalf("expected: %d, got: %d", 4, id)
+		}
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,158 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/akrovv/warehouse/internal/domain"
+)
+
+func (s *warehouseStorage) SetThreshold(ctx context.Context, t *domain.StockThreshold) error {
+	result, err := s.db.ExecContext(ctx, `UPDATE warehouse_products SET min_quantity = $3, reorder_point = $4
+						  WHERE warehouse_id = $1 AND product_code = $2`,
+		t.WarehouseID, t.Code, t.MinQuantity, t.ReorderPoint)
+
+	if err != nil {
+		return fmt.Errorf("db.Exec with command UPDATE to warehouse_products returned: %w", mapError(err))
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("result.RowsAffected() returned: %w", err)
+	}
+
+	if affected == 0 {
+		return fmt.Errorf("%w: product %s in warehouse %d", domain.ErrNotFound, t.Code, t.WarehouseID)
+	}
+
+	return nil
+}
+
+// LowStock leaves archived warehouses out, they keep no stock on purpose.
+func (s *warehouseStorage) LowStock(ctx context.Context, f *domain.LowStockFilter) ([]domain.LowStockItem, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT wp.warehouse_id, wp.product_code, p.name, wp.available_quantity, wp.min_quantity, wp.reorder_point
+		FROM warehouse_products wp
+		JOIN products p ON p.code = wp.product_code
+		JOIN warehouses w ON w.id = wp.warehouse_id
+		WHERE wp.available_quantity < wp.reorder_point AND w.archived_at IS NULL
+			AND ($1 = 0 OR wp.warehouse_id = $1)
+		ORDER BY wp.warehouse_id, wp.product_code`,
+		f.WarehouseID)
+
+	if err != nil {
+		return nil, fmt.Errorf("db.Query with command SELECT to warehouse_products returned: %w", mapError(err))
+	}
+	defer rows.Close()
+
+	item := domain.LowStockItem{}
+	items := make([]domain.LowStockItem, 0, domain.BasicSliceLength)
+	for rows.Next() {
+		err = rows.Scan(&item.WarehouseID, &item.Code, &item.Name, &item.Available, &item.MinQuantity, &item.ReorderPoint)
+		if err != nil {
+			return nil, fmt.Errorf("row scan returned: %w", err)
+		}
+
+		item.Level = item.StockThreshold.Level(item.Available)
+		items = append(items, item)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err() returned: %w", err)
+	}
+
+	return items, nil
+}
+
+func (s *warehouseStorage) LowStockEvents(ctx context.Context, f *domain.LowStockEventFilter) ([]domain.LowStockEvent, error) {
+	c := conditions{}
+	c.add("id > $%d", f.AfterID)
+
+	if f.WarehouseID != 0 {
+		c.add("warehouse_id = $%d", f.WarehouseID)
+	}
+
+	query := `SELECT id, warehouse_id, product_code, level, threshold, available, request_id, created_at
+			  FROM low_stock_events` + c.where() + " ORDER BY id" + c.limit(f.Limit)
+
+	rows, err := s.db.QueryContext(ctx, query, c.args...)
+	if err != nil {
+		return nil, fmt.Errorf("db.Query with command SELECT to low_stock_events returned: %w", mapError(err))
+	}
+	defer rows.Close()
+
+	e := domain.LowStockEvent{}
+	events := make([]domain.LowStockEvent, 0, domain.BasicSliceLength)
+	for rows.Next() {
+		err = rows.Scan(&e.ID, &e.WarehouseID, &e.Code, &e.Level, &e.Threshold, &e.Available, &e.RequestID, &e.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("row scan returned: %w", err)
+		}
+
+		events = append(events, e)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err() returned: %w", err)
+	}
+
+	return events, nil
+}
+
+// recordLowStock writes an event for every threshold the change took
+// available stock below.
+func recordLowStock(ctx context.Context, ex executor, c stockChange, t *domain.StockThreshold, available uint64) error {
+	before := uint64(int64(available) - c.available)
+
+	for _, level := range t.Crossed(before, available) {
+		_, err := ex.ExecContext(ctx, `
+			INSERT INTO low_stock_events (warehouse_id, product_code, level, threshold, available, request_id)
+			VALUES ($1, $2, $3, $4, $5, $6)`,
+			c.warehouseID, c.code, level, t.Threshold(level), available, c.requestID)
+
+		if err != nil {
+			return fmt.Errorf("db.Exec with command INSERT to low_stock_events returned: %w", mapError(err))
+		}
+	}
+
+	return nil
+}
+
+// StockLevels reads available warehouses only, the others can neither give
+// nor receive stock.
+func (s *productStorage) StockLevels(ctx context.Context, st *domain.SuggestTransfers) ([]domain.StockLevel, error) {
+	c := conditions{}
+	c.add("w.availability = $%d", true)
+
+	if st.Code != "" {
+		c.add("wp.product_code = $%d", st.Code)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT wp.warehouse_id, wp.product_code, wp.available_quantity, wp.min_quantity, wp.reorder_point
+		FROM warehouse_products wp JOIN warehouses w ON w.id = wp.warehouse_id`+c.where()+`
+		ORDER BY wp.product_code, wp.warehouse_id`,
+		c.args...)
+
+	if err != nil {
+		return nil, fmt.Errorf("db.Query with command SELECT to warehouse_products returned: %w", mapError(err))
+	}
+	defer rows.Close()
+
+	level := domain.StockLevel{}
+	levels := make([]domain.StockLevel, 0, domain.BasicSliceLength)
+	for rows.Next() {
+		err = rows.Scan(&level.WarehouseID, &level.Code, &level.Available, &level.MinQuantity, &level.ReorderPoint)
+		if err != nil {
+			return nil, fmt.Errorf("row scan returned: %w", err)
+		}
+
+		levels = append(levels, level)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err() returned: %w", err)
+	}
+
+	return levels, nil
+}
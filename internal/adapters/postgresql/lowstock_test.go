@@ -0,0 +1,140 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/akrovv/warehouse/internal/domain"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func expectLowStockEvent(mock sqlmock.Sqlmock, warehouseID int64, code, level string, threshold, available uint64) {
+	mock.ExpectExec("INSERT INTO low_stock_events").
+		WithArgs(warehouseID, code, level, threshold, available, "").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+}
+
+func TestChangeStockLowStock(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("can't create mock: %s", err)
+	}
+	defer db.Close()
+
+	c := stockChange{movementType: domain.MovementReserve, warehouseID: 1, code: "test-1", available: -8, reserved: 8}
+
+	expectChangeStock(mock, 1, "test-1", -8, 8).WillReturnRows(thresholdRows(2, 8, 3, 6))
+	expectMovement(mock, domain.MovementReserve, 1, "test-1", domain.BucketAvailable, -8, 2)
+	expectMovement(mock, domain.MovementReserve, 1, "test-1", domain.BucketReserved, 8, 8)
+	expectLowStockEvent(mock, 1, "test-1", domain.LowStockReorder, 6, 2)
+	expectLowStockEvent(mock, 1, "test-1", domain.LowStockMin, 3, 2)
+
+	if err = changeStock(context.Background(), db, c); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	c = stockChange{movementType: domain.MovementReserve, warehouseID: 1, code: "test-1", available: -1, reserved: 1}
+
+	expectChangeStock(mock, 1, "test-1", -1, 1).WillReturnRows(thresholdRows(1, 9, 3, 6))
+	expectMovement(mock, domain.MovementReserve, 1, "test-1", domain.BucketAvailable, -1, 1)
+	expectMovement(mock, domain.MovementReserve, 1, "test-1", domain.BucketReserved, 1, 9)
+
+	if err = changeStock(context.Background(), db, c); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestWarehouseSetThreshold(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("can't create mock: %s", err)
+	}
+	defer db.Close()
+
+	storage := NewWarehouseStorage(db)
+	threshold := domain.StockThreshold{WarehouseID: 1, Code: "test-1", MinQuantity: 3, ReorderPoint: 6}
+
+	mock.ExpectExec("UPDATE warehouse_products SET min_quantity").
+		WithArgs(1, "test-1", 3, 6).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE warehouse_products SET min_quantity").
+		WithArgs(1, "test-1", 3, 6).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	if err = storage.SetThreshold(context.Background(), &threshold); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err = storage.SetThreshold(context.Background(), &threshold); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("expected: %v, got: %v", domain.ErrNotFound, err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestWarehouseLowStock(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("can't create mock: %s", err)
+	}
+	defer db.Close()
+
+	storage := NewWarehouseStorage(db)
+
+	mock.ExpectQuery("SELECT (.+) FROM warehouse_products (.+) WHERE wp.available_quantity < wp.reorder_point").
+		WithArgs(0).
+		WillReturnRows(sqlmock.NewRows([]string{"warehouse_id", "product_code", "name", "available_quantity",
+			"min_quantity", "reorder_point"}).
+			AddRow(1, "test-1", "test", 4, 3, 6).
+			AddRow(2, "test-1", "test", 1, 3, 6))
+
+	items, err := storage.LowStock(context.Background(), &domain.LowStockFilter{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(items) != 2 || items[0].Level != domain.LowStockReorder || items[1].Level != domain.LowStockMin {
+		t.Fatalf("unexpected items: %+v", items)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestProductStockLevels(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("can't create mock: %s", err)
+	}
+	defer db.Close()
+
+	storage := NewProductStorage(db)
+
+	mock.ExpectQuery("SELECT (.+) FROM warehouse_products wp JOIN warehouses w (.+) WHERE w.availability = (.+) AND wp.product_code = ").
+		WithArgs(true, "test-1").
+		WillReturnRows(sqlmock.NewRows([]string{"warehouse_id", "product_code", "available_quantity", "min_quantity",
+			"reorder_point"}).
+			AddRow(1, "test-1", 10, 0, 4).
+			AddRow(2, "test-1", 1, 2, 5))
+
+	levels, err := storage.StockLevels(context.Background(), &domain.SuggestTransfers{Code: "test-1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(levels) != 2 || levels[1].WarehouseID != 2 || levels[1].ReorderPoint != 5 || levels[1].Available != 1 {
+		t.Fatalf("unexpected levels: %+v", levels)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,314 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationsLockID serializes migrations run from several instances at once.
+const migrationsLockID = 7351902
+
+var ErrSchemaOutdated = errors.New("schema is outdated")
+
+type Migration struct {
+	Version int
+	Name    string
+	up      string
+	down    string
+}
+
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time
+}
+
+type migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+func NewMigrator(db *sql.DB) (*migrator, error) {
+	migrations, err := loadMigrations(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	return &migrator{
+		db:         db,
+		migrations: migrations,
+	}, nil
+}
+
+// Version is the schema version this build needs.
+func (m *migrator) Version() int {
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Up applies every pending migration, each one in its own transaction
+// together with its row in schema_migrations.
+func (m *migrator) Up(ctx context.Context) ([]Migration, error) {
+	_, err := m.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations(
+								version INTEGER PRIMARY KEY,
+								name VARCHAR(255) NOT NULL,
+								applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW())`)
+	if err != nil {
+		return nil, fmt.Errorf("can't create schema_migrations: %w", err)
+	}
+
+	applied := make([]Migration, 0, len(m.migrations))
+
+	for _, migration := range m.migrations {
+		ok := false
+
+		err = withTx(ctx, m.db, func(tx *sql.Tx) error {
+			ok = false
+
+			if err := lockMigrations(ctx, tx); err != nil {
+				return err
+			}
+
+			var exists bool
+			err := tx.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM schema_migrations WHERE version = $1)`,
+				migration.Version).Scan(&exists)
+			if err != nil || exists {
+				return err
+			}
+
+			if _, err = tx.ExecContext(ctx, migration.up); err != nil {
+				return err
+			}
+
+			_, err = tx.ExecContext(ctx, `INSERT INTO schema_migrations(version, name) VALUES($1, $2)`,
+				migration.Version, migration.Name)
+			ok = err == nil
+
+			return err
+		})
+
+		if err != nil {
+			return applied, fmt.Errorf("can't apply migration %04d_%s: %w", migration.Version, migration.Name, err)
+		}
+
+		if ok {
+			applied = append(applied, migration)
+		}
+	}
+
+	return applied, nil
+}
+
+// Down reverts the latest applied migration, it returns nil if there is
+// nothing to revert.
+func (m *migrator) Down(ctx context.Context) (*Migration, error) {
+	var reverted *Migration
+
+	err := withTx(ctx, m.db, func(tx *sql.Tx) error {
+		reverted = nil
+
+		if err := lockMigrations(ctx, tx); err != nil {
+			return err
+		}
+
+		var version int
+		err := tx.QueryRowContext(ctx, `SELECT version FROM schema_migrations ORDER BY version DESC LIMIT 1`).
+			Scan(&version)
+		if errors.Is(err, sql.ErrNoRows) || isUndefinedTable(err) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		migration, err := m.find(version)
+		if err != nil {
+			return err
+		}
+
+		if _, err = tx.ExecContext(ctx, migration.down); err != nil {
+			return err
+		}
+
+		if _, err = tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = $1`, version); err != nil {
+			return err
+		}
+
+		reverted = &migration
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("can't revert migration: %w", err)
+	}
+
+	return reverted, nil
+}
+
+// Status lists every known migration and when it was applied.
+func (m *migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	appliedAt, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := MigrationStatus{Version: migration.Version, Name: migration.Name}
+		if at, ok := appliedAt[migration.Version]; ok {
+			status.AppliedAt = &at
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+// Check returns ErrSchemaOutdated if the database is behind Version. A newer
+// schema is fine, migrations only add what older builds can ignore.
+func (m *migrator) Check(ctx context.Context) error {
+	appliedAt, err := m.applied(ctx)
+	if err != nil {
+		return err
+	}
+
+	current := 0
+	for version := range appliedAt {
+		current = max(current, version)
+	}
+
+	if current < m.Version() {
+		return fmt.Errorf("%w: database is at version %d, %d is required, run migrate up",
+			ErrSchemaOutdated, current, m.Version())
+	}
+
+	return nil
+}
+
+func (m *migrator) applied(ctx context.Context) (map[int]time.Time, error) {
+	rows, err := m.db.QueryContext(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if isUndefinedTable(err) {
+		return map[int]time.Time{}, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("can't read schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	appliedAt := make(map[int]time.Time, len(m.migrations))
+	for rows.Next() {
+		var (
+			version int
+			at      time.Time
+		)
+
+		if err = rows.Scan(&version, &at); err != nil {
+			return nil, err
+		}
+
+		appliedAt[version] = at
+	}
+
+	return appliedAt, rows.Err()
+}
+
+func (m *migrator) find(version int) (Migration, error) {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return migration, nil
+		}
+	}
+
+	return Migration{}, fmt.Errorf("migration %d is applied, but unknown to this build", version)
+}
+
+func lockMigrations(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, migrationsLockID)
+	return err
+}
+
+func isUndefinedTable(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == codeUndefinedTable
+}
+
+// loadMigrations reads <version>_<name>.up.sql and <version>_<name>.down.sql
+// pairs from dir, versions must go one after another starting from 1.
+func loadMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration, len(entries))
+
+	for _, entry := range entries {
+		base, direction, ok := strings.Cut(strings.TrimSuffix(entry.Name(), ".sql"), ".")
+		prefix, name, found := strings.Cut(base, "_")
+		version, err := strconv.Atoi(prefix)
+
+		if !ok || !found || err != nil || version <= 0 || (direction != "up" && direction != "down") {
+			return nil, fmt.Errorf("unexpected migration file name %q", entry.Name())
+		}
+
+		body, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: name}
+			byVersion[version] = migration
+		}
+
+		if migration.Name != name {
+			return nil, fmt.Errorf("migration %d has two names: %q and %q", version, migration.Name, name)
+		}
+
+		if direction == "up" {
+			migration.up = string(body)
+		} else {
+			migration.down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		migrations = append(migrations, *migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	if len(migrations) == 0 {
+		return nil, fmt.Errorf("no migrations in %s", dir)
+	}
+
+	for i, migration := range migrations {
+		switch {
+		case migration.Version != i+1:
+			return nil, fmt.Errorf("migration %d is missing", i+1)
+		case migration.up == "" || migration.down == "":
+			return nil, fmt.Errorf("migration %d must have both up and down files", migration.Version)
+		}
+	}
+
+	return migrations, nil
+}
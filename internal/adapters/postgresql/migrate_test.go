@@ -0,0 +1,261 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io/fs"
+	"os"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/akrovv/warehouse/internal/domain"
+	"github.com/lib/pq"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+type loadMigrationsTestCase struct {
+	name          string
+	files         []string
+	expectVersion int
+	expectError   bool
+}
+
+func TestLoadMigrations(t *testing.T) {
+	testCases := []loadMigrationsTestCase{
+		{
+			name:          "pairs",
+			files:         []string{"0001_init.up.sql", "0001_init.down.sql", "0002_lots.up.sql", "0002_lots.down.sql", "0003_bins.up.sql", "0003_bins.down.sql", "0004_low_stock.up.sql", "0004_low_stock.down.sql", "0005_transfers.up.sql", "0005_transfers.down.sql", "0006_purchase_orders.up.sql", "0006_purchase_orders.down.sql", "0007_returns.up.sql", "0007_returns.down.sql", "0008_stock_buckets.up.sql", "0008_stock_buckets.down.sql", "0009_adjustments.up.sql", "0009_adjustments.down.sql", "0010_counts.up.sql", "0010_counts.down.sql", "0011_count_movements.up.sql", "0011_count_movements.down.sql"},
+			expectVersion: 11,
+		},
+		{
+			name:        "no down",
+			files:       []string{"0001_init.up.sql"},
+			expectError: true,
+		},
+		{
+			name:        "gap",
+			files:       []string{"0001_init.up.sql", "0001_init.down.sql", "0003_lots.up.sql", "0003_lots.down.sql"},
+			expectError: true,
+		},
+		{
+			name:        "bad name",
+			files:       []string{"init.up.sql", "init.down.sql"},
+			expectError: true,
+		},
+		{
+			name:        "empty",
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		fsys := fstest.MapFS{"migrations": &fstest.MapFile{Mode: fs.ModeDir | 0o755}}
+		for _, name := range tc.files {
+			fsys["migrations/"+name] = &fstest.MapFile{Data: []byte("SELECT 1;")}
+		}
+
+		migrations, err := loadMigrations(fsys, "migrations")
+		if tc.expectError != (err != nil) {
+			t.Fatalf("%s: unexpected error: %v", tc.name, err)
+		}
+
+		if err == nil && migrations[len(migrations)-1].Version != tc.expectVersion {
+			t.Fatalf("%s: expected: %d, got: %v", tc.name, tc.expectVersion, migrations)
+		}
+	}
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	if _, err := NewMigrator(nil); err != nil {
+		t.Fatalf("expected: %v, got: %v", nil, err)
+	}
+}
+
+func TestMigratorUp(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("can't create mock: %s", err)
+	}
+	defer db.Close()
+
+	m := &migrator{db: db, migrations: []Migration{
+		{Version: 1, Name: "init", up: "CREATE TABLE a", down: "DROP TABLE a"},
+		{Version: 2, Name: "more", up: "CREATE TABLE b", down: "DROP TABLE b"},
+	}}
+
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectBegin()
+	mock.ExpectExec("SELECT pg_advisory_xact_lock").WithArgs(migrationsLockID).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT EXISTS").WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectCommit()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("SELECT pg_advisory_xact_lock").WithArgs(migrationsLockID).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT EXISTS").WithArgs(2).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectExec("CREATE TABLE b").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO schema_migrations").WithArgs(2, "more").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	applied, err := m.Up(context.Background())
+	if err != nil || len(applied) != 1 || applied[0].Version != 2 {
+		t.Fatalf("expected migration 2 applied, got: %v, %v", applied, err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestMigratorDown(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("can't create mock: %s", err)
+	}
+	defer db.Close()
+
+	m := &migrator{db: db, migrations: []Migration{
+		{Version: 1, Name: "init", up: "CREATE TABLE a", down: "DROP TABLE a"},
+	}}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("SELECT pg_advisory_xact_lock").WithArgs(migrationsLockID).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT version FROM schema_migrations").WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(1))
+	mock.ExpectExec("DROP TABLE a").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("DELETE FROM schema_migrations").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	reverted, err := m.Down(context.Background())
+	if err != nil || reverted == nil || reverted.Version != 1 {
+		t.Fatalf("expected migration 1 reverted, got: %v, %v", reverted, err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+type checkTestCase struct {
+	rows        *sqlmock.Rows
+	queryError  error
+	expectError error
+}
+
+func TestMigratorCheck(t *testing.T) {
+	columns := []string{"version", "applied_at"}
+	now := time.Now()
+
+	testCases := []checkTestCase{
+		{
+			rows: sqlmock.NewRows(columns).AddRow(1, now).AddRow(2, now),
+		},
+		{
+			rows: sqlmock.NewRows(columns).AddRow(1, now).AddRow(2, now).AddRow(3, now),
+		},
+		{
+			rows:        sqlmock.NewRows(columns).AddRow(1, now),
+			expectError: ErrSchemaOutdated,
+		},
+		{
+			queryError:  &pq.Error{Code: codeUndefinedTable},
+			expectError: ErrSchemaOutdated,
+		},
+	}
+
+	for _, tc := range testCases {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("can't create mock: %s", err)
+		}
+
+		m := &migrator{db: db, migrations: []Migration{{Version: 1}, {Version: 2}}}
+
+		query := mock.ExpectQuery("SELECT version, applied_at FROM schema_migrations")
+		if tc.queryError != nil {
+			query.WillReturnError(tc.queryError)
+		} else {
+			query.WillReturnRows(tc.rows)
+		}
+
+		if err = m.Check(context.Background()); !errors.Is(err, tc.expectError) {
+			t.Fatalf("expected: %v, got: %v", tc.expectError, err)
+		}
+
+		db.Close()
+	}
+}
+
+// TestMigrateFromBaseline migrates a database created by the first
+// deploy/init.sql, in a schema of its own in the conformance database.
+func TestMigrateFromBaseline(t *testing.T) {
+	dsn := os.Getenv(conformanceDSN)
+	if dsn == "" {
+		t.Skipf("%s is not set", conformanceDSN)
+	}
+
+	const schema = "migrate_baseline"
+
+	admin, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("can't open database: %s", err)
+	}
+	defer admin.Close()
+
+	if _, err = admin.Exec(`DROP SCHEMA IF EXISTS ` + schema + ` CASCADE; CREATE SCHEMA ` + schema); err != nil {
+		t.Fatalf("can't create schema: %s", err)
+	}
+	defer admin.Exec(`DROP SCHEMA IF EXISTS ` + schema + ` CASCADE`) //nolint:errcheck
+
+	separator := " "
+	if strings.Contains(dsn, "://") {
+		separator = "?"
+		if strings.Contains(dsn, "?") {
+			separator = "&"
+		}
+	}
+
+	db, err := sql.Open("postgres", dsn+separator+"search_path="+schema)
+	if err != nil {
+		t.Fatalf("can't open database: %s", err)
+	}
+	defer db.Close()
+
+	baseline, err := os.ReadFile("testdata/baseline_init.sql")
+	if err != nil {
+		t.Fatalf("can't read baseline schema: %s", err)
+	}
+
+	if _, err = db.Exec(string(baseline)); err != nil {
+		t.Fatalf("can't create baseline schema: %s", err)
+	}
+
+	if _, err = db.Exec(`INSERT INTO warehouses (name, availability) VALUES ('old', true)`); err != nil {
+		t.Fatalf("can't insert warehouse: %s", err)
+	}
+
+	migrator, err := NewMigrator(db)
+	if err != nil {
+		t.Fatalf("can't load migrations: %s", err)
+	}
+
+	if _, err = migrator.Up(context.Background()); err != nil {
+		t.Fatalf("can't migrate: %s", err)
+	}
+
+	w, err := NewWarehouseStorage(db).Get(context.Background(), &domain.GetWarehouse{ID: 1})
+	if err != nil || w.Name != "old" || w.ArchivedAt != nil {
+		t.Fatalf("expected the old warehouse, got: %+v, %v", w, err)
+	}
+
+	var rule string
+	err = db.QueryRow(`SELECT confdeltype FROM pg_constraint
+					   WHERE conname = 'warehouse_products_warehouse_id_fkey' AND connamespace = $1::regnamespace`,
+		schema).Scan(&rule)
+
+	if err != nil || rule != "r" {
+		t.Fatalf("expected a RESTRICT foreign key, got: %q, %v", rule, err)
+	}
+}
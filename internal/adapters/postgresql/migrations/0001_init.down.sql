@@ -0,0 +1,12 @@
+DROP PROCEDURE IF EXISTS insertWarehouseProducts(INTEGER, UUID);
+
+DROP TABLE IF EXISTS movements;
+DROP TABLE IF EXISTS shipment_lines;
+DROP TABLE IF EXISTS shipments;
+DROP TABLE IF EXISTS reservations;
+DROP TABLE IF EXISTS warehouse_products;
+DROP TABLE IF EXISTS products;
+DROP TABLE IF EXISTS warehouses;
+
+DROP FUNCTION IF EXISTS wareproducts_availability();
+DROP FUNCTION IF EXISTS movements_append_only();
@@ -0,0 +1,135 @@
+CREATE TABLE IF NOT EXISTS warehouses (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    availability BOOLEAN DEFAULT FALSE,
+    archived_at TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS products (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    size TEXT NOT NULL,
+    code UUID UNIQUE NOT NULL,
+    quantity INTEGER NOT NULL DEFAULT 0 CHECK (quantity >= 0)
+);
+
+CREATE TABLE IF NOT EXISTS warehouse_products(
+    id SERIAL PRIMARY KEY,
+    warehouse_id INTEGER REFERENCES warehouses(id) ON DELETE RESTRICT,
+    product_code UUID REFERENCES products(code) ON DELETE CASCADE,
+    available_quantity INTEGER NOT NULL DEFAULT 0 CHECK(available_quantity >= 0),
+    reserved_quantity INTEGER NOT NULL DEFAULT 0 CHECK(reserved_quantity >= 0),
+    CONSTRAINT unique_warehouse_product UNIQUE (warehouse_id, product_code)
+);
+
+-- Databases created by the first deploy/init.sql have neither archived_at
+-- nor the RESTRICT foreign key, the tables above are left as they are there.
+ALTER TABLE warehouses ADD COLUMN IF NOT EXISTS archived_at TIMESTAMPTZ;
+
+ALTER TABLE warehouse_products DROP CONSTRAINT IF EXISTS warehouse_products_warehouse_id_fkey;
+ALTER TABLE warehouse_products ADD CONSTRAINT warehouse_products_warehouse_id_fkey
+    FOREIGN KEY (warehouse_id) REFERENCES warehouses(id) ON DELETE RESTRICT;
+
+CREATE TABLE IF NOT EXISTS reservations(
+    id SERIAL PRIMARY KEY,
+    order_ref VARCHAR(255) NOT NULL DEFAULT '',
+    warehouse_id INTEGER NOT NULL REFERENCES warehouses(id) ON DELETE CASCADE,
+    product_code UUID NOT NULL REFERENCES products(code) ON DELETE CASCADE,
+    quantity INTEGER NOT NULL CHECK(quantity > 0),
+    status VARCHAR(16) NOT NULL DEFAULT 'active',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS reservations_active_expires_at ON reservations (expires_at) WHERE status = 'active';
+
+CREATE TABLE IF NOT EXISTS shipments(
+    id SERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS shipment_lines(
+    id SERIAL PRIMARY KEY,
+    shipment_id INTEGER NOT NULL REFERENCES shipments(id) ON DELETE CASCADE,
+    reservation_id INTEGER NOT NULL,
+    warehouse_id INTEGER NOT NULL,
+    product_code UUID NOT NULL,
+    quantity INTEGER NOT NULL CHECK(quantity > 0),
+    CONSTRAINT unique_shipment_reservation UNIQUE (reservation_id)
+);
+
+CREATE TABLE IF NOT EXISTS movements(
+    id BIGSERIAL PRIMARY KEY,
+    type VARCHAR(32) NOT NULL,
+    warehouse_id INTEGER NOT NULL,
+    product_code UUID NOT NULL,
+    bucket VARCHAR(16) NOT NULL,
+    delta INTEGER NOT NULL CHECK(delta <> 0),
+    balance INTEGER NOT NULL CHECK(balance >= 0),
+    request_id VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS movements_product_code_created_at ON movements (product_code, created_at);
+CREATE INDEX IF NOT EXISTS movements_warehouse_id_created_at ON movements (warehouse_id, created_at);
+
+CREATE OR REPLACE FUNCTION movements_append_only()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'movements are append-only';
+END $$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE TRIGGER tr_movements_append_only
+BEFORE UPDATE OR DELETE ON movements
+FOR EACH ROW
+EXECUTE FUNCTION movements_append_only();
+
+CREATE OR REPLACE FUNCTION wareproducts_availability()
+RETURNS TRIGGER AS $$
+DECLARE
+    available BOOLEAN;
+    warehouse_id INTEGER;
+BEGIN
+    CASE TG_OP
+        WHEN 'INSERT', 'UPDATE' THEN
+            warehouse_id := NEW.warehouse_id;
+        WHEN 'DELETE' THEN
+            warehouse_id := OLD.warehouse_id;
+    END CASE;
+
+    SELECT availability INTO available
+    FROM warehouses 
+    WHERE id = warehouse_id;
+
+    IF available = false then
+        RAISE EXCEPTION USING ERRCODE = 70001, MESSAGE = 'insert/update/delete in no available warehouse';
+    END IF;
+
+    IF TG_OP = 'DELETE' THEN
+        RETURN OLD;
+    END IF;
+
+    RETURN NEW;
+END $$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE TRIGGER tr_wareproducts_availability
+BEFORE INSERT OR UPDATE OR DELETE ON warehouse_products
+FOR EACH ROW
+EXECUTE FUNCTION wareproducts_availability();
+
+CREATE OR REPLACE PROCEDURE insertWarehouseProducts(wi INTEGER, pc UUID)
+AS $$
+DECLARE
+    available_quantity INTEGER;
+BEGIN
+    SELECT quantity INTO available_quantity
+    FROM products
+    WHERE code = pc;
+
+    IF available_quantity IS NULL THEN
+        RAISE EXCEPTION USING ERRCODE = 70002, MESSAGE = 'Dont find proudct with that id'; 
+    END IF;
+
+    INSERT INTO warehouse_products (warehouse_id, product_code, available_quantity, reserved_quantity)
+    VALUES (wi, pc, available_quantity, 0);
+END $$ LANGUAGE plpgsql;
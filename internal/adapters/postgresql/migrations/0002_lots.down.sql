@@ -0,0 +1,20 @@
+CREATE OR REPLACE PROCEDURE insertWarehouseProducts(wi INTEGER, pc UUID)
+AS $$
+DECLARE
+    available_quantity INTEGER;
+BEGIN
+    SELECT quantity INTO available_quantity
+    FROM products
+    WHERE code = pc;
+
+    IF available_quantity IS NULL THEN
+        RAISE EXCEPTION USING ERRCODE = 70002, MESSAGE = 'Dont find proudct with that id'; 
+    END IF;
+
+    INSERT INTO warehouse_products (warehouse_id, product_code, available_quantity, reserved_quantity)
+    VALUES (wi, pc, available_quantity, 0);
+END $$ LANGUAGE plpgsql;
+
+DROP TABLE IF EXISTS reservation_lots;
+DROP TABLE IF EXISTS stock_lots;
+DROP TABLE IF EXISTS lots;
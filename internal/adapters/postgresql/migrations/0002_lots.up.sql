@@ -0,0 +1,66 @@
+CREATE TABLE IF NOT EXISTS lots(
+    id SERIAL PRIMARY KEY,
+    product_code UUID NOT NULL REFERENCES products(code) ON DELETE CASCADE,
+    number VARCHAR(255) NOT NULL DEFAULT '',
+    manufactured_at TIMESTAMPTZ,
+    expires_at TIMESTAMPTZ,
+    CONSTRAINT unique_product_lot UNIQUE (product_code, number)
+);
+
+CREATE TABLE IF NOT EXISTS stock_lots(
+    warehouse_id INTEGER NOT NULL REFERENCES warehouses(id) ON DELETE RESTRICT,
+    lot_id INTEGER NOT NULL REFERENCES lots(id) ON DELETE CASCADE,
+    available_quantity INTEGER NOT NULL DEFAULT 0 CHECK(available_quantity >= 0),
+    reserved_quantity INTEGER NOT NULL DEFAULT 0 CHECK(reserved_quantity >= 0),
+    PRIMARY KEY (warehouse_id, lot_id)
+);
+
+CREATE TABLE IF NOT EXISTS reservation_lots(
+    reservation_id INTEGER NOT NULL REFERENCES reservations(id) ON DELETE CASCADE,
+    lot_id INTEGER NOT NULL REFERENCES lots(id) ON DELETE CASCADE,
+    quantity INTEGER NOT NULL CHECK(quantity > 0),
+    PRIMARY KEY (reservation_id, lot_id)
+);
+
+-- Stock that is already there belongs to the default lot of its product.
+INSERT INTO lots (product_code)
+SELECT code FROM products
+ON CONFLICT (product_code, number) DO NOTHING;
+
+INSERT INTO stock_lots (warehouse_id, lot_id, available_quantity, reserved_quantity)
+SELECT wp.warehouse_id, l.id, wp.available_quantity, wp.reserved_quantity
+FROM warehouse_products wp
+JOIN lots l ON l.product_code = wp.product_code AND l.number = ''
+ON CONFLICT (warehouse_id, lot_id) DO NOTHING;
+
+INSERT INTO reservation_lots (reservation_id, lot_id, quantity)
+SELECT r.id, l.id, r.quantity
+FROM reservations r
+JOIN lots l ON l.product_code = r.product_code AND l.number = ''
+WHERE r.status = 'active'
+ON CONFLICT (reservation_id, lot_id) DO NOTHING;
+
+CREATE OR REPLACE PROCEDURE insertWarehouseProducts(wi INTEGER, pc UUID)
+AS $$
+DECLARE
+    available_quantity INTEGER;
+    default_lot INTEGER;
+BEGIN
+    SELECT quantity INTO available_quantity
+    FROM products
+    WHERE code = pc;
+
+    IF available_quantity IS NULL THEN
+        RAISE EXCEPTION USING ERRCODE = 70002, MESSAGE = 'Dont find proudct with that id'; 
+    END IF;
+
+    INSERT INTO warehouse_products (warehouse_id, product_code, available_quantity, reserved_quantity)
+    VALUES (wi, pc, available_quantity, 0);
+
+    INSERT INTO lots (product_code) VALUES (pc)
+    ON CONFLICT (product_code, number) DO UPDATE SET number = EXCLUDED.number
+    RETURNING id INTO default_lot;
+
+    INSERT INTO stock_lots (warehouse_id, lot_id, available_quantity, reserved_quantity)
+    VALUES (wi, default_lot, available_quantity, 0);
+END $$ LANGUAGE plpgsql;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS reservation_bins;
+DROP TABLE IF EXISTS bin_stock;
+DROP TABLE IF EXISTS bins;
@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS bins(
+    id SERIAL PRIMARY KEY,
+    warehouse_id INTEGER NOT NULL REFERENCES warehouses(id) ON DELETE RESTRICT,
+    zone VARCHAR(64) NOT NULL DEFAULT '',
+    aisle VARCHAR(64) NOT NULL DEFAULT '',
+    rack VARCHAR(64) NOT NULL DEFAULT '',
+    bin VARCHAR(64) NOT NULL,
+    CONSTRAINT unique_warehouse_bin UNIQUE (warehouse_id, zone, aisle, rack, bin)
+);
+
+-- Units of warehouse_products that have no row here are unplaced.
+CREATE TABLE IF NOT EXISTS bin_stock(
+    bin_id INTEGER NOT NULL REFERENCES bins(id) ON DELETE RESTRICT,
+    product_code UUID NOT NULL REFERENCES products(code) ON DELETE CASCADE,
+    available_quantity INTEGER NOT NULL DEFAULT 0 CHECK(available_quantity >= 0),
+    reserved_quantity INTEGER NOT NULL DEFAULT 0 CHECK(reserved_quantity >= 0),
+    PRIMARY KEY (bin_id, product_code)
+);
+
+CREATE TABLE IF NOT EXISTS reservation_bins(
+    reservation_id INTEGER NOT NULL REFERENCES reservations(id) ON DELETE CASCADE,
+    bin_id INTEGER NOT NULL REFERENCES bins(id) ON DELETE RESTRICT,
+    quantity INTEGER NOT NULL CHECK(quantity > 0),
+    PRIMARY KEY (reservation_id, bin_id)
+);
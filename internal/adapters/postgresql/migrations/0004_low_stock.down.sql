@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS low_stock_events;
+
+ALTER TABLE warehouse_products
+    DROP CONSTRAINT IF EXISTS min_quantity_within_reorder_point,
+    DROP COLUMN IF EXISTS reorder_point,
+    DROP COLUMN IF EXISTS min_quantity;
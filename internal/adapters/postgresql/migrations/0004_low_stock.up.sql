@@ -0,0 +1,19 @@
+ALTER TABLE warehouse_products
+    ADD COLUMN IF NOT EXISTS min_quantity INTEGER NOT NULL DEFAULT 0 CHECK(min_quantity >= 0),
+    ADD COLUMN IF NOT EXISTS reorder_point INTEGER NOT NULL DEFAULT 0 CHECK(reorder_point >= 0),
+    ADD CONSTRAINT min_quantity_within_reorder_point CHECK(min_quantity <= reorder_point);
+
+-- Like movements, events are written in the transaction of the stock change
+-- that caused them and keep no foreign keys, so they outlive the product.
+CREATE TABLE IF NOT EXISTS low_stock_events(
+    id BIGSERIAL PRIMARY KEY,
+    warehouse_id INTEGER NOT NULL,
+    product_code UUID NOT NULL,
+    level VARCHAR(16) NOT NULL,
+    threshold INTEGER NOT NULL,
+    available INTEGER NOT NULL,
+    request_id VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS low_stock_events_warehouse_id_id ON low_stock_events (warehouse_id, id);
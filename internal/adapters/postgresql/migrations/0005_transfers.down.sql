@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS transfer_lots;
+DROP TABLE IF EXISTS transfers;
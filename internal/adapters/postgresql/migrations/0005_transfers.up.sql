@@ -0,0 +1,22 @@
+-- Units of a transfer in transit belong to no warehouse, quantity minus
+-- received_quantity of an open transfer is on the way.
+CREATE TABLE IF NOT EXISTS transfers(
+    id SERIAL PRIMARY KEY,
+    warehouse_from_id INTEGER NOT NULL REFERENCES warehouses(id) ON DELETE RESTRICT,
+    warehouse_to_id INTEGER NOT NULL REFERENCES warehouses(id) ON DELETE RESTRICT,
+    product_code UUID NOT NULL REFERENCES products(code) ON DELETE CASCADE,
+    quantity INTEGER NOT NULL CHECK(quantity > 0),
+    received_quantity INTEGER NOT NULL DEFAULT 0 CHECK(received_quantity >= 0),
+    status VARCHAR(16) NOT NULL DEFAULT 'in_transit',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT received_within_quantity CHECK(received_quantity <= quantity)
+);
+
+CREATE INDEX IF NOT EXISTS transfers_in_transit ON transfers (id) WHERE status = 'in_transit';
+
+CREATE TABLE IF NOT EXISTS transfer_lots(
+    transfer_id INTEGER NOT NULL REFERENCES transfers(id) ON DELETE CASCADE,
+    lot_id INTEGER NOT NULL REFERENCES lots(id) ON DELETE CASCADE,
+    quantity INTEGER NOT NULL CHECK(quantity >= 0),
+    PRIMARY KEY (transfer_id, lot_id)
+);
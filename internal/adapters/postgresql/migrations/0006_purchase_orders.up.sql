@@ -0,0 +1,28 @@
+CREATE TABLE IF NOT EXISTS suppliers(
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS purchase_orders(
+    id SERIAL PRIMARY KEY,
+    supplier_id INTEGER NOT NULL REFERENCES suppliers(id) ON DELETE RESTRICT,
+    reference VARCHAR(255) NOT NULL DEFAULT '',
+    status VARCHAR(16) NOT NULL DEFAULT 'open',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    closed_at TIMESTAMPTZ
+);
+
+-- Stock received against a line goes through the same path as Products.Add,
+-- received only counts it.
+CREATE TABLE IF NOT EXISTS purchase_order_lines(
+    id SERIAL PRIMARY KEY,
+    order_id INTEGER NOT NULL REFERENCES purchase_orders(id) ON DELETE CASCADE,
+    warehouse_id INTEGER NOT NULL REFERENCES warehouses(id) ON DELETE RESTRICT,
+    product_code UUID NOT NULL REFERENCES products(code) ON DELETE CASCADE,
+    ordered INTEGER NOT NULL CHECK(ordered > 0),
+    received INTEGER NOT NULL DEFAULT 0 CHECK(received >= 0),
+    CONSTRAINT received_within_ordered CHECK(received <= ordered),
+    CONSTRAINT unique_order_line UNIQUE (order_id, warehouse_id, product_code)
+);
+
+CREATE INDEX IF NOT EXISTS purchase_orders_open ON purchase_orders (id) WHERE status = 'open';
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS return_lines;
+DROP TABLE IF EXISTS returns;
+
+ALTER TABLE warehouse_products
+    DROP COLUMN IF EXISTS quarantined_quantity;
@@ -0,0 +1,28 @@
+ALTER TABLE warehouse_products
+    ADD COLUMN IF NOT EXISTS quarantined_quantity INTEGER NOT NULL DEFAULT 0 CHECK(quarantined_quantity >= 0);
+
+CREATE TABLE IF NOT EXISTS returns(
+    id SERIAL PRIMARY KEY,
+    shipment_id INTEGER NOT NULL REFERENCES shipments(id) ON DELETE CASCADE,
+    reason VARCHAR(255) NOT NULL DEFAULT '',
+    status VARCHAR(16) NOT NULL DEFAULT 'open',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- A line is a shipped reservation, what came back of it is counted by
+-- disposition.
+CREATE TABLE IF NOT EXISTS return_lines(
+    id SERIAL PRIMARY KEY,
+    return_id INTEGER NOT NULL REFERENCES returns(id) ON DELETE CASCADE,
+    reservation_id INTEGER NOT NULL,
+    warehouse_id INTEGER NOT NULL REFERENCES warehouses(id) ON DELETE RESTRICT,
+    product_code UUID NOT NULL REFERENCES products(code) ON DELETE CASCADE,
+    quantity INTEGER NOT NULL CHECK(quantity > 0),
+    restocked INTEGER NOT NULL DEFAULT 0 CHECK(restocked >= 0),
+    quarantined INTEGER NOT NULL DEFAULT 0 CHECK(quarantined >= 0),
+    written_off INTEGER NOT NULL DEFAULT 0 CHECK(written_off >= 0),
+    CONSTRAINT received_within_quantity CHECK(restocked + quarantined + written_off <= quantity),
+    CONSTRAINT unique_return_reservation UNIQUE (return_id, reservation_id)
+);
+
+CREATE INDEX IF NOT EXISTS return_lines_reservation_id ON return_lines (reservation_id);
@@ -0,0 +1,5 @@
+ALTER TABLE movements
+    DROP COLUMN IF EXISTS reason;
+
+ALTER TABLE warehouse_products
+    DROP COLUMN IF EXISTS damaged_quantity;
@@ -0,0 +1,5 @@
+ALTER TABLE warehouse_products
+    ADD COLUMN IF NOT EXISTS damaged_quantity INTEGER NOT NULL DEFAULT 0 CHECK(damaged_quantity >= 0);
+
+ALTER TABLE movements
+    ADD COLUMN IF NOT EXISTS reason VARCHAR(32) NOT NULL DEFAULT '';
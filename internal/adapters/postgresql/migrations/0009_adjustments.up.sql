@@ -0,0 +1,19 @@
+-- A pending adjustment has changed no stock yet, applied ones are in the
+-- movements ledger too.
+CREATE TABLE IF NOT EXISTS adjustments(
+    id SERIAL PRIMARY KEY,
+    warehouse_id INTEGER NOT NULL REFERENCES warehouses(id) ON DELETE RESTRICT,
+    product_code UUID NOT NULL REFERENCES products(code) ON DELETE CASCADE,
+    bucket VARCHAR(16) NOT NULL,
+    quantity INTEGER NOT NULL CHECK(quantity <> 0),
+    reason VARCHAR(32) NOT NULL,
+    lot_number VARCHAR(255) NOT NULL DEFAULT '',
+    status VARCHAR(16) NOT NULL,
+    requested_by VARCHAR(255) NOT NULL,
+    decided_by VARCHAR(255) NOT NULL DEFAULT '',
+    request_id VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    decided_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS adjustments_pending ON adjustments (id) WHERE status = 'pending';
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS count_lines;
+DROP TABLE IF EXISTS count_sessions;
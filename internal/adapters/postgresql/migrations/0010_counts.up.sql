@@ -0,0 +1,20 @@
+-- Expected quantities of a count are frozen when it opens, counted is NULL
+-- until the product is counted.
+CREATE TABLE IF NOT EXISTS count_sessions(
+    id SERIAL PRIMARY KEY,
+    warehouse_id INTEGER NOT NULL REFERENCES warehouses(id) ON DELETE RESTRICT,
+    limited BOOLEAN NOT NULL DEFAULT FALSE,
+    status VARCHAR(16) NOT NULL DEFAULT 'open',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    closed_at TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS count_sessions_open_warehouse ON count_sessions (warehouse_id) WHERE status = 'open';
+
+CREATE TABLE IF NOT EXISTS count_lines(
+    session_id INTEGER NOT NULL REFERENCES count_sessions(id) ON DELETE CASCADE,
+    product_code UUID NOT NULL REFERENCES products(code) ON DELETE CASCADE,
+    expected INTEGER NOT NULL CHECK(expected >= 0),
+    counted INTEGER CHECK(counted >= 0),
+    PRIMARY KEY (session_id, product_code)
+);
@@ -0,0 +1,2 @@
+ALTER TABLE count_lines DROP COLUMN IF EXISTS moved;
+ALTER TABLE count_sessions DROP COLUMN IF EXISTS movement_id;
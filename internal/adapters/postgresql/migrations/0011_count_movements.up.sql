@@ -0,0 +1,8 @@
+-- movement_id is the last movement of the ledger when the count opened, the
+-- stock moved after it is netted out of the differences. moved is the net
+-- change of the stock from the opening to the last count of the line.
+ALTER TABLE count_sessions ADD COLUMN IF NOT EXISTS movement_id BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE count_lines ADD COLUMN IF NOT EXISTS moved INTEGER NOT NULL DEFAULT 0;
+
+-- Counts opened before the watermark existed start from the current ledger.
+UPDATE count_sessions SET movement_id = (SELECT COALESCE(MAX(id), 0) FROM movements) WHERE status = 'open';
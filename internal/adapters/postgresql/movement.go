@@ -0,0 +1,256 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/akrovv/warehouse/internal/domain"
+)
+
+type movementStorage struct {
+	db *sql.DB
+}
+
+func NewMovementStorage(db *sql.DB) *movementStorage {
+	return &movementStorage{
+		db: db,
+	}
+}
+
+func (s *movementStorage) List(ctx context.Context, f *domain.MovementFilter) ([]domain.Movement, error) {
+	c := conditions{}
+
+	if f.Code != "" {
+		c.add("product_code = $%d", f.Code)
+	}
+
+	if f.WarehouseID != 0 {
+		c.add("warehouse_id = $%d", f.WarehouseID)
+	}
+
+	if !f.From.IsZero() {
+		c.add("created_at >= $%d", f.From)
+	}
+
+	if !f.To.IsZero() {
+		c.add("created_at < $%d", f.To)
+	}
+
+	query := `SELECT id, type, warehouse_id, product_code, bucket, delta, balance, reason, request_id, created_at
+			  FROM movements` + c.where() + " ORDER BY id" + c.limit(f.Limit)
+
+	rows, err := s.db.QueryContext(ctx, query, c.args...)
+	if err != nil {
+		return nil, fmt.Errorf("db.Query with command SELECT to movements returned: %w", mapError(err))
+	}
+	defer rows.Close()
+
+	m := domain.Movement{}
+	movements := make([]domain.Movement, 0, domain.BasicSliceLength)
+	for rows.Next() {
+		err = rows.Scan(&m.ID, &m.Type, &m.WarehouseID, &m.Code, &m.Bucket, &m.Delta, &m.Balance, &m.Reason,
+			&m.RequestID, &m.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("row scan returned: %w", err)
+		}
+
+		movements = append(movements, m)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err() returned: %w", err)
+	}
+
+	return movements, nil
+}
+
+// stockChange describes a change of warehouse stock buckets, every non zero
+// delta is written to the ledger together with the resulting balance.
+type stockChange struct {
+	movementType string
+	reason       string
+	requestID    string
+	warehouseID  int64
+	code         string
+	available    int64
+	reserved     int64
+}
+
+// changeStock updates the row only if no bucket goes negative. The update
+// waits for concurrent writers of the row and checks the condition against
+// their result, so two decrements can't both pass on the same stock.
+func changeStock(ctx context.Context, ex executor, c stockChange) error {
+	var (
+		available, reserved uint64
+		threshold           domain.StockThreshold
+	)
+
+	err := ex.QueryRowContext(ctx, `
+		UPDATE warehouse_products
+		SET available_quantity = available_quantity + $3,
+			reserved_quantity = reserved_quantity + $4
+		WHERE warehouse_id = $1 AND product_code = $2
+			AND available_quantity + $3 >= 0 AND reserved_quantity + $4 >= 0
+		RETURNING available_quantity, reserved_quantity, min_quantity, reorder_point`,
+		c.warehouseID, c.code, c.available, c.reserved).
+		Scan(&available, &reserved, &threshold.MinQuantity, &threshold.ReorderPoint)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return stockShortage(ctx, ex, c)
+	}
+
+	if err != nil {
+		return fmt.Errorf("db.QueryRow with command UPDATE to warehouse_products returned: %w", mapError(err))
+	}
+
+	if err = recordMovements(ctx, ex, c, available, reserved); err != nil {
+		return err
+	}
+
+	return recordLowStock(ctx, ex, c, &threshold, available)
+}
+
+// stockShortage tells why the update of changeStock matched no row: there is
+// no such product in the warehouse or there is not enough of it.
+func stockShortage(ctx context.Context, ex executor, c stockChange) error {
+	var available, reserved uint64
+
+	err := ex.QueryRowContext(ctx, `SELECT available_quantity, reserved_quantity FROM warehouse_products
+						WHERE warehouse_id = $1 AND product_code = $2`,
+		c.warehouseID, c.code).
+		Scan(&available, &reserved)
+
+	if err != nil {
+		return fmt.Errorf("db.QueryRow with command SELECT to warehouse_products returned: %w", mapError(err))
+	}
+
+	if err = domain.StockShortage(c.warehouseID, c.code, available, reserved, c.available, c.reserved); err != nil {
+		return err
+	}
+
+	return fmt.Errorf("%w: stock of %s in warehouse %d was changed concurrently",
+		domain.ErrInsufficientStock, c.code, c.warehouseID)
+}
+
+// receiveStock is changeStock for incoming stock, the product appears in the
+// warehouse if it wasn't there yet.
+func receiveStock(ctx context.Context, ex executor, c stockChange) error {
+	var available, reserved uint64
+
+	err := ex.QueryRowContext(ctx, `
+		INSERT INTO warehouse_products (warehouse_id, product_code, available_quantity, reserved_quantity)
+		VALUES ($1, $2, $3, 0)
+		ON CONFLICT (warehouse_id, product_code) DO UPDATE
+		SET available_quantity = warehouse_products.available_quantity + EXCLUDED.available_quantity
+		RETURNING available_quantity, reserved_quantity`,
+		c.warehouseID, c.code, c.available).
+		Scan(&available, &reserved)
+
+	if err != nil {
+		return fmt.Errorf("db.QueryRow with command INSERT/UPDATE to warehouse_products returned: %w", mapError(err))
+	}
+
+	return recordMovements(ctx, ex, c, available, reserved)
+}
+
+// bucketColumns are the columns of warehouse_products that hold stock which
+// is neither available nor reserved.
+var bucketColumns = map[string]string{
+	domain.BucketQuarantined: "quarantined_quantity",
+	domain.BucketDamaged:     "damaged_quantity",
+}
+
+// changeBucket is changeStock for one of bucketColumns, the product appears
+// in the warehouse on first receipt like with receiveStock. Such stock is
+// kept out of lots and bins and doesn't count for low stock. Stock is taken
+// away with a conditional update, an upsert would fail the CHECK constraints
+// on the row it proposes to insert.
+func changeBucket(ctx context.Context, ex executor, c stockChange, bucket string, delta int64) error {
+	column, ok := bucketColumns[bucket]
+	if !ok {
+		return fmt.Errorf("%w: unknown stock bucket %s", domain.ErrValidationFailed, bucket)
+	}
+
+	query := `UPDATE warehouse_products SET ` + column + ` = ` + column + ` + $3
+		WHERE warehouse_id = $1 AND product_code = $2 AND ` + column + ` + $3 >= 0
+		RETURNING ` + column
+
+	if delta >= 0 {
+		query = `INSERT INTO warehouse_products (warehouse_id, product_code, ` + column + `)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (warehouse_id, product_code) DO UPDATE
+		SET ` + column + ` = warehouse_products.` + column + ` + EXCLUDED.` + column + `
+		RETURNING ` + column
+	}
+
+	var balance uint64
+
+	err := ex.QueryRowContext(ctx, query, c.warehouseID, c.code, delta).Scan(&balance)
+	if errors.Is(err, sql.ErrNoRows) {
+		return bucketShortage(ctx, ex, c, bucket, delta)
+	}
+
+	if err != nil {
+		return fmt.Errorf("db.QueryRow with command INSERT/UPDATE to warehouse_products returned: %w", mapError(err))
+	}
+
+	return recordMovement(ctx, ex, c, bucket, delta, balance)
+}
+
+// bucketShortage is stockShortage of changeBucket.
+func bucketShortage(ctx context.Context, ex executor, c stockChange, bucket string, delta int64) error {
+	var balance uint64
+
+	err := ex.QueryRowContext(ctx, `SELECT `+bucketColumns[bucket]+` FROM warehouse_products
+						WHERE warehouse_id = $1 AND product_code = $2`,
+		c.warehouseID, c.code).
+		Scan(&balance)
+
+	if err != nil {
+		return fmt.Errorf("db.QueryRow with command SELECT to warehouse_products returned: %w", mapError(err))
+	}
+
+	if uint64(-delta) > balance {
+		return &domain.InsufficientStockError{
+			WarehouseID: c.warehouseID,
+			Code:        c.code,
+			Bucket:      bucket,
+			Requested:   uint64(-delta),
+			Available:   balance,
+		}
+	}
+
+	return fmt.Errorf("%w: stock of %s in warehouse %d was changed concurrently",
+		domain.ErrInsufficientStock, c.code, c.warehouseID)
+}
+
+func recordMovements(ctx context.Context, ex executor, c stockChange, available, reserved uint64) error {
+	if c.available != 0 {
+		if err := recordMovement(ctx, ex, c, domain.BucketAvailable, c.available, available); err != nil {
+			return err
+		}
+	}
+
+	if c.reserved != 0 {
+		if err := recordMovement(ctx, ex, c, domain.BucketReserved, c.reserved, reserved); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func recordMovement(ctx context.Context, ex executor, c stockChange, bucket string, delta int64, balance uint64) error {
+	_, err := ex.ExecContext(ctx, `
+		INSERT INTO movements (type, warehouse_id, product_code, bucket, delta, balance, reason, request_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		c.movementType, c.warehouseID, c.code, bucket, delta, balance, c.reason, c.requestID)
+
+	if err != nil {
+		return fmt.Errorf("db.Exec with command INSERT to movements returned: %w", mapError(err))
+	}
+
+	return nil
+}
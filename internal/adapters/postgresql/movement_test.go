@@ -0,0 +1,79 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql/driver"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/akrovv/warehouse/internal/domain"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+type movementTestCase struct {
+	filter domain.MovementFilter
+	query  string
+	args   []driver.Value
+}
+
+func TestMovementList(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("can't create mock: %s", err)
+	}
+	defer db.Close()
+
+	storage := NewMovementStorage(db)
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+	columns := []string{"id", "type", "warehouse_id", "product_code", "bucket", "delta", "balance", "reason", "request_id",
+		"created_at"}
+	expectResult := []domain.Movement{
+		{
+			ID:          1,
+			Type:        domain.MovementAdd,
+			WarehouseID: 1,
+			Code:        "test",
+			Bucket:      domain.BucketAvailable,
+			Delta:       10,
+			Balance:     10,
+			RequestID:   "req-1",
+			CreatedAt:   from,
+		},
+	}
+
+	testCases := []movementTestCase{
+		{
+			filter: domain.MovementFilter{Limit: 100},
+			query:  `FROM movements ORDER BY id LIMIT \$1`,
+			args:   []driver.Value{100},
+		},
+		{
+			filter: domain.MovementFilter{Code: "test", WarehouseID: 1, From: from, To: to, Limit: 10},
+			query: `FROM movements WHERE product_code = \$1 AND warehouse_id = \$2 ` +
+				`AND created_at >= \$3 AND created_at < \$4 ORDER BY id LIMIT \$5`,
+			args: []driver.Value{"test", 1, from, to, 10},
+		},
+	}
+
+	for _, tc := range testCases {
+		mock.ExpectQuery(tc.query).
+			WithArgs(tc.args...).
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow(1, domain.MovementAdd, 1, "test", domain.BucketAvailable, 10, 10, "", "req-1", from))
+
+		movements, err := storage.List(context.Background(), &tc.filter)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !reflect.DeepEqual(movements, expectResult) {
+			t.Fatalf("expected: %v, got: %v", expectResult, movements)
+		}
+
+		if err = mock.ExpectationsWereMet(); err != nil {
+			t.Fatalf("there were unfulfilled expectations: %s", err)
+		}
+	}
+}
@@ -1,11 +1,13 @@
 package postgresql
 
 import (
+	"context"
 	"database/sql"
-	"errors"
 	"fmt"
+	"time"
 
 	"github.com/akrovv/warehouse/internal/domain"
+	"github.com/lib/pq"
 )
 
 type productStorage struct {
@@ -18,12 +20,12 @@ func NewProductStorage(db *sql.DB) *productStorage {
 	}
 }
 
-func (s *productStorage) Create(product *domain.Product) error {
-	res, err := s.db.Exec("INSERT INTO products (name, size, code, quantity) VALUES ($1, $2, $3, $4)",
+func (s *productStorage) Create(ctx context.Context, product *domain.Product) error {
+	res, err := s.db.ExecContext(ctx, "INSERT INTO products (name, size, code, quantity) VALUES ($1, $2, $3, $4)",
 		product.Name, product.Size, product.Code, product.Quantity)
 
 	if err != nil {
-		return fmt.Errorf("db.Exec with command INSERT to products returned: %w", err)
+		return fmt.Errorf("db.Exec with command INSERT to products returned: %w", mapError(err))
 	}
 
 	affected, err := res.RowsAffected()
@@ -32,22 +34,245 @@ func (s *productStorage) Create(product *domain.Product) error {
 	}
 
 	if affected == 0 {
-		return errors.New("affected 0 rows")
+		return errNoRowsAffected
 	}
 
 	return nil
 }
 
-func (s *productStorage) Reserve(wp *domain.WarehouseProduct) error {
-	res, err := s.db.Exec(`
-		UPDATE warehouse_products 
-		SET available_quantity = available_quantity - $3, 
-			reserved_quantity = reserved_quantity + $3 
-		WHERE warehouse_id = $1 AND product_code = $2`,
-		wp.WarehouseID, wp.Code, wp.Quantity)
+func (s *productStorage) Get(ctx context.Context, gp *domain.GetProduct) (*domain.Product, error) {
+	product := domain.Product{}
+
+	err := s.db.QueryRowContext(ctx, `SELECT name, size, code, quantity FROM products WHERE code = $1`, gp.Code).
+		Scan(&product.Name, &product.Size, &product.Code, &product.Quantity)
+
+	if err != nil {
+		return nil, fmt.Errorf("db.QueryRow with command SELECT to products returned: %w", mapError(err))
+	}
+
+	return &product, nil
+}
+
+func (s *productStorage) List(ctx context.Context, f *domain.ProductFilter) ([]domain.Product, error) {
+	c := conditions{}
+
+	if f.Name != "" {
+		c.add("name ILIKE '%%' || $%d || '%%'", f.Name)
+	}
+
+	if f.Size != "" {
+		c.add("size = $%d", f.Size)
+	}
+
+	if f.Code != "" {
+		c.add("code = $%d", f.Code)
+	}
+
+	query := `SELECT name, size, code, quantity FROM products` + c.where() +
+		" ORDER BY id" + c.page(f.Limit, f.Offset)
+
+	rows, err := s.db.QueryContext(ctx, query, c.args...)
+	if err != nil {
+		return nil, fmt.Errorf("db.Query with command SELECT to products returned: %w", mapError(err))
+	}
+	defer rows.Close()
+
+	product := domain.Product{}
+	products := make([]domain.Product, 0, domain.BasicSliceLength)
+	for rows.Next() {
+		if err = rows.Scan(&product.Name, &product.Size, &product.Code, &product.Quantity); err != nil {
+			return nil, fmt.Errorf("row scan returned: %w", err)
+		}
+
+		products = append(products, product)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err() returned: %w", err)
+	}
+
+	return products, nil
+}
+
+func (s *productStorage) Update(ctx context.Context, up *domain.UpdateProduct) (*domain.Product, error) {
+	product := domain.Product{}
+
+	err := s.db.QueryRowContext(ctx, `
+		UPDATE products
+		SET name = COALESCE(NULLIF($2, ''), name),
+			size = COALESCE(NULLIF($3, ''), size)
+		WHERE code = $1
+		RETURNING name, size, code, quantity`,
+		up.Code, up.Name, up.Size).
+		Scan(&product.Name, &product.Size, &product.Code, &product.Quantity)
+
+	if err != nil {
+		return nil, fmt.Errorf("db.QueryRow with command UPDATE to products returned: %w", mapError(err))
+	}
+
+	return &product, nil
+}
+
+func (s *productStorage) Reserve(ctx context.Context, r *domain.Reservation) error {
+	return withTx(ctx, s.db, func(tx *sql.Tx) error {
+		return reserve(ctx, tx, r)
+	})
+}
+
+func (s *productStorage) ReserveBatch(ctx context.Context, rs []domain.Reservation) error {
+	return withTx(ctx, s.db, func(tx *sql.Tx) error {
+		for i := range rs {
+			if err := reserve(ctx, tx, &rs[i]); err != nil {
+				return &domain.BatchError{Index: i, Err: err}
+			}
+		}
+
+		return nil
+	})
+}
+
+func (s *productStorage) CancelReservation(ctx context.Context, cr *domain.CancelReservation) (*domain.Reservation, error) {
+	reservation := domain.Reservation{}
+
+	err := withTx(ctx, s.db, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, `
+			UPDATE reservations SET status = $2
+			WHERE id = $1 AND status = $3
+			RETURNING `+reservationColumns,
+			cr.ReservationID, domain.ReservationCanceled, domain.ReservationActive).
+			Scan(reservationFields(&reservation)...)
+
+		if err != nil {
+			return fmt.Errorf("db.QueryRow with command UPDATE to reservations returned: %w", mapError(err))
+		}
+
+		return release(ctx, tx, &reservation, domain.MovementRelease, cr.RequestID)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &reservation, nil
+}
+
+// Fulfill ships active reservations: reserved stock leaves the warehouse and
+// the global product quantity, all lines are recorded in one shipment.
+func (s *productStorage) Fulfill(ctx context.Context, f *domain.Fulfillment) (*domain.Shipment, error) {
+	shipment := domain.Shipment{}
+
+	err := withTx(ctx, s.db, func(tx *sql.Tx) error {
+		shipment.Lines = make([]domain.ShipmentLine, 0, len(f.ReservationIDs))
+
+		err := tx.QueryRowContext(ctx, `INSERT INTO shipments DEFAULT VALUES RETURNING id, created_at`).
+			Scan(&shipment.ID, &shipment.CreatedAt)
+
+		if err != nil {
+			return fmt.Errorf("db.QueryRow with command INSERT to shipments returned: %w", mapError(err))
+		}
+
+		for _, id := range f.ReservationIDs {
+			line, err := ship(ctx, tx, shipment.ID, id, f.RequestID)
+			if err != nil {
+				return fmt.Errorf("can't ship reservation %d: %w", id, err)
+			}
+
+			shipment.Lines = append(shipment.Lines, *line)
+		}
+
+		return nil
+	})
 
 	if err != nil {
-		return fmt.Errorf("db.Exec with command UPDATE to warehouse_products returned: %w", err)
+		return nil, err
+	}
+
+	return &shipment, nil
+}
+
+// ReleaseExpired returns reserved stock of expired reservations back to
+// available. Reservations in unavailable warehouses are left until the
+// warehouse is available again, because the trigger rejects any change there.
+func (s *productStorage) ReleaseExpired(ctx context.Context, now time.Time) ([]domain.Reservation, error) {
+	var reservations []domain.Reservation
+
+	err := withTx(ctx, s.db, func(tx *sql.Tx) error {
+		reservations = make([]domain.Reservation, 0, domain.BasicSliceLength)
+
+		rows, err := tx.QueryContext(ctx, `
+			UPDATE reservations r SET status = $2
+			FROM warehouses w
+			WHERE r.warehouse_id = w.id AND w.availability = true
+				AND r.status = $3 AND r.expires_at <= $1
+			RETURNING r.id, r.order_ref, r.warehouse_id, r.product_code, r.quantity,
+				r.status, r.created_at, r.expires_at`,
+			now, domain.ReservationExpired, domain.ReservationActive)
+
+		if err != nil {
+			return fmt.Errorf("db.Query with command UPDATE to reservations returned: %w", mapError(err))
+		}
+		defer rows.Close()
+
+		reservation := domain.Reservation{}
+		for rows.Next() {
+			if err = rows.Scan(reservationFields(&reservation)...); err != nil {
+				return fmt.Errorf("row scan returned: %w", err)
+			}
+
+			reservations = append(reservations, reservation)
+		}
+
+		if err = rows.Err(); err != nil {
+			return fmt.Errorf("rows.Err() returned: %w", err)
+		}
+
+		for i := range reservations {
+			if err = release(ctx, tx, &reservations[i], domain.MovementExpire, domain.SweeperRequestID); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return reservations, nil
+}
+
+func (s *productStorage) Transfer(ctx context.Context, td *domain.TransferProduct) error {
+	return withTx(ctx, s.db, func(tx *sql.Tx) error {
+		return transfer(ctx, tx, td)
+	})
+}
+
+func (s *productStorage) TransferBatch(ctx context.Context, tds []domain.TransferProduct) error {
+	return withTx(ctx, s.db, func(tx *sql.Tx) error {
+		for i := range tds {
+			if err := transfer(ctx, tx, &tds[i]); err != nil {
+				return &domain.BatchError{Index: i, Err: err}
+			}
+		}
+
+		return nil
+	})
+}
+
+func (s *productStorage) Add(ctx context.Context, ad *domain.AddProduct) error {
+	return withTx(ctx, s.db, func(tx *sql.Tx) error {
+		return add(ctx, tx, ad, domain.MovementAdd)
+	})
+}
+
+// add places the product in the warehouse on first receipt.
+func add(ctx context.Context, ex executor, ad *domain.AddProduct, movementType string) error {
+	res, err := ex.ExecContext(ctx, `UPDATE products SET quantity = quantity + $1 WHERE code = $2`,
+		ad.Quantity, ad.Code)
+
+	if err != nil {
+		return fmt.Errorf("db.Exec with command UPDATE to products returned: %w", mapError(err))
 	}
 
 	affected, err := res.RowsAffected()
@@ -56,22 +281,49 @@ func (s *productStorage) Reserve(wp *domain.WarehouseProduct) error {
 	}
 
 	if affected == 0 {
-		return errors.New("affected 0 rows")
+		return errNoRowsAffected
 	}
 
-	return nil
+	err = receiveStock(ctx, ex, stockChange{
+		movementType: movementType,
+		requestID:    ad.RequestID,
+		warehouseID:  ad.WarehouseID,
+		code:         ad.Code,
+		available:    int64(ad.Quantity),
+	})
+
+	if err != nil {
+		return err
+	}
+
+	lotID, err := receiveLot(ctx, ex, ad.Code, ad.Lot)
+	if err != nil {
+		return err
+	}
+
+	if err = changeLot(ctx, ex, ad.WarehouseID, lotID, int64(ad.Quantity), 0); err != nil {
+		return err
+	}
+
+	if ad.BinID == 0 {
+		return nil
+	}
+
+	if err = checkBins(ctx, ex, ad.WarehouseID, ad.BinID); err != nil {
+		return err
+	}
+
+	return changeBin(ctx, ex, ad.BinID, ad.Code, int64(ad.Quantity), 0)
 }
 
-func (s *productStorage) CancelReservation(wp *domain.WarehouseProduct) error {
-	res, err := s.db.Exec(`
-		UPDATE warehouse_products 
-		SET available_quantity = available_quantity + $3, 
-			reserved_quantity = reserved_quantity - $3
-		WHERE warehouse_id = $1 AND product_code = $2
-		`, wp.WarehouseID, wp.Code, wp.Quantity)
+// changeQuantity changes the quantity of the product for stock that came or
+// went without passing through add or Delete.
+func changeQuantity(ctx context.Context, ex executor, code string, delta int64) error {
+	res, err := ex.ExecContext(ctx, `UPDATE products SET quantity = quantity + $1 WHERE code = $2`,
+		delta, code)
 
 	if err != nil {
-		return fmt.Errorf("db.Exec with command UPDATE to warehouse_products returned: %w", err)
+		return fmt.Errorf("db.Exec with command UPDATE to products returned: %w", mapError(err))
 	}
 
 	affected, err := res.RowsAffected()
@@ -80,140 +332,365 @@ func (s *productStorage) CancelReservation(wp *domain.WarehouseProduct) error {
 	}
 
 	if affected == 0 {
-		return errors.New("affected 0 rows")
+		return errNoRowsAffected
 	}
 
 	return nil
 }
 
-func (s *productStorage) Transfer(td *domain.TransferProduct) error {
-	tx, err := s.db.Begin()
-	if err != nil {
-		return fmt.Errorf("db.Begin() returned: %w", err)
-	}
+// Delete removes the product from every warehouse first, so the ledger gets
+// the stock that was written off with it. Units in transit are in no
+// warehouse, the product can't be deleted until they arrive or return.
+func (s *productStorage) Delete(ctx context.Context, dp *domain.DeleteProduct) (*domain.Product, error) {
+	product := domain.Product{}
+
+	err := withTx(ctx, s.db, func(tx *sql.Tx) error {
+		var transfers pq.Int64Array
+
+		err := tx.QueryRowContext(ctx, `SELECT ARRAY(SELECT id FROM transfers
+							   WHERE product_code = $1 AND status = $2 ORDER BY id)`,
+			dp.Code, domain.TransferInTransit).
+			Scan(&transfers)
+
+		if err != nil {
+			return fmt.Errorf("db.QueryRow with command SELECT to transfers returned: %w", mapError(err))
+		}
+
+		if len(transfers) > 0 {
+			return fmt.Errorf("%w: product %s has transfers in transit %v",
+				domain.ErrValidationFailed, dp.Code, []int64(transfers))
+		}
+
+		rows, err := tx.QueryContext(ctx, `DELETE FROM warehouse_products WHERE product_code = $1
+							   RETURNING warehouse_id, available_quantity, reserved_quantity,
+							   quarantined_quantity, damaged_quantity`,
+			dp.Code)
+
+		if err != nil {
+			return fmt.Errorf("db.Query with command DELETE to warehouse_products returned: %w", mapError(err))
+		}
+		defer rows.Close()
+
+		changes := make([]stockChange, 0, domain.BasicSliceLength)
+		others := make([][]uint64, 0, domain.BasicSliceLength)
+		for rows.Next() {
+			var available, reserved, quarantined, damaged uint64
+			c := stockChange{
+				movementType: domain.MovementDelete,
+				requestID:    dp.RequestID,
+				code:         dp.Code,
+			}
+
+			if err = rows.Scan(&c.warehouseID, &available, &reserved, &quarantined, &damaged); err != nil {
+				return fmt.Errorf("row scan returned: %w", err)
+			}
+
+			c.available, c.reserved = -int64(available), -int64(reserved)
+			changes = append(changes, c)
+			others = append(others, []uint64{quarantined, damaged})
+		}
+
+		if err = rows.Err(); err != nil {
+			return fmt.Errorf("rows.Err() returned: %w", err)
+		}
+
+		for i, c := range changes {
+			if err = recordMovements(ctx, tx, c, 0, 0); err != nil {
+				return err
+			}
+
+			for j, bucket := range []string{domain.BucketQuarantined, domain.BucketDamaged} {
+				if others[i][j] == 0 {
+					continue
+				}
+
+				if err = recordMovement(ctx, tx, c, bucket, -int64(others[i][j]), 0); err != nil {
+					return err
+				}
+			}
+		}
+
+		err = tx.QueryRowContext(ctx, `DELETE FROM products WHERE code = $1
+						   RETURNING name, size, code, quantity`,
+			dp.Code).
+			Scan(&product.Name, &product.Size, &product.Code, &product.Quantity)
 
-	defer func() {
 		if err != nil {
-			_ = tx.Rollback()
+			return fmt.Errorf("db.Exec with command DELETE to products returned: %w", mapError(err))
 		}
-		_ = tx.Commit()
-	}()
 
-	var quantity uint64
+		return nil
+	})
 
-	err = tx.QueryRow(`SELECT available_quantity FROM warehouse_products
-						WHERE warehouse_id = $1 AND product_code = $2`,
-		td.WarehouseFromID, td.Code).Scan(&quantity)
 	if err != nil {
-		return fmt.Errorf("db.QueryRow with command SELECT to warehouse_products returned: %w", err)
+		return nil, err
 	}
 
-	if quantity < td.Quantity {
-		return fmt.Errorf("not enough quantity: %d, in warehouse: %d. available: %d", td.Quantity, td.WarehouseFromID, quantity)
+	return &product, nil
+}
+
+func reserve(ctx context.Context, ex executor, r *domain.Reservation) error {
+	err := changeStock(ctx, ex, stockChange{
+		movementType: domain.MovementReserve,
+		requestID:    r.RequestID,
+		warehouseID:  r.WarehouseID,
+		code:         r.Code,
+		available:    -int64(r.Quantity),
+		reserved:     int64(r.Quantity),
+	})
+
+	if err != nil {
+		return err
 	}
 
-	res, err := tx.Exec(`UPDATE warehouse_products 
-					SET available_quantity = available_quantity - $3
-					WHERE warehouse_id = $1 AND product_code = $2 `,
-		td.WarehouseFromID, td.Code, td.Quantity)
+	lots, err := takeLots(ctx, ex, lotPick{
+		warehouseID: r.WarehouseID,
+		code:        r.Code,
+		quantity:    r.Quantity,
+		number:      r.Lot,
+		reserve:     true,
+	})
+
 	if err != nil {
-		return fmt.Errorf("db.Exec with command UPDATE to warehouse_products returned: %w", err)
+		return err
 	}
 
-	affected, err := res.RowsAffected()
+	bins, err := takeBins(ctx, ex, r.WarehouseID, r.Code, r.Quantity, true)
 	if err != nil {
-		return fmt.Errorf("rows.RowsAffected() returned: %w", err)
+		return err
 	}
 
-	if affected == 0 {
-		return errors.New("affected 0 rows")
+	err = ex.QueryRowContext(ctx, `
+		INSERT INTO reservations (order_ref, warehouse_id, product_code, quantity, status, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at`,
+		r.OrderRef, r.WarehouseID, r.Code, r.Quantity, r.Status, r.ExpiresAt).
+		Scan(&r.ID, &r.CreatedAt)
+
+	if err != nil {
+		return fmt.Errorf("db.QueryRow with command INSERT to reservations returned: %w", mapError(err))
+	}
+
+	for _, lot := range lots {
+		_, err = ex.ExecContext(ctx, `INSERT INTO reservation_lots (reservation_id, lot_id, quantity) VALUES ($1, $2, $3)`,
+			r.ID, lot.id, lot.Quantity)
+
+		if err != nil {
+			return fmt.Errorf("db.Exec with command INSERT to reservation_lots returned: %w", mapError(err))
+		}
 	}
 
-	res, err = tx.Exec(`INSERT INTO warehouse_products (warehouse_id, product_code, available_quantity, reserved_quantity)
-					VALUES ($1, $2, $3, $4)
-					ON CONFLICT (warehouse_id, product_code) DO UPDATE
-					SET available_quantity = warehouse_products.available_quantity + EXCLUDED.available_quantity`,
-		td.WarehouseToID, td.Code, td.Quantity, 0)
+	for _, bin := range bins {
+		_, err = ex.ExecContext(ctx, `INSERT INTO reservation_bins (reservation_id, bin_id, quantity) VALUES ($1, $2, $3)`,
+			r.ID, bin.ID, bin.Quantity)
+
+		if err != nil {
+			return fmt.Errorf("db.Exec with command INSERT to reservation_bins returned: %w", mapError(err))
+		}
+	}
+
+	r.Lots = lotQuantities(lots)
+	r.Bins = bins
+
+	return nil
+}
+
+// release returns reserved units to available stock of the lots and bins
+// they were reserved from, even if the lots have expired since.
+func release(ctx context.Context, ex executor, r *domain.Reservation, movementType, requestID string) error {
+	err := changeStock(ctx, ex, stockChange{
+		movementType: movementType,
+		requestID:    requestID,
+		warehouseID:  r.WarehouseID,
+		code:         r.Code,
+		available:    int64(r.Quantity),
+		reserved:     -int64(r.Quantity),
+	})
 
 	if err != nil {
-		return fmt.Errorf("db.Exec with command INSERT/UPDATE to warehouse_products returned: %w", err)
+		return err
 	}
 
-	affected, err = res.RowsAffected()
+	lots, err := reservationLots(ctx, ex, r.ID)
 	if err != nil {
-		return fmt.Errorf("rows.RowsAffected() returned: %w", err)
+		return err
 	}
 
-	if affected == 0 {
-		return errors.New("affected 0 rows")
+	for _, lot := range lots {
+		if err = changeLot(ctx, ex, r.WarehouseID, lot.id, int64(lot.Quantity), -int64(lot.Quantity)); err != nil {
+			return err
+		}
+	}
+
+	bins, err := reservationBins(ctx, ex, r.ID)
+	if err != nil {
+		return err
 	}
 
+	for _, bin := range bins {
+		if err = changeBin(ctx, ex, bin.ID, r.Code, int64(bin.Quantity), -int64(bin.Quantity)); err != nil {
+			return err
+		}
+	}
+
+	r.Lots = lotQuantities(lots)
+	r.Bins = bins
+
 	return nil
 }
 
-func (s *productStorage) Add(ad *domain.AddProduct) error {
-	tx, err := s.db.Begin()
+func transfer(ctx context.Context, ex executor, td *domain.TransferProduct) error {
+	if err := lockStock(ctx, ex, td.Code, td.WarehouseFromID, td.WarehouseToID); err != nil {
+		return err
+	}
+
+	err := changeStock(ctx, ex, stockChange{
+		movementType: domain.MovementTransferOut,
+		requestID:    td.RequestID,
+		warehouseID:  td.WarehouseFromID,
+		code:         td.Code,
+		available:    -int64(td.Quantity),
+	})
+
 	if err != nil {
-		return fmt.Errorf("db.Begin() returned: %w", err)
+		return err
 	}
 
-	defer func() {
-		if err != nil {
-			_ = tx.Rollback()
-			return
+	lots, err := takeLots(ctx, ex, lotPick{
+		warehouseID: td.WarehouseFromID,
+		code:        td.Code,
+		quantity:    td.Quantity,
+		number:      td.Lot,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	bins, err := takeBins(ctx, ex, td.WarehouseFromID, td.Code, td.Quantity, false)
+	if err != nil {
+		return err
+	}
+
+	err = receiveStock(ctx, ex, stockChange{
+		movementType: domain.MovementTransferIn,
+		requestID:    td.RequestID,
+		warehouseID:  td.WarehouseToID,
+		code:         td.Code,
+		available:    int64(td.Quantity),
+	})
+
+	if err != nil {
+		return err
+	}
+
+	for _, lot := range lots {
+		if err = changeLot(ctx, ex, td.WarehouseToID, lot.id, int64(lot.Quantity), 0); err != nil {
+			return err
 		}
-		_ = tx.Commit()
-	}()
+	}
 
-	res, err := tx.Exec(`UPDATE products SET quantity = quantity + $1 WHERE code = $2`,
-		ad.Quantity, ad.Code)
+	td.Lots = lotQuantities(lots)
+	td.Bins = bins
+
+	return nil
+}
+
+func ship(ctx context.Context, ex executor, shipmentID, reservationID int64, requestID string) (*domain.ShipmentLine, error) {
+	r := domain.Reservation{}
+
+	err := ex.QueryRowContext(ctx, `
+		UPDATE reservations SET status = $2
+		WHERE id = $1 AND status = $3 AND expires_at > NOW()
+		RETURNING `+reservationColumns,
+		reservationID, domain.ReservationFulfilled, domain.ReservationActive).
+		Scan(reservationFields(&r)...)
 
 	if err != nil {
-		return fmt.Errorf("db.Exec with command UPDATE to products returned: %w", err)
+		return nil, fmt.Errorf("db.QueryRow with command UPDATE to reservations returned: %w", mapError(err))
 	}
 
-	affected, err := res.RowsAffected()
+	err = changeStock(ctx, ex, stockChange{
+		movementType: domain.MovementFulfill,
+		requestID:    requestID,
+		warehouseID:  r.WarehouseID,
+		code:         r.Code,
+		reserved:     -int64(r.Quantity),
+	})
+
 	if err != nil {
-		return fmt.Errorf("rows.RowsAffected() returned: %w", err)
+		return nil, err
 	}
 
-	if affected == 0 {
-		return errors.New("affected 0 rows")
+	lots, err := reservationLots(ctx, ex, r.ID)
+	if err != nil {
+		return nil, err
 	}
 
-	res, err = tx.Exec(`
-		UPDATE warehouse_products
-		SET available_quantity = available_quantity + $3
-		WHERE warehouse_id = $1 AND product_code = $2`,
-		ad.WarehouseID, ad.Code, ad.Quantity)
+	for _, lot := range lots {
+		if err = changeLot(ctx, ex, r.WarehouseID, lot.id, 0, -int64(lot.Quantity)); err != nil {
+			return nil, err
+		}
+	}
 
+	bins, err := reservationBins(ctx, ex, r.ID)
 	if err != nil {
-		return fmt.Errorf("db.Exec with command UPDATE to warehouse_products returned: %w", err)
+		return nil, err
 	}
 
-	affected, err = res.RowsAffected()
+	for _, bin := range bins {
+		if err = changeBin(ctx, ex, bin.ID, r.Code, 0, -int64(bin.Quantity)); err != nil {
+			return nil, err
+		}
+	}
+
+	res, err := ex.ExecContext(ctx, `UPDATE products SET quantity = quantity - $2 WHERE code = $1`, r.Code, r.Quantity)
 	if err != nil {
-		return fmt.Errorf("rows.RowsAffected() returned: %w", err)
+		return nil, fmt.Errorf("db.Exec with command UPDATE to products returned: %w", mapError(err))
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("rows.RowsAffected() returned: %w", err)
 	}
 
 	if affected == 0 {
-		return errors.New("affected 0 rows")
+		return nil, errNoRowsAffected
 	}
 
-	return nil
-}
+	line := domain.ShipmentLine{
+		ReservationID: r.ID,
+		OrderRef:      r.OrderRef,
+		WarehouseID:   r.WarehouseID,
+		Code:          r.Code,
+		Quantity:      r.Quantity,
+		Lots:          lotQuantities(lots),
+		Bins:          bins,
+	}
 
-func (s *productStorage) Delete(dp *domain.DeleteProduct) (*domain.Product, error) {
-	product := domain.Product{}
+	_, err = ex.ExecContext(ctx, `
+		INSERT INTO shipment_lines (shipment_id, reservation_id, warehouse_id, product_code, quantity)
+		VALUES ($1, $2, $3, $4, $5)`,
+		shipmentID, line.ReservationID, line.WarehouseID, line.Code, line.Quantity)
 
-	err := s.db.QueryRow(`DELETE FROM products WHERE code = $1
-						  RETURNING name, size, code, quantity`,
-		dp.Code).
-		Scan(&product.Name, &product.Size, &product.Code, &product.Quantity)
+	if err != nil {
+		return nil, fmt.Errorf("db.Exec with command INSERT to shipment_lines returned: %w", mapError(err))
+	}
+
+	return &line, nil
+}
+
+// lockStock locks rows of the product in the warehouses in the order of
+// their ids, so transfers in opposite directions don't deadlock.
+func lockStock(ctx context.Context, ex executor, code string, warehouseIDs ...int64) error {
+	_, err := ex.ExecContext(ctx, `SELECT 1 FROM warehouse_products
+					   WHERE product_code = $1 AND warehouse_id = ANY($2)
+					   ORDER BY warehouse_id FOR UPDATE`,
+		code, pq.Array(warehouseIDs))
 
 	if err != nil {
-		return nil, fmt.Errorf("db.Exec with command DELETE to products returned: %w", err)
+		return fmt.Errorf("db.Exec with command SELECT to warehouse_products returned: %w", mapError(err))
 	}
 
-	return &product, nil
+	return nil
 }
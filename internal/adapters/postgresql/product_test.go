@@ -1,10 +1,14 @@
 package postgresql
 
 import (
+	"context"
+	"database/sql"
 	"database/sql/driver"
 	"errors"
+	"fmt"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/akrovv/warehouse/internal/domain"
 	"gopkg.in/DATA-DOG/go-sqlmock.v1"
@@ -12,7 +16,6 @@ import (
 
 type productTestCase struct {
 	product  domain.Product
-	wp       domain.WarehouseProduct
 	dp       domain.DeleteProduct
 	query    string
 	rows     *sqlmock.Rows
@@ -26,19 +29,75 @@ type productTransactTestCase struct {
 	ad                     domain.AddProduct
 	td                     domain.TransferProduct
 	expectedQuery          string
-	expectedSelect         string
-	expectedSelectError    error
-	selectRows             *sqlmock.Rows
+	expectLock             bool
+	lockError              error
+	shortage               *sqlmock.Rows
 	expectedExecQuery      string
 	expectedExecQueryError error
 	execArgs               []driver.Value
-	execResult             driver.Result
 	execError              error
 	expectCommit           bool
 	expectError            bool
 	beginError             error
 }
 
+type reservationTestCase struct {
+	updateError  error
+	shortage     *sqlmock.Rows
+	queryError   error
+	expectError  bool
+	expectCommit bool
+}
+
+type batchTestCase struct {
+	failAt int
+}
+
+func expectChangeStock(mock sqlmock.Sqlmock, warehouseID int64, code string, available, reserved int64) *sqlmock.ExpectedQuery {
+	return mock.ExpectQuery("UPDATE warehouse_products").
+		WithArgs(warehouseID, code, available, reserved)
+}
+
+// expectStockShortage is the query changeStock runs when its update matched
+// no row, shortage nil means there is no such row either.
+func expectStockShortage(mock sqlmock.Sqlmock, warehouseID int64, code string, shortage *sqlmock.Rows) {
+	expect := mock.ExpectQuery("SELECT available_quantity, reserved_quantity FROM warehouse_products").
+		WithArgs(warehouseID, code)
+
+	if shortage == nil {
+		expect.WillReturnError(sql.ErrNoRows)
+		return
+	}
+
+	expect.WillReturnRows(shortage)
+}
+
+func expectLockStock(mock sqlmock.Sqlmock, code string, from, to int64) *sqlmock.ExpectedExec {
+	return mock.ExpectExec("SELECT 1 FROM warehouse_products (.+) FOR UPDATE").
+		WithArgs(code, fmt.Sprintf("{%d,%d}", from, to))
+}
+
+func stockRows(available, reserved uint64) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"available_quantity", "reserved_quantity"}).AddRow(available, reserved)
+}
+
+// changedRows are the rows changeStock returns for a product without
+// thresholds.
+func changedRows(available, reserved uint64) *sqlmock.Rows {
+	return thresholdRows(available, reserved, 0, 0)
+}
+
+func thresholdRows(available, reserved, minQuantity, reorderPoint uint64) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"available_quantity", "reserved_quantity", "min_quantity", "reorder_point"}).
+		AddRow(available, reserved, minQuantity, reorderPoint)
+}
+
+func expectMovement(mock sqlmock.Sqlmock, movementType string, warehouseID int64, code, bucket string, delta int64, balance uint64) {
+	mock.ExpectExec("INSERT INTO movements").
+		WithArgs(movementType, warehouseID, code, bucket, delta, balance, "", "").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+}
+
 func TestProductCreate(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
@@ -93,7 +152,7 @@ func TestProductCreate(t *testing.T) {
 			WillReturnResult(tc.returned).
 			WillReturnError(tc.result)
 
-		err = storage.Create(&tc.product)
+		err = storage.Create(context.Background(), &tc.product)
 
 		if !errors.Is(err, tc.result) {
 			if tc.isError && err != nil {
@@ -108,7 +167,7 @@ func TestProductCreate(t *testing.T) {
 	}
 }
 
-func TestProductReserve(t *testing.T) {
+func TestProductGet(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
 		t.Fatalf("can't create mock: %s", err)
@@ -116,63 +175,137 @@ func TestProductReserve(t *testing.T) {
 	defer db.Close()
 
 	storage := NewProductStorage(db)
-	wp := domain.WarehouseProduct{
-		WarehouseID: 10,
-		Code:        "test-1",
-		Quantity:    10,
+	gp := domain.GetProduct{Code: "test"}
+	expectedResult := &domain.Product{
+		Name:     "test",
+		Size:     "test",
+		Code:     "test",
+		Quantity: 10,
+	}
+
+	mock.ExpectQuery(`SELECT name, size, code, quantity FROM products WHERE code = \$1`).
+		WithArgs("test").
+		WillReturnRows(sqlmock.NewRows([]string{"name", "size", "code", "quantity"}).
+			AddRow("test", "test", "test", 10))
+
+	product, err := storage.Get(context.Background(), &gp)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(product, expectedResult) {
+		t.Fatalf("expected: %v, got: %v", expectedResult, product)
 	}
 
-	query := `
-	UPDATE warehouse_products
-	SET available_quantity = available_quantity - \$3,
-		reserved_quantity = reserved_quantity \+ \$3
-	WHERE warehouse_id = \$1 AND product_code = \$2`
+	mock.ExpectQuery("SELECT name, size, code, quantity FROM products").
+		WithArgs("test").
+		WillReturnError(sql.ErrNoRows)
+
+	if _, err = storage.Get(context.Background(), &gp); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("expected: %v, got: %v", domain.ErrNotFound, err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestProductList(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("can't create mock: %s", err)
+	}
+	defer db.Close()
+
+	storage := NewProductStorage(db)
+	columns := []string{"name", "size", "code", "quantity"}
 
-	args := []driver.Value{10, "test-1", 10}
 	testCases := []productTestCase{
 		{
-			wp:       wp,
-			query:    query,
-			args:     args,
-			returned: sqlmock.NewResult(0, 1),
-			result:   nil,
+			query: `FROM products ORDER BY id LIMIT \$1 OFFSET \$2`,
+			args:  []driver.Value{100, 0},
 		},
 		{
-			wp:      wp,
-			query:   query,
-			args:    args,
-			result:  domain.ErrTest,
-			isError: true,
+			query: `FROM products WHERE name ILIKE '%' \|\| \$1 \|\| '%' AND size = \$2 AND code = \$3 ` +
+				`ORDER BY id LIMIT \$4 OFFSET \$5`,
+			args: []driver.Value{"test", "50x50", "test", 10, 20},
 		},
+	}
+	filters := []domain.ProductFilter{
+		{Limit: 100},
+		{Name: "test", Size: "50x50", Code: "test", Limit: 10, Offset: 20},
+	}
+	expectedResult := []domain.Product{
 		{
-			wp:       wp,
-			query:    query,
-			args:     args,
-			returned: sqlmock.NewResult(0, 0),
-			isError:  true,
+			Name:     "test",
+			Size:     "50x50",
+			Code:     "test",
+			Quantity: 10,
 		},
+	}
+
+	for i, tc := range testCases {
+		mock.ExpectQuery(tc.query).
+			WithArgs(tc.args...).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow("test", "50x50", "test", 10))
+
+		products, err := storage.List(context.Background(), &filters[i])
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !reflect.DeepEqual(products, expectedResult) {
+			t.Fatalf("expected: %v, got: %v", expectedResult, products)
+		}
+
+		if err = mock.ExpectationsWereMet(); err != nil {
+			t.Fatalf("there were unfulfilled expectations: %s", err)
+		}
+	}
+}
+
+func TestProductUpdate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("can't create mock: %s", err)
+	}
+	defer db.Close()
+
+	storage := NewProductStorage(db)
+	up := domain.UpdateProduct{
+		Code: "test",
+		Name: "new name",
+	}
+	expectedResult := &domain.Product{
+		Name:     "new name",
+		Size:     "test",
+		Code:     "test",
+		Quantity: 10,
+	}
+
+	testCases := []productTestCase{
 		{
-			wp:       wp,
-			query:    query,
-			args:     args,
-			returned: sqlmock.NewErrorResult(domain.ErrTest),
-			isError:  true,
+			rows: sqlmock.NewRows([]string{"name", "size", "code", "quantity"}).
+				AddRow("new name", "test", "test", 10),
+		},
+		{
+			result: sql.ErrNoRows,
 		},
 	}
 
 	for _, tc := range testCases {
-		mock.ExpectExec(tc.query).
-			WithArgs(tc.args...).
-			WillReturnResult(tc.returned).
+		mock.ExpectQuery("UPDATE products").
+			WithArgs("test", "new name", "").
+			WillReturnRows(tc.rows).
 			WillReturnError(tc.result)
 
-		err = storage.Reserve(&tc.wp)
-
-		if !errors.Is(err, tc.result) {
-			if tc.isError && err != nil {
-				continue
+		product, err := storage.Update(context.Background(), &up)
+		if tc.result != nil {
+			if !errors.Is(err, domain.ErrNotFound) {
+				t.Fatalf("expected: %v, got: %v", domain.ErrNotFound, err)
 			}
-			t.Errorf("expected: %v, got: %v", tc.result, err)
+		} else if !reflect.DeepEqual(product, expectedResult) {
+			t.Fatalf("expected: %v, got: %v", expectedResult, product)
 		}
 
 		if err = mock.ExpectationsWereMet(); err != nil {
@@ -181,7 +314,7 @@ func TestProductReserve(t *testing.T) {
 	}
 }
 
-func TestProductCancelReservation(t *testing.T) {
+func TestProductReserve(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
 		t.Fatalf("can't create mock: %s", err)
@@ -189,62 +322,186 @@ func TestProductCancelReservation(t *testing.T) {
 	defer db.Close()
 
 	storage := NewProductStorage(db)
-	wp := domain.WarehouseProduct{
+	expiresAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	createdAt := expiresAt.Add(-time.Hour)
+	r := domain.Reservation{
+		OrderRef:    "order-1",
 		WarehouseID: 10,
 		Code:        "test-1",
 		Quantity:    10,
+		Status:      domain.ReservationActive,
+		ExpiresAt:   expiresAt,
 	}
 
-	query := `
-	UPDATE warehouse_products
-	SET available_quantity = available_quantity \+ \$3,
-		reserved_quantity = reserved_quantity - \$3
-	WHERE warehouse_id = \$1 AND product_code = \$2`
-	args := []driver.Value{10, "test-1", 10}
-	testCases := []productTestCase{
+	insertQuery := "INSERT INTO reservations"
+
+	testCases := []reservationTestCase{
 		{
-			wp:       wp,
-			query:    query,
-			args:     args,
-			returned: sqlmock.NewResult(0, 1),
-			result:   nil,
+			expectCommit: true,
 		},
 		{
-			wp:      wp,
-			query:   query,
-			args:    args,
-			result:  domain.ErrTest,
-			isError: true,
+			updateError: domain.ErrTest,
+			expectError: true,
 		},
 		{
-			wp:       wp,
-			query:    query,
-			args:     args,
-			returned: sqlmock.NewResult(0, 0),
-			isError:  true,
+			updateError: sql.ErrNoRows,
+			expectError: true,
 		},
 		{
-			wp:       wp,
-			query:    query,
-			args:     args,
-			returned: sqlmock.NewErrorResult(domain.ErrTest),
-			isError:  true,
+			updateError: sql.ErrNoRows,
+			shortage:    stockRows(4, 0),
+			expectError: true,
+		},
+		{
+			queryError:  domain.ErrTest,
+			expectError: true,
 		},
 	}
 
 	for _, tc := range testCases {
-		mock.ExpectExec(tc.query).
-			WithArgs(tc.args...).
-			WillReturnResult(tc.returned).
-			WillReturnError(tc.result)
+		reservation := r
 
-		err = storage.CancelReservation(&tc.wp)
+		mock.ExpectBegin()
+		expect := expectChangeStock(mock, r.WarehouseID, r.Code, -10, 10)
 
-		if !errors.Is(err, tc.result) {
-			if tc.isError && err != nil {
-				continue
+		if tc.updateError != nil {
+			expect.WillReturnError(tc.updateError)
+		}
+
+		if errors.Is(tc.updateError, sql.ErrNoRows) {
+			expectStockShortage(mock, r.WarehouseID, r.Code, tc.shortage)
+		}
+
+		if tc.updateError == nil {
+			expect.WillReturnRows(changedRows(0, 10))
+			expectMovement(mock, domain.MovementReserve, r.WarehouseID, r.Code, domain.BucketAvailable, -10, 0)
+			expectMovement(mock, domain.MovementReserve, r.WarehouseID, r.Code, domain.BucketReserved, 10, 10)
+			expectTakeLots(mock, r.WarehouseID, r.Code, "", true, defaultLotRows(10))
+			expectChangeLot(mock, r.WarehouseID, 1, -10, 10)
+			expectTakeBins(mock, r.WarehouseID, r.Code, sqlmock.NewRows(binColumnNames).AddRow(3, 10, "A", "01", "R1", "B1", 4))
+			expectChangeBin(mock, 3, r.Code, -4, 4)
+
+			mock.ExpectQuery(insertQuery).
+				WithArgs(r.OrderRef, r.WarehouseID, r.Code, r.Quantity, r.Status, r.ExpiresAt).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(1, createdAt)).
+				WillReturnError(tc.queryError)
+		}
+
+		if tc.updateError == nil && tc.queryError == nil {
+			mock.ExpectExec("INSERT INTO reservation_lots").
+				WithArgs(1, 1, 10).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectExec("INSERT INTO reservation_bins").
+				WithArgs(1, 3, 4).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+		}
+
+		if tc.expectCommit {
+			mock.ExpectCommit()
+		} else {
+			mock.ExpectRollback()
+		}
+
+		err = storage.Reserve(context.Background(), &reservation)
+		if (err != nil) != tc.expectError {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if errors.Is(tc.updateError, sql.ErrNoRows) && tc.shortage == nil && !errors.Is(err, domain.ErrNotFound) {
+			t.Fatalf("expected: %v, got: %v", domain.ErrNotFound, err)
+		}
+
+		var shortage *domain.InsufficientStockError
+		if tc.shortage != nil && (!errors.As(err, &shortage) || shortage.Available != 4 || shortage.Requested != 10) {
+			t.Fatalf("expected: %v, got: %v", domain.ErrInsufficientStock, err)
+		}
+
+		if tc.expectCommit && (reservation.ID != 1 || !reservation.CreatedAt.Equal(createdAt) || len(reservation.Lots) != 1 || len(reservation.Bins) != 1) {
+			t.Fatalf("reservation is not filled: %+v", reservation)
+		}
+
+		if err = mock.ExpectationsWereMet(); err != nil {
+			t.Fatalf("there were unfulfilled expectations: %s", err)
+		}
+	}
+}
+
+func TestProductCancelReservation(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("can't create mock: %s", err)
+	}
+	defer db.Close()
+
+	storage := NewProductStorage(db)
+	cr := domain.CancelReservation{ReservationID: 1}
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	columns := []string{"id", "order_ref", "warehouse_id", "product_code", "quantity", "status", "created_at", "expires_at"}
+
+	selectQuery := "UPDATE reservations SET status"
+
+	testCases := []reservationTestCase{
+		{
+			expectCommit: true,
+		},
+		{
+			queryError:  sql.ErrNoRows,
+			expectError: true,
+		},
+		{
+			updateError: domain.ErrTest,
+			expectError: true,
+		},
+		{
+			updateError: sql.ErrNoRows,
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		mock.ExpectBegin()
+		mock.ExpectQuery(selectQuery).
+			WithArgs(cr.ReservationID, domain.ReservationCanceled, domain.ReservationActive).
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow(1, "order-1", 10, "test-1", 5, domain.ReservationCanceled, now, now)).
+			WillReturnError(tc.queryError)
+
+		if tc.queryError == nil {
+			expect := expectChangeStock(mock, 10, "test-1", 5, -5)
+
+			switch {
+			case errors.Is(tc.updateError, sql.ErrNoRows):
+				expect.WillReturnError(tc.updateError)
+				expectStockShortage(mock, 10, "test-1", stockRows(0, 3))
+			case tc.updateError != nil:
+				expect.WillReturnError(tc.updateError)
+			default:
+				expect.WillReturnRows(changedRows(5, 0))
+				expectMovement(mock, domain.MovementRelease, 10, "test-1", domain.BucketAvailable, 5, 5)
+				expectMovement(mock, domain.MovementRelease, 10, "test-1", domain.BucketReserved, -5, 0)
+				expectReservationLots(mock, 1, defaultLotRows(5))
+				expectChangeLot(mock, 10, 1, 5, -5)
+				expectReservationBins(mock, 1, noBinRows())
 			}
-			t.Errorf("expected: %v, got: %v", tc.result, err)
+		}
+
+		if tc.expectCommit {
+			mock.ExpectCommit()
+		} else {
+			mock.ExpectRollback()
+		}
+
+		reservation, err := storage.CancelReservation(context.Background(), &cr)
+		if (err != nil) != tc.expectError {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if errors.Is(tc.queryError, sql.ErrNoRows) && !errors.Is(err, domain.ErrNotFound) {
+			t.Fatalf("expected: %v, got: %v", domain.ErrNotFound, err)
+		}
+
+		if tc.expectCommit && reservation.Status != domain.ReservationCanceled {
+			t.Fatalf("expected status: %s, got: %s", domain.ReservationCanceled, reservation.Status)
 		}
 
 		if err = mock.ExpectationsWereMet(); err != nil {
@@ -253,6 +510,310 @@ func TestProductCancelReservation(t *testing.T) {
 	}
 }
 
+func TestProductFulfill(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("can't create mock: %s", err)
+	}
+	defer db.Close()
+
+	storage := NewProductStorage(db)
+	f := domain.Fulfillment{ReservationIDs: []int64{1, 2}}
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	columns := []string{"id", "order_ref", "warehouse_id", "product_code", "quantity", "status", "created_at", "expires_at"}
+
+	testCases := []batchTestCase{
+		{
+			failAt: -1,
+		},
+		{
+			failAt: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		mock.ExpectBegin()
+		mock.ExpectQuery("INSERT INTO shipments").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(7, now))
+
+		for i, id := range f.ReservationIDs {
+			expect := mock.ExpectQuery("UPDATE reservations SET status").
+				WithArgs(id, domain.ReservationFulfilled, domain.ReservationActive)
+			if i == tc.failAt {
+				expect.WillReturnError(sql.ErrNoRows)
+				break
+			}
+
+			expect.WillReturnRows(sqlmock.NewRows(columns).
+				AddRow(id, "order-1", 10, "test-1", 5, domain.ReservationFulfilled, now, now))
+			expectChangeStock(mock, 10, "test-1", 0, -5).
+				WillReturnRows(changedRows(0, 0))
+			expectMovement(mock, domain.MovementFulfill, 10, "test-1", domain.BucketReserved, -5, 0)
+			expectReservationLots(mock, id, defaultLotRows(5))
+			expectChangeLot(mock, 10, 1, 0, -5)
+			expectReservationBins(mock, id, noBinRows())
+			mock.ExpectExec("UPDATE products SET quantity").
+				WithArgs("test-1", 5).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectExec("INSERT INTO shipment_lines").
+				WithArgs(7, id, 10, "test-1", 5).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+		}
+
+		if tc.failAt < 0 {
+			mock.ExpectCommit()
+		} else {
+			mock.ExpectRollback()
+		}
+
+		shipment, err := storage.Fulfill(context.Background(), &f)
+		if tc.failAt < 0 {
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if shipment.ID != 7 || len(shipment.Lines) != len(f.ReservationIDs) {
+				t.Fatalf("unexpected shipment: %+v", shipment)
+			}
+		} else if !errors.Is(err, domain.ErrNotFound) {
+			t.Fatalf("expected: %v, got: %v", domain.ErrNotFound, err)
+		}
+
+		if err = mock.ExpectationsWereMet(); err != nil {
+			t.Fatalf("there were unfulfilled expectations: %s", err)
+		}
+	}
+}
+
+func TestProductReleaseExpired(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("can't create mock: %s", err)
+	}
+	defer db.Close()
+
+	storage := NewProductStorage(db)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	columns := []string{"id", "order_ref", "warehouse_id", "product_code", "quantity", "status", "created_at", "expires_at"}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("UPDATE reservations r SET status").
+		WithArgs(now, domain.ReservationExpired, domain.ReservationActive).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(1, "order-1", 10, "test-1", 5, domain.ReservationExpired, now, now).
+			AddRow(2, "order-2", 11, "test-1", 3, domain.ReservationExpired, now, now))
+
+	expectChangeStock(mock, 10, "test-1", 5, -5).WillReturnRows(changedRows(5, 0))
+	mock.ExpectExec("INSERT INTO movements").
+		WithArgs(domain.MovementExpire, 10, "test-1", domain.BucketAvailable, 5, 5, "", domain.SweeperRequestID).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO movements").
+		WithArgs(domain.MovementExpire, 10, "test-1", domain.BucketReserved, -5, 0, "", domain.SweeperRequestID).
+		WillReturnResult(sqlmock.NewResult(2, 1))
+	expectReservationLots(mock, 1, defaultLotRows(5))
+	expectChangeLot(mock, 10, 1, 5, -5)
+	expectReservationBins(mock, 1, noBinRows())
+
+	expectChangeStock(mock, 11, "test-1", 3, -3).WillReturnRows(changedRows(3, 0))
+	mock.ExpectExec("INSERT INTO movements").
+		WithArgs(domain.MovementExpire, 11, "test-1", domain.BucketAvailable, 3, 3, "", domain.SweeperRequestID).
+		WillReturnResult(sqlmock.NewResult(3, 1))
+	mock.ExpectExec("INSERT INTO movements").
+		WithArgs(domain.MovementExpire, 11, "test-1", domain.BucketReserved, -3, 0, "", domain.SweeperRequestID).
+		WillReturnResult(sqlmock.NewResult(4, 1))
+	expectReservationLots(mock, 2, defaultLotRows(3))
+	expectChangeLot(mock, 11, 1, 3, -3)
+	expectReservationBins(mock, 2, noBinRows())
+	mock.ExpectCommit()
+
+	released, err := storage.ReleaseExpired(context.Background(), now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(released) != 2 || released[0].ID != 1 || released[1].ID != 2 {
+		t.Fatalf("unexpected released reservations: %+v", released)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestProductReserveBatch(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("can't create mock: %s", err)
+	}
+	defer db.Close()
+
+	storage := NewProductStorage(db)
+	expiresAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	rs := []domain.Reservation{
+		{
+			WarehouseID: 1,
+			Code:        "test-1",
+			Quantity:    10,
+			Status:      domain.ReservationActive,
+			ExpiresAt:   expiresAt,
+		},
+		{
+			WarehouseID: 2,
+			Code:        "test-1",
+			Quantity:    5,
+			Status:      domain.ReservationActive,
+			ExpiresAt:   expiresAt,
+		},
+	}
+
+	testCases := []batchTestCase{
+		{
+			failAt: -1,
+		},
+		{
+			failAt: 1,
+		},
+		{
+			failAt: 0,
+		},
+	}
+
+	for _, tc := range testCases {
+		mock.ExpectBegin()
+		for i, r := range rs {
+			quantity := int64(r.Quantity)
+			expect := expectChangeStock(mock, r.WarehouseID, r.Code, -quantity, quantity)
+			if i == tc.failAt {
+				expect.WillReturnError(domain.ErrTest)
+				break
+			}
+
+			expect.WillReturnRows(changedRows(0, r.Quantity))
+			expectMovement(mock, domain.MovementReserve, r.WarehouseID, r.Code, domain.BucketAvailable, -quantity, 0)
+			expectMovement(mock, domain.MovementReserve, r.WarehouseID, r.Code, domain.BucketReserved, quantity, r.Quantity)
+			expectTakeLots(mock, r.WarehouseID, r.Code, "", true, defaultLotRows(r.Quantity))
+			expectChangeLot(mock, r.WarehouseID, 1, -quantity, quantity)
+			expectTakeBins(mock, r.WarehouseID, r.Code, noBinRows())
+			mock.ExpectQuery("INSERT INTO reservations").
+				WithArgs(r.OrderRef, r.WarehouseID, r.Code, r.Quantity, r.Status, r.ExpiresAt).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(i+1, expiresAt))
+			mock.ExpectExec("INSERT INTO reservation_lots").
+				WithArgs(i+1, 1, quantity).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+		}
+
+		if tc.failAt < 0 {
+			mock.ExpectCommit()
+		} else {
+			mock.ExpectRollback()
+		}
+
+		err = storage.ReserveBatch(context.Background(), rs)
+		checkBatchError(t, err, tc.failAt)
+
+		if err = mock.ExpectationsWereMet(); err != nil {
+			t.Fatalf("there were unfulfilled expectations: %s", err)
+		}
+	}
+}
+
+func TestProductTransferBatch(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("can't create mock: %s", err)
+	}
+	defer db.Close()
+
+	storage := NewProductStorage(db)
+	tds := []domain.TransferProduct{
+		{
+			WarehouseFromID: 1,
+			WarehouseToID:   2,
+			Code:            "test-1",
+			Quantity:        5,
+		},
+		{
+			WarehouseFromID: 2,
+			WarehouseToID:   3,
+			Code:            "test-1",
+			Quantity:        5,
+		},
+	}
+
+	testCases := []batchTestCase{
+		{
+			failAt: -1,
+		},
+		{
+			failAt: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		mock.ExpectBegin()
+		for i, td := range tds {
+			expectLockStock(mock, td.Code, td.WarehouseFromID, td.WarehouseToID).
+				WillReturnResult(sqlmock.NewResult(0, 2))
+
+			if i == tc.failAt {
+				expectChangeStock(mock, td.WarehouseFromID, td.Code, -5, 0).
+					WillReturnError(sql.ErrNoRows)
+				expectStockShortage(mock, td.WarehouseFromID, td.Code, stockRows(0, 0))
+				break
+			}
+
+			expectChangeStock(mock, td.WarehouseFromID, td.Code, -5, 0).
+				WillReturnRows(changedRows(5, 0))
+			expectMovement(mock, domain.MovementTransferOut, td.WarehouseFromID, td.Code, domain.BucketAvailable, -5, 5)
+			expectTakeLots(mock, td.WarehouseFromID, td.Code, "", false, defaultLotRows(10))
+			expectChangeLot(mock, td.WarehouseFromID, 1, -5, 0)
+			expectTakeBins(mock, td.WarehouseFromID, td.Code, noBinRows())
+			mock.ExpectQuery("INSERT INTO warehouse_products").
+				WithArgs(td.WarehouseToID, td.Code, 5).
+				WillReturnRows(stockRows(5, 0))
+			expectMovement(mock, domain.MovementTransferIn, td.WarehouseToID, td.Code, domain.BucketAvailable, 5, 5)
+			expectChangeLot(mock, td.WarehouseToID, 1, 5, 0)
+		}
+
+		if tc.failAt < 0 {
+			mock.ExpectCommit()
+		} else {
+			mock.ExpectRollback()
+		}
+
+		err = storage.TransferBatch(context.Background(), tds)
+		checkBatchError(t, err, tc.failAt)
+
+		if tc.failAt >= 0 && !errors.Is(err, domain.ErrInsufficientStock) {
+			t.Fatalf("expected: %v, got: %v", domain.ErrInsufficientStock, err)
+		}
+
+		if err = mock.ExpectationsWereMet(); err != nil {
+			t.Fatalf("there were unfulfilled expectations: %s", err)
+		}
+	}
+}
+
+func checkBatchError(t *testing.T, err error, failAt int) {
+	t.Helper()
+
+	if failAt < 0 {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return
+	}
+
+	var batchErr *domain.BatchError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("expected batch error, got: %v", err)
+	}
+
+	if batchErr.Index != failAt {
+		t.Fatalf("expected failed item: %d, got: %d", failAt, batchErr.Index)
+	}
+}
+
 func TestProductTransfer(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
@@ -268,19 +829,16 @@ func TestProductTransfer(t *testing.T) {
 		Quantity:        5,
 	}
 
-	expectedSelect := "SELECT available_quantity FROM warehouse_products"
 	expectedQuery := "INSERT INTO warehouse_products"
 	expectedExecQuery := "UPDATE warehouse_products"
 
 	testCases := []productTransactTestCase{
 		{
 			td:                td,
+			expectLock:        true,
 			expectedExecQuery: expectedExecQuery,
-			expectedSelect:    expectedSelect,
-			selectRows:        sqlmock.NewRows([]string{"available_quantity"}).AddRow(10),
 			expectedQuery:     expectedQuery,
-			execArgs:          []driver.Value{2, "test-1", 5, 0},
-			execResult:        sqlmock.NewResult(0, 1),
+			execArgs:          []driver.Value{2, "test-1", 5},
 			expectCommit:      true,
 		},
 		{
@@ -289,71 +847,92 @@ func TestProductTransfer(t *testing.T) {
 			beginError:  domain.ErrTest,
 		},
 		{
-			td:                  td,
-			expectedSelect:      expectedSelect,
-			expectError:         true,
-			expectedSelectError: domain.ErrTest,
+			td:          td,
+			expectLock:  true,
+			lockError:   domain.ErrTest,
+			expectError: true,
 		},
 		{
 			td:                     td,
-			expectedSelect:         expectedSelect,
+			expectLock:             true,
 			expectedExecQuery:      expectedExecQuery,
-			selectRows:             sqlmock.NewRows([]string{"available_quantity"}).AddRow(10),
-			expectError:            true,
 			expectedExecQueryError: domain.ErrTest,
+			expectError:            true,
 		},
 		{
-			td:             td,
-			expectedSelect: expectedSelect,
-			selectRows:     sqlmock.NewRows([]string{"available_quantity"}).AddRow(0),
-			expectError:    true,
+			td:                     td,
+			expectLock:             true,
+			expectedExecQuery:      expectedExecQuery,
+			expectedExecQueryError: sql.ErrNoRows,
+			shortage:               stockRows(3, 0),
+			expectError:            true,
 		},
 		{
 			td:                td,
+			expectLock:        true,
 			expectedExecQuery: expectedExecQuery,
-			expectedSelect:    expectedSelect,
-			selectRows:        sqlmock.NewRows([]string{"available_quantity"}).AddRow(10),
 			expectedQuery:     expectedQuery,
-			execArgs:          []driver.Value{2, "test-1", 5, 0},
-			execResult:        sqlmock.NewResult(0, 1),
+			execArgs:          []driver.Value{2, "test-1", 5},
 			execError:         domain.ErrTest,
 			expectError:       true,
 		},
 	}
 
 	for _, tc := range testCases {
-		if tc.expectedSelect != "" {
-			mock.ExpectBegin().WillReturnError(tc.beginError)
+		mock.ExpectBegin().WillReturnError(tc.beginError)
 
-			mock.ExpectQuery(tc.expectedSelect).
-				WithArgs(tc.td.WarehouseFromID, tc.td.Code).
-				WillReturnRows(tc.selectRows).
-				WillReturnError(tc.expectedSelectError)
+		if tc.expectLock {
+			expectLockStock(mock, tc.td.Code, tc.td.WarehouseFromID, tc.td.WarehouseToID).
+				WillReturnResult(sqlmock.NewResult(0, 2)).
+				WillReturnError(tc.lockError)
 		}
 
 		if tc.expectedExecQuery != "" {
-			mock.ExpectExec(tc.expectedExecQuery).
-				WithArgs(tc.td.WarehouseFromID, tc.td.Code, tc.td.Quantity).
-				WillReturnResult(sqlmock.NewResult(0, 1)).
+			mock.ExpectQuery(tc.expectedExecQuery).
+				WithArgs(tc.td.WarehouseFromID, tc.td.Code, -5, 0).
+				WillReturnRows(changedRows(5, 0)).
 				WillReturnError(tc.expectedExecQueryError)
+
+			if tc.expectedExecQueryError == nil {
+				expectMovement(mock, domain.MovementTransferOut, 1, "test-1", domain.BucketAvailable, -5, 5)
+				expectTakeLots(mock, 1, "test-1", "", false, defaultLotRows(10))
+				expectChangeLot(mock, 1, 1, -5, 0)
+				expectTakeBins(mock, 1, "test-1", noBinRows())
+			}
+		}
+
+		if tc.shortage != nil {
+			expectStockShortage(mock, tc.td.WarehouseFromID, tc.td.Code, tc.shortage)
 		}
 
 		if tc.expectedQuery != "" {
-			mock.ExpectExec(tc.expectedQuery).
+			mock.ExpectQuery(tc.expectedQuery).
 				WithArgs(tc.execArgs...).
-				WillReturnResult(tc.execResult).
+				WillReturnRows(stockRows(5, 0)).
 				WillReturnError(tc.execError)
+
+			if tc.execError == nil {
+				expectMovement(mock, domain.MovementTransferIn, 2, "test-1", domain.BucketAvailable, 5, 5)
+				expectChangeLot(mock, 2, 1, 5, 0)
+			}
 		}
 
-		if tc.expectCommit {
+		switch {
+		case tc.expectCommit:
 			mock.ExpectCommit()
+		case tc.beginError == nil:
+			mock.ExpectRollback()
 		}
 
-		err = storage.Transfer(&tc.td)
+		err = storage.Transfer(context.Background(), &tc.td)
 		if (err != nil) != tc.expectError {
 			t.Errorf("unexpected error: %v", err)
 		}
 
+		if tc.shortage != nil && !errors.Is(err, domain.ErrInsufficientStock) {
+			t.Errorf("expected: %v, got: %v", domain.ErrInsufficientStock, err)
+		}
+
 		if err = mock.ExpectationsWereMet(); err != nil {
 			t.Errorf("there were unfulfilled expectations: %s", err)
 		}
@@ -374,7 +953,7 @@ func TestProductAdd(t *testing.T) {
 		WarehouseID: 1,
 	}
 
-	expectedQuery := "UPDATE warehouse_products"
+	expectedQuery := "INSERT INTO warehouse_products"
 	expectedExecQuery := "UPDATE products"
 
 	testCases := []productTransactTestCase{
@@ -383,7 +962,6 @@ func TestProductAdd(t *testing.T) {
 			expectedExecQuery: expectedExecQuery,
 			expectedQuery:     expectedQuery,
 			execArgs:          []driver.Value{1, "test-1", 10},
-			execResult:        sqlmock.NewResult(0, 1),
 			expectCommit:      true,
 		},
 		{
@@ -399,7 +977,6 @@ func TestProductAdd(t *testing.T) {
 			expectError:       true,
 			execError:         domain.ErrTest,
 			execArgs:          []driver.Value{1, "test-1", 10},
-			execResult:        sqlmock.NewResult(0, 1),
 		},
 	}
 
@@ -414,17 +991,25 @@ func TestProductAdd(t *testing.T) {
 		}
 
 		if tc.expectedQuery != "" {
-			mock.ExpectExec(tc.expectedQuery).
+			mock.ExpectQuery(tc.expectedQuery).
 				WithArgs(tc.execArgs...).
-				WillReturnResult(tc.execResult).
+				WillReturnRows(stockRows(15, 0)).
 				WillReturnError(tc.execError)
+
+			if tc.execError == nil {
+				expectMovement(mock, domain.MovementAdd, 1, "test-1", domain.BucketAvailable, 10, 15)
+				mock.ExpectQuery("INSERT INTO lots").
+					WithArgs("test-1", "", nil, nil).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "manufactured_at", "expires_at"}).AddRow(1, nil, nil))
+				expectChangeLot(mock, 1, 1, 10, 0)
+			}
 		}
 
 		if tc.expectCommit {
 			mock.ExpectCommit()
 		}
 
-		err = storage.Add(&tc.ad)
+		err = storage.Add(context.Background(), &tc.ad)
 		if (err != nil) != tc.expectError {
 			t.Errorf("unexpected error: %v", err)
 		}
@@ -449,8 +1034,6 @@ func TestProductDelete(t *testing.T) {
 
 	query := `DELETE FROM products WHERE code = \$1
 			  RETURNING name, size, code, quantity`
-	rows := sqlmock.NewRows([]string{"name", "size", "code", "quantity"}).
-		AddRow("test", "test", "test", 10)
 	args := []driver.Value{"test"}
 	expectedResult := &domain.Product{
 		Name:     "test",
@@ -463,7 +1046,7 @@ func TestProductDelete(t *testing.T) {
 		{
 			dp:     dp,
 			query:  query,
-			rows:   rows,
+			rows:   sqlmock.NewRows([]string{"name", "size", "code", "quantity"}).AddRow("test", "test", "test", 10),
 			args:   args,
 			result: nil,
 		},
@@ -476,12 +1059,31 @@ func TestProductDelete(t *testing.T) {
 	}
 
 	for _, tc := range testCases {
+		mock.ExpectBegin()
+		expectTransfersInTransit(mock, "{}")
+		mock.ExpectQuery("DELETE FROM warehouse_products").
+			WithArgs(tc.args...).
+			WillReturnRows(sqlmock.NewRows([]string{"warehouse_id", "available_quantity", "reserved_quantity",
+				"quarantined_quantity", "damaged_quantity"}).
+				AddRow(1, 7, 3, 0, 0).
+				AddRow(2, 0, 0, 2, 1))
+		expectMovement(mock, domain.MovementDelete, 1, "test", domain.BucketAvailable, -7, 0)
+		expectMovement(mock, domain.MovementDelete, 1, "test", domain.BucketReserved, -3, 0)
+		expectMovement(mock, domain.MovementDelete, 2, "test", domain.BucketQuarantined, -2, 0)
+		expectMovement(mock, domain.MovementDelete, 2, "test", domain.BucketDamaged, -1, 0)
+
 		mock.ExpectQuery(tc.query).
 			WithArgs(tc.args...).
 			WillReturnRows(tc.rows).
 			WillReturnError(tc.result)
 
-		product, err := storage.Delete(&tc.dp)
+		if tc.result == nil {
+			mock.ExpectCommit()
+		} else {
+			mock.ExpectRollback()
+		}
+
+		product, err := storage.Delete(context.Background(), &tc.dp)
 
 		if !errors.Is(err, tc.result) {
 			t.Errorf("expected: %v, got: %v", tc.result, err)
@@ -495,4 +1097,23 @@ func TestProductDelete(t *testing.T) {
 			t.Fatalf("there were unfulfilled expectations: %s", err)
 		}
 	}
+
+	// Units in transit keep the product.
+	mock.ExpectBegin()
+	expectTransfersInTransit(mock, "{4}")
+	mock.ExpectRollback()
+
+	if _, err = storage.Delete(context.Background(), &dp); !errors.Is(err, domain.ErrValidationFailed) {
+		t.Fatalf("expected: %v, got: %v", domain.ErrValidationFailed, err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func expectTransfersInTransit(mock sqlmock.Sqlmock, ids string) {
+	mock.ExpectQuery("SELECT ARRAY(.+) FROM transfers").
+		WithArgs("test", domain.TransferInTransit).
+		WillReturnRows(sqlmock.NewRows([]string{"ids"}).AddRow(ids))
 }
@@ -0,0 +1,277 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/akrovv/warehouse/internal/domain"
+)
+
+const purchaseOrderColumns = "id, supplier_id, reference, status, created_at, closed_at"
+
+func purchaseOrderFields(po *domain.PurchaseOrder) []any {
+	return []any{&po.ID, &po.SupplierID, &po.Reference, &po.Status, &po.CreatedAt, &po.ClosedAt}
+}
+
+type purchaseStorage struct {
+	db *sql.DB
+}
+
+func NewPurchaseStorage(db *sql.DB) *purchaseStorage {
+	return &purchaseStorage{
+		db: db,
+	}
+}
+
+func (s *purchaseStorage) CreateSupplier(ctx context.Context, supplier *domain.Supplier) error {
+	err := s.db.QueryRowContext(ctx, `INSERT INTO suppliers (name) VALUES ($1) RETURNING id`, supplier.Name).
+		Scan(&supplier.ID)
+
+	if err != nil {
+		return fmt.Errorf("db.QueryRow with command INSERT to suppliers returned: %w", mapError(err))
+	}
+
+	return nil
+}
+
+func (s *purchaseStorage) ListSuppliers(ctx context.Context, f *domain.SupplierFilter) ([]domain.Supplier, error) {
+	c := conditions{}
+
+	rows, err := s.db.QueryContext(ctx, `SELECT id, name FROM suppliers ORDER BY id`+c.page(f.Limit, f.Offset), c.args...)
+	if err != nil {
+		return nil, fmt.Errorf("db.Query with command SELECT to suppliers returned: %w", mapError(err))
+	}
+	defer rows.Close()
+
+	supplier := domain.Supplier{}
+	suppliers := make([]domain.Supplier, 0, domain.BasicSliceLength)
+	for rows.Next() {
+		if err = rows.Scan(&supplier.ID, &supplier.Name); err != nil {
+			return nil, fmt.Errorf("row scan returned: %w", err)
+		}
+
+		suppliers = append(suppliers, supplier)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err() returned: %w", err)
+	}
+
+	return suppliers, nil
+}
+
+func (s *purchaseStorage) CreateOrder(ctx context.Context, po *domain.PurchaseOrder) error {
+	return withTx(ctx, s.db, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, `
+			INSERT INTO purchase_orders (supplier_id, reference, status) VALUES ($1, $2, $3)
+			RETURNING `+purchaseOrderColumns,
+			po.SupplierID, po.Reference, po.Status).
+			Scan(purchaseOrderFields(po)...)
+
+		if err != nil {
+			return fmt.Errorf("db.QueryRow with command INSERT to purchase_orders returned: %w", mapError(err))
+		}
+
+		for i := range po.Lines {
+			line := &po.Lines[i]
+
+			err = tx.QueryRowContext(ctx, `
+				INSERT INTO purchase_order_lines (order_id, warehouse_id, product_code, ordered)
+				VALUES ($1, $2, $3, $4)
+				RETURNING id`,
+				po.ID, line.WarehouseID, line.Code, line.Ordered).
+				Scan(&line.ID)
+
+			if err != nil {
+				return fmt.Errorf("db.QueryRow with command INSERT to purchase_order_lines returned: %w", mapError(err))
+			}
+		}
+
+		return nil
+	})
+}
+
+func (s *purchaseStorage) GetOrder(ctx context.Context, gp *domain.GetPurchaseOrder) (*domain.PurchaseOrder, error) {
+	po := domain.PurchaseOrder{}
+
+	err := s.db.QueryRowContext(ctx, `SELECT `+purchaseOrderColumns+` FROM purchase_orders WHERE id = $1`, gp.ID).
+		Scan(purchaseOrderFields(&po)...)
+
+	if err != nil {
+		return nil, fmt.Errorf("db.QueryRow with command SELECT to purchase_orders returned: %w", mapError(err))
+	}
+
+	if po.Lines, err = orderLines(ctx, s.db, po.ID); err != nil {
+		return nil, err
+	}
+
+	return &po, nil
+}
+
+// Receive adds the received stock like Products.Add does, in the
+// transaction that counts it against the order.
+func (s *purchaseStorage) Receive(ctx context.Context, pr *domain.PurchaseReceipt) (*domain.PurchaseOrder, error) {
+	po := domain.PurchaseOrder{}
+
+	err := withTx(ctx, s.db, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, `SELECT `+purchaseOrderColumns+` FROM purchase_orders WHERE id = $1 FOR UPDATE`,
+			pr.OrderID).
+			Scan(purchaseOrderFields(&po)...)
+
+		if err != nil {
+			return fmt.Errorf("db.QueryRow with command SELECT to purchase_orders returned: %w", mapError(err))
+		}
+
+		if po.Lines, err = orderLines(ctx, tx, po.ID); err != nil {
+			return err
+		}
+
+		adds, err := po.Receive(pr)
+		if err != nil {
+			return err
+		}
+
+		for i := range adds {
+			if err = add(ctx, tx, &adds[i], domain.MovementAdd); err != nil {
+				return err
+			}
+		}
+
+		for _, line := range po.Lines {
+			if !slices.ContainsFunc(pr.Lines, func(r domain.ReceiptLine) bool { return r.LineID == line.ID }) {
+				continue
+			}
+
+			_, err = tx.ExecContext(ctx, `UPDATE purchase_order_lines SET received = $2 WHERE id = $1`,
+				line.ID, line.Received)
+
+			if err != nil {
+				return fmt.Errorf("db.Exec with command UPDATE to purchase_order_lines returned: %w", mapError(err))
+			}
+		}
+
+		if po.Status == domain.PurchaseOrderOpen {
+			return nil
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE purchase_orders SET status = $2 WHERE id = $1`, po.ID, po.Status)
+		if err != nil {
+			return fmt.Errorf("db.Exec with command UPDATE to purchase_orders returned: %w", mapError(err))
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &po, nil
+}
+
+func (s *purchaseStorage) CloseOrder(ctx context.Context, cp *domain.ClosePurchaseOrder) (*domain.PurchaseOrder, error) {
+	po := domain.PurchaseOrder{}
+
+	err := s.db.QueryRowContext(ctx, `
+		UPDATE purchase_orders SET status = $2, closed_at = NOW()
+		WHERE id = $1 AND status = $3
+		RETURNING `+purchaseOrderColumns,
+		cp.OrderID, domain.PurchaseOrderClosed, domain.PurchaseOrderOpen).
+		Scan(purchaseOrderFields(&po)...)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: open purchase order %d", domain.ErrNotFound, cp.OrderID)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("db.QueryRow with command UPDATE to purchase_orders returned: %w", mapError(err))
+	}
+
+	if po.Lines, err = orderLines(ctx, s.db, po.ID); err != nil {
+		return nil, err
+	}
+
+	return &po, nil
+}
+
+// Outstanding lists lines of open orders only, closing an order gives up on
+// its lines.
+func (s *purchaseStorage) Outstanding(ctx context.Context, f *domain.OutstandingFilter) ([]domain.OutstandingLine, error) {
+	c := conditions{}
+	c.add("o.status = $%d", domain.PurchaseOrderOpen)
+
+	if f.SupplierID != 0 {
+		c.add("o.supplier_id = $%d", f.SupplierID)
+	}
+
+	if f.WarehouseID != 0 {
+		c.add("l.warehouse_id = $%d", f.WarehouseID)
+	}
+
+	if f.Code != "" {
+		c.add("l.product_code = $%d", f.Code)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT o.id, o.supplier_id, o.reference, l.id, l.warehouse_id, l.product_code, l.ordered, l.received
+		FROM purchase_order_lines l JOIN purchase_orders o ON o.id = l.order_id`+c.where()+`
+			AND l.received < l.ordered
+		ORDER BY o.id, l.id`+c.page(f.Limit, f.Offset),
+		c.args...)
+
+	if err != nil {
+		return nil, fmt.Errorf("db.Query with command SELECT to purchase_order_lines returned: %w", mapError(err))
+	}
+	defer rows.Close()
+
+	line := domain.OutstandingLine{}
+	lines := make([]domain.OutstandingLine, 0, domain.BasicSliceLength)
+	for rows.Next() {
+		err = rows.Scan(&line.OrderID, &line.SupplierID, &line.Reference,
+			&line.ID, &line.WarehouseID, &line.Code, &line.Ordered, &line.Received)
+
+		if err != nil {
+			return nil, fmt.Errorf("row scan returned: %w", err)
+		}
+
+		line.Outstanding = line.PurchaseOrderLine.Outstanding()
+		lines = append(lines, line)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err() returned: %w", err)
+	}
+
+	return lines, nil
+}
+
+func orderLines(ctx context.Context, ex executor, orderID int64) ([]domain.PurchaseOrderLine, error) {
+	rows, err := ex.QueryContext(ctx, `
+		SELECT id, warehouse_id, product_code, ordered, received
+		FROM purchase_order_lines WHERE order_id = $1 ORDER BY id`,
+		orderID)
+
+	if err != nil {
+		return nil, fmt.Errorf("db.Query with command SELECT to purchase_order_lines returned: %w", mapError(err))
+	}
+	defer rows.Close()
+
+	line := domain.PurchaseOrderLine{}
+	lines := make([]domain.PurchaseOrderLine, 0, domain.BasicSliceLength)
+	for rows.Next() {
+		if err = rows.Scan(&line.ID, &line.WarehouseID, &line.Code, &line.Ordered, &line.Received); err != nil {
+			return nil, fmt.Errorf("row scan returned: %w", err)
+		}
+
+		lines = append(lines, line)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err() returned: %w", err)
+	}
+
+	return lines, nil
+}
@@ -0,0 +1,196 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/akrovv/warehouse/internal/domain"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+var purchaseOrderColumnNames = []string{"id", "supplier_id", "reference", "status", "created_at", "closed_at"}
+
+// orderRows are rows of purchase order 3 of supplier 1.
+func orderRows(status string) *sqlmock.Rows {
+	return sqlmock.NewRows(purchaseOrderColumnNames).
+		AddRow(3, 1, "PO-1", status, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), nil)
+}
+
+// expectOrderLines returns line 5 of ten units to warehouse 1.
+func expectOrderLines(mock sqlmock.Sqlmock, received uint64) {
+	mock.ExpectQuery("SELECT (.+) FROM purchase_order_lines WHERE order_id = (.+)").
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "warehouse_id", "product_code", "ordered", "received"}).
+			AddRow(5, 1, "test-1", 10, received))
+}
+
+func TestPurchaseCreateOrder(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("can't create mock: %s", err)
+	}
+	defer db.Close()
+
+	storage := NewPurchaseStorage(db)
+	po := domain.PurchaseOrder{SupplierID: 1, Reference: "PO-1", Status: domain.PurchaseOrderOpen,
+		Lines: []domain.PurchaseOrderLine{{WarehouseID: 1, Code: "test-1", Ordered: 10}}}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO purchase_orders").
+		WithArgs(1, "PO-1", domain.PurchaseOrderOpen).
+		WillReturnRows(orderRows(domain.PurchaseOrderOpen))
+	mock.ExpectQuery("INSERT INTO purchase_order_lines").
+		WithArgs(3, 1, "test-1", 10).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(5))
+	mock.ExpectCommit()
+
+	if err = storage.CreateOrder(context.Background(), &po); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if po.ID != 3 || po.Lines[0].ID != 5 {
+		t.Fatalf("expected order 3 with line 5, got: %+v", po)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestPurchaseReceive(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("can't create mock: %s", err)
+	}
+	defer db.Close()
+
+	storage := NewPurchaseStorage(db)
+	pr := domain.PurchaseReceipt{OrderID: 3, Lines: []domain.ReceiptLine{{LineID: 5, Quantity: 6}}}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT (.+) FROM purchase_orders WHERE id = (.+) FOR UPDATE").
+		WithArgs(3).
+		WillReturnRows(orderRows(domain.PurchaseOrderOpen))
+	expectOrderLines(mock, 4)
+	mock.ExpectExec("UPDATE products").
+		WithArgs(6, "test-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("INSERT INTO warehouse_products").
+		WithArgs(1, "test-1", 6).
+		WillReturnRows(stockRows(6, 0))
+	expectMovement(mock, domain.MovementAdd, 1, "test-1", domain.BucketAvailable, 6, 6)
+	mock.ExpectQuery("INSERT INTO lots").
+		WithArgs("test-1", "", nil, nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "manufactured_at", "expires_at"}).AddRow(1, nil, nil))
+	expectChangeLot(mock, 1, 1, 6, 0)
+	mock.ExpectExec("UPDATE purchase_order_lines").
+		WithArgs(5, 10).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE purchase_orders").
+		WithArgs(3, domain.PurchaseOrderReceived).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	po, err := storage.Receive(context.Background(), &pr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if po.Status != domain.PurchaseOrderReceived || po.Lines[0].Received != 10 {
+		t.Fatalf("expected the order received, got: %+v", po)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT (.+) FROM purchase_orders WHERE id = (.+) FOR UPDATE").
+		WithArgs(3).
+		WillReturnRows(orderRows(domain.PurchaseOrderOpen))
+	expectOrderLines(mock, 8)
+	mock.ExpectRollback()
+
+	if _, err = storage.Receive(context.Background(), &pr); !errors.Is(err, domain.ErrValidationFailed) {
+		t.Fatalf("expected: %v, got: %v", domain.ErrValidationFailed, err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT (.+) FROM purchase_orders WHERE id = (.+) FOR UPDATE").
+		WithArgs(3).
+		WillReturnRows(orderRows(domain.PurchaseOrderClosed))
+	expectOrderLines(mock, 4)
+	mock.ExpectRollback()
+
+	if _, err = storage.Receive(context.Background(), &pr); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("expected: %v, got: %v", domain.ErrNotFound, err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestPurchaseCloseOrder(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("can't create mock: %s", err)
+	}
+	defer db.Close()
+
+	storage := NewPurchaseStorage(db)
+
+	mock.ExpectQuery("UPDATE purchase_orders SET status").
+		WithArgs(3, domain.PurchaseOrderClosed, domain.PurchaseOrderOpen).
+		WillReturnRows(orderRows(domain.PurchaseOrderClosed))
+	expectOrderLines(mock, 4)
+
+	po, err := storage.CloseOrder(context.Background(), &domain.ClosePurchaseOrder{OrderID: 3})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if po.Status != domain.PurchaseOrderClosed || len(po.Lines) != 1 {
+		t.Fatalf("expected the order closed, got: %+v", po)
+	}
+
+	mock.ExpectQuery("UPDATE purchase_orders SET status").
+		WithArgs(3, domain.PurchaseOrderClosed, domain.PurchaseOrderOpen).
+		WillReturnError(sql.ErrNoRows)
+
+	if _, err = storage.CloseOrder(context.Background(), &domain.ClosePurchaseOrder{OrderID: 3}); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("expected: %v, got: %v", domain.ErrNotFound, err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestPurchaseOutstanding(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("can't create mock: %s", err)
+	}
+	defer db.Close()
+
+	storage := NewPurchaseStorage(db)
+
+	mock.ExpectQuery(`FROM purchase_order_lines l JOIN purchase_orders o ON o.id = l.order_id WHERE o.status = \$1 AND l.warehouse_id = \$2\s+AND l.received < l.ordered\s+ORDER BY o.id, l.id LIMIT \$3 OFFSET \$4`).
+		WithArgs(domain.PurchaseOrderOpen, 1, 10, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "supplier_id", "reference", "id", "warehouse_id", "product_code",
+			"ordered", "received"}).
+			AddRow(3, 1, "PO-1", 5, 1, "test-1", 10, 4))
+
+	lines, err := storage.Outstanding(context.Background(), &domain.OutstandingFilter{WarehouseID: 1, Limit: 10})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(lines) != 1 || lines[0].OrderID != 3 || lines[0].Outstanding != 6 {
+		t.Fatalf("expected six units outstanding on order 3, got: %+v", lines)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("there were unfulfilled expectations: %s", err)
+	}
+}
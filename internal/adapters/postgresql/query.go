@@ -0,0 +1,37 @@
+package postgresql
+
+import (
+	"fmt"
+	"strings"
+)
+
+// conditions builds a WHERE clause from optional filters, placeholders are
+// numbered in the order the filters are added.
+type conditions struct {
+	clauses []string
+	args    []any
+}
+
+func (c *conditions) add(clause string, arg any) {
+	c.args = append(c.args, arg)
+	c.clauses = append(c.clauses, fmt.Sprintf(clause, len(c.args)))
+}
+
+func (c *conditions) where() string {
+	if len(c.clauses) == 0 {
+		return ""
+	}
+
+	return " WHERE " + strings.Join(c.clauses, " AND ")
+}
+
+func (c *conditions) limit(limit uint64) string {
+	c.args = append(c.args, limit)
+	return fmt.Sprintf(" LIMIT $%d", len(c.args))
+}
+
+func (c *conditions) page(limit, offset uint64) string {
+	query := c.limit(limit)
+	c.args = append(c.args, offset)
+	return query + fmt.Sprintf(" OFFSET $%d", len(c.args))
+}
@@ -0,0 +1,41 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/akrovv/warehouse/internal/domain"
+)
+
+const reservationColumns = "id, order_ref, warehouse_id, product_code, quantity, status, created_at, expires_at"
+
+func reservationFields(r *domain.Reservation) []any {
+	return []any{&r.ID, &r.OrderRef, &r.WarehouseID, &r.Code, &r.Quantity, &r.Status, &r.CreatedAt, &r.ExpiresAt}
+}
+
+func openReservations(ctx context.Context, ex executor, warehouseID int64) ([]domain.Reservation, error) {
+	rows, err := ex.QueryContext(ctx, `SELECT `+reservationColumns+` FROM reservations
+						   WHERE warehouse_id = $1 AND status = $2 ORDER BY id`,
+		warehouseID, domain.ReservationActive)
+
+	if err != nil {
+		return nil, fmt.Errorf("db.Query with command SELECT to reservations returned: %w", mapError(err))
+	}
+	defer rows.Close()
+
+	reservation := domain.Reservation{}
+	reservations := make([]domain.Reservation, 0, domain.BasicSliceLength)
+	for rows.Next() {
+		if err = rows.Scan(reservationFields(&reservation)...); err != nil {
+			return nil, fmt.Errorf("row scan returned: %w", err)
+		}
+
+		reservations = append(reservations, reservation)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err() returned: %w", err)
+	}
+
+	return reservations, nil
+}
@@ -0,0 +1,296 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+
+	"github.com/akrovv/warehouse/internal/domain"
+	"github.com/lib/pq"
+)
+
+const returnColumns = "id, shipment_id, reason, status, created_at"
+
+func returnFields(r *domain.Return) []any {
+	return []any{&r.ID, &r.ShipmentID, &r.Reason, &r.Status, &r.CreatedAt}
+}
+
+type returnStorage struct {
+	db *sql.DB
+}
+
+func NewReturnStorage(db *sql.DB) *returnStorage {
+	return &returnStorage{
+		db: db,
+	}
+}
+
+// Create locks the shipped lines, so concurrent returns of the same
+// reservation can't authorize more than was shipped.
+func (s *returnStorage) Create(ctx context.Context, cr *domain.CreateReturn) (*domain.Return, error) {
+	ret := domain.Return{}
+
+	err := withTx(ctx, s.db, func(tx *sql.Tx) error {
+		shipmentID, shipped, err := shippedLines(ctx, tx, cr)
+		if err != nil {
+			return err
+		}
+
+		ids := make([]int64, 0, len(shipped))
+		for _, sl := range shipped {
+			ids = append(ids, sl.ReservationID)
+		}
+
+		returned, err := returnedQuantities(ctx, tx, ids)
+		if err != nil {
+			return err
+		}
+
+		if ret, err = domain.NewReturn(cr, shipmentID, shipped, returned); err != nil {
+			return err
+		}
+
+		err = tx.QueryRowContext(ctx, `
+			INSERT INTO returns (shipment_id, reason, status) VALUES ($1, $2, $3)
+			RETURNING `+returnColumns,
+			ret.ShipmentID, ret.Reason, ret.Status).
+			Scan(returnFields(&ret)...)
+
+		if err != nil {
+			return fmt.Errorf("db.QueryRow with command INSERT to returns returned: %w", mapError(err))
+		}
+
+		for i := range ret.Lines {
+			line := &ret.Lines[i]
+
+			err = tx.QueryRowContext(ctx, `
+				INSERT INTO return_lines (return_id, reservation_id, warehouse_id, product_code, quantity)
+				VALUES ($1, $2, $3, $4, $5)
+				RETURNING id`,
+				ret.ID, line.ReservationID, line.WarehouseID, line.Code, line.Quantity).
+				Scan(&line.ID)
+
+			if err != nil {
+				return fmt.Errorf("db.QueryRow with command INSERT to return_lines returned: %w", mapError(err))
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
+
+func (s *returnStorage) Get(ctx context.Context, gr *domain.GetReturn) (*domain.Return, error) {
+	ret := domain.Return{}
+
+	err := s.db.QueryRowContext(ctx, `SELECT `+returnColumns+` FROM returns WHERE id = $1`, gr.ID).
+		Scan(returnFields(&ret)...)
+
+	if err != nil {
+		return nil, fmt.Errorf("db.QueryRow with command SELECT to returns returned: %w", mapError(err))
+	}
+
+	if ret.Lines, err = returnLines(ctx, s.db, ret.ID); err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
+
+// Receive brings the returned units back in the transaction that counts them
+// against the return.
+func (s *returnStorage) Receive(ctx context.Context, rr *domain.ReturnReceipt) (*domain.Return, error) {
+	ret := domain.Return{}
+
+	err := withTx(ctx, s.db, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, `SELECT `+returnColumns+` FROM returns WHERE id = $1 FOR UPDATE`, rr.ReturnID).
+			Scan(returnFields(&ret)...)
+
+		if err != nil {
+			return fmt.Errorf("db.QueryRow with command SELECT to returns returned: %w", mapError(err))
+		}
+
+		if ret.Lines, err = returnLines(ctx, tx, ret.ID); err != nil {
+			return err
+		}
+
+		stock, err := ret.Receive(rr)
+		if err != nil {
+			return err
+		}
+
+		for i := range stock {
+			if err = returnStock(ctx, tx, &stock[i]); err != nil {
+				return err
+			}
+		}
+
+		for _, line := range ret.Lines {
+			if !slices.ContainsFunc(rr.Lines, func(r domain.ReturnReceiptLine) bool { return r.LineID == line.ID }) {
+				continue
+			}
+
+			_, err = tx.ExecContext(ctx, `
+				UPDATE return_lines SET restocked = $2, quarantined = $3, written_off = $4 WHERE id = $1`,
+				line.ID, line.Restocked, line.Quarantined, line.WrittenOff)
+
+			if err != nil {
+				return fmt.Errorf("db.Exec with command UPDATE to return_lines returned: %w", mapError(err))
+			}
+		}
+
+		if ret.Status == domain.ReturnOpen {
+			return nil
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE returns SET status = $2 WHERE id = $1`, ret.ID, ret.Status)
+		if err != nil {
+			return fmt.Errorf("db.Exec with command UPDATE to returns returned: %w", mapError(err))
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
+
+// returnStock puts restocked units into available stock like add does and
+// quarantined ones into the quarantine. Written off units pass through the
+// quarantine, so the ledger shows them coming back and leaving.
+func returnStock(ctx context.Context, ex executor, rs *domain.ReturnedStock) error {
+	if rs.Disposition == domain.DispositionRestock {
+		return add(ctx, ex, &rs.AddProduct, domain.MovementReturn)
+	}
+
+	c := stockChange{
+		movementType: domain.MovementReturn,
+		requestID:    rs.RequestID,
+		warehouseID:  rs.WarehouseID,
+		code:         rs.Code,
+	}
+
+	if err := changeBucket(ctx, ex, c, domain.BucketQuarantined, int64(rs.Quantity)); err != nil {
+		return err
+	}
+
+	if rs.Disposition == domain.DispositionWriteOff {
+		c.movementType = domain.MovementWriteOff
+		return changeBucket(ctx, ex, c, domain.BucketQuarantined, -int64(rs.Quantity))
+	}
+
+	return changeQuantity(ctx, ex, rs.Code, int64(rs.Quantity))
+}
+
+// shippedLines locks the shipment lines cr returns and returns the shipment
+// they belong to.
+func shippedLines(ctx context.Context, ex executor, cr *domain.CreateReturn) (int64, []domain.ShipmentLine, error) {
+	c := conditions{}
+	if cr.ShipmentID != 0 {
+		c.add("shipment_id = $%d", cr.ShipmentID)
+	} else {
+		c.add("reservation_id = $%d", cr.ReservationID)
+	}
+
+	rows, err := ex.QueryContext(ctx, `
+		SELECT shipment_id, reservation_id, warehouse_id, product_code, quantity
+		FROM shipment_lines`+c.where()+` ORDER BY id FOR UPDATE`,
+		c.args...)
+
+	if err != nil {
+		return 0, nil, fmt.Errorf("db.Query with command SELECT to shipment_lines returned: %w", mapError(err))
+	}
+	defer rows.Close()
+
+	var shipmentID int64
+	line := domain.ShipmentLine{}
+	lines := make([]domain.ShipmentLine, 0, domain.BasicSliceLength)
+	for rows.Next() {
+		err = rows.Scan(&shipmentID, &line.ReservationID, &line.WarehouseID, &line.Code, &line.Quantity)
+		if err != nil {
+			return 0, nil, fmt.Errorf("row scan returned: %w", err)
+		}
+
+		lines = append(lines, line)
+	}
+
+	if err = rows.Err(); err != nil {
+		return 0, nil, fmt.Errorf("rows.Err() returned: %w", err)
+	}
+
+	return shipmentID, lines, nil
+}
+
+// returnedQuantities returns the quantity of each reservation authorized by
+// returns so far.
+func returnedQuantities(ctx context.Context, ex executor, reservationIDs []int64) (map[int64]uint64, error) {
+	rows, err := ex.QueryContext(ctx, `
+		SELECT reservation_id, SUM(quantity) FROM return_lines
+		WHERE reservation_id = ANY($1) GROUP BY reservation_id`,
+		pq.Array(reservationIDs))
+
+	if err != nil {
+		return nil, fmt.Errorf("db.Query with command SELECT to return_lines returned: %w", mapError(err))
+	}
+	defer rows.Close()
+
+	returned := make(map[int64]uint64, len(reservationIDs))
+	for rows.Next() {
+		var (
+			id       int64
+			quantity uint64
+		)
+
+		if err = rows.Scan(&id, &quantity); err != nil {
+			return nil, fmt.Errorf("row scan returned: %w", err)
+		}
+
+		returned[id] = quantity
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err() returned: %w", err)
+	}
+
+	return returned, nil
+}
+
+func returnLines(ctx context.Context, ex executor, returnID int64) ([]domain.ReturnLine, error) {
+	rows, err := ex.QueryContext(ctx, `
+		SELECT id, reservation_id, warehouse_id, product_code, quantity, restocked, quarantined, written_off
+		FROM return_lines WHERE return_id = $1 ORDER BY id`,
+		returnID)
+
+	if err != nil {
+		return nil, fmt.Errorf("db.Query with command SELECT to return_lines returned: %w", mapError(err))
+	}
+	defer rows.Close()
+
+	line := domain.ReturnLine{}
+	lines := make([]domain.ReturnLine, 0, domain.BasicSliceLength)
+	for rows.Next() {
+		err = rows.Scan(&line.ID, &line.ReservationID, &line.WarehouseID, &line.Code, &line.Quantity,
+			&line.Restocked, &line.Quarantined, &line.WrittenOff)
+
+		if err != nil {
+			return nil, fmt.Errorf("row scan returned: %w", err)
+		}
+
+		lines = append(lines, line)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err() returned: %w", err)
+	}
+
+	return lines, nil
+}
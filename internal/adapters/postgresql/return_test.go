@@ -0,0 +1,150 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/akrovv/warehouse/internal/domain"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+var returnColumnNames = []string{"id", "shipment_id", "reason", "status", "created_at"}
+
+// returnRows are rows of return 4 of shipment 2.
+func returnRows(status string) *sqlmock.Rows {
+	return sqlmock.NewRows(returnColumnNames).
+		AddRow(4, 2, "damaged", status, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+}
+
+// expectShippedLines returns reservation 7 of five units shipped from
+// warehouse 1, one of which is returned already.
+func expectShippedLines(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery("SELECT (.+) FROM shipment_lines WHERE shipment_id = (.+) FOR UPDATE").
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"shipment_id", "reservation_id", "warehouse_id", "product_code",
+			"quantity"}).AddRow(2, 7, 1, "test-1", 5))
+	mock.ExpectQuery("SELECT (.+) FROM return_lines WHERE reservation_id = ANY").
+		WithArgs("{7}").
+		WillReturnRows(sqlmock.NewRows([]string{"reservation_id", "sum"}).AddRow(7, 1))
+}
+
+// expectReturnLines returns line 9 of four units of reservation 7.
+func expectReturnLines(mock sqlmock.Sqlmock, quarantined uint64) {
+	mock.ExpectQuery("SELECT (.+) FROM return_lines WHERE return_id = (.+)").
+		WithArgs(4).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "reservation_id", "warehouse_id", "product_code", "quantity",
+			"restocked", "quarantined", "written_off"}).
+			AddRow(9, 7, 1, "test-1", 4, 0, quarantined, 0))
+}
+
+func TestReturnCreate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("can't create mock: %s", err)
+	}
+	defer db.Close()
+
+	storage := NewReturnStorage(db)
+	cr := domain.CreateReturn{ShipmentID: 2, Reason: "damaged"}
+
+	mock.ExpectBegin()
+	expectShippedLines(mock)
+	mock.ExpectQuery("INSERT INTO returns").
+		WithArgs(2, "damaged", domain.ReturnOpen).
+		WillReturnRows(returnRows(domain.ReturnOpen))
+	mock.ExpectQuery("INSERT INTO return_lines").
+		WithArgs(4, 7, 1, "test-1", 4).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(9))
+	mock.ExpectCommit()
+
+	ret, err := storage.Create(context.Background(), &cr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if ret.ID != 4 || len(ret.Lines) != 1 || ret.Lines[0].ID != 9 || ret.Lines[0].Quantity != 4 {
+		t.Fatalf("expected return 4 with line 9 of four units, got: %+v", ret)
+	}
+
+	cr.Lines = []domain.ReturnLine{{ReservationID: 7, Quantity: 5}}
+
+	mock.ExpectBegin()
+	expectShippedLines(mock)
+	mock.ExpectRollback()
+
+	if _, err = storage.Create(context.Background(), &cr); !errors.Is(err, domain.ErrValidationFailed) {
+		t.Fatalf("expected: %v, got: %v", domain.ErrValidationFailed, err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestReturnReceive(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("can't create mock: %s", err)
+	}
+	defer db.Close()
+
+	storage := NewReturnStorage(db)
+	rr := domain.ReturnReceipt{ReturnID: 4, Lines: []domain.ReturnReceiptLine{
+		{LineID: 9, Quantity: 1, Disposition: domain.DispositionWriteOff},
+		{LineID: 9, Quantity: 2, Disposition: domain.DispositionQuarantine},
+	}}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT (.+) FROM returns WHERE id = (.+) FOR UPDATE").
+		WithArgs(4).
+		WillReturnRows(returnRows(domain.ReturnOpen))
+	expectReturnLines(mock, 1)
+	mock.ExpectQuery("INSERT INTO warehouse_products").
+		WithArgs(1, "test-1", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"quarantined_quantity"}).AddRow(2))
+	expectMovement(mock, domain.MovementReturn, 1, "test-1", domain.BucketQuarantined, 1, 2)
+	mock.ExpectQuery("UPDATE warehouse_products SET quarantined_quantity").
+		WithArgs(1, "test-1", -1).
+		WillReturnRows(sqlmock.NewRows([]string{"quarantined_quantity"}).AddRow(1))
+	expectMovement(mock, domain.MovementWriteOff, 1, "test-1", domain.BucketQuarantined, -1, 1)
+	mock.ExpectQuery("INSERT INTO warehouse_products").
+		WithArgs(1, "test-1", 2).
+		WillReturnRows(sqlmock.NewRows([]string{"quarantined_quantity"}).AddRow(3))
+	expectMovement(mock, domain.MovementReturn, 1, "test-1", domain.BucketQuarantined, 2, 3)
+	mock.ExpectExec("UPDATE products SET quantity").
+		WithArgs(2, "test-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE return_lines").
+		WithArgs(9, 0, 3, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE returns").
+		WithArgs(4, domain.ReturnReceived).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	ret, err := storage.Receive(context.Background(), &rr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if ret.Status != domain.ReturnReceived || ret.Lines[0].Quarantined != 3 || ret.Lines[0].WrittenOff != 1 {
+		t.Fatalf("expected the return received, got: %+v", ret)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT (.+) FROM returns WHERE id = (.+) FOR UPDATE").
+		WithArgs(4).
+		WillReturnRows(returnRows(domain.ReturnOpen))
+	expectReturnLines(mock, 2)
+	mock.ExpectRollback()
+
+	if _, err = storage.Receive(context.Background(), &rr); !errors.Is(err, domain.ErrValidationFailed) {
+		t.Fatalf("expected: %v, got: %v", domain.ErrValidationFailed, err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,358 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/akrovv/warehouse/internal/domain"
+	"github.com/lib/pq"
+)
+
+const transferColumns = "id, warehouse_from_id, warehouse_to_id, product_code, quantity, received_quantity, status, created_at"
+
+func transferFields(t *domain.TransitTransfer) []any {
+	return []any{&t.ID, &t.WarehouseFromID, &t.WarehouseToID, &t.Code, &t.Quantity, &t.Received, &t.Status, &t.CreatedAt}
+}
+
+// Dispatch takes stock out of the source warehouse like Transfer does, but
+// the units stay in the transfer until they are received.
+func (s *productStorage) Dispatch(ctx context.Context, td *domain.TransferProduct) (*domain.TransitTransfer, error) {
+	t := domain.NewTransitTransfer(td)
+
+	err := withTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := lockDestination(ctx, tx, td.WarehouseToID); err != nil {
+			return err
+		}
+
+		err := changeStock(ctx, tx, stockChange{
+			movementType: domain.MovementDispatch,
+			requestID:    td.RequestID,
+			warehouseID:  td.WarehouseFromID,
+			code:         td.Code,
+			available:    -int64(td.Quantity),
+		})
+
+		if err != nil {
+			return err
+		}
+
+		lots, err := takeLots(ctx, tx, lotPick{
+			warehouseID: td.WarehouseFromID,
+			code:        td.Code,
+			quantity:    td.Quantity,
+			number:      td.Lot,
+		})
+
+		if err != nil {
+			return err
+		}
+
+		bins, err := takeBins(ctx, tx, td.WarehouseFromID, td.Code, td.Quantity, false)
+		if err != nil {
+			return err
+		}
+
+		err = tx.QueryRowContext(ctx, `
+			INSERT INTO transfers (warehouse_from_id, warehouse_to_id, product_code, quantity, status)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING `+transferColumns,
+			t.WarehouseFromID, t.WarehouseToID, t.Code, t.Quantity, t.Status).
+			Scan(transferFields(&t)...)
+
+		if err != nil {
+			return fmt.Errorf("db.QueryRow with command INSERT to transfers returned: %w", mapError(err))
+		}
+
+		for _, lot := range lots {
+			_, err = tx.ExecContext(ctx, `INSERT INTO transfer_lots (transfer_id, lot_id, quantity) VALUES ($1, $2, $3)`,
+				t.ID, lot.id, lot.Quantity)
+
+			if err != nil {
+				return fmt.Errorf("db.Exec with command INSERT to transfer_lots returned: %w", mapError(err))
+			}
+		}
+
+		t.Lots = lotQuantities(lots)
+		t.Bins = bins
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+// lockDestination checks that the destination can receive the transfer. The
+// share lock keeps Decommission, which locks the warehouse for update, from
+// archiving it until the transfer is recorded.
+func lockDestination(ctx context.Context, ex executor, id int64) error {
+	var availability bool
+
+	err := ex.QueryRowContext(ctx, `SELECT availability FROM warehouses WHERE id = $1 AND archived_at IS NULL FOR SHARE`,
+		id).
+		Scan(&availability)
+
+	if err != nil {
+		return fmt.Errorf("db.QueryRow with command SELECT to warehouses returned: %w", mapError(err))
+	}
+
+	if !availability {
+		return fmt.Errorf("%w: destination warehouse %d", domain.ErrWarehouseUnavailable, id)
+	}
+
+	return nil
+}
+
+// Receive adds the arrived units to available stock of the destination,
+// lots arrive first-expired-first-out and unplaced.
+func (s *productStorage) Receive(ctx context.Context, rt *domain.ReceiveTransfer) (*domain.TransitTransfer, error) {
+	t := domain.TransitTransfer{}
+
+	err := withTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := lockTransfer(ctx, tx, rt.TransferID, &t); err != nil {
+			return err
+		}
+
+		quantity, err := t.Receive(rt.Quantity)
+		if err != nil {
+			return err
+		}
+
+		err = receiveStock(ctx, tx, stockChange{
+			movementType: domain.MovementReceive,
+			requestID:    rt.RequestID,
+			warehouseID:  t.WarehouseToID,
+			code:         t.Code,
+			available:    int64(quantity),
+		})
+
+		if err != nil {
+			return err
+		}
+
+		byTransfer, err := transferLots(ctx, tx, t.ID)
+		if err != nil {
+			return err
+		}
+
+		lots := byTransfer[t.ID]
+
+		picked, err := domain.PickLots(t.WarehouseToID, t.Code, lotQuantities(lots), quantity)
+		if err != nil {
+			return err
+		}
+
+		for i, lot := range picked {
+			if err = changeTransferLot(ctx, tx, t.ID, lots[i].id, lot.Quantity); err != nil {
+				return err
+			}
+
+			if err = changeLot(ctx, tx, t.WarehouseToID, lots[i].id, int64(lot.Quantity), 0); err != nil {
+				return err
+			}
+
+			lots[i].Quantity -= lot.Quantity
+		}
+
+		t.Lots = inTransitLots(lots)
+
+		return updateTransfer(ctx, tx, &t)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+// CancelTransfer returns the units in transit to available stock of the
+// source, even if their lots have expired on the way.
+func (s *productStorage) CancelTransfer(ctx context.Context, ct *domain.CancelTransfer) (*domain.TransitTransfer, error) {
+	t := domain.TransitTransfer{}
+
+	err := withTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := lockTransfer(ctx, tx, ct.TransferID, &t); err != nil {
+			return err
+		}
+
+		err := receiveStock(ctx, tx, stockChange{
+			movementType: domain.MovementDispatchCancel,
+			requestID:    ct.RequestID,
+			warehouseID:  t.WarehouseFromID,
+			code:         t.Code,
+			available:    int64(t.InTransit()),
+		})
+
+		if err != nil {
+			return err
+		}
+
+		byTransfer, err := transferLots(ctx, tx, t.ID)
+		if err != nil {
+			return err
+		}
+
+		for _, lot := range byTransfer[t.ID] {
+			if err = changeTransferLot(ctx, tx, t.ID, lot.id, lot.Quantity); err != nil {
+				return err
+			}
+
+			if err = changeLot(ctx, tx, t.WarehouseFromID, lot.id, int64(lot.Quantity), 0); err != nil {
+				return err
+			}
+		}
+
+		t.Status = domain.TransferCanceled
+
+		return updateTransfer(ctx, tx, &t)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+func (s *productStorage) ListTransfers(ctx context.Context, f *domain.TransferFilter) ([]domain.TransitTransfer, error) {
+	c := conditions{}
+	c.add("status = $%d", domain.TransferInTransit)
+
+	if f.WarehouseID != 0 {
+		c.add("(warehouse_from_id = $%[1]d OR warehouse_to_id = $%[1]d)", f.WarehouseID)
+	}
+
+	if f.Code != "" {
+		c.add("product_code = $%d", f.Code)
+	}
+
+	query := `SELECT ` + transferColumns + ` FROM transfers` + c.where() + " ORDER BY id" + c.page(f.Limit, f.Offset)
+
+	rows, err := s.db.QueryContext(ctx, query, c.args...)
+	if err != nil {
+		return nil, fmt.Errorf("db.Query with command SELECT to transfers returned: %w", mapError(err))
+	}
+	defer rows.Close()
+
+	t := domain.TransitTransfer{}
+	transfers := make([]domain.TransitTransfer, 0, domain.BasicSliceLength)
+	ids := make([]int64, 0, domain.BasicSliceLength)
+	for rows.Next() {
+		if err = rows.Scan(transferFields(&t)...); err != nil {
+			return nil, fmt.Errorf("row scan returned: %w", err)
+		}
+
+		transfers = append(transfers, t)
+		ids = append(ids, t.ID)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err() returned: %w", err)
+	}
+
+	byTransfer, err := transferLots(ctx, s.db, ids...)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range transfers {
+		transfers[i].Lots = inTransitLots(byTransfer[transfers[i].ID])
+	}
+
+	return transfers, nil
+}
+
+// lockTransfer reads an open transfer and locks it until the transaction
+// ends.
+func lockTransfer(ctx context.Context, ex executor, id int64, t *domain.TransitTransfer) error {
+	err := ex.QueryRowContext(ctx, `SELECT `+transferColumns+` FROM transfers WHERE id = $1 AND status = $2 FOR UPDATE`,
+		id, domain.TransferInTransit).
+		Scan(transferFields(t)...)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: open transfer %d", domain.ErrNotFound, id)
+	}
+
+	if err != nil {
+		return fmt.Errorf("db.QueryRow with command SELECT to transfers returned: %w", mapError(err))
+	}
+
+	return nil
+}
+
+func updateTransfer(ctx context.Context, ex executor, t *domain.TransitTransfer) error {
+	_, err := ex.ExecContext(ctx, `UPDATE transfers SET received_quantity = $2, status = $3 WHERE id = $1`,
+		t.ID, t.Received, t.Status)
+
+	if err != nil {
+		return fmt.Errorf("db.Exec with command UPDATE to transfers returned: %w", mapError(err))
+	}
+
+	return nil
+}
+
+// transferLots returns the lots still in transit by transfer id,
+// first-expired-first-out.
+func transferLots(ctx context.Context, ex executor, ids ...int64) (map[int64][]stockLot, error) {
+	rows, err := ex.QueryContext(ctx, `
+		SELECT tl.transfer_id, l.id, l.number, l.manufactured_at, l.expires_at, tl.quantity
+		FROM transfer_lots tl JOIN lots l ON l.id = tl.lot_id
+		WHERE tl.transfer_id = ANY($1) AND tl.quantity > 0
+		ORDER BY tl.transfer_id, l.expires_at ASC NULLS LAST, l.id`,
+		pq.Array(ids))
+
+	if err != nil {
+		return nil, fmt.Errorf("db.Query with command SELECT to transfer_lots returned: %w", mapError(err))
+	}
+	defer rows.Close()
+
+	lots := make(map[int64][]stockLot, len(ids))
+	for rows.Next() {
+		var (
+			id  int64
+			lot stockLot
+		)
+
+		err = rows.Scan(&id, &lot.id, &lot.Number, &lot.ManufacturedAt, &lot.ExpiresAt, &lot.Quantity)
+		if err != nil {
+			return nil, fmt.Errorf("row scan returned: %w", err)
+		}
+
+		lots[id] = append(lots[id], lot)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err() returned: %w", err)
+	}
+
+	return lots, nil
+}
+
+// changeTransferLot takes quantity of the lot off the transfer.
+func changeTransferLot(ctx context.Context, ex executor, transferID, lotID int64, quantity uint64) error {
+	_, err := ex.ExecContext(ctx, `UPDATE transfer_lots SET quantity = quantity - $3 WHERE transfer_id = $1 AND lot_id = $2`,
+		transferID, lotID, quantity)
+
+	if err != nil {
+		return fmt.Errorf("db.Exec with command UPDATE to transfer_lots returned: %w", mapError(err))
+	}
+
+	return nil
+}
+
+func inTransitLots(lots []stockLot) []domain.LotQuantity {
+	quantities := make([]domain.LotQuantity, 0, len(lots))
+	for _, lot := range lots {
+		if lot.Quantity > 0 {
+			quantities = append(quantities, lot.LotQuantity)
+		}
+	}
+
+	return quantities
+}
@@ -0,0 +1,222 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/akrovv/warehouse/internal/domain"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+var transferColumnNames = []string{"id", "warehouse_from_id", "warehouse_to_id", "product_code", "quantity",
+	"received_quantity", "status", "created_at"}
+
+// transferRows are rows of transfer 7 of five units from warehouse 1 to 2.
+func transferRows(received uint64) *sqlmock.Rows {
+	return sqlmock.NewRows(transferColumnNames).
+		AddRow(7, 1, 2, "test-1", 5, received, domain.TransferInTransit, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+}
+
+func expectLockTransfer(mock sqlmock.Sqlmock, id int64) *sqlmock.ExpectedQuery {
+	return mock.ExpectQuery("SELECT (.+) FROM transfers WHERE id = (.+) FOR UPDATE").
+		WithArgs(id, domain.TransferInTransit)
+}
+
+func expectTransferLots(mock sqlmock.Sqlmock, ids string, quantity uint64) {
+	mock.ExpectQuery("SELECT (.+) FROM transfer_lots").
+		WithArgs(ids).
+		WillReturnRows(sqlmock.NewRows(append([]string{"transfer_id"}, lotColumns...)).AddRow(7, 1, "", nil, nil, quantity))
+}
+
+func expectChangeTransferLot(mock sqlmock.Sqlmock, quantity uint64) {
+	mock.ExpectExec("UPDATE transfer_lots").
+		WithArgs(7, 1, quantity).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+func expectUpdateTransfer(mock sqlmock.Sqlmock, received uint64, status string) {
+	mock.ExpectExec("UPDATE transfers").
+		WithArgs(7, received, status).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+func expectLockDestination(mock sqlmock.Sqlmock, id int64, availability bool) {
+	mock.ExpectQuery("SELECT availability FROM warehouses WHERE id = (.+) FOR SHARE").
+		WithArgs(id).
+		WillReturnRows(sqlmock.NewRows([]string{"availability"}).AddRow(availability))
+}
+
+func TestProductDispatch(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("can't create mock: %s", err)
+	}
+	defer db.Close()
+
+	storage := NewProductStorage(db)
+	td := domain.TransferProduct{WarehouseFromID: 1, WarehouseToID: 2, Code: "test-1", Quantity: 5}
+
+	mock.ExpectBegin()
+	expectLockDestination(mock, 2, true)
+	expectChangeStock(mock, 1, "test-1", -5, 0).WillReturnRows(changedRows(5, 0))
+	expectMovement(mock, domain.MovementDispatch, 1, "test-1", domain.BucketAvailable, -5, 5)
+	expectTakeLots(mock, 1, "test-1", "", false, defaultLotRows(10))
+	expectChangeLot(mock, 1, 1, -5, 0)
+	expectTakeBins(mock, 1, "test-1", noBinRows())
+	mock.ExpectQuery("INSERT INTO transfers").
+		WithArgs(1, 2, "test-1", 5, domain.TransferInTransit).
+		WillReturnRows(transferRows(0))
+	mock.ExpectExec("INSERT INTO transfer_lots").
+		WithArgs(7, 1, 5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	transfer, err := storage.Dispatch(context.Background(), &td)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if transfer.ID != 7 || transfer.InTransit() != 5 || len(transfer.Lots) != 1 || transfer.Lots[0].Quantity != 5 {
+		t.Fatalf("expected five units of the default lot in transit, got: %+v", transfer)
+	}
+
+	mock.ExpectBegin()
+	expectLockDestination(mock, 2, true)
+	expectChangeStock(mock, 1, "test-1", -5, 0).WillReturnError(sql.ErrNoRows)
+	expectStockShortage(mock, 1, "test-1", stockRows(3, 0))
+	mock.ExpectRollback()
+
+	if _, err = storage.Dispatch(context.Background(), &td); !errors.Is(err, domain.ErrInsufficientStock) {
+		t.Fatalf("expected: %v, got: %v", domain.ErrInsufficientStock, err)
+	}
+
+	mock.ExpectBegin()
+	expectLockDestination(mock, 2, false)
+	mock.ExpectRollback()
+
+	if _, err = storage.Dispatch(context.Background(), &td); !errors.Is(err, domain.ErrWarehouseUnavailable) {
+		t.Fatalf("expected: %v, got: %v", domain.ErrWarehouseUnavailable, err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestProductReceive(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("can't create mock: %s", err)
+	}
+	defer db.Close()
+
+	storage := NewProductStorage(db)
+
+	mock.ExpectBegin()
+	expectLockTransfer(mock, 7).WillReturnRows(transferRows(0))
+	mock.ExpectQuery("INSERT INTO warehouse_products").
+		WithArgs(2, "test-1", 3).
+		WillReturnRows(stockRows(3, 0))
+	expectMovement(mock, domain.MovementReceive, 2, "test-1", domain.BucketAvailable, 3, 3)
+	expectTransferLots(mock, "{7}", 5)
+	expectChangeTransferLot(mock, 3)
+	expectChangeLot(mock, 2, 1, 3, 0)
+	expectUpdateTransfer(mock, 3, domain.TransferInTransit)
+	mock.ExpectCommit()
+
+	transfer, err := storage.Receive(context.Background(), &domain.ReceiveTransfer{TransferID: 7, Quantity: 3})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if transfer.Received != 3 || transfer.Status != domain.TransferInTransit || transfer.Lots[0].Quantity != 2 {
+		t.Fatalf("expected two units left in transit, got: %+v", transfer)
+	}
+
+	mock.ExpectBegin()
+	expectLockTransfer(mock, 7).WillReturnRows(transferRows(3))
+	mock.ExpectRollback()
+
+	_, err = storage.Receive(context.Background(), &domain.ReceiveTransfer{TransferID: 7, Quantity: 3})
+	if !errors.Is(err, domain.ErrValidationFailed) {
+		t.Fatalf("expected: %v, got: %v", domain.ErrValidationFailed, err)
+	}
+
+	mock.ExpectBegin()
+	expectLockTransfer(mock, 7).WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+
+	if _, err = storage.Receive(context.Background(), &domain.ReceiveTransfer{TransferID: 7}); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("expected: %v, got: %v", domain.ErrNotFound, err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestProductCancelTransfer(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("can't create mock: %s", err)
+	}
+	defer db.Close()
+
+	storage := NewProductStorage(db)
+
+	mock.ExpectBegin()
+	expectLockTransfer(mock, 7).WillReturnRows(transferRows(3))
+	mock.ExpectQuery("INSERT INTO warehouse_products").
+		WithArgs(1, "test-1", 2).
+		WillReturnRows(stockRows(7, 0))
+	expectMovement(mock, domain.MovementDispatchCancel, 1, "test-1", domain.BucketAvailable, 2, 7)
+	expectTransferLots(mock, "{7}", 2)
+	expectChangeTransferLot(mock, 2)
+	expectChangeLot(mock, 1, 1, 2, 0)
+	expectUpdateTransfer(mock, 3, domain.TransferCanceled)
+	mock.ExpectCommit()
+
+	transfer, err := storage.CancelTransfer(context.Background(), &domain.CancelTransfer{TransferID: 7})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if transfer.Status != domain.TransferCanceled || transfer.InTransit() != 0 {
+		t.Fatalf("expected the transfer to be canceled, got: %+v", transfer)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestProductListTransfers(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("can't create mock: %s", err)
+	}
+	defer db.Close()
+
+	storage := NewProductStorage(db)
+
+	mock.ExpectQuery(`SELECT (.+) FROM transfers WHERE status = \$1 AND \(warehouse_from_id = \$2 OR warehouse_to_id = \$2\) ORDER BY id LIMIT \$3 OFFSET \$4`).
+		WithArgs(domain.TransferInTransit, 2, 10, 0).
+		WillReturnRows(transferRows(1))
+	expectTransferLots(mock, "{7}", 4)
+
+	transfers, err := storage.ListTransfers(context.Background(), &domain.TransferFilter{WarehouseID: 2, Limit: 10})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(transfers) != 1 || transfers[0].InTransit() != 4 || transfers[0].Lots[0].Quantity != 4 {
+		t.Fatalf("expected one transfer with four units in transit, got: %+v", transfers)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("there were unfulfilled expectations: %s", err)
+	}
+}
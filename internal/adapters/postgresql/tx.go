@@ -0,0 +1,61 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// executor is implemented by both *sql.DB and *sql.Tx, so the same query
+// can run on its own or as a part of a bigger transaction.
+type executor interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+const (
+	maxTxAttempts = 5
+	txRetryDelay  = 10 * time.Millisecond
+)
+
+// withTx runs fn in a transaction and runs it again if the transaction fails
+// on a serialization failure or a deadlock, fn must not have side effects
+// outside of tx.
+func withTx(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) error {
+	for attempt := 1; ; attempt++ {
+		err := runTx(ctx, db, fn)
+		if !isRetryable(err) || attempt == maxTxAttempts {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w, last attempt returned: %w", ctx.Err(), err)
+		case <-time.After(time.Duration(attempt) * txRetryDelay):
+		}
+	}
+}
+
+// runTx rolls back the transaction if fn fails, database/sql also rolls it
+// back when ctx is done before the commit.
+func runTx(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) (err error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("db.BeginTx() returned: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+			return
+		}
+
+		if err = tx.Commit(); err != nil {
+			err = fmt.Errorf("tx.Commit() returned: %w", err)
+		}
+	}()
+
+	return fn(tx)
+}
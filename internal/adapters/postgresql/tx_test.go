@@ -0,0 +1,103 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/akrovv/warehouse/internal/domain"
+	"github.com/lib/pq"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+type txTestCase struct {
+	errs         []error
+	expectCalls  int
+	expectCommit bool
+}
+
+func TestWithTxRetries(t *testing.T) {
+	deadlock := &pq.Error{Code: codeDeadlockDetected}
+	serialization := &pq.Error{Code: codeSerializationFailure}
+
+	testCases := []txTestCase{
+		{
+			errs:         []error{deadlock, serialization, nil},
+			expectCalls:  3,
+			expectCommit: true,
+		},
+		{
+			errs:        []error{domain.ErrTest},
+			expectCalls: 1,
+		},
+		{
+			errs:        []error{deadlock, deadlock, deadlock, deadlock, deadlock},
+			expectCalls: maxTxAttempts,
+		},
+	}
+
+	for _, tc := range testCases {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("can't create mock: %s", err)
+		}
+
+		for _, e := range tc.errs {
+			mock.ExpectBegin()
+			if e == nil {
+				mock.ExpectCommit()
+			} else {
+				mock.ExpectRollback()
+			}
+		}
+
+		calls := 0
+		err = withTx(context.Background(), db, func(tx *sql.Tx) error {
+			calls++
+			return tc.errs[calls-1]
+		})
+
+		if tc.expectCommit != (err == nil) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !tc.expectCommit && !errors.Is(err, tc.errs[len(tc.errs)-1]) {
+			t.Fatalf("expected: %v, got: %v", tc.errs[len(tc.errs)-1], err)
+		}
+
+		if calls != tc.expectCalls {
+			t.Fatalf("expected calls: %d, got: %d", tc.expectCalls, calls)
+		}
+
+		if err = mock.ExpectationsWereMet(); err != nil {
+			t.Fatalf("there were unfulfilled expectations: %s", err)
+		}
+
+		db.Close()
+	}
+}
+
+func TestWithTxCanceled(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("can't create mock: %s", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err = withTx(ctx, db, func(tx *sql.Tx) error {
+		t.Fatalf("fn must not run with a canceled context")
+		return nil
+	})
+
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected: %v, got: %v", context.Canceled, err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("there were unfulfilled expectations: %s", err)
+	}
+}
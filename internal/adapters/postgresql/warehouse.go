@@ -1,13 +1,20 @@
 package postgresql
 
 import (
+	"context"
 	"database/sql"
-	"errors"
 	"fmt"
 
 	"github.com/akrovv/warehouse/internal/domain"
+	"github.com/lib/pq"
 )
 
+const warehouseColumns = "id, name, availability, archived_at"
+
+func warehouseFields(w *domain.Warehouse) []any {
+	return []any{&w.ID, &w.Name, &w.Availability, &w.ArchivedAt}
+}
+
 type warehouseStorage struct {
 	db *sql.DB
 }
@@ -18,51 +25,363 @@ func NewWarehouseStorage(db *sql.DB) *warehouseStorage {
 	}
 }
 
-func (s *warehouseStorage) Create(warehouse *domain.Warehouse) error {
-	res, err := s.db.Exec("INSERT INTO warehouses (name, availability) VALUES ($1, $2)", warehouse.Name, warehouse.Availability)
+func (s *warehouseStorage) Create(ctx context.Context, warehouse *domain.Warehouse) error {
+	err := s.db.QueryRowContext(ctx, "INSERT INTO warehouses (name, availability) VALUES ($1, $2) RETURNING id",
+		warehouse.Name, warehouse.Availability).
+		Scan(&warehouse.ID)
 
 	if err != nil {
-		return fmt.Errorf("db.Exec with command INSERT to warehouses returned: %w", err)
+		return fmt.Errorf("db.QueryRow with command INSERT to warehouses returned: %w", mapError(err))
 	}
 
-	affected, err := res.RowsAffected()
+	return nil
+}
+
+func (s *warehouseStorage) Get(ctx context.Context, gw *domain.GetWarehouse) (*domain.Warehouse, error) {
+	warehouse := domain.Warehouse{}
+
+	err := s.db.QueryRowContext(ctx, `SELECT `+warehouseColumns+` FROM warehouses WHERE id = $1`, gw.ID).
+		Scan(warehouseFields(&warehouse)...)
+
 	if err != nil {
-		return fmt.Errorf("rows.RowsAffected() returned: %w", err)
+		return nil, fmt.Errorf("db.QueryRow with command SELECT to warehouses returned: %w", mapError(err))
+	}
+
+	return &warehouse, nil
+}
+
+func (s *warehouseStorage) List(ctx context.Context, f *domain.WarehouseFilter) ([]domain.Warehouse, error) {
+	c := conditions{}
+	query := `SELECT ` + warehouseColumns + ` FROM warehouses`
+	if !f.IncludeArchived {
+		query += ` WHERE archived_at IS NULL`
 	}
+	query += ` ORDER BY id` + c.page(f.Limit, f.Offset)
 
-	if affected == 0 {
-		return errors.New("affected 0 rows")
+	rows, err := s.db.QueryContext(ctx, query, c.args...)
+	if err != nil {
+		return nil, fmt.Errorf("db.Query with command SELECT to warehouses returned: %w", mapError(err))
 	}
+	defer rows.Close()
 
-	return nil
+	warehouse := domain.Warehouse{}
+	warehouses := make([]domain.Warehouse, 0, domain.BasicSliceLength)
+	for rows.Next() {
+		if err = rows.Scan(warehouseFields(&warehouse)...); err != nil {
+			return nil, fmt.Errorf("row scan returned: %w", err)
+		}
+
+		warehouses = append(warehouses, warehouse)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err() returned: %w", err)
+	}
+
+	return warehouses, nil
+}
+
+func (s *warehouseStorage) Update(ctx context.Context, uw *domain.UpdateWarehouse) (*domain.Warehouse, error) {
+	warehouse := domain.Warehouse{}
+
+	err := s.db.QueryRowContext(ctx, `UPDATE warehouses SET name = $2 WHERE id = $1 RETURNING `+warehouseColumns,
+		uw.ID, uw.Name).
+		Scan(warehouseFields(&warehouse)...)
+
+	if err != nil {
+		return nil, fmt.Errorf("db.QueryRow with command UPDATE to warehouses returned: %w", mapError(err))
+	}
+
+	return &warehouse, nil
+}
+
+// SetAvailability locks the warehouse row, so no reservation can be made
+// between the check of open reservations and the update.
+func (s *warehouseStorage) SetAvailability(ctx context.Context, sa *domain.SetAvailability) (*domain.AvailabilityChange, error) {
+	change := domain.AvailabilityChange{}
+
+	err := withTx(ctx, s.db, func(tx *sql.Tx) error {
+		change = domain.AvailabilityChange{}
+
+		err := lockWarehouse(ctx, tx, sa.ID, &change.Warehouse)
+		if err != nil {
+			return err
+		}
+
+		if !sa.Availability {
+			change.OpenReservations, err = openReservations(ctx, tx, sa.ID)
+			if err != nil {
+				return err
+			}
+
+			if len(change.OpenReservations) > 0 && !sa.Force {
+				return nil
+			}
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE warehouses SET availability = $2 WHERE id = $1`, sa.ID, sa.Availability)
+		if err != nil {
+			return fmt.Errorf("db.Exec with command UPDATE to warehouses returned: %w", mapError(err))
+		}
+
+		change.Warehouse.Availability = sa.Availability
+		change.Applied = true
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &change, nil
 }
 
-func (s *warehouseStorage) GetLeftOvers(gw *domain.GetFromWarehouse) ([]domain.Product, error) {
-	product := domain.Product{}
+func (s *warehouseStorage) GetLeftOvers(ctx context.Context, gw *domain.GetFromWarehouse) ([]domain.Product, error) {
+	columns, stocked := "", "available_quantity > 0"
+	if gw.AllBuckets {
+		columns = ", reserved_quantity, quarantined_quantity, damaged_quantity"
+		stocked = "(available_quantity > 0 OR reserved_quantity > 0 OR quarantined_quantity > 0 OR damaged_quantity > 0)"
+	}
+
 	products := make([]domain.Product, 0, domain.BasicSliceLength)
-	rows, err := s.db.Query(`SELECT p.name, size, code, available_quantity FROM warehouse_products wp 
+	rows, err := s.db.QueryContext(ctx, `SELECT p.name, size, code, available_quantity`+columns+` FROM warehouse_products wp 
 							JOIN products p ON wp.product_code = p.code
 							JOIN warehouses w ON wp.warehouse_id = w.id
-						  	WHERE availability = true AND warehouse_id = $1 AND available_quantity > 0`,
+						  	WHERE availability = true AND warehouse_id = $1 AND `+stocked,
 		gw.WarehouseID)
 
 	if err != nil {
-		return nil, fmt.Errorf("db.Query with command SELECT to warehouse_products returned: %w", err)
+		return nil, fmt.Errorf("db.Query with command SELECT to warehouse_products returned: %w", mapError(err))
 	}
 	defer rows.Close()
 
 	for rows.Next() {
-		err = rows.Scan(&product.Name, &product.Size, &product.Code, &product.Quantity)
-		if err != nil {
+		product := domain.Product{}
+		fields := []any{&product.Name, &product.Size, &product.Code, &product.Quantity}
+		if gw.AllBuckets {
+			product.Buckets = &domain.StockBuckets{}
+			fields = append(fields, &product.Buckets.Reserved, &product.Buckets.Quarantined, &product.Buckets.Damaged)
+		}
+
+		if err = rows.Scan(fields...); err != nil {
 			return nil, fmt.Errorf("row scan returned: %w", err)
 		}
 
+		if product.Buckets != nil {
+			product.Buckets.Available = product.Quantity
+		}
+
 		products = append(products, product)
 	}
 
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err() returned: %w", err)
+	}
+
 	if len(products) == 0 {
-		return nil, sql.ErrNoRows
+		return nil, fmt.Errorf("warehouse %d has no available products: %w", gw.WarehouseID, mapError(sql.ErrNoRows))
+	}
+
+	if gw.ByLot {
+		lots, err := availableLots(ctx, s.db, gw.WarehouseID)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range products {
+			products[i].Lots = lots[products[i].Code]
+		}
+	}
+
+	if gw.ByBin {
+		bins, err := binStock(ctx, s.db, gw.WarehouseID)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range products {
+			products[i].Bins = bins[products[i].Code]
+		}
 	}
 
 	return products, nil
 }
+
+// Decommission moves all available stock out of the warehouse with the same
+// transfer as Products.Transfer and archives it. Archived warehouse is
+// unavailable, so the trigger keeps its stock untouched afterwards.
+func (s *warehouseStorage) Decommission(ctx context.Context, d *domain.Decommission) (*domain.DecommissionResult, error) {
+	result := domain.DecommissionResult{}
+
+	err := withTx(ctx, s.db, func(tx *sql.Tx) error {
+		result.Transfers = make([]domain.TransferProduct, 0, domain.BasicSliceLength)
+
+		err := lockWarehouse(ctx, tx, d.WarehouseID, &result.Warehouse)
+		if err != nil {
+			return err
+		}
+
+		// The availability trigger would refuse to move the stock out.
+		if !result.Warehouse.Availability {
+			return fmt.Errorf("%w: warehouse %d must be available to move its stock out",
+				domain.ErrWarehouseUnavailable, d.WarehouseID)
+		}
+
+		reservations, err := openReservations(ctx, tx, d.WarehouseID)
+		if err != nil {
+			return err
+		}
+
+		if len(reservations) > 0 {
+			return fmt.Errorf("%w: warehouse %d has %d active reservations",
+				domain.ErrOpenReservations, d.WarehouseID, len(reservations))
+		}
+
+		blockers, err := decommissionBlockers(ctx, tx, d.WarehouseID)
+		if err != nil {
+			return err
+		}
+
+		if err = blockers.Err(d.WarehouseID); err != nil {
+			return err
+		}
+
+		// Only available stock is transferred, quarantined and damaged stock
+		// has to be released or written off first.
+		var quarantined, damaged uint64
+
+		err = tx.QueryRowContext(ctx, `SELECT COALESCE(SUM(quarantined_quantity), 0), COALESCE(SUM(damaged_quantity), 0)
+						   FROM warehouse_products WHERE warehouse_id = $1`,
+			d.WarehouseID).
+			Scan(&quarantined, &damaged)
+
+		if err != nil {
+			return fmt.Errorf("db.QueryRow with command SELECT to warehouse_products returned: %w", mapError(err))
+		}
+
+		if quarantined > 0 || damaged > 0 {
+			return fmt.Errorf("%w: warehouse %d holds %d quarantined and %d damaged items",
+				domain.ErrValidationFailed, d.WarehouseID, quarantined, damaged)
+		}
+
+		for i := range d.Plan {
+			td := d.Plan[i].Transfer(d)
+			if err = transfer(ctx, tx, &td); err != nil {
+				return fmt.Errorf("plan line %d: %w", i, err)
+			}
+
+			result.Transfers = append(result.Transfers, td)
+		}
+
+		// The rest is read after the plan, codes of the plan may be spelled
+		// differently from the stored ones.
+		stock, err := availableStock(ctx, tx, d.WarehouseID)
+		if err != nil {
+			return err
+		}
+
+		for _, wp := range stock {
+			if d.TargetID == 0 {
+				return fmt.Errorf("%w: %d of %s are not in the plan and there is no target_id",
+					domain.ErrValidationFailed, wp.Quantity, wp.Code)
+			}
+
+			td := domain.TransferProduct{
+				WarehouseFromID: d.WarehouseID,
+				WarehouseToID:   d.TargetID,
+				Code:            wp.Code,
+				Quantity:        wp.Quantity,
+				RequestID:       d.RequestID,
+			}
+
+			if err = transfer(ctx, tx, &td); err != nil {
+				return err
+			}
+
+			result.Transfers = append(result.Transfers, td)
+		}
+
+		err = tx.QueryRowContext(ctx, `UPDATE warehouses SET availability = false, archived_at = NOW()
+						   WHERE id = $1 RETURNING `+warehouseColumns,
+			d.WarehouseID).
+			Scan(warehouseFields(&result.Warehouse)...)
+
+		if err != nil {
+			return fmt.Errorf("db.QueryRow with command UPDATE to warehouses returned: %w", mapError(err))
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// decommissionBlockers reads everything in the warehouse that waits for a
+// stock change in one query.
+func decommissionBlockers(ctx context.Context, ex executor, warehouseID int64) (domain.DecommissionBlockers, error) {
+	b := domain.DecommissionBlockers{}
+
+	err := ex.QueryRowContext(ctx, `SELECT
+			ARRAY(SELECT id FROM transfers
+				WHERE status = $2 AND (warehouse_from_id = $1 OR warehouse_to_id = $1) ORDER BY id),
+			ARRAY(SELECT DISTINCT o.id FROM purchase_orders o JOIN purchase_order_lines l ON l.order_id = o.id
+				WHERE l.warehouse_id = $1 AND o.status = $3 AND l.received < l.ordered ORDER BY o.id),
+			ARRAY(SELECT id FROM adjustments WHERE warehouse_id = $1 AND status = $4 ORDER BY id),
+			ARRAY(SELECT id FROM count_sessions WHERE warehouse_id = $1 AND status = $5 ORDER BY id)`,
+		warehouseID, domain.TransferInTransit, domain.PurchaseOrderOpen, domain.AdjustmentPending, domain.CountOpen).
+		Scan((*pq.Int64Array)(&b.Transfers), (*pq.Int64Array)(&b.PurchaseOrders),
+			(*pq.Int64Array)(&b.Adjustments), (*pq.Int64Array)(&b.Counts))
+
+	if err != nil {
+		return b, fmt.Errorf("db.QueryRow with command SELECT of decommission blockers returned: %w", mapError(err))
+	}
+
+	return b, nil
+}
+
+// lockWarehouse locks a warehouse that is not archived for the rest of the
+// transaction.
+func lockWarehouse(ctx context.Context, ex executor, id int64, w *domain.Warehouse) error {
+	err := ex.QueryRowContext(ctx, `SELECT `+warehouseColumns+` FROM warehouses
+						WHERE id = $1 AND archived_at IS NULL FOR UPDATE`, id).
+		Scan(warehouseFields(w)...)
+
+	if err != nil {
+		return fmt.Errorf("db.QueryRow with command SELECT to warehouses returned: %w", mapError(err))
+	}
+
+	return nil
+}
+
+func availableStock(ctx context.Context, ex executor, warehouseID int64) ([]domain.WarehouseProduct, error) {
+	rows, err := ex.QueryContext(ctx, `SELECT product_code, available_quantity FROM warehouse_products
+						   WHERE warehouse_id = $1 AND available_quantity > 0
+						   ORDER BY product_code`,
+		warehouseID)
+
+	if err != nil {
+		return nil, fmt.Errorf("db.Query with command SELECT to warehouse_products returned: %w", mapError(err))
+	}
+	defer rows.Close()
+
+	wp := domain.WarehouseProduct{WarehouseID: warehouseID}
+	stock := make([]domain.WarehouseProduct, 0, domain.BasicSliceLength)
+	for rows.Next() {
+		if err = rows.Scan(&wp.Code, &wp.Quantity); err != nil {
+			return nil, fmt.Errorf("row scan returned: %w", err)
+		}
+
+		stock = append(stock, wp)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err() returned: %w", err)
+	}
+
+	return stock, nil
+}
@@ -1,10 +1,14 @@
 package postgresql
 
 import (
+	"context"
+	"database/sql"
 	"database/sql/driver"
 	"errors"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/akrovv/warehouse/internal/domain"
 	"gopkg.in/DATA-DOG/go-sqlmock.v1"
@@ -21,6 +25,14 @@ type warehouseTestCase struct {
 	isError   bool
 }
 
+var warehouseColumnNames = []string{"id", "name", "availability", "archived_at"}
+
+type availabilityTestCase struct {
+	sa            domain.SetAvailability
+	reservations  int
+	expectApplied bool
+}
+
 func TestWarehouseCreate(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
@@ -29,57 +41,238 @@ func TestWarehouseCreate(t *testing.T) {
 	defer db.Close()
 
 	storage := NewWarehouseStorage(db)
-	warehouse := domain.Warehouse{
-		Name:         "test-1",
-		Availability: true,
-	}
-	query := `INSERT INTO warehouses \(name, availability\) VALUES \(\$1, \$2\)`
+	query := `INSERT INTO warehouses \(name, availability\) VALUES \(\$1, \$2\) RETURNING id`
 	args := []driver.Value{"test-1", true}
 
 	testCases := []warehouseTestCase{
 		{
-			warehouse: warehouse,
+			warehouse: domain.Warehouse{Name: "test-1", Availability: true},
 			query:     query,
 			args:      args,
-			returned:  sqlmock.NewResult(0, 1),
+			rows:      sqlmock.NewRows([]string{"id"}).AddRow(7),
 			result:    nil,
 		},
 		{
-			warehouse: warehouse,
+			warehouse: domain.Warehouse{Name: "test-1", Availability: true},
 			query:     query,
 			args:      args,
-			returned:  sqlmock.NewResult(0, 0),
 			result:    domain.ErrTest,
 		},
+	}
+
+	for _, tc := range testCases {
+		mock.ExpectQuery(tc.query).
+			WithArgs(tc.args...).
+			WillReturnRows(tc.rows).
+			WillReturnError(tc.result)
+
+		err = storage.Create(context.Background(), &tc.warehouse)
+		if !errors.Is(err, tc.result) {
+			t.Errorf("expected: %v, got: %v", tc.result, err)
+		}
+
+		if tc.result == nil && tc.warehouse.ID != 7 {
+			t.Fatalf("expected id: %d, got: %d", 7, tc.warehouse.ID)
+		}
+
+		if err = mock.ExpectationsWereMet(); err != nil {
+			t.Fatalf("there were unfulfilled expectations: %s", err)
+		}
+	}
+}
+
+func TestWarehouseGet(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("can't create mock: %s", err)
+	}
+	defer db.Close()
+
+	storage := NewWarehouseStorage(db)
+	gw := domain.GetWarehouse{ID: 1}
+	expectedResult := &domain.Warehouse{ID: 1, Name: "test", Availability: true}
+
+	mock.ExpectQuery(`SELECT id, name, availability, archived_at FROM warehouses WHERE id = \$1`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(warehouseColumnNames).AddRow(1, "test", true, nil))
+
+	warehouse, err := storage.Get(context.Background(), &gw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(warehouse, expectedResult) {
+		t.Fatalf("expected: %v, got: %v", expectedResult, warehouse)
+	}
+
+	mock.ExpectQuery("SELECT (.+) FROM warehouses").
+		WithArgs(1).
+		WillReturnError(sql.ErrNoRows)
+
+	if _, err = storage.Get(context.Background(), &gw); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("expected: %v, got: %v", domain.ErrNotFound, err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestWarehouseList(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("can't create mock: %s", err)
+	}
+	defer db.Close()
+
+	storage := NewWarehouseStorage(db)
+	archivedAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	expectedResult := []domain.Warehouse{
+		{ID: 21, Name: "test-1", Availability: true},
+		{ID: 22, Name: "test-2", Availability: false, ArchivedAt: &archivedAt},
+	}
+
+	testCases := []warehouseTestCase{
 		{
-			warehouse: warehouse,
-			query:     query,
-			args:      args,
-			returned:  sqlmock.NewErrorResult(domain.ErrTest),
-			isError:   true,
+			query: `FROM warehouses WHERE archived_at IS NULL ORDER BY id LIMIT \$1 OFFSET \$2`,
+			args:  []driver.Value{10, 20},
+			rows:  sqlmock.NewRows(warehouseColumnNames).AddRow(21, "test-1", true, nil),
 		},
 		{
-			warehouse: warehouse,
-			query:     query,
-			args:      args,
-			returned:  sqlmock.NewResult(0, 0),
-			isError:   true,
+			query: `FROM warehouses ORDER BY id LIMIT \$1 OFFSET \$2`,
+			args:  []driver.Value{10, 20},
+			rows: sqlmock.NewRows(warehouseColumnNames).
+				AddRow(21, "test-1", true, nil).
+				AddRow(22, "test-2", false, archivedAt),
 		},
 	}
+	filters := []domain.WarehouseFilter{
+		{Limit: 10, Offset: 20},
+		{IncludeArchived: true, Limit: 10, Offset: 20},
+	}
 
-	for _, tc := range testCases {
-		mock.ExpectExec(tc.query).
+	for i, tc := range testCases {
+		mock.ExpectQuery(tc.query).
 			WithArgs(tc.args...).
-			WillReturnResult(tc.returned).
-			WillReturnError(tc.result)
+			WillReturnRows(tc.rows)
 
-		err = storage.Create(&tc.warehouse)
+		warehouses, err := storage.List(context.Background(), &filters[i])
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
 
-		if !errors.Is(err, tc.result) {
-			if tc.isError && err != nil {
-				continue
+		if !reflect.DeepEqual(warehouses, expectedResult[:i+1]) {
+			t.Fatalf("expected: %v, got: %v", expectedResult[:i+1], warehouses)
+		}
+
+		if err = mock.ExpectationsWereMet(); err != nil {
+			t.Fatalf("there were unfulfilled expectations: %s", err)
+		}
+	}
+}
+
+func TestWarehouseUpdate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("can't create mock: %s", err)
+	}
+	defer db.Close()
+
+	storage := NewWarehouseStorage(db)
+	uw := domain.UpdateWarehouse{ID: 1, Name: "new name"}
+	expectedResult := &domain.Warehouse{ID: 1, Name: "new name", Availability: true}
+
+	mock.ExpectQuery(`UPDATE warehouses SET name = \$2 WHERE id = \$1`).
+		WithArgs(1, "new name").
+		WillReturnRows(sqlmock.NewRows(warehouseColumnNames).AddRow(1, "new name", true, nil))
+
+	warehouse, err := storage.Update(context.Background(), &uw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(warehouse, expectedResult) {
+		t.Fatalf("expected: %v, got: %v", expectedResult, warehouse)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestWarehouseSetAvailability(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("can't create mock: %s", err)
+	}
+	defer db.Close()
+
+	storage := NewWarehouseStorage(db)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	columns := []string{"id", "order_ref", "warehouse_id", "product_code", "quantity", "status", "created_at", "expires_at"}
+
+	testCases := []availabilityTestCase{
+		{
+			sa:            domain.SetAvailability{ID: 1, Availability: true},
+			expectApplied: true,
+		},
+		{
+			sa:            domain.SetAvailability{ID: 1},
+			reservations:  0,
+			expectApplied: true,
+		},
+		{
+			sa:            domain.SetAvailability{ID: 1},
+			reservations:  1,
+			expectApplied: false,
+		},
+		{
+			sa:            domain.SetAvailability{ID: 1, Force: true},
+			reservations:  1,
+			expectApplied: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT (.+) FROM warehouses WHERE id = .+ AND archived_at IS NULL FOR UPDATE").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows(warehouseColumnNames).AddRow(1, "test", !tc.sa.Availability, nil))
+
+		if !tc.sa.Availability {
+			rows := sqlmock.NewRows(columns)
+			for i := 0; i < tc.reservations; i++ {
+				rows.AddRow(i+1, "order-1", 1, "test", 5, domain.ReservationActive, now, now)
 			}
-			t.Errorf("expected: %v, got: %v", tc.result, err)
+
+			mock.ExpectQuery("SELECT (.+) FROM reservations").
+				WithArgs(1, domain.ReservationActive).
+				WillReturnRows(rows)
+		}
+
+		if tc.expectApplied {
+			mock.ExpectExec("UPDATE warehouses SET availability").
+				WithArgs(1, tc.sa.Availability).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+		}
+		mock.ExpectCommit()
+
+		change, err := storage.SetAvailability(context.Background(), &tc.sa)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if change.Applied != tc.expectApplied || len(change.OpenReservations) != tc.reservations {
+			t.Fatalf("unexpected change: %+v", change)
+		}
+
+		expectAvailability := tc.sa.Availability
+		if !tc.expectApplied {
+			expectAvailability = !tc.sa.Availability
+		}
+
+		if change.Warehouse.Availability != expectAvailability {
+			t.Fatalf("expected availability: %v, got: %v", expectAvailability, change.Warehouse.Availability)
 		}
 
 		if err = mock.ExpectationsWereMet(); err != nil {
@@ -88,6 +281,132 @@ func TestWarehouseCreate(t *testing.T) {
 	}
 }
 
+func TestWarehouseDecommission(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("can't create mock: %s", err)
+	}
+	defer db.Close()
+
+	storage := NewWarehouseStorage(db)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	reservationColumnNames := []string{"id", "order_ref", "warehouse_id", "product_code", "quantity", "status", "created_at", "expires_at"}
+	d := domain.Decommission{
+		WarehouseID: 1,
+		TargetID:    2,
+		Plan: []domain.DecommissionLine{
+			{
+				WarehouseToID: 3,
+				Code:          "test-1",
+				Quantity:      4,
+			},
+		},
+	}
+
+	expectTransfer := func(to int64, code string, quantity uint64) {
+		expectLockStock(mock, code, 1, to).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		expectChangeStock(mock, 1, code, -int64(quantity), 0).
+			WillReturnRows(changedRows(10-quantity, 0))
+		expectMovement(mock, domain.MovementTransferOut, 1, code, domain.BucketAvailable, -int64(quantity), 10-quantity)
+		expectTakeLots(mock, 1, code, "", false, defaultLotRows(10))
+		expectChangeLot(mock, 1, 1, -int64(quantity), 0)
+		expectTakeBins(mock, 1, code, noBinRows())
+		mock.ExpectQuery("INSERT INTO warehouse_products").
+			WithArgs(to, code, quantity).
+			WillReturnRows(stockRows(quantity, 0))
+		expectMovement(mock, domain.MovementTransferIn, to, code, domain.BucketAvailable, int64(quantity), quantity)
+		expectChangeLot(mock, to, 1, int64(quantity), 0)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT (.+) FROM warehouses WHERE id = .+ FOR UPDATE").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(warehouseColumnNames).AddRow(1, "test", true, nil))
+	mock.ExpectQuery("SELECT (.+) FROM reservations").
+		WithArgs(1, domain.ReservationActive).
+		WillReturnRows(sqlmock.NewRows(reservationColumnNames))
+	expectDecommissionBlockers(mock, "{}")
+	mock.ExpectQuery("SELECT COALESCE(.+) FROM warehouse_products").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"quarantined", "damaged"}).AddRow(0, 0))
+	expectTransfer(3, "test-1", 4)
+	mock.ExpectQuery("SELECT product_code, available_quantity FROM warehouse_products").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"product_code", "available_quantity"}).
+			AddRow("test-1", 6).
+			AddRow("test-2", 5))
+	expectTransfer(2, "test-1", 6)
+	expectTransfer(2, "test-2", 5)
+	mock.ExpectQuery("UPDATE warehouses SET availability = false, archived_at = NOW()").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(warehouseColumnNames).AddRow(1, "test", false, now))
+	mock.ExpectCommit()
+
+	result, err := storage.Decommission(context.Background(), &d)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(result.Transfers) != 3 || result.Warehouse.ArchivedAt == nil || result.Warehouse.Availability {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT (.+) FROM warehouses WHERE id = .+ FOR UPDATE").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(warehouseColumnNames).AddRow(1, "test", true, nil))
+	mock.ExpectQuery("SELECT (.+) FROM reservations").
+		WithArgs(1, domain.ReservationActive).
+		WillReturnRows(sqlmock.NewRows(reservationColumnNames).
+			AddRow(1, "order-1", 1, "test-1", 5, domain.ReservationActive, now, now))
+	mock.ExpectRollback()
+
+	if _, err = storage.Decommission(context.Background(), &d); !errors.Is(err, domain.ErrOpenReservations) {
+		t.Fatalf("expected: %v, got: %v", domain.ErrOpenReservations, err)
+	}
+
+	// Pending adjustments could not be approved in an archived warehouse.
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT (.+) FROM warehouses WHERE id = .+ FOR UPDATE").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(warehouseColumnNames).AddRow(1, "test", true, nil))
+	mock.ExpectQuery("SELECT (.+) FROM reservations").
+		WithArgs(1, domain.ReservationActive).
+		WillReturnRows(sqlmock.NewRows(reservationColumnNames))
+	expectDecommissionBlockers(mock, "{4,7}")
+	mock.ExpectRollback()
+
+	_, err = storage.Decommission(context.Background(), &d)
+	if !errors.Is(err, domain.ErrValidationFailed) || !strings.Contains(err.Error(), "pending adjustments [4 7]") {
+		t.Fatalf("expected: %v listing the adjustments, got: %v", domain.ErrValidationFailed, err)
+	}
+
+	// The stock of an unavailable warehouse can't be moved out.
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT (.+) FROM warehouses WHERE id = .+ FOR UPDATE").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(warehouseColumnNames).AddRow(1, "test", false, nil))
+	mock.ExpectRollback()
+
+	if _, err = storage.Decommission(context.Background(), &d); !errors.Is(err, domain.ErrWarehouseUnavailable) {
+		t.Fatalf("expected: %v, got: %v", domain.ErrWarehouseUnavailable, err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+// expectDecommissionBlockers returns the adjustments array literal, nothing
+// else blocks the decommission.
+func expectDecommissionBlockers(mock sqlmock.Sqlmock, adjustments string) {
+	mock.ExpectQuery("SELECT ARRAY(.+) FROM transfers").
+		WithArgs(1, domain.TransferInTransit, domain.PurchaseOrderOpen, domain.AdjustmentPending, domain.CountOpen).
+		WillReturnRows(sqlmock.NewRows([]string{"transfers", "purchase_orders", "adjustments", "counts"}).
+			AddRow("{}", "{}", adjustments, "{}"))
+}
+
 func TestWarehouseGetLeftOvers(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
@@ -144,7 +463,7 @@ func TestWarehouseGetLeftOvers(t *testing.T) {
 			WillReturnRows(tc.rows).
 			WillReturnError(tc.result)
 
-		products, err := storage.GetLeftOvers(&tc.gw)
+		products, err := storage.GetLeftOvers(context.Background(), &tc.gw)
 		if !errors.Is(err, tc.result) {
 			if tc.isError && err != nil {
 				continue
@@ -161,3 +480,70 @@ func TestWarehouseGetLeftOvers(t *testing.T) {
 		}
 	}
 }
+
+func TestWarehouseGetLeftOversAllBuckets(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("can't create mock: %s", err)
+	}
+	defer db.Close()
+
+	storage := NewWarehouseStorage(db)
+
+	mock.ExpectQuery(`SELECT p.name, size, code, available_quantity, reserved_quantity, quarantined_quantity, ` +
+		`damaged_quantity FROM warehouse_products (.+) AND \(available_quantity > 0 OR (.+) OR damaged_quantity > 0\)`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"name", "size", "code", "available_quantity", "reserved_quantity",
+			"quarantined_quantity", "damaged_quantity"}).
+			AddRow("test", "test", "test-1", 0, 0, 2, 1))
+
+	products, err := storage.GetLeftOvers(context.Background(), &domain.GetFromWarehouse{WarehouseID: 1, AllBuckets: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := domain.StockBuckets{Quarantined: 2, Damaged: 1}
+	if len(products) != 1 || products[0].Buckets == nil || *products[0].Buckets != expected {
+		t.Fatalf("expected buckets: %+v, got: %+v", expected, products)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestWarehouseGetLeftOversByLot(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("can't create mock: %s", err)
+	}
+	defer db.Close()
+
+	storage := NewWarehouseStorage(db)
+	expiresAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery("SELECT p.name, size, code, available_quantity FROM warehouse_products").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"name", "size", "code", "available_quantity"}).
+			AddRow("test", "test", "test-1", 10).
+			AddRow("test", "test", "test-2", 5))
+	mock.ExpectQuery("SELECT (.+) FROM stock_lots").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"product_code", "number", "manufactured_at", "expires_at", "quantity"}).
+			AddRow("test-1", "A", nil, expiresAt, 4).
+			AddRow("test-1", "", nil, nil, 6).
+			AddRow("test-2", "", nil, nil, 5))
+
+	products, err := storage.GetLeftOvers(context.Background(), &domain.GetFromWarehouse{WarehouseID: 1, ByLot: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(products[0].Lots) != 2 || products[0].Lots[0].Number != "A" || len(products[1].Lots) != 1 {
+		t.Fatalf("unexpected lots: %+v", products)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("there were unfulfilled expectations: %s", err)
+	}
+}
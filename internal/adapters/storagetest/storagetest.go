@@ -0,0 +1,1834 @@
+// Package storagetest is a conformance suite for storage adapters. It checks
+// behaviour through the services interfaces only, so every adapter is held to
+// the same rules the PostgreSQL schema enforces.
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/akrovv/warehouse/internal/domain"
+	"github.com/akrovv/warehouse/internal/services"
+)
+
+const (
+	TestCode  = "8b4cd8c7-3a1d-4f5e-9b0a-2c6e1f7d9a10"
+	OtherCode = "0f3c2a1b-5d6e-4a7b-8c9d-0e1f2a3b4c5d"
+
+	initialQuantity = 10
+	concurrentCalls = 50
+)
+
+// Storages is one adapter under test. InsertWarehouseProducts puts the whole
+// product quantity into a warehouse, like the insertWarehouseProducts
+// procedure, since no RPC does that.
+type Storages struct {
+	Products                services.ProductStorage
+	Warehouses              services.WarehouseStorage
+	Movements               services.MovementStorage
+	Purchases               services.PurchaseStorage
+	Returns                 services.ReturnStorage
+	Counts                  services.CountStorage
+	InsertWarehouseProducts func(ctx context.Context, warehouseID int64, code string) error
+}
+
+// Open returns empty storages for every test.
+type Open func(t *testing.T) Storages
+
+type fixture struct {
+	Storages
+	ctx  context.Context //nolint:containedctx
+	from int64
+	to   int64
+}
+
+// Run runs the suite. Every test starts with two available warehouses, from
+// and to, and initialQuantity units of TestCode in from.
+func Run(t *testing.T, open Open) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, f *fixture)
+	}{
+		{"CreateDuplicateCode", testCreateDuplicateCode},
+		{"ReserveCancelRoundTrip", testReserveCancelRoundTrip},
+		{"ReserveInsufficientStock", testReserveInsufficientStock},
+		{"ReserveBatchAtomic", testReserveBatchAtomic},
+		{"Fulfill", testFulfill},
+		{"ReleaseExpired", testReleaseExpired},
+		{"TransferConservation", testTransferConservation},
+		{"TransferBatchAtomic", testTransferBatchAtomic},
+		{"UnavailableWarehouse", testUnavailableWarehouse},
+		{"DeleteCascade", testDeleteCascade},
+		{"Decommission", testDecommission},
+		{"DecommissionBlockers", testDecommissionBlockers},
+		{"Ledger", testLedger},
+		{"ConcurrentReserveNeverOversells", testConcurrentReserve},
+		{"ConcurrentStockChangesConserveStock", testConcurrentStockChanges},
+		{"CanceledContext", testCanceledContext},
+		{"LotsFirstExpiredFirstOut", testLotsFEFO},
+		{"LotDatesConflict", testLotDatesConflict},
+		{"ReserveSkipsExpiredLots", testReserveSkipsExpiredLots},
+		{"Bins", testBins},
+		{"BinsOfOtherWarehouse", testBinsOfOtherWarehouse},
+		{"LowStock", testLowStock},
+		{"SuggestTransfers", testSuggestTransfers},
+		{"Allocate", testAllocate},
+		{"TransfersInTransit", testTransfersInTransit},
+		{"PurchaseOrders", testPurchaseOrders},
+		{"Returns", testReturns},
+		{"StockBuckets", testStockBuckets},
+		{"Adjustments", testAdjustments},
+		{"Counts", testCounts},
+		{"CountDuringFulfill", testCountDuringFulfill},
+		{"CountThenMoved", testCountThenMoved},
+		{"ConcurrentCountOpen", testConcurrentCountOpen},
+		{"CountApproval", testCountApproval},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.fn(t, setup(t, open(t)))
+		})
+	}
+}
+
+func setup(t *testing.T, s Storages) *fixture {
+	t.Helper()
+
+	ctx := context.Background()
+	f := &fixture{Storages: s, ctx: ctx}
+
+	for _, id := range []*int64{&f.from, &f.to} {
+		w := domain.Warehouse{Name: "warehouse", Availability: true}
+		if err := s.Warehouses.Create(ctx, &w); err != nil {
+			t.Fatalf("can't create warehouse: %s", err)
+		}
+
+		*id = w.ID
+	}
+
+	p := domain.Product{Name: "shirt", Size: "M", Code: TestCode, Quantity: initialQuantity}
+	if err := s.Products.Create(ctx, &p); err != nil {
+		t.Fatalf("can't create product: %s", err)
+	}
+
+	if err := s.InsertWarehouseProducts(ctx, f.from, TestCode); err != nil {
+		t.Fatalf("can't insert warehouse products: %s", err)
+	}
+
+	return f
+}
+
+// available is the available quantity of TestCode in the warehouse.
+func (f *fixture) available(t *testing.T, warehouseID int64) uint64 {
+	t.Helper()
+
+	products, err := f.Warehouses.GetLeftOvers(f.ctx, &domain.GetFromWarehouse{WarehouseID: warehouseID})
+	if errors.Is(err, domain.ErrNotFound) {
+		return 0
+	}
+
+	if err != nil {
+		t.Fatalf("can't get leftovers: %s", err)
+	}
+
+	for _, p := range products {
+		if p.Code == TestCode {
+			return p.Quantity
+		}
+	}
+
+	return 0
+}
+
+func (f *fixture) expectAvailable(t *testing.T, warehouseID int64, expected uint64) {
+	t.Helper()
+
+	if got := f.available(t, warehouseID); got != expected {
+		t.Fatalf("expected available in warehouse %d: %d, got: %d", warehouseID, expected, got)
+	}
+}
+
+func (f *fixture) reserve(t *testing.T, warehouseID int64, quantity uint64, expiresAt time.Time) domain.Reservation {
+	t.Helper()
+
+	wp := domain.WarehouseProduct{WarehouseID: warehouseID, Code: TestCode, Quantity: quantity}
+	r := domain.NewReservation(&wp, expiresAt)
+
+	if err := f.Products.Reserve(f.ctx, &r); err != nil {
+		t.Fatalf("can't reserve: %s", err)
+	}
+
+	return r
+}
+
+func expectError(t *testing.T, err, expected error) {
+	t.Helper()
+
+	if !errors.Is(err, expected) {
+		t.Fatalf("expected: %v, got: %v", expected, err)
+	}
+}
+
+func testCreateDuplicateCode(t *testing.T, f *fixture) {
+	err := f.Products.Create(f.ctx, &domain.Product{Name: "hat", Size: "L", Code: TestCode})
+	expectError(t, err, domain.ErrDuplicateCode)
+
+	err = f.Products.Create(f.ctx, &domain.Product{Name: "hat", Size: "L", Code: OtherCode})
+	expectError(t, err, nil)
+}
+
+func testReserveCancelRoundTrip(t *testing.T, f *fixture) {
+	r := f.reserve(t, f.from, 3, time.Now().Add(time.Hour))
+	if r.ID == 0 || r.Status != domain.ReservationActive {
+		t.Fatalf("expected active reservation with id, got: %+v", r)
+	}
+
+	f.expectAvailable(t, f.from, initialQuantity-3)
+
+	canceled, err := f.Products.CancelReservation(f.ctx, &domain.CancelReservation{ReservationID: r.ID})
+	expectError(t, err, nil)
+
+	if canceled.Status != domain.ReservationCanceled || canceled.Quantity != 3 ||
+		len(canceled.Lots) != 1 || canceled.Lots[0].Quantity != 3 {
+		t.Fatalf("expected canceled reservation of 3 from the default lot, got: %+v", canceled)
+	}
+
+	f.expectAvailable(t, f.from, initialQuantity)
+
+	_, err = f.Products.CancelReservation(f.ctx, &domain.CancelReservation{ReservationID: r.ID})
+	expectError(t, err, domain.ErrNotFound)
+}
+
+func testReserveInsufficientStock(t *testing.T, f *fixture) {
+	wp := domain.WarehouseProduct{WarehouseID: f.from, Code: TestCode, Quantity: initialQuantity + 1}
+	r := domain.NewReservation(&wp, time.Now().Add(time.Hour))
+
+	expectError(t, f.Products.Reserve(f.ctx, &r), domain.ErrInsufficientStock)
+	f.expectAvailable(t, f.from, initialQuantity)
+
+	wp.WarehouseID = f.to
+	r = domain.NewReservation(&wp, time.Now().Add(time.Hour))
+
+	expectError(t, f.Products.Reserve(f.ctx, &r), domain.ErrNotFound)
+}
+
+func testReserveBatchAtomic(t *testing.T, f *fixture) {
+	expiresAt := time.Now().Add(time.Hour)
+	rs := []domain.Reservation{
+		domain.NewReservation(&domain.WarehouseProduct{WarehouseID: f.from, Code: TestCode, Quantity: 3}, expiresAt),
+		domain.NewReservation(&domain.WarehouseProduct{WarehouseID: f.from, Code: TestCode, Quantity: 8}, expiresAt),
+	}
+
+	err := f.Products.ReserveBatch(f.ctx, rs)
+
+	var batchErr *domain.BatchError
+	if !errors.As(err, &batchErr) || batchErr.Index != 1 {
+		t.Fatalf("expected batch error at index 1, got: %v", err)
+	}
+
+	expectError(t, err, domain.ErrInsufficientStock)
+	f.expectAvailable(t, f.from, initialQuantity)
+}
+
+func testFulfill(t *testing.T, f *fixture) {
+	r := f.reserve(t, f.from, 3, time.Now().Add(time.Hour))
+
+	shipment, err := f.Products.Fulfill(f.ctx, &domain.Fulfillment{ReservationIDs: []int64{r.ID}})
+	expectError(t, err, nil)
+
+	if len(shipment.Lines) != 1 || shipment.Lines[0].Quantity != 3 {
+		t.Fatalf("expected one line of 3, got: %+v", shipment)
+	}
+
+	p, err := f.Products.Get(f.ctx, &domain.GetProduct{Code: TestCode})
+	expectError(t, err, nil)
+
+	if p.Quantity != initialQuantity-3 {
+		t.Fatalf("expected quantity: %d, got: %d", initialQuantity-3, p.Quantity)
+	}
+
+	f.expectAvailable(t, f.from, initialQuantity-3)
+
+	_, err = f.Products.Fulfill(f.ctx, &domain.Fulfillment{ReservationIDs: []int64{r.ID}})
+	expectError(t, err, domain.ErrNotFound)
+}
+
+func testReleaseExpired(t *testing.T, f *fixture) {
+	now := time.Now()
+	expired := f.reserve(t, f.from, 2, now.Add(-time.Minute))
+	f.reserve(t, f.from, 3, now.Add(time.Hour))
+
+	released, err := f.Products.ReleaseExpired(f.ctx, now)
+	expectError(t, err, nil)
+
+	if len(released) != 1 || released[0].ID != expired.ID || released[0].Status != domain.ReservationExpired {
+		t.Fatalf("expected reservation %d expired, got: %+v", expired.ID, released)
+	}
+
+	f.expectAvailable(t, f.from, initialQuantity-3)
+}
+
+// testTransferConservation checks that stock is moved, not created or lost,
+// and that the destination can use it right away.
+func testTransferConservation(t *testing.T, f *fixture) {
+	for _, q := range []uint64{4, 6} {
+		td := domain.TransferProduct{WarehouseFromID: f.from, WarehouseToID: f.to, Code: TestCode, Quantity: q}
+		expectError(t, f.Products.Transfer(f.ctx, &td), nil)
+
+		if total := f.available(t, f.from) + f.available(t, f.to); total != initialQuantity {
+			t.Fatalf("expected total: %d, got: %d", initialQuantity, total)
+		}
+	}
+
+	f.expectAvailable(t, f.to, initialQuantity)
+
+	td := domain.TransferProduct{WarehouseFromID: f.from, WarehouseToID: f.to, Code: TestCode, Quantity: 1}
+	expectError(t, f.Products.Transfer(f.ctx, &td), domain.ErrInsufficientStock)
+
+	f.reserve(t, f.to, initialQuantity, time.Now().Add(time.Hour))
+	f.expectAvailable(t, f.to, 0)
+
+	p, err := f.Products.Get(f.ctx, &domain.GetProduct{Code: TestCode})
+	expectError(t, err, nil)
+
+	if p.Quantity != initialQuantity {
+		t.Fatalf("expected quantity: %d, got: %d", initialQuantity, p.Quantity)
+	}
+}
+
+func testTransferBatchAtomic(t *testing.T, f *fixture) {
+	err := f.Products.TransferBatch(f.ctx, []domain.TransferProduct{
+		{WarehouseFromID: f.from, WarehouseToID: f.to, Code: TestCode, Quantity: 4},
+		{WarehouseFromID: f.from, WarehouseToID: f.to, Code: TestCode, Quantity: 7},
+	})
+
+	var batchErr *domain.BatchError
+	if !errors.As(err, &batchErr) || batchErr.Index != 1 {
+		t.Fatalf("expected batch error at index 1, got: %v", err)
+	}
+
+	f.expectAvailable(t, f.from, initialQuantity)
+	f.expectAvailable(t, f.to, 0)
+}
+
+func testUnavailableWarehouse(t *testing.T, f *fixture) {
+	change, err := f.Warehouses.SetAvailability(f.ctx, &domain.SetAvailability{ID: f.to})
+	expectError(t, err, nil)
+
+	if !change.Applied {
+		t.Fatalf("expected applied change, got: %+v", change)
+	}
+
+	td := domain.TransferProduct{WarehouseFromID: f.from, WarehouseToID: f.to, Code: TestCode, Quantity: 1}
+	expectError(t, f.Products.Transfer(f.ctx, &td), domain.ErrWarehouseUnavailable)
+	f.expectAvailable(t, f.from, initialQuantity)
+
+	r := f.reserve(t, f.from, 1, time.Now().Add(time.Hour))
+
+	change, err = f.Warehouses.SetAvailability(f.ctx, &domain.SetAvailability{ID: f.from})
+	expectError(t, err, nil)
+
+	if change.Applied || len(change.OpenReservations) != 1 {
+		t.Fatalf("expected refused change with one reservation, got: %+v", change)
+	}
+
+	_, err = f.Warehouses.SetAvailability(f.ctx, &domain.SetAvailability{ID: f.from, Force: true})
+	expectError(t, err, nil)
+
+	_, err = f.Products.CancelReservation(f.ctx, &domain.CancelReservation{ReservationID: r.ID})
+	expectError(t, err, domain.ErrWarehouseUnavailable)
+
+	wp := domain.WarehouseProduct{WarehouseID: f.from, Code: TestCode, Quantity: 1}
+	next := domain.NewReservation(&wp, time.Now().Add(time.Hour))
+	expectError(t, f.Products.Reserve(f.ctx, &next), domain.ErrWarehouseUnavailable)
+
+	ad := domain.AddProduct{WarehouseID: f.from, Code: TestCode, Quantity: 1}
+	expectError(t, f.Products.Add(f.ctx, &ad), domain.ErrWarehouseUnavailable)
+}
+
+func testDeleteCascade(t *testing.T, f *fixture) {
+	r := f.reserve(t, f.from, 2, time.Now().Add(time.Hour))
+
+	// Units in transit would leave the ledger with the transfer.
+	td := domain.TransferProduct{WarehouseFromID: f.from, WarehouseToID: f.to, Code: TestCode, Quantity: 3}
+	transfer, err := f.Products.Dispatch(f.ctx, &td)
+	expectError(t, err, nil)
+
+	_, err = f.Products.Delete(f.ctx, &domain.DeleteProduct{Code: TestCode})
+	expectError(t, err, domain.ErrValidationFailed)
+
+	_, err = f.Products.CancelTransfer(f.ctx, &domain.CancelTransfer{TransferID: transfer.ID})
+	expectError(t, err, nil)
+
+	deleted, err := f.Products.Delete(f.ctx, &domain.DeleteProduct{Code: TestCode})
+	expectError(t, err, nil)
+
+	if deleted.Code != TestCode {
+		t.Fatalf("expected: %s, got: %s", TestCode, deleted.Code)
+	}
+
+	_, err = f.Products.Get(f.ctx, &domain.GetProduct{Code: TestCode})
+	expectError(t, err, domain.ErrNotFound)
+
+	f.expectAvailable(t, f.from, 0)
+
+	_, err = f.Products.CancelReservation(f.ctx, &domain.CancelReservation{ReservationID: r.ID})
+	expectError(t, err, domain.ErrNotFound)
+
+	_, err = f.Products.Delete(f.ctx, &domain.DeleteProduct{Code: TestCode})
+	expectError(t, err, domain.ErrNotFound)
+
+	expectError(t, f.Products.Create(f.ctx, &domain.Product{Name: "shirt", Size: "M", Code: TestCode}), nil)
+}
+
+func testDecommission(t *testing.T, f *fixture) {
+	r := f.reserve(t, f.from, 1, time.Now().Add(time.Hour))
+
+	_, err := f.Warehouses.Decommission(f.ctx, &domain.Decommission{WarehouseID: f.from, TargetID: f.to})
+	expectError(t, err, domain.ErrOpenReservations)
+
+	_, err = f.Products.CancelReservation(f.ctx, &domain.CancelReservation{ReservationID: r.ID})
+	expectError(t, err, nil)
+
+	// Only available stock is moved, quarantined stock would be lost.
+	m := domain.BucketMove{WarehouseID: f.from, Code: TestCode, From: domain.BucketAvailable,
+		To: domain.BucketQuarantined, Quantity: 2, Reason: domain.ReasonQAHold}
+	expectError(t, f.Products.MoveStock(f.ctx, &m), nil)
+
+	_, err = f.Warehouses.Decommission(f.ctx, &domain.Decommission{WarehouseID: f.from, TargetID: f.to})
+	expectError(t, err, domain.ErrValidationFailed)
+	f.expectAvailable(t, f.to, 0)
+
+	m = domain.BucketMove{WarehouseID: f.from, Code: TestCode, From: domain.BucketQuarantined,
+		To: domain.BucketAvailable, Quantity: 2, Reason: domain.ReasonQARelease}
+	expectError(t, f.Products.MoveStock(f.ctx, &m), nil)
+
+	// The plan spells the code differently, the rest goes to the target.
+	result, err := f.Warehouses.Decommission(f.ctx, &domain.Decommission{WarehouseID: f.from, TargetID: f.to,
+		Plan: []domain.DecommissionLine{{WarehouseToID: f.to, Code: strings.ToUpper(TestCode), Quantity: 4}}})
+	expectError(t, err, nil)
+
+	if result.Warehouse.ArchivedAt == nil || result.Warehouse.Availability || len(result.Transfers) != 2 ||
+		result.Transfers[1].Quantity != initialQuantity-4 {
+		t.Fatalf("expected archived warehouse with the rest moved to the target, got: %+v", result)
+	}
+
+	f.expectAvailable(t, f.to, initialQuantity)
+
+	warehouses, err := f.Warehouses.List(f.ctx, &domain.WarehouseFilter{Limit: 10})
+	expectError(t, err, nil)
+
+	if len(warehouses) != 1 || warehouses[0].ID != f.to {
+		t.Fatalf("expected only warehouse %d listed, got: %+v", f.to, warehouses)
+	}
+}
+
+// testDecommissionBlockers checks that nothing waiting for a stock change in
+// the warehouse is stranded by its decommission.
+func testDecommissionBlockers(t *testing.T, f *fixture) {
+	decommission := func(expected error) {
+		t.Helper()
+
+		_, err := f.Warehouses.Decommission(f.ctx, &domain.Decommission{WarehouseID: f.from, TargetID: f.to})
+		expectError(t, err, expected)
+	}
+
+	_, err := f.Warehouses.SetAvailability(f.ctx, &domain.SetAvailability{ID: f.from})
+	expectError(t, err, nil)
+	decommission(domain.ErrWarehouseUnavailable)
+
+	_, err = f.Warehouses.SetAvailability(f.ctx, &domain.SetAvailability{ID: f.from, Availability: true})
+	expectError(t, err, nil)
+
+	supplier := domain.Supplier{Name: "Supplier"}
+	expectError(t, f.Purchases.CreateSupplier(f.ctx, &supplier), nil)
+
+	po := domain.PurchaseOrder{SupplierID: supplier.ID, Lines: []domain.PurchaseOrderLine{
+		{WarehouseID: f.from, Code: TestCode, Ordered: 5},
+	}}
+	expectError(t, po.Validate(), nil)
+	expectError(t, f.Purchases.CreateOrder(f.ctx, &po), nil)
+	decommission(domain.ErrValidationFailed)
+
+	_, err = f.Purchases.CloseOrder(f.ctx, &domain.ClosePurchaseOrder{OrderID: po.ID})
+	expectError(t, err, nil)
+
+	ps := services.NewProductService(f.Products, time.Hour, 3)
+	a := domain.Adjustment{WarehouseID: f.from, Code: TestCode, Quantity: -5, Reason: domain.ReasonTheft,
+		RequestedBy: "alice"}
+	expectError(t, ps.Adjust(f.ctx, &a), nil)
+	decommission(domain.ErrValidationFailed)
+
+	_, err = ps.RejectAdjustment(f.ctx, &domain.DecideAdjustment{ID: a.ID, User: "alice"})
+	expectError(t, err, nil)
+
+	session, err := f.Counts.Open(f.ctx, &domain.OpenCount{WarehouseID: f.from})
+	expectError(t, err, nil)
+	decommission(domain.ErrValidationFailed)
+
+	_, err = f.Counts.Cancel(f.ctx, &domain.CancelCount{SessionID: session.ID})
+	expectError(t, err, nil)
+
+	decommission(nil)
+	f.expectAvailable(t, f.to, initialQuantity)
+}
+
+// testLedger checks that movements add up to the stock they describe.
+func testLedger(t *testing.T, f *fixture) {
+	r := f.reserve(t, f.from, 3, time.Now().Add(time.Hour))
+
+	_, err := f.Products.CancelReservation(f.ctx, &domain.CancelReservation{ReservationID: r.ID, RequestID: "cancel-1"})
+	expectError(t, err, nil)
+
+	td := domain.TransferProduct{WarehouseFromID: f.from, WarehouseToID: f.to, Code: TestCode, Quantity: 4}
+	expectError(t, f.Products.Transfer(f.ctx, &td), nil)
+
+	movements, err := f.Movements.List(f.ctx, &domain.MovementFilter{Code: TestCode, Limit: 100})
+	expectError(t, err, nil)
+
+	// setup inserts the initial stock directly, so it isn't in the ledger.
+	balances := map[int64]int64{f.from: initialQuantity}
+	for _, m := range movements {
+		if m.Bucket != domain.BucketAvailable {
+			continue
+		}
+
+		balances[m.WarehouseID] += m.Delta
+		if balances[m.WarehouseID] != int64(m.Balance) {
+			t.Fatalf("movement %d: expected balance: %d, got: %d", m.ID, balances[m.WarehouseID], m.Balance)
+		}
+	}
+
+	for _, id := range []int64{f.from, f.to} {
+		if got := f.available(t, id); int64(got) != balances[id] {
+			t.Fatalf("expected available in warehouse %d: %d, got: %d", id, balances[id], got)
+		}
+	}
+}
+
+func testConcurrentReserve(t *testing.T, f *fixture) {
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		reserved uint64
+		failures []error
+	)
+
+	for i := 0; i < concurrentCalls; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			wp := domain.WarehouseProduct{WarehouseID: f.from, Code: TestCode, Quantity: 1}
+			r := domain.NewReservation(&wp, time.Now().Add(time.Hour))
+			err := f.Products.Reserve(f.ctx, &r)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if err != nil {
+				failures = append(failures, err)
+				return
+			}
+
+			reserved++
+		}()
+	}
+
+	wg.Wait()
+
+	if reserved != initialQuantity {
+		t.Fatalf("expected reserved: %d, got: %d", initialQuantity, reserved)
+	}
+
+	for _, err := range failures {
+		expectError(t, err, domain.ErrInsufficientStock)
+	}
+
+	f.expectAvailable(t, f.from, 0)
+}
+
+// testConcurrentStockChanges runs transfers in both directions together with
+// reservations. Every failure must be a clean insufficient stock error and
+// no unit may be created or lost.
+func testConcurrentStockChanges(t *testing.T, f *fixture) {
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		reserved uint64
+		failures []error
+	)
+
+	for i := 0; i < concurrentCalls; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			var err error
+			from, to := f.from, f.to
+			if i%2 == 1 {
+				from, to = to, from
+			}
+
+			if i%3 == 0 {
+				wp := domain.WarehouseProduct{WarehouseID: from, Code: TestCode, Quantity: 1}
+				r := domain.NewReservation(&wp, time.Now().Add(time.Hour))
+				if err = f.Products.Reserve(f.ctx, &r); err == nil {
+					mu.Lock()
+					reserved++
+					mu.Unlock()
+				}
+			} else {
+				td := domain.TransferProduct{WarehouseFromID: from, WarehouseToID: to, Code: TestCode, Quantity: 3}
+				err = f.Products.Transfer(f.ctx, &td)
+			}
+
+			// to has no row until the first transfer reaches it.
+			if err != nil && !errors.Is(err, domain.ErrNotFound) {
+				mu.Lock()
+				failures = append(failures, err)
+				mu.Unlock()
+			}
+		}(i)
+	}
+
+	wg.Wait()
+
+	for _, err := range failures {
+		expectError(t, err, domain.ErrInsufficientStock)
+	}
+
+	if total := f.available(t, f.from) + f.available(t, f.to) + reserved; total != initialQuantity {
+		t.Fatalf("expected total: %d, got: %d", initialQuantity, total)
+	}
+}
+
+func testCanceledContext(t *testing.T, f *fixture) {
+	ctx, cancel := context.WithCancel(f.ctx)
+	cancel()
+
+	wp := domain.WarehouseProduct{WarehouseID: f.from, Code: TestCode, Quantity: 1}
+	r := domain.NewReservation(&wp, time.Now().Add(time.Hour))
+
+	expectError(t, f.Products.Reserve(ctx, &r), context.Canceled)
+
+	err := f.Products.Transfer(ctx, &domain.TransferProduct{
+		WarehouseFromID: f.from,
+		WarehouseToID:   f.to,
+		Code:            TestCode,
+		Quantity:        1,
+	})
+	expectError(t, err, context.Canceled)
+
+	_, err = f.Warehouses.GetLeftOvers(ctx, &domain.GetFromWarehouse{WarehouseID: f.from})
+	expectError(t, err, context.Canceled)
+
+	f.expectAvailable(t, f.from, initialQuantity)
+	f.expectAvailable(t, f.to, 0)
+}
+
+// addLot receives quantity of TestCode into the lot that expires at expiresAt.
+func (f *fixture) addLot(t *testing.T, warehouseID int64, number string, quantity uint64, expiresAt time.Time) {
+	t.Helper()
+
+	ad := domain.AddProduct{
+		Code:        TestCode,
+		Quantity:    quantity,
+		WarehouseID: warehouseID,
+		Lot:         &domain.Lot{Number: number, ExpiresAt: &expiresAt},
+	}
+
+	if err := f.Products.Add(f.ctx, &ad); err != nil {
+		t.Fatalf("can't add lot %s: %s", number, err)
+	}
+}
+
+// lots is available stock of TestCode in the warehouse as "number:quantity"
+// in the order leftovers list them.
+func (f *fixture) lots(t *testing.T, warehouseID int64) []string {
+	t.Helper()
+
+	products, err := f.Warehouses.GetLeftOvers(f.ctx, &domain.GetFromWarehouse{WarehouseID: warehouseID, ByLot: true})
+	if err != nil {
+		t.Fatalf("can't get leftovers: %s", err)
+	}
+
+	lots := make([]string, 0, domain.BasicSliceLength)
+	for _, p := range products {
+		if p.Code != TestCode {
+			continue
+		}
+
+		for _, lot := range p.Lots {
+			lots = append(lots, fmt.Sprintf("%s:%d", lot.Number, lot.Quantity))
+		}
+	}
+
+	return lots
+}
+
+func (f *fixture) expectLots(t *testing.T, warehouseID int64, expected ...string) {
+	t.Helper()
+
+	if got := f.lots(t, warehouseID); !slices.Equal(got, expected) {
+		t.Fatalf("expected lots in warehouse %d: %v, got: %v", warehouseID, expected, got)
+	}
+}
+
+// testLotsFEFO starts with the default lot of initialQuantity units, it has
+// no expiry date and goes after any lot that has one.
+func testLotsFEFO(t *testing.T, f *fixture) {
+	now := time.Now()
+	f.addLot(t, f.from, "B", 5, now.AddDate(0, 0, 2))
+	f.addLot(t, f.from, "A", 5, now.AddDate(0, 0, 1))
+	f.expectLots(t, f.from, "A:5", "B:5", ":10")
+
+	r := f.reserve(t, f.from, 7, now.Add(time.Hour))
+	if len(r.Lots) != 2 || r.Lots[0].Number != "A" || r.Lots[1].Quantity != 2 {
+		t.Fatalf("expected 5 of A and 2 of B reserved, got: %+v", r.Lots)
+	}
+
+	td := domain.TransferProduct{WarehouseFromID: f.from, WarehouseToID: f.to, Code: TestCode, Quantity: 6}
+	expectError(t, f.Products.Transfer(f.ctx, &td), nil)
+	f.expectLots(t, f.from, ":7")
+	f.expectLots(t, f.to, "B:3", ":3")
+
+	_, err := f.Products.CancelReservation(f.ctx, &domain.CancelReservation{ReservationID: r.ID})
+	expectError(t, err, nil)
+	f.expectLots(t, f.from, "A:5", "B:2", ":7")
+
+	td = domain.TransferProduct{WarehouseFromID: f.from, WarehouseToID: f.to, Code: TestCode, Quantity: 3, Lot: "A"}
+	expectError(t, f.Products.Transfer(f.ctx, &td), nil)
+	f.expectLots(t, f.to, "A:3", "B:3", ":3")
+
+	td.Quantity = 3
+	expectError(t, f.Products.Transfer(f.ctx, &td), domain.ErrInsufficientStock)
+
+	wp := domain.WarehouseProduct{WarehouseID: f.from, Code: TestCode, Quantity: 2, Lot: "B"}
+	r = domain.NewReservation(&wp, now.Add(time.Hour))
+	expectError(t, f.Products.Reserve(f.ctx, &r), nil)
+
+	shipment, err := f.Products.Fulfill(f.ctx, &domain.Fulfillment{ReservationIDs: []int64{r.ID}})
+	expectError(t, err, nil)
+
+	if len(shipment.Lines[0].Lots) != 1 || shipment.Lines[0].Lots[0].Number != "B" {
+		t.Fatalf("expected lot B shipped, got: %+v", shipment.Lines[0].Lots)
+	}
+
+	f.expectLots(t, f.from, "A:2", ":7")
+}
+
+func testLotDatesConflict(t *testing.T, f *fixture) {
+	expiresAt := time.Now().AddDate(0, 1, 0).Truncate(time.Second)
+	f.addLot(t, f.from, "A", 2, expiresAt)
+
+	other := expiresAt.AddDate(0, 0, 1)
+	ad := domain.AddProduct{
+		Code:        TestCode,
+		Quantity:    1,
+		WarehouseID: f.from,
+		Lot:         &domain.Lot{Number: "A", ExpiresAt: &other},
+	}
+	expectError(t, f.Products.Add(f.ctx, &ad), domain.ErrValidationFailed)
+
+	ad.Lot = &domain.Lot{Number: "A"}
+	expectError(t, f.Products.Add(f.ctx, &ad), nil)
+	f.expectLots(t, f.from, "A:3", ":10")
+}
+
+// testReserveSkipsExpiredLots checks expired units can't be reserved, but can
+// still be moved, first of all.
+func testReserveSkipsExpiredLots(t *testing.T, f *fixture) {
+	f.addLot(t, f.from, "OLD", 5, time.Now().AddDate(0, 0, -1))
+
+	wp := domain.WarehouseProduct{WarehouseID: f.from, Code: TestCode, Quantity: initialQuantity + 1}
+	r := domain.NewReservation(&wp, time.Now().Add(time.Hour))
+	expectError(t, f.Products.Reserve(f.ctx, &r), domain.ErrInsufficientStock)
+	f.expectAvailable(t, f.from, initialQuantity+5)
+
+	td := domain.TransferProduct{WarehouseFromID: f.from, WarehouseToID: f.to, Code: TestCode, Quantity: 6}
+	expectError(t, f.Products.Transfer(f.ctx, &td), nil)
+	f.expectLots(t, f.to, "OLD:5", ":1")
+}
+
+func (f *fixture) createBin(t *testing.T, warehouseID int64, name string) int64 {
+	t.Helper()
+
+	bin := domain.Bin{WarehouseID: warehouseID, Zone: "A", Aisle: "01", Rack: "R1", Bin: name}
+	if err := f.Warehouses.CreateBin(f.ctx, &bin); err != nil {
+		t.Fatalf("can't create bin %s: %s", name, err)
+	}
+
+	return bin.ID
+}
+
+// bins is stock of TestCode in bins of the warehouse as
+// "bin:available/reserved" in the order leftovers list them.
+func (f *fixture) bins(t *testing.T, warehouseID int64) []string {
+	t.Helper()
+
+	products, err := f.Warehouses.GetLeftOvers(f.ctx, &domain.GetFromWarehouse{WarehouseID: warehouseID, ByBin: true})
+	if err != nil {
+		t.Fatalf("can't get leftovers: %s", err)
+	}
+
+	bins := make([]string, 0, domain.BasicSliceLength)
+	for _, p := range products {
+		if p.Code != TestCode {
+			continue
+		}
+
+		for _, bin := range p.Bins {
+			bins = append(bins, fmt.Sprintf("%s:%d/%d", bin.Bin.Bin, bin.Available, bin.Reserved))
+		}
+	}
+
+	return bins
+}
+
+func (f *fixture) expectBins(t *testing.T, warehouseID int64, expected ...string) {
+	t.Helper()
+
+	if got := f.bins(t, warehouseID); !slices.Equal(got, expected) {
+		t.Fatalf("expected bins in warehouse %d: %v, got: %v", warehouseID, expected, got)
+	}
+}
+
+// testBins starts with initialQuantity units unplaced, picks take bins in
+// walk order before unplaced stock.
+func testBins(t *testing.T, f *fixture) {
+	b2 := f.createBin(t, f.from, "B2")
+	b1 := f.createBin(t, f.from, "B1")
+
+	err := f.Warehouses.CreateBin(f.ctx, &domain.Bin{WarehouseID: f.from, Zone: "A", Aisle: "01", Rack: "R1", Bin: "B1"})
+	expectError(t, err, domain.ErrDuplicateCode)
+
+	bins, err := f.Warehouses.ListBins(f.ctx, &domain.ListBins{WarehouseID: f.from})
+	expectError(t, err, nil)
+
+	if len(bins) != 2 || bins[0].ID != b1 || bins[1].ID != b2 {
+		t.Fatalf("expected bins B1 and B2, got: %+v", bins)
+	}
+
+	rl := domain.Relocation{WarehouseID: f.from, Code: TestCode, Quantity: 6, ToBinID: b2}
+	expectError(t, f.Products.Relocate(f.ctx, &rl), nil)
+
+	rl = domain.Relocation{WarehouseID: f.from, Code: TestCode, Quantity: 2, FromBinID: b2, ToBinID: b1}
+	expectError(t, f.Products.Relocate(f.ctx, &rl), nil)
+	f.expectBins(t, f.from, "B1:2/0", "B2:4/0")
+
+	rl = domain.Relocation{WarehouseID: f.from, Code: TestCode, Quantity: 5, ToBinID: b1}
+	expectError(t, f.Products.Relocate(f.ctx, &rl), domain.ErrInsufficientStock)
+
+	ad := domain.AddProduct{Code: TestCode, Quantity: 3, WarehouseID: f.from, BinID: b1}
+	expectError(t, f.Products.Add(f.ctx, &ad), nil)
+	f.expectBins(t, f.from, "B1:5/0", "B2:4/0")
+	f.expectAvailable(t, f.from, initialQuantity+3)
+
+	r := f.reserve(t, f.from, 7, time.Now().Add(time.Hour))
+	if len(r.Bins) != 2 || r.Bins[0].ID != b1 || r.Bins[1].Quantity != 2 {
+		t.Fatalf("expected 5 of B1 and 2 of B2 reserved, got: %+v", r.Bins)
+	}
+
+	f.expectBins(t, f.from, "B1:0/5", "B2:2/2")
+
+	_, err = f.Products.CancelReservation(f.ctx, &domain.CancelReservation{ReservationID: r.ID})
+	expectError(t, err, nil)
+	f.expectBins(t, f.from, "B1:5/0", "B2:4/0")
+
+	td := domain.TransferProduct{WarehouseFromID: f.from, WarehouseToID: f.to, Code: TestCode, Quantity: 11}
+	expectError(t, f.Products.Transfer(f.ctx, &td), nil)
+	f.expectBins(t, f.from)
+	f.expectAvailable(t, f.from, 2)
+	f.expectAvailable(t, f.to, 11)
+
+	r = f.reserve(t, f.from, 2, time.Now().Add(time.Hour))
+	if len(r.Bins) != 0 {
+		t.Fatalf("expected unplaced stock reserved, got: %+v", r.Bins)
+	}
+}
+
+func testBinsOfOtherWarehouse(t *testing.T, f *fixture) {
+	other := f.createBin(t, f.to, "B1")
+
+	rl := domain.Relocation{WarehouseID: f.from, Code: TestCode, Quantity: 1, ToBinID: other}
+	expectError(t, f.Products.Relocate(f.ctx, &rl), domain.ErrNotFound)
+
+	ad := domain.AddProduct{Code: TestCode, Quantity: 1, WarehouseID: f.from, BinID: other}
+	expectError(t, f.Products.Add(f.ctx, &ad), domain.ErrNotFound)
+	f.expectAvailable(t, f.from, initialQuantity)
+
+	err := f.Warehouses.CreateBin(f.ctx, &domain.Bin{WarehouseID: f.to + 100, Bin: "B1"})
+	expectError(t, err, domain.ErrNotFound)
+}
+
+func (f *fixture) lowStockEvents(t *testing.T, afterID int64) []domain.LowStockEvent {
+	t.Helper()
+
+	events, err := f.Warehouses.LowStockEvents(f.ctx, &domain.LowStockEventFilter{AfterID: afterID, Limit: 10})
+	if err != nil {
+		t.Fatalf("can't list low stock events: %s", err)
+	}
+
+	return events
+}
+
+// testLowStock checks an event is written once per crossing, not for every
+// change below a threshold.
+func testLowStock(t *testing.T, f *fixture) {
+	threshold := domain.StockThreshold{WarehouseID: f.from, Code: TestCode, MinQuantity: 3, ReorderPoint: 6}
+	expectError(t, f.Warehouses.SetThreshold(f.ctx, &threshold), nil)
+
+	missing := domain.StockThreshold{WarehouseID: f.to, Code: TestCode, ReorderPoint: 1}
+	expectError(t, f.Warehouses.SetThreshold(f.ctx, &missing), domain.ErrNotFound)
+
+	r := f.reserve(t, f.from, 5, time.Now().Add(time.Hour))
+	f.reserve(t, f.from, 1, time.Now().Add(time.Hour))
+
+	events := f.lowStockEvents(t, 0)
+	if len(events) != 1 || events[0].Level != domain.LowStockReorder || events[0].Available != 5 ||
+		events[0].Threshold != 6 {
+		t.Fatalf("expected reorder event at 5, got: %+v", events)
+	}
+
+	f.reserve(t, f.from, 3, time.Now().Add(time.Hour))
+
+	events = f.lowStockEvents(t, events[0].ID)
+	if len(events) != 1 || events[0].Level != domain.LowStockMin || events[0].Available != 1 {
+		t.Fatalf("expected min event at 1, got: %+v", events)
+	}
+
+	items, err := f.Warehouses.LowStock(f.ctx, &domain.LowStockFilter{WarehouseID: f.from})
+	expectError(t, err, nil)
+
+	if len(items) != 1 || items[0].Available != 1 || items[0].Level != domain.LowStockMin {
+		t.Fatalf("expected %s below its minimum level, got: %+v", TestCode, items)
+	}
+
+	_, err = f.Products.CancelReservation(f.ctx, &domain.CancelReservation{ReservationID: r.ID})
+	expectError(t, err, nil)
+
+	items, err = f.Warehouses.LowStock(f.ctx, &domain.LowStockFilter{})
+	expectError(t, err, nil)
+
+	if len(items) != 0 {
+		t.Fatalf("expected no low stock, got: %+v", items)
+	}
+
+	if events = f.lowStockEvents(t, events[0].ID); len(events) != 0 {
+		t.Fatalf("expected no events for incoming stock, got: %+v", events)
+	}
+}
+
+func (f *fixture) setThreshold(t *testing.T, warehouseID int64, reorderPoint uint64) {
+	t.Helper()
+
+	threshold := domain.StockThreshold{WarehouseID: warehouseID, Code: TestCode, ReorderPoint: reorderPoint}
+	if err := f.Warehouses.SetThreshold(f.ctx, &threshold); err != nil {
+		t.Fatalf("can't set threshold: %s", err)
+	}
+}
+
+// testSuggestTransfers leaves from with its reorder point of 4 and gives the
+// rest to to.
+func testSuggestTransfers(t *testing.T, f *fixture) {
+	td := domain.TransferProduct{WarehouseFromID: f.from, WarehouseToID: f.to, Code: TestCode, Quantity: 1}
+	expectError(t, f.Products.Transfer(f.ctx, &td), nil)
+
+	f.setThreshold(t, f.from, 4)
+	f.setThreshold(t, f.to, 5)
+
+	ps := services.NewProductService(f.Products, time.Hour, 0)
+	sg := domain.SuggestTransfers{Code: TestCode}
+
+	suggestion, err := ps.SuggestTransfers(f.ctx, &sg)
+	expectError(t, err, nil)
+
+	if len(suggestion.Transfers) != 1 || suggestion.Transfers[0].WarehouseFromID != f.from ||
+		suggestion.Transfers[0].Quantity != 4 || len(suggestion.Shortfalls) != 0 || suggestion.Executed {
+		t.Fatalf("expected 4 suggested from %d, got: %+v", f.from, suggestion)
+	}
+
+	f.expectAvailable(t, f.to, 1)
+
+	f.setThreshold(t, f.to, 20)
+	sg.Execute = true
+
+	suggestion, err = ps.SuggestTransfers(f.ctx, &sg)
+	expectError(t, err, nil)
+
+	if len(suggestion.Transfers) != 1 || suggestion.Transfers[0].Quantity != 5 || !suggestion.Executed ||
+		len(suggestion.Shortfalls) != 1 || suggestion.Shortfalls[0].Quantity != 14 {
+		t.Fatalf("expected 5 transferred and 14 short, got: %+v", suggestion)
+	}
+
+	f.expectAvailable(t, f.from, 4)
+	f.expectAvailable(t, f.to, 6)
+}
+
+// allocate returns the allocation as "warehouse:quantity" and cancels it.
+func (f *fixture) allocate(t *testing.T, a domain.Allocation) []string {
+	t.Helper()
+
+	a.Code = TestCode
+	a.ExpiresAt = time.Now().Add(time.Hour)
+
+	reservations, err := f.Products.Allocate(f.ctx, &a)
+	if err != nil {
+		t.Fatalf("can't allocate: %s", err)
+	}
+
+	allocated := make([]string, 0, len(reservations))
+	for _, r := range reservations {
+		allocated = append(allocated, fmt.Sprintf("%d:%d", r.WarehouseID, r.Quantity))
+
+		if _, err = f.Products.CancelReservation(f.ctx, &domain.CancelReservation{ReservationID: r.ID}); err != nil {
+			t.Fatalf("can't cancel reservation %d: %s", r.ID, err)
+		}
+	}
+
+	return allocated
+}
+
+func (f *fixture) expectAllocated(t *testing.T, a domain.Allocation, expected ...int64) {
+	t.Helper()
+
+	want := make([]string, 0, len(expected)/2)
+	for i := 0; i < len(expected); i += 2 {
+		want = append(want, fmt.Sprintf("%d:%d", expected[i], expected[i+1]))
+	}
+
+	if got := f.allocate(t, a); !slices.Equal(got, want) {
+		t.Fatalf("expected %s allocation: %v, got: %v", a.Strategy, want, got)
+	}
+}
+
+// testAllocate starts with 6 units in from and 4 in to.
+func testAllocate(t *testing.T, f *fixture) {
+	td := domain.TransferProduct{WarehouseFromID: f.from, WarehouseToID: f.to, Code: TestCode, Quantity: 4}
+	expectError(t, f.Products.Transfer(f.ctx, &td), nil)
+
+	f.expectAllocated(t, domain.Allocation{Quantity: 5, Strategy: domain.AllocateSingleFirst}, f.from, 5)
+	f.expectAllocated(t, domain.Allocation{Quantity: 8, Strategy: domain.AllocateSingleFirst}, f.from, 6, f.to, 2)
+	f.expectAllocated(t, domain.Allocation{Quantity: 3, Strategy: domain.AllocateFewestSplits}, f.to, 3)
+	f.expectAllocated(t, domain.Allocation{Quantity: 8, Strategy: domain.AllocateFewestSplits}, f.from, 6, f.to, 2)
+	f.expectAllocated(t, domain.Allocation{Quantity: 3, Strategy: domain.AllocateMostStock}, f.from, 3)
+
+	preferred := []int64{f.to, f.from}
+	f.expectAllocated(t, domain.Allocation{Quantity: 3, WarehouseIDs: preferred, Strategy: domain.AllocateSingleFirst},
+		f.to, 3)
+	f.expectAllocated(t, domain.Allocation{Quantity: 5, WarehouseIDs: preferred, Strategy: domain.AllocateSingleFirst},
+		f.from, 5)
+
+	f.addLot(t, f.from, "OLD", 5, time.Now().AddDate(0, 0, -1))
+
+	a := domain.Allocation{Code: TestCode, Quantity: 11, Strategy: domain.AllocateMostStock, ExpiresAt: time.Now().Add(time.Hour)}
+	_, err := f.Products.Allocate(f.ctx, &a)
+	expectError(t, err, domain.ErrInsufficientStock)
+
+	a.WarehouseIDs = []int64{f.to}
+	a.Quantity = 5
+	_, err = f.Products.Allocate(f.ctx, &a)
+	expectError(t, err, domain.ErrInsufficientStock)
+
+	f.expectAvailable(t, f.from, 11)
+	f.expectAvailable(t, f.to, 4)
+}
+
+func (f *fixture) expectInTransit(t *testing.T, warehouseID int64, expected ...uint64) {
+	t.Helper()
+
+	transfers, err := f.Products.ListTransfers(f.ctx, &domain.TransferFilter{WarehouseID: warehouseID, Limit: 10})
+	if err != nil {
+		t.Fatalf("can't list transfers: %s", err)
+	}
+
+	got := make([]uint64, 0, len(transfers))
+	for _, transfer := range transfers {
+		got = append(got, transfer.InTransit())
+	}
+
+	if !slices.Equal(got, expected) {
+		t.Fatalf("expected in transit to or from warehouse %d: %v, got: %v", warehouseID, expected, got)
+	}
+}
+
+// testTransfersInTransit checks that units on the way are in neither
+// warehouse until they are received or the transfer is canceled.
+func testTransfersInTransit(t *testing.T, f *fixture) {
+	f.addLot(t, f.from, "A", 4, time.Now().AddDate(0, 0, 1))
+
+	td := domain.TransferProduct{WarehouseFromID: f.from, WarehouseToID: f.to, Code: TestCode, Quantity: 6}
+	transfer, err := f.Products.Dispatch(f.ctx, &td)
+	expectError(t, err, nil)
+
+	f.expectAvailable(t, f.from, 8)
+	f.expectAvailable(t, f.to, 0)
+	f.expectInTransit(t, f.to, 6)
+
+	transfer, err = f.Products.Receive(f.ctx, &domain.ReceiveTransfer{TransferID: transfer.ID, Quantity: 5})
+	expectError(t, err, nil)
+
+	if transfer.Status != domain.TransferInTransit || len(transfer.Lots) != 1 || transfer.Lots[0].Quantity != 1 {
+		t.Fatalf("expected one unit of the default lot in transit, got: %+v", transfer)
+	}
+
+	f.expectAvailable(t, f.to, 5)
+	f.expectLots(t, f.to, "A:4", ":1")
+
+	_, err = f.Products.Receive(f.ctx, &domain.ReceiveTransfer{TransferID: transfer.ID, Quantity: 2})
+	expectError(t, err, domain.ErrValidationFailed)
+
+	transfer, err = f.Products.CancelTransfer(f.ctx, &domain.CancelTransfer{TransferID: transfer.ID})
+	expectError(t, err, nil)
+
+	if transfer.Status != domain.TransferCanceled || transfer.Received != 5 {
+		t.Fatalf("expected the transfer canceled after 5 received, got: %+v", transfer)
+	}
+
+	f.expectLots(t, f.from, ":9")
+	f.expectInTransit(t, f.from)
+
+	_, err = f.Products.Receive(f.ctx, &domain.ReceiveTransfer{TransferID: transfer.ID})
+	expectError(t, err, domain.ErrNotFound)
+
+	td.Quantity = 3
+	transfer, err = f.Products.Dispatch(f.ctx, &td)
+	expectError(t, err, nil)
+
+	transfer, err = f.Products.Receive(f.ctx, &domain.ReceiveTransfer{TransferID: transfer.ID})
+	expectError(t, err, nil)
+
+	if transfer.Status != domain.TransferReceived {
+		t.Fatalf("expected the transfer received, got: %+v", transfer)
+	}
+
+	f.expectAvailable(t, f.from, 6)
+	f.expectAvailable(t, f.to, 8)
+	f.expectInTransit(t, 0)
+
+	td.WarehouseToID = f.to + 100
+	_, err = f.Products.Dispatch(f.ctx, &td)
+	expectError(t, err, domain.ErrNotFound)
+	f.expectAvailable(t, f.from, 6)
+
+	// A transfer in transit keeps both of its warehouses from being archived.
+	td.WarehouseToID = f.to
+	transfer, err = f.Products.Dispatch(f.ctx, &td)
+	expectError(t, err, nil)
+
+	for _, id := range []int64{f.from, f.to} {
+		_, err = f.Warehouses.Decommission(f.ctx, &domain.Decommission{WarehouseID: id, TargetID: f.from + f.to - id})
+		expectError(t, err, domain.ErrValidationFailed)
+	}
+
+	_, err = f.Products.CancelTransfer(f.ctx, &domain.CancelTransfer{TransferID: transfer.ID})
+	expectError(t, err, nil)
+
+	_, err = f.Warehouses.SetAvailability(f.ctx, &domain.SetAvailability{ID: f.to})
+	expectError(t, err, nil)
+
+	_, err = f.Products.Dispatch(f.ctx, &td)
+	expectError(t, err, domain.ErrWarehouseUnavailable)
+
+	_, err = f.Warehouses.SetAvailability(f.ctx, &domain.SetAvailability{ID: f.to, Availability: true})
+	expectError(t, err, nil)
+
+	_, err = f.Warehouses.Decommission(f.ctx, &domain.Decommission{WarehouseID: f.to, TargetID: f.from})
+	expectError(t, err, nil)
+
+	_, err = f.Products.Dispatch(f.ctx, &td)
+	expectError(t, err, domain.ErrNotFound)
+	f.expectAvailable(t, f.from, initialQuantity+4)
+}
+
+func (f *fixture) expectOutstanding(t *testing.T, supplierID int64, expected ...uint64) {
+	t.Helper()
+
+	lines, err := f.Purchases.Outstanding(f.ctx, &domain.OutstandingFilter{SupplierID: supplierID, Limit: 10})
+	expectError(t, err, nil)
+
+	got := make([]uint64, 0, len(lines))
+	for _, line := range lines {
+		got = append(got, line.Outstanding)
+	}
+
+	if !slices.Equal(got, expected) {
+		t.Fatalf("expected outstanding: %v, got: %+v", expected, lines)
+	}
+}
+
+func testPurchaseOrders(t *testing.T, f *fixture) {
+	supplier := domain.Supplier{Name: "Supplier"}
+	expectError(t, f.Purchases.CreateSupplier(f.ctx, &supplier), nil)
+
+	po := domain.PurchaseOrder{
+		SupplierID: supplier.ID,
+		Reference:  "PO-1",
+		Lines: []domain.PurchaseOrderLine{
+			{WarehouseID: f.from, Code: TestCode, Ordered: 5},
+			{WarehouseID: f.to, Code: TestCode, Ordered: 4},
+		},
+	}
+	expectError(t, po.Validate(), nil)
+	expectError(t, f.Purchases.CreateOrder(f.ctx, &po), nil)
+
+	f.expectOutstanding(t, supplier.ID, 5, 4)
+
+	receipt := domain.PurchaseReceipt{OrderID: po.ID, Lines: []domain.ReceiptLine{
+		{LineID: po.Lines[0].ID, Quantity: 2},
+		{LineID: po.Lines[1].ID, Quantity: 4},
+	}}
+	received, err := f.Purchases.Receive(f.ctx, &receipt)
+	expectError(t, err, nil)
+
+	if received.Status != domain.PurchaseOrderOpen || received.Lines[0].Received != 2 || received.Lines[1].Received != 4 {
+		t.Fatalf("expected the order partially received, got: %+v", received)
+	}
+
+	// The product was never placed in to, the receipt places it.
+	f.expectAvailable(t, f.from, 12)
+	f.expectAvailable(t, f.to, 4)
+	f.expectOutstanding(t, supplier.ID, 3)
+
+	receipt.Lines = []domain.ReceiptLine{{LineID: po.Lines[0].ID, Quantity: 4}}
+	_, err = f.Purchases.Receive(f.ctx, &receipt)
+	expectError(t, err, domain.ErrValidationFailed)
+	f.expectAvailable(t, f.from, 12)
+
+	receipt.Lines = []domain.ReceiptLine{{LineID: po.Lines[0].ID, Quantity: 3}}
+	received, err = f.Purchases.Receive(f.ctx, &receipt)
+	expectError(t, err, nil)
+
+	if received.Status != domain.PurchaseOrderReceived {
+		t.Fatalf("expected the order received, got: %+v", received)
+	}
+
+	f.expectAvailable(t, f.from, 15)
+	f.expectOutstanding(t, supplier.ID)
+
+	_, err = f.Purchases.CloseOrder(f.ctx, &domain.ClosePurchaseOrder{OrderID: po.ID})
+	expectError(t, err, domain.ErrNotFound)
+
+	po.ID, po.Reference = 0, "PO-2"
+	expectError(t, po.Validate(), nil)
+	expectError(t, f.Purchases.CreateOrder(f.ctx, &po), nil)
+	f.expectOutstanding(t, supplier.ID, 5, 4)
+
+	closed, err := f.Purchases.CloseOrder(f.ctx, &domain.ClosePurchaseOrder{OrderID: po.ID})
+	expectError(t, err, nil)
+
+	if closed.Status != domain.PurchaseOrderClosed || closed.ClosedAt == nil || len(closed.Lines) != 2 {
+		t.Fatalf("expected the order closed, got: %+v", closed)
+	}
+
+	f.expectOutstanding(t, supplier.ID)
+
+	receipt = domain.PurchaseReceipt{OrderID: po.ID, Lines: []domain.ReceiptLine{{LineID: po.Lines[0].ID, Quantity: 1}}}
+	_, err = f.Purchases.Receive(f.ctx, &receipt)
+	expectError(t, err, domain.ErrNotFound)
+
+	po.SupplierID = supplier.ID + 100
+	expectError(t, f.Purchases.CreateOrder(f.ctx, &po), domain.ErrNotFound)
+}
+
+// quarantined is the quarantined quantity of TestCode in the warehouse, as the
+// ledger tells.
+func (f *fixture) quarantined(t *testing.T, warehouseID int64) (balance uint64, writtenOff int64) {
+	t.Helper()
+
+	movements, err := f.Movements.List(f.ctx, &domain.MovementFilter{Code: TestCode, WarehouseID: warehouseID, Limit: 100})
+	if err != nil {
+		t.Fatalf("can't list movements: %s", err)
+	}
+
+	for _, m := range movements {
+		if m.Bucket != domain.BucketQuarantined {
+			continue
+		}
+
+		balance = m.Balance
+		if m.Type == domain.MovementWriteOff {
+			writtenOff -= m.Delta
+		}
+	}
+
+	return balance, writtenOff
+}
+
+func (f *fixture) expectQuantity(t *testing.T, expected uint64) {
+	t.Helper()
+
+	p, err := f.Products.Get(f.ctx, &domain.GetProduct{Code: TestCode})
+	expectError(t, err, nil)
+
+	if p.Quantity != expected {
+		t.Fatalf("expected quantity: %d, got: %d", expected, p.Quantity)
+	}
+}
+
+func testReturns(t *testing.T, f *fixture) {
+	r := f.reserve(t, f.from, 6, time.Now().Add(time.Hour))
+	other := f.reserve(t, f.from, 2, time.Now().Add(time.Hour))
+
+	shipment, err := f.Products.Fulfill(f.ctx, &domain.Fulfillment{ReservationIDs: []int64{r.ID, other.ID}})
+	expectError(t, err, nil)
+	f.expectQuantity(t, initialQuantity-8)
+
+	cr := domain.CreateReturn{ShipmentID: shipment.ID, Reason: "damaged in transit",
+		Lines: []domain.ReturnLine{{ReservationID: r.ID, Quantity: 5}}}
+	expectError(t, cr.Validate(), nil)
+
+	ret, err := f.Returns.Create(f.ctx, &cr)
+	expectError(t, err, nil)
+
+	if ret.Status != domain.ReturnOpen || len(ret.Lines) != 1 || ret.Lines[0].Quantity != 5 ||
+		ret.Lines[0].WarehouseID != f.from {
+		t.Fatalf("expected an open return of 5 from warehouse %d, got: %+v", f.from, ret)
+	}
+
+	// One unit of r is left to return.
+	cr.Lines[0].Quantity = 2
+	_, err = f.Returns.Create(f.ctx, &cr)
+	expectError(t, err, domain.ErrValidationFailed)
+
+	rr := domain.ReturnReceipt{ReturnID: ret.ID, RequestID: "return-1", Lines: []domain.ReturnReceiptLine{
+		{LineID: ret.Lines[0].ID, Quantity: 2, Disposition: domain.DispositionRestock},
+		{LineID: ret.Lines[0].ID, Quantity: 2, Disposition: domain.DispositionQuarantine},
+	}}
+	expectError(t, rr.Validate(), nil)
+
+	received, err := f.Returns.Receive(f.ctx, &rr)
+	expectError(t, err, nil)
+
+	if received.Status != domain.ReturnOpen || received.Lines[0].Outstanding() != 1 {
+		t.Fatalf("expected one unit outstanding, got: %+v", received)
+	}
+
+	// Restocked units are available again, quarantined ones are owned but
+	// can't be reserved.
+	f.expectAvailable(t, f.from, 4)
+	f.expectQuantity(t, initialQuantity-4)
+
+	if balance, _ := f.quarantined(t, f.from); balance != 2 {
+		t.Fatalf("expected quarantined: 2, got: %d", balance)
+	}
+
+	rr.Lines = []domain.ReturnReceiptLine{{LineID: ret.Lines[0].ID, Quantity: 2, Disposition: domain.DispositionWriteOff}}
+	_, err = f.Returns.Receive(f.ctx, &rr)
+	expectError(t, err, domain.ErrValidationFailed)
+
+	rr.Lines[0].Quantity = 1
+	received, err = f.Returns.Receive(f.ctx, &rr)
+	expectError(t, err, nil)
+
+	if received.Status != domain.ReturnReceived {
+		t.Fatalf("expected the return received, got: %+v", received)
+	}
+
+	f.expectQuantity(t, initialQuantity-4)
+
+	if balance, writtenOff := f.quarantined(t, f.from); balance != 2 || writtenOff != 1 {
+		t.Fatalf("expected quarantined: 2 and written off: 1, got: %d and %d", balance, writtenOff)
+	}
+
+	got, err := f.Returns.Get(f.ctx, &domain.GetReturn{ID: ret.ID})
+	expectError(t, err, nil)
+
+	if got.Status != domain.ReturnReceived || got.Lines[0].Restocked != 2 || got.Lines[0].Quarantined != 2 ||
+		got.Lines[0].WrittenOff != 1 {
+		t.Fatalf("expected the received return, got: %+v", got)
+	}
+
+	_, err = f.Returns.Receive(f.ctx, &rr)
+	expectError(t, err, domain.ErrNotFound)
+
+	byReservation, err := f.Returns.Create(f.ctx, &domain.CreateReturn{ReservationID: other.ID})
+	expectError(t, err, nil)
+
+	if len(byReservation.Lines) != 1 || byReservation.Lines[0].Quantity != 2 || byReservation.ShipmentID != shipment.ID {
+		t.Fatalf("expected a return of 2 from shipment %d, got: %+v", shipment.ID, byReservation)
+	}
+
+	rest, err := f.Returns.Create(f.ctx, &domain.CreateReturn{ShipmentID: shipment.ID})
+	expectError(t, err, nil)
+
+	if len(rest.Lines) != 1 || rest.Lines[0].ReservationID != r.ID || rest.Lines[0].Quantity != 1 {
+		t.Fatalf("expected a return of the last unit of reservation %d, got: %+v", r.ID, rest)
+	}
+
+	_, err = f.Returns.Create(f.ctx, &domain.CreateReturn{ShipmentID: shipment.ID})
+	expectError(t, err, domain.ErrValidationFailed)
+
+	unshipped := f.reserve(t, f.from, 1, time.Now().Add(time.Hour))
+	_, err = f.Returns.Create(f.ctx, &domain.CreateReturn{ReservationID: unshipped.ID})
+	expectError(t, err, domain.ErrNotFound)
+}
+
+func (f *fixture) expectBuckets(t *testing.T, warehouseID int64, expected domain.StockBuckets) {
+	t.Helper()
+
+	products, err := f.Warehouses.GetLeftOvers(f.ctx, &domain.GetFromWarehouse{WarehouseID: warehouseID, AllBuckets: true})
+	if err != nil {
+		t.Fatalf("can't get leftovers: %s", err)
+	}
+
+	for _, p := range products {
+		if p.Code == TestCode && p.Buckets != nil && *p.Buckets == expected {
+			return
+		}
+	}
+
+	t.Fatalf("expected buckets in warehouse %d: %+v, got: %+v", warehouseID, expected, products)
+}
+
+// testStockBuckets checks that moves between buckets keep the product
+// quantity and that only available stock can be reserved.
+func testStockBuckets(t *testing.T, f *fixture) {
+	r := f.reserve(t, f.from, 2, time.Now().Add(time.Hour))
+
+	moves := []domain.BucketMove{
+		{From: domain.BucketAvailable, To: domain.BucketQuarantined, Quantity: 5, Reason: domain.ReasonQAHold},
+		{From: domain.BucketQuarantined, To: domain.BucketDamaged, Quantity: 2, Reason: domain.ReasonDamage},
+		{From: domain.BucketQuarantined, To: domain.BucketAvailable, Quantity: 1, Reason: domain.ReasonQARelease},
+	}
+
+	for i := range moves {
+		m := &moves[i]
+		m.WarehouseID, m.Code, m.RequestID = f.from, TestCode, "count-1"
+		expectError(t, m.Validate(), nil)
+		expectError(t, f.Products.MoveStock(f.ctx, m), nil)
+	}
+
+	if len(moves[0].Lots) != 1 || moves[0].Lots[0].Quantity != 5 {
+		t.Fatalf("expected five units taken from the default lot, got: %+v", moves[0].Lots)
+	}
+
+	f.expectBuckets(t, f.from, domain.StockBuckets{Available: 4, Reserved: 2, Quarantined: 2, Damaged: 2})
+	f.expectQuantity(t, initialQuantity)
+	f.expectLots(t, f.from, ":4")
+
+	wp := domain.WarehouseProduct{WarehouseID: f.from, Code: TestCode, Quantity: 5}
+	over := domain.NewReservation(&wp, time.Now().Add(time.Hour))
+	expectError(t, f.Products.Reserve(f.ctx, &over), domain.ErrInsufficientStock)
+
+	m := domain.BucketMove{WarehouseID: f.from, Code: TestCode, From: domain.BucketDamaged,
+		To: domain.BucketAvailable, Quantity: 3, Reason: domain.ReasonRepair}
+	expectError(t, f.Products.MoveStock(f.ctx, &m), domain.ErrInsufficientStock)
+
+	m.WarehouseID = f.to
+	expectError(t, f.Products.MoveStock(f.ctx, &m), domain.ErrNotFound)
+
+	movements, err := f.Movements.List(f.ctx, &domain.MovementFilter{Code: TestCode, WarehouseID: f.from, Limit: 100})
+	expectError(t, err, nil)
+
+	moved := 0
+	for _, m := range movements {
+		if m.Type != domain.MovementBucketMove {
+			continue
+		}
+
+		if m.Reason == "" || m.RequestID != "count-1" {
+			t.Fatalf("expected the reason and request id in movement %d, got: %+v", m.ID, m)
+		}
+
+		moved++
+	}
+
+	if moved != 2*len(moves) {
+		t.Fatalf("expected %d bucket moves in the ledger, got: %d", 2*len(moves), moved)
+	}
+
+	// Stock out of available only is still reported with every bucket.
+	_, err = f.Products.CancelReservation(f.ctx, &domain.CancelReservation{ReservationID: r.ID})
+	expectError(t, err, nil)
+
+	m = domain.BucketMove{WarehouseID: f.from, Code: TestCode, From: domain.BucketAvailable,
+		To: domain.BucketDamaged, Quantity: 6, Reason: domain.ReasonRecall}
+	expectError(t, f.Products.MoveStock(f.ctx, &m), nil)
+
+	_, err = f.Warehouses.GetLeftOvers(f.ctx, &domain.GetFromWarehouse{WarehouseID: f.from})
+	expectError(t, err, domain.ErrNotFound)
+	f.expectBuckets(t, f.from, domain.StockBuckets{Quarantined: 2, Damaged: 8})
+}
+
+// testAdjustments runs through the product service, it decides which
+// adjustments wait for approval.
+func testAdjustments(t *testing.T, f *fixture) {
+	ps := services.NewProductService(f.Products, time.Hour, 5)
+
+	small := domain.Adjustment{WarehouseID: f.from, Code: TestCode, Quantity: -3, Reason: domain.ReasonDamage,
+		RequestedBy: "alice", RequestID: "adjust-1"}
+	expectError(t, ps.Adjust(f.ctx, &small), nil)
+
+	if small.ID == 0 || small.Status != domain.AdjustmentApplied || len(small.Lots) != 1 || small.Lots[0].Quantity != 3 {
+		t.Fatalf("expected an applied adjustment of three units of the default lot, got: %+v", small)
+	}
+
+	f.expectAvailable(t, f.from, initialQuantity-3)
+	f.expectQuantity(t, initialQuantity-3)
+	f.expectLots(t, f.from, ":7")
+
+	big := domain.Adjustment{WarehouseID: f.to, Code: TestCode, Quantity: 8, Reason: domain.ReasonCountCorrection,
+		RequestedBy: "alice"}
+	expectError(t, ps.Adjust(f.ctx, &big), nil)
+
+	if big.Status != domain.AdjustmentPending {
+		t.Fatalf("expected a pending adjustment, got: %+v", big)
+	}
+
+	f.expectAvailable(t, f.to, 0)
+
+	pending, err := ps.ListAdjustments(f.ctx, &domain.AdjustmentFilter{})
+	expectError(t, err, nil)
+
+	if len(pending) != 1 || pending[0].ID != big.ID {
+		t.Fatalf("expected adjustment %d pending, got: %+v", big.ID, pending)
+	}
+
+	_, err = ps.ApproveAdjustment(f.ctx, &domain.DecideAdjustment{ID: big.ID, User: "alice"})
+	expectError(t, err, domain.ErrValidationFailed)
+
+	approved, err := ps.ApproveAdjustment(f.ctx, &domain.DecideAdjustment{ID: big.ID, User: "bob"})
+	expectError(t, err, nil)
+
+	if approved.Status != domain.AdjustmentApplied || approved.DecidedBy != "bob" || approved.DecidedAt == nil {
+		t.Fatalf("expected the adjustment approved by bob, got: %+v", approved)
+	}
+
+	f.expectAvailable(t, f.to, 8)
+	f.expectQuantity(t, initialQuantity+5)
+
+	_, err = ps.ApproveAdjustment(f.ctx, &domain.DecideAdjustment{ID: big.ID, User: "carol"})
+	expectError(t, err, domain.ErrValidationFailed)
+
+	// Stock that is gone by the approval leaves the adjustment pending.
+	theft := domain.Adjustment{WarehouseID: f.from, Code: TestCode, Quantity: -9, Reason: domain.ReasonTheft,
+		RequestedBy: "alice"}
+	expectError(t, ps.Adjust(f.ctx, &theft), nil)
+
+	_, err = ps.ApproveAdjustment(f.ctx, &domain.DecideAdjustment{ID: theft.ID, User: "bob"})
+	expectError(t, err, domain.ErrInsufficientStock)
+
+	rejected, err := ps.RejectAdjustment(f.ctx, &domain.DecideAdjustment{ID: theft.ID, User: "alice"})
+	expectError(t, err, nil)
+
+	if rejected.Status != domain.AdjustmentRejected {
+		t.Fatalf("expected the adjustment rejected, got: %+v", rejected)
+	}
+
+	pending, err = ps.ListAdjustments(f.ctx, &domain.AdjustmentFilter{})
+	expectError(t, err, nil)
+
+	if len(pending) != 0 {
+		t.Fatalf("expected no pending adjustments, got: %+v", pending)
+	}
+
+	f.expectAvailable(t, f.from, initialQuantity-3)
+
+	damaged := domain.Adjustment{WarehouseID: f.from, Code: TestCode, Bucket: domain.BucketDamaged, Quantity: -1,
+		Reason: domain.ReasonExpiry, RequestedBy: "alice"}
+	expectError(t, ps.Adjust(f.ctx, &damaged), domain.ErrInsufficientStock)
+
+	other := domain.Adjustment{WarehouseID: f.from, Code: OtherCode, Quantity: 1, Reason: domain.ReasonCountCorrection,
+		RequestedBy: "alice"}
+	expectError(t, ps.Adjust(f.ctx, &other), domain.ErrNotFound)
+
+	movements, err := f.Movements.List(f.ctx, &domain.MovementFilter{Code: TestCode, Limit: 100})
+	expectError(t, err, nil)
+
+	adjusted := 0
+	for _, m := range movements {
+		if m.Type == domain.MovementAdjust {
+			adjusted++
+		}
+	}
+
+	if adjusted != 2 {
+		t.Fatalf("expected two adjustments in the ledger, got: %d", adjusted)
+	}
+}
+
+// countLine returns the line of the product in the session.
+func countLine(t *testing.T, session *domain.CountSession, code string) domain.CountLine {
+	t.Helper()
+
+	i := slices.IndexFunc(session.Lines, func(l domain.CountLine) bool { return l.Code == code })
+	if i < 0 {
+		t.Fatalf("expected line of %s, got: %+v", code, session.Lines)
+	}
+
+	return session.Lines[i]
+}
+
+func testCounts(t *testing.T, f *fixture) {
+	cs := services.NewCountService(f.Counts, 0)
+	f.reserve(t, f.from, 2, time.Now().Add(time.Hour))
+
+	session, err := cs.Open(f.ctx, &domain.OpenCount{WarehouseID: f.from})
+	expectError(t, err, nil)
+
+	if session.Status != domain.CountOpen || session.Limited || len(session.Lines) != 1 ||
+		session.Lines[0].Expected != initialQuantity || session.Lines[0].Counted != nil {
+		t.Fatalf("expected an open count expecting the whole quantity, got: %+v", session)
+	}
+
+	_, err = cs.Open(f.ctx, &domain.OpenCount{WarehouseID: f.from})
+	expectError(t, err, domain.ErrValidationFailed)
+
+	// Reservations made during the count don't change the snapshot.
+	r := f.reserve(t, f.from, 1, time.Now().Add(time.Hour))
+	_, err = f.Products.CancelReservation(f.ctx, &domain.CancelReservation{ReservationID: r.ID})
+	expectError(t, err, nil)
+
+	err = f.Products.Create(f.ctx, &domain.Product{Name: "hat", Size: "L", Code: OtherCode})
+	expectError(t, err, nil)
+
+	for _, lines := range [][]domain.CountedQuantity{
+		{{Code: TestCode, Quantity: 4}},
+		{{Code: TestCode, Quantity: 5}, {Code: OtherCode, Quantity: 2}},
+	} {
+		session, err = cs.Submit(f.ctx, &domain.CountSubmission{SessionID: session.ID, Lines: lines})
+		expectError(t, err, nil)
+	}
+
+	if line := countLine(t, session, TestCode); *line.Counted != 9 || line.Difference != -1 {
+		t.Fatalf("expected submissions to add up to 9, got: %+v", line)
+	}
+
+	if line := countLine(t, session, OtherCode); line.Expected != 0 || line.Difference != 2 {
+		t.Fatalf("expected an unexpected product found, got: %+v", line)
+	}
+
+	_, err = cs.Submit(f.ctx, &domain.CountSubmission{SessionID: session.ID, Lines: []domain.CountedQuantity{
+		{Code: "1f3c2a1b-5d6e-4a7b-8c9d-0e1f2a3b4c5d", Quantity: 1},
+	}})
+	expectError(t, err, domain.ErrNotFound)
+
+	// Counting less than is reserved fails the post and leaves the count open.
+	_, err = cs.Submit(f.ctx, &domain.CountSubmission{SessionID: session.ID, Replace: true, Lines: []domain.CountedQuantity{
+		{Code: TestCode, Quantity: 1},
+	}})
+	expectError(t, err, nil)
+
+	_, err = cs.Post(f.ctx, &domain.PostCount{SessionID: session.ID, User: "alice"})
+	expectError(t, err, domain.ErrInsufficientStock)
+
+	f.expectAvailable(t, f.from, initialQuantity-2)
+
+	_, err = cs.Submit(f.ctx, &domain.CountSubmission{SessionID: session.ID, Replace: true, Lines: []domain.CountedQuantity{
+		{Code: TestCode, Quantity: 8},
+	}})
+	expectError(t, err, nil)
+
+	got, err := cs.Get(f.ctx, &domain.GetCount{ID: session.ID})
+	expectError(t, err, nil)
+
+	if got.Status != domain.CountOpen || countLine(t, got, TestCode).Difference != -2 {
+		t.Fatalf("expected an open count short of two units, got: %+v", got)
+	}
+
+	posted, err := cs.Post(f.ctx, &domain.PostCount{SessionID: session.ID, User: "alice", RequestID: "count-1"})
+	expectError(t, err, nil)
+
+	if posted.Status != domain.CountPosted || posted.ClosedAt == nil {
+		t.Fatalf("expected a posted count, got: %+v", posted)
+	}
+
+	f.expectAvailable(t, f.from, initialQuantity-4)
+	f.expectQuantity(t, initialQuantity-2)
+
+	ps := services.NewProductService(f.Products, time.Hour, 0)
+	applied, err := ps.ListAdjustments(f.ctx, &domain.AdjustmentFilter{Status: domain.AdjustmentApplied})
+	expectError(t, err, nil)
+
+	if len(applied) != 2 || applied[0].Reason != domain.ReasonCountCorrection || applied[0].RequestedBy != "alice" {
+		t.Fatalf("expected two count corrections, got: %+v", applied)
+	}
+
+	_, err = cs.Post(f.ctx, &domain.PostCount{SessionID: session.ID, User: "alice"})
+	expectError(t, err, domain.ErrValidationFailed)
+
+	limited, err := cs.Open(f.ctx, &domain.OpenCount{WarehouseID: f.to, Codes: []string{OtherCode}})
+	expectError(t, err, nil)
+
+	if !limited.Limited || len(limited.Lines) != 1 || limited.Lines[0].Code != OtherCode {
+		t.Fatalf("expected a count of %s only, got: %+v", OtherCode, limited)
+	}
+
+	_, err = cs.Submit(f.ctx, &domain.CountSubmission{SessionID: limited.ID, Lines: []domain.CountedQuantity{
+		{Code: TestCode, Quantity: 1},
+	}})
+	expectError(t, err, domain.ErrValidationFailed)
+
+	canceled, err := cs.Cancel(f.ctx, &domain.CancelCount{SessionID: limited.ID})
+	expectError(t, err, nil)
+
+	if canceled.Status != domain.CountCanceled || canceled.ClosedAt == nil {
+		t.Fatalf("expected a canceled count, got: %+v", canceled)
+	}
+
+	_, err = cs.Get(f.ctx, &domain.GetCount{ID: limited.ID + 1})
+	expectError(t, err, domain.ErrNotFound)
+}
+
+// testCountDuringFulfill checks that stock moved while the count is open and
+// before the product is counted is not corrected by the count once more.
+func testCountDuringFulfill(t *testing.T, f *fixture) {
+	cs := services.NewCountService(f.Counts, 0)
+	r := f.reserve(t, f.from, 2, time.Now().Add(time.Hour))
+
+	session, err := cs.Open(f.ctx, &domain.OpenCount{WarehouseID: f.from})
+	expectError(t, err, nil)
+
+	_, err = f.Products.Fulfill(f.ctx, &domain.Fulfillment{ReservationIDs: []int64{r.ID}})
+	expectError(t, err, nil)
+
+	td := domain.TransferProduct{WarehouseFromID: f.from, WarehouseToID: f.to, Code: TestCode, Quantity: 3}
+	expectError(t, f.Products.Transfer(f.ctx, &td), nil)
+
+	// One unit is missing from the five left after the fulfilment and the
+	// transfer.
+	_, err = cs.Submit(f.ctx, &domain.CountSubmission{SessionID: session.ID, Lines: []domain.CountedQuantity{
+		{Code: TestCode, Quantity: 4},
+	}})
+	expectError(t, err, nil)
+
+	posted, err := cs.Post(f.ctx, &domain.PostCount{SessionID: session.ID, User: "alice"})
+	expectError(t, err, nil)
+
+	if line := countLine(t, posted, TestCode); line.Expected != initialQuantity || line.Moved != -5 ||
+		line.Difference != -1 {
+		t.Fatalf("expected a count short of one unit of the stock moved before counting, got: %+v", line)
+	}
+
+	f.expectAvailable(t, f.from, initialQuantity-6)
+
+	got, err := cs.Get(f.ctx, &domain.GetCount{ID: session.ID})
+	expectError(t, err, nil)
+
+	if line := countLine(t, got, TestCode); line.Moved != -5 || line.Difference != -1 {
+		t.Fatalf("expected the posted lines stored, got: %+v", line)
+	}
+}
+
+// testCountThenMoved checks that stock moved after the product is counted is
+// not netted out of the count, the counted units were already gone by then.
+func testCountThenMoved(t *testing.T, f *fixture) {
+	cs := services.NewCountService(f.Counts, 0)
+
+	session, err := cs.Open(f.ctx, &domain.OpenCount{WarehouseID: f.from})
+	expectError(t, err, nil)
+
+	_, err = cs.Submit(f.ctx, &domain.CountSubmission{SessionID: session.ID, Lines: []domain.CountedQuantity{
+		{Code: TestCode, Quantity: initialQuantity},
+	}})
+	expectError(t, err, nil)
+
+	td := domain.TransferProduct{WarehouseFromID: f.from, WarehouseToID: f.to, Code: TestCode, Quantity: 5}
+	expectError(t, f.Products.Transfer(f.ctx, &td), nil)
+
+	posted, err := cs.Post(f.ctx, &domain.PostCount{SessionID: session.ID, User: "alice"})
+	expectError(t, err, nil)
+
+	if line := countLine(t, posted, TestCode); line.Moved != 0 || line.Difference != 0 {
+		t.Fatalf("expected no difference, got: %+v", line)
+	}
+
+	f.expectAvailable(t, f.from, initialQuantity-5)
+
+	ps := services.NewProductService(f.Products, time.Hour, 0)
+	adjustments, err := ps.ListAdjustments(f.ctx, &domain.AdjustmentFilter{Status: domain.AdjustmentApplied})
+	expectError(t, err, nil)
+
+	if len(adjustments) != 0 {
+		t.Fatalf("expected no count corrections, got: %+v", adjustments)
+	}
+}
+
+// testConcurrentCountOpen checks that concurrent opens of a count of one
+// warehouse open one session and refuse the others.
+func testConcurrentCountOpen(t *testing.T, f *fixture) {
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		opened   int
+		failures []error
+	)
+
+	cs := services.NewCountService(f.Counts, 0)
+	for i := 0; i < concurrentCalls; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			_, err := cs.Open(f.ctx, &domain.OpenCount{WarehouseID: f.from})
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if err != nil {
+				failures = append(failures, err)
+				return
+			}
+
+			opened++
+		}()
+	}
+
+	wg.Wait()
+
+	if opened != 1 {
+		t.Fatalf("expected one open count, got: %d", opened)
+	}
+
+	for _, err := range failures {
+		expectError(t, err, domain.ErrValidationFailed)
+	}
+}
+
+// testCountApproval checks that a count difference above the approval
+// threshold waits for another user like an adjustment.
+func testCountApproval(t *testing.T, f *fixture) {
+	cs := services.NewCountService(f.Counts, 3)
+	ps := services.NewProductService(f.Products, time.Hour, 3)
+
+	session, err := cs.Open(f.ctx, &domain.OpenCount{WarehouseID: f.from})
+	expectError(t, err, nil)
+
+	_, err = cs.Submit(f.ctx, &domain.CountSubmission{SessionID: session.ID, Lines: []domain.CountedQuantity{
+		{Code: TestCode, Quantity: 4},
+	}})
+	expectError(t, err, nil)
+
+	posted, err := cs.Post(f.ctx, &domain.PostCount{SessionID: session.ID, User: "alice"})
+	expectError(t, err, nil)
+
+	if posted.Status != domain.CountPosted {
+		t.Fatalf("expected a posted count, got: %+v", posted)
+	}
+
+	f.expectAvailable(t, f.from, initialQuantity)
+
+	pending, err := ps.ListAdjustments(f.ctx, &domain.AdjustmentFilter{})
+	expectError(t, err, nil)
+
+	if len(pending) != 1 || pending[0].Reason != domain.ReasonCountCorrection || pending[0].Quantity != -6 {
+		t.Fatalf("expected a pending count correction of six units, got: %+v", pending)
+	}
+
+	_, err = ps.ApproveAdjustment(f.ctx, &domain.DecideAdjustment{ID: pending[0].ID, User: "alice"})
+	expectError(t, err, domain.ErrValidationFailed)
+
+	_, err = ps.ApproveAdjustment(f.ctx, &domain.DecideAdjustment{ID: pending[0].ID, User: "bob"})
+	expectError(t, err, nil)
+
+	f.expectAvailable(t, f.from, initialQuantity-6)
+}
@@ -1,8 +1,16 @@
 package config
 
-import "github.com/spf13/viper"
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/viper"
+)
 
 type config struct {
+	Storage struct {
+		Driver string `yaml:"driver"`
+	} `yaml:"storage"`
 	Database struct {
 		Host     string `yaml:"host"`
 		User     string `yaml:"user"`
@@ -12,25 +20,60 @@ type config struct {
 		Port     int    `yaml:"port"`
 	} `yaml:"database"`
 	Server struct {
-		Host string `yaml:"host"`
-		Port int    `yaml:"port"`
+		Host           string        `yaml:"host"`
+		Port           int           `yaml:"port"`
+		RequestTimeout time.Duration `yaml:"request_timeout" mapstructure:"request_timeout"`
 	} `yaml:"server"`
+	Reservations struct {
+		TTL           time.Duration `yaml:"ttl"`
+		SweepInterval time.Duration `yaml:"sweep_interval" mapstructure:"sweep_interval"`
+	} `yaml:"reservations"`
+	Adjustments struct {
+		ApprovalThreshold uint64 `yaml:"approval_threshold" mapstructure:"approval_threshold"`
+	} `yaml:"adjustments"`
 }
 
+const (
+	defaultReservationTTL = 30 * time.Minute
+	defaultSweepInterval  = time.Minute
+)
+
+// NewConfig reads the config, reservation durations that are not set get
+// the defaults of config.yml.
 func NewConfig(configType, path, filename string) (*config, error) {
 	cfg := new(config)
+	v := viper.New()
+
+	v.AddConfigPath(path)
+	v.SetConfigFile(filename)
+	v.SetConfigType(configType)
+	v.SetDefault("reservations.ttl", defaultReservationTTL)
+	v.SetDefault("reservations.sweep_interval", defaultSweepInterval)
 
-	viper.AddConfigPath(path)
-	viper.SetConfigFile(filename)
-	viper.SetConfigType(configType)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, err
+	}
 
-	if err := viper.ReadInConfig(); err != nil {
+	if err := v.Unmarshal(cfg); err != nil {
 		return nil, err
 	}
 
-	if err := viper.Unmarshal(cfg); err != nil {
+	if err := cfg.validate(); err != nil {
 		return nil, err
 	}
 
 	return cfg, nil
 }
+
+// validate rejects durations that break reservations: with a TTL that is not
+// positive every reservation is created expired and is never shipped.
+func (c *config) validate() error {
+	switch {
+	case c.Reservations.TTL <= 0:
+		return fmt.Errorf("reservations.ttl must be positive, got: %s", c.Reservations.TTL)
+	case c.Reservations.SweepInterval <= 0:
+		return fmt.Errorf("reservations.sweep_interval must be positive, got: %s", c.Reservations.SweepInterval)
+	}
+
+	return nil
+}
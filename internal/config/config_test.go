@@ -0,0 +1,72 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type configTestCase struct {
+	name          string
+	reservations  string
+	expectError   bool
+	expectTTL     time.Duration
+	expectSweeper time.Duration
+}
+
+func TestNewConfigReservations(t *testing.T) {
+	testCases := []configTestCase{
+		{
+			name:          "set",
+			reservations:  "reservations:\n  ttl: 5m\n  sweep_interval: 10s\n",
+			expectTTL:     5 * time.Minute,
+			expectSweeper: 10 * time.Second,
+		},
+		{
+			name:          "defaults",
+			reservations:  "",
+			expectTTL:     defaultReservationTTL,
+			expectSweeper: defaultSweepInterval,
+		},
+		{
+			name:         "zero ttl",
+			reservations: "reservations:\n  ttl: 0s\n",
+			expectError:  true,
+		},
+		{
+			name:         "negative ttl",
+			reservations: "reservations:\n  ttl: -1m\n",
+			expectError:  true,
+		},
+		{
+			name:         "negative sweep interval",
+			reservations: "reservations:\n  sweep_interval: -1s\n",
+			expectError:  true,
+		},
+	}
+
+	for _, tc := range testCases {
+		filename := filepath.Join(t.TempDir(), "config.yml")
+		if err := os.WriteFile(filename, []byte("storage:\n  driver: memory\n"+tc.reservations), 0o600); err != nil {
+			t.Fatalf("can't write config: %s", err)
+		}
+
+		cfg, err := NewConfig("yml", ".", filename)
+		if tc.expectError {
+			if err == nil {
+				t.Fatalf("[%s] expected an error, got: %+v", tc.name, cfg)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Fatalf("[%s] unexpected error: %v", tc.name, err)
+		}
+
+		if cfg.Reservations.TTL != tc.expectTTL || cfg.Reservations.SweepInterval != tc.expectSweeper {
+			t.Fatalf("[%s] expected: %s and %s, got: %+v", tc.name, tc.expectTTL, tc.expectSweeper, cfg.Reservations)
+		}
+	}
+}
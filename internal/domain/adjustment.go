@@ -0,0 +1,164 @@
+package domain
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+const (
+	AdjustmentApplied  = "applied"
+	AdjustmentPending  = "pending"
+	AdjustmentRejected = "rejected"
+)
+
+// Reason codes of adjustments, ReasonDamage is shared with moves between
+// buckets.
+const (
+	ReasonTheft           = "theft"
+	ReasonCountCorrection = "count_correction"
+	ReasonExpiry          = "expiry"
+)
+
+var adjustmentReasons = []string{ReasonDamage, ReasonTheft, ReasonCountCorrection, ReasonExpiry}
+
+// Adjustment corrects the stock of a product in a warehouse by Quantity,
+// negative to write units off. It changes the available bucket unless Bucket
+// is set. Units are written off available like a transfer takes them, from
+// lots first-expired-first-out or only the Lot number if it is set, and from
+// bins, Lots and Bins are where they were taken from. Units added to
+// available go unplaced into the default lot.
+//
+// An adjustment above the approval threshold is pending and changes nothing
+// until a user other than RequestedBy approves it.
+type Adjustment struct {
+	ID          int64         `json:"id"`
+	WarehouseID int64         `json:"warehouse_id"`
+	Code        string        `json:"code"`
+	Bucket      string        `json:"bucket"`
+	Quantity    int64         `json:"quantity"`
+	Reason      string        `json:"reason"`
+	Lot         string        `json:"lot"`
+	Status      string        `json:"status"`
+	RequestedBy string        `json:"requested_by"`
+	DecidedBy   string        `json:"decided_by,omitempty"`
+	RequestID   string        `json:"request_id"`
+	CreatedAt   time.Time     `json:"created_at"`
+	DecidedAt   *time.Time    `json:"decided_at,omitempty"`
+	Lots        []LotQuantity `json:"lots,omitempty"`
+	Bins        []BinQuantity `json:"bins,omitempty"`
+}
+
+// DecideAdjustment approves or rejects a pending adjustment on behalf of
+// User.
+type DecideAdjustment struct {
+	ID   int64  `json:"id"`
+	User string `json:"user"`
+}
+
+// AdjustmentFilter lists adjustments of Status, the pending ones if it is
+// not set.
+type AdjustmentFilter struct {
+	WarehouseID int64  `json:"warehouse_id"`
+	Code        string `json:"code"`
+	Status      string `json:"status"`
+	Limit       uint64 `json:"limit"`
+	Offset      uint64 `json:"offset"`
+}
+
+func (a *Adjustment) Validate() error {
+	if a.Bucket == "" {
+		a.Bucket = BucketAvailable
+	}
+
+	switch {
+	case a.WarehouseID <= 0:
+		return fmt.Errorf("%w: warehouse_id must be positive", ErrValidationFailed)
+	case a.Code == "":
+		return fmt.Errorf("%w: code is required", ErrValidationFailed)
+	case a.Quantity == 0:
+		return fmt.Errorf("%w: quantity must not be zero", ErrValidationFailed)
+	case !slices.Contains(movableBuckets, a.Bucket):
+		return fmt.Errorf("%w: bucket must be %s, %s or %s", ErrValidationFailed,
+			BucketAvailable, BucketQuarantined, BucketDamaged)
+	case !slices.Contains(adjustmentReasons, a.Reason):
+		return fmt.Errorf("%w: reason must be one of %v", ErrValidationFailed, adjustmentReasons)
+	case a.Lot != "" && (a.Bucket != BucketAvailable || a.Quantity > 0):
+		return fmt.Errorf("%w: lot is for write-offs from %s only", ErrValidationFailed, BucketAvailable)
+	case a.RequestedBy == "":
+		return fmt.Errorf("%w: requested_by is required", ErrValidationFailed)
+	}
+
+	return nil
+}
+
+func (d *DecideAdjustment) Validate() error {
+	switch {
+	case d.ID <= 0:
+		return fmt.Errorf("%w: id must be positive", ErrValidationFailed)
+	case d.User == "":
+		return fmt.Errorf("%w: user is required", ErrValidationFailed)
+	}
+
+	return nil
+}
+
+func (f *AdjustmentFilter) Validate() error {
+	if f.Status == "" {
+		f.Status = AdjustmentPending
+	}
+
+	switch {
+	case f.WarehouseID < 0:
+		return fmt.Errorf("%w: warehouse_id must be positive", ErrValidationFailed)
+	case !slices.Contains([]string{AdjustmentApplied, AdjustmentPending, AdjustmentRejected}, f.Status):
+		return fmt.Errorf("%w: status must be %s, %s or %s", ErrValidationFailed,
+			AdjustmentApplied, AdjustmentPending, AdjustmentRejected)
+	case f.Limit > maxListLimit:
+		return fmt.Errorf("%w: limit must not exceed %d", ErrValidationFailed, maxListLimit)
+	}
+
+	if f.Limit == 0 {
+		f.Limit = defaultListLimit
+	}
+
+	return nil
+}
+
+// Hold makes the adjustment pending if its size is above threshold, 0
+// applies every adjustment right away.
+func (a *Adjustment) Hold(threshold uint64) {
+	a.Status = AdjustmentApplied
+
+	size := uint64(a.Quantity)
+	if a.Quantity < 0 {
+		size = uint64(-a.Quantity)
+	}
+
+	if threshold > 0 && size > threshold {
+		a.Status = AdjustmentPending
+	}
+}
+
+// Decide approves or rejects the pending adjustment. Only another user can
+// approve it, the requester can reject their own. The storage sets
+// DecidedAt.
+func (a *Adjustment) Decide(d *DecideAdjustment, approve bool) error {
+	if a.Status != AdjustmentPending {
+		return fmt.Errorf("%w: adjustment %d is %s", ErrValidationFailed, a.ID, a.Status)
+	}
+
+	if approve && d.User == a.RequestedBy {
+		return fmt.Errorf("%w: adjustment %d must be approved by another user than %s",
+			ErrValidationFailed, a.ID, a.RequestedBy)
+	}
+
+	a.Status = AdjustmentRejected
+	if approve {
+		a.Status = AdjustmentApplied
+	}
+
+	a.DecidedBy = d.User
+
+	return nil
+}
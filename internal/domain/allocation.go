@@ -0,0 +1,166 @@
+package domain
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+)
+
+const (
+	AllocateSingleFirst  = "single_first"
+	AllocateFewestSplits = "fewest_splits"
+	AllocateMostStock    = "most_stock"
+)
+
+// Allocation reserves Quantity of a product across available warehouses.
+// WarehouseIDs limits it to those warehouses and orders them by preference,
+// otherwise every warehouse is a candidate in the order of its id. Strategy
+// decides how candidates are taken:
+//   - single_first reserves everything in the first warehouse that has enough
+//     and falls back to taking warehouses in order;
+//   - fewest_splits reserves in as few warehouses as it can, a single one
+//     with the least stock that is enough if there is any;
+//   - most_stock takes the warehouses with the most stock first.
+type Allocation struct {
+	Code         string    `json:"code"`
+	Quantity     uint64    `json:"quantity"`
+	WarehouseIDs []int64   `json:"warehouse_ids"`
+	Strategy     string    `json:"strategy"`
+	OrderRef     string    `json:"order_ref"`
+	RequestID    string    `json:"request_id"`
+	ExpiresAt    time.Time `json:"-"`
+}
+
+// WarehouseStock is stock of a product in a warehouse that can be reserved.
+type WarehouseStock struct {
+	WarehouseID int64
+	Available   uint64
+}
+
+func (a *Allocation) Validate() error {
+	switch {
+	case a.Code == "":
+		return fmt.Errorf("%w: code is required", ErrValidationFailed)
+	case a.Quantity == 0:
+		return fmt.Errorf("%w: quantity must be positive", ErrValidationFailed)
+	}
+
+	switch a.Strategy {
+	case "":
+		a.Strategy = AllocateSingleFirst
+	case AllocateSingleFirst, AllocateFewestSplits, AllocateMostStock:
+	default:
+		return fmt.Errorf("%w: unknown strategy %q", ErrValidationFailed, a.Strategy)
+	}
+
+	for i, id := range a.WarehouseIDs {
+		if id <= 0 {
+			return fmt.Errorf("%w: warehouse_ids must be positive", ErrValidationFailed)
+		}
+
+		if slices.Contains(a.WarehouseIDs[:i], id) {
+			return fmt.Errorf("%w: warehouse %d is listed twice", ErrValidationFailed, id)
+		}
+	}
+
+	return nil
+}
+
+// Plan splits the allocation across the warehouses, stock is in the order of
+// warehouse ids.
+func (a *Allocation) Plan(stock []WarehouseStock) ([]WarehouseProduct, error) {
+	candidates := a.candidates(stock)
+
+	total := uint64(0)
+	for _, c := range candidates {
+		total += c.Available
+	}
+
+	if total < a.Quantity {
+		return nil, &InsufficientStockError{
+			Code:      a.Code,
+			Bucket:    BucketAvailable,
+			Requested: a.Quantity,
+			Available: total,
+		}
+	}
+
+	switch a.Strategy {
+	case AllocateFewestSplits:
+		if i := a.leastEnough(candidates); i >= 0 {
+			return a.take(candidates[i : i+1]), nil
+		}
+
+		fallthrough
+	case AllocateMostStock:
+		sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].Available > candidates[j].Available })
+	default:
+		i := slices.IndexFunc(candidates, func(c WarehouseStock) bool { return c.Available >= a.Quantity })
+		if i >= 0 {
+			return a.take(candidates[i : i+1]), nil
+		}
+	}
+
+	return a.take(candidates), nil
+}
+
+// candidates returns the warehouses with stock in the order of preference.
+func (a *Allocation) candidates(stock []WarehouseStock) []WarehouseStock {
+	candidates := make([]WarehouseStock, 0, len(stock))
+
+	if len(a.WarehouseIDs) == 0 {
+		for _, s := range stock {
+			if s.Available > 0 {
+				candidates = append(candidates, s)
+			}
+		}
+
+		return candidates
+	}
+
+	for _, id := range a.WarehouseIDs {
+		i := slices.IndexFunc(stock, func(s WarehouseStock) bool { return s.WarehouseID == id })
+		if i >= 0 && stock[i].Available > 0 {
+			candidates = append(candidates, stock[i])
+		}
+	}
+
+	return candidates
+}
+
+func (a *Allocation) leastEnough(candidates []WarehouseStock) int {
+	least := -1
+	for i, c := range candidates {
+		if c.Available >= a.Quantity && (least < 0 || c.Available < candidates[least].Available) {
+			least = i
+		}
+	}
+
+	return least
+}
+
+// take reserves from the candidates in order until the quantity is reached.
+func (a *Allocation) take(candidates []WarehouseStock) []WarehouseProduct {
+	wps := make([]WarehouseProduct, 0, len(candidates))
+	quantity := a.Quantity
+
+	for _, c := range candidates {
+		if quantity == 0 {
+			break
+		}
+
+		take := min(c.Available, quantity)
+		quantity -= take
+
+		wps = append(wps, WarehouseProduct{
+			WarehouseID: c.WarehouseID,
+			Code:        a.Code,
+			Quantity:    take,
+			OrderRef:    a.OrderRef,
+			RequestID:   a.RequestID,
+		})
+	}
+
+	return wps
+}
@@ -0,0 +1,48 @@
+package domain
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// Batch is a list of items processed by one call. It is decoded either from
+// a plain array or from an object with items and the atomic flag, in atomic
+// mode either all items are applied or none of them.
+type Batch[T any] struct {
+	Items  []T  `json:"items"`
+	Atomic bool `json:"atomic"`
+}
+
+func (b *Batch[T]) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '[' {
+		b.Atomic = false
+		return json.Unmarshal(data, &b.Items)
+	}
+
+	var v struct {
+		Items  []T  `json:"items"`
+		Atomic bool `json:"atomic"`
+	}
+
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	b.Items, b.Atomic = v.Items, v.Atomic
+	return nil
+}
+
+type BatchError struct {
+	Index int
+	Err   error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("item %d: %s", e.Index, e.Err.Error())
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
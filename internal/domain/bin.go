@@ -0,0 +1,100 @@
+package domain
+
+import "fmt"
+
+// Bin is a storage place inside a warehouse, addressed by zone, aisle, rack
+// and bin. Zone, aisle and rack are optional for warehouses that don't use
+// them. Stock that is not put into any bin is unplaced.
+type Bin struct {
+	ID          int64  `json:"id"`
+	WarehouseID int64  `json:"warehouse_id"`
+	Zone        string `json:"zone"`
+	Aisle       string `json:"aisle"`
+	Rack        string `json:"rack"`
+	Bin         string `json:"bin"`
+}
+
+// BinQuantity is the part of a reservation, transfer or shipment that is
+// taken from the bin.
+type BinQuantity struct {
+	Bin
+	Quantity uint64 `json:"quantity"`
+}
+
+type BinStock struct {
+	Bin
+	Available uint64 `json:"available"`
+	Reserved  uint64 `json:"reserved"`
+}
+
+type ListBins struct {
+	WarehouseID int64 `json:"warehouse_id"`
+}
+
+// Relocation moves available stock between bins of one warehouse, bin id 0
+// is unplaced stock. The warehouse stock doesn't change, so the ledger
+// doesn't get it.
+type Relocation struct {
+	WarehouseID int64  `json:"warehouse_id"`
+	Code        string `json:"code"`
+	Quantity    uint64 `json:"quantity"`
+	FromBinID   int64  `json:"from_bin_id"`
+	ToBinID     int64  `json:"to_bin_id"`
+}
+
+func (b *Bin) Validate() error {
+	switch {
+	case b.WarehouseID <= 0:
+		return fmt.Errorf("%w: warehouse_id must be positive", ErrValidationFailed)
+	case b.Bin == "":
+		return fmt.Errorf("%w: bin is required", ErrValidationFailed)
+	}
+
+	return nil
+}
+
+func (lb *ListBins) Validate() error {
+	if lb.WarehouseID <= 0 {
+		return fmt.Errorf("%w: warehouse_id must be positive", ErrValidationFailed)
+	}
+
+	return nil
+}
+
+func (rl *Relocation) Validate() error {
+	switch {
+	case rl.WarehouseID <= 0:
+		return fmt.Errorf("%w: warehouse_id must be positive", ErrValidationFailed)
+	case rl.Code == "":
+		return fmt.Errorf("%w: code is required", ErrValidationFailed)
+	case rl.Quantity == 0:
+		return fmt.Errorf("%w: quantity must be positive", ErrValidationFailed)
+	case rl.FromBinID < 0 || rl.ToBinID < 0:
+		return fmt.Errorf("%w: bin ids must not be negative", ErrValidationFailed)
+	case rl.FromBinID == rl.ToBinID:
+		return fmt.Errorf("%w: can't relocate to the same bin", ErrValidationFailed)
+	}
+
+	return nil
+}
+
+// PickBins takes quantity from bins in the order they are given, what bins
+// don't have is taken from unplaced stock. The i-th picked bin is taken from
+// the i-th bin.
+func PickBins(bins []BinQuantity, quantity uint64) []BinQuantity {
+	picked := make([]BinQuantity, 0, len(bins))
+
+	for _, bin := range bins {
+		if quantity == 0 {
+			break
+		}
+
+		take := min(bin.Quantity, quantity)
+		quantity -= take
+
+		bin.Quantity = take
+		picked = append(picked, bin)
+	}
+
+	return picked
+}
@@ -0,0 +1,277 @@
+package domain
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	CountOpen     = "open"
+	CountPosted   = "posted"
+	CountCanceled = "canceled"
+)
+
+// CountSession is a physical count of a warehouse. Expected quantities are
+// frozen when the session opens, they are the available and reserved units,
+// so reservations made or canceled during the count don't change them.
+// MovementID is the last movement of the ledger when the session opened,
+// the stock moved after it is netted out of the differences.
+// Limited sessions count only the products they were opened with.
+type CountSession struct {
+	ID          int64       `json:"id"`
+	WarehouseID int64       `json:"warehouse_id"`
+	Limited     bool        `json:"limited"`
+	Status      string      `json:"status"`
+	CreatedAt   time.Time   `json:"created_at"`
+	ClosedAt    *time.Time  `json:"closed_at,omitempty"`
+	MovementID  int64       `json:"-"`
+	Lines       []CountLine `json:"lines"`
+}
+
+// CountLine is the expected and counted quantity of a product, Counted is
+// nil until the product is counted. Moved is the net change of the available
+// and reserved units from the opening of the session to the last count of
+// the line, stock moved after the line is counted is already out of the
+// counted units. Difference is Counted minus Expected and Moved.
+// Lines are sorted by code.
+type CountLine struct {
+	Code       string  `json:"code"`
+	Expected   uint64  `json:"expected"`
+	Moved      int64   `json:"moved"`
+	Counted    *uint64 `json:"counted"`
+	Difference int64   `json:"difference"`
+}
+
+// OpenCount opens a count of the warehouse, of the products with Codes only
+// if they are set.
+type OpenCount struct {
+	WarehouseID int64    `json:"warehouse_id"`
+	Codes       []string `json:"codes"`
+}
+
+type GetCount struct {
+	ID int64 `json:"id"`
+}
+
+// CountSubmission adds quantities counted by a scanner to the session, or
+// replaces the counted quantities if Replace is set.
+type CountSubmission struct {
+	SessionID int64             `json:"session_id"`
+	Lines     []CountedQuantity `json:"lines"`
+	Replace   bool              `json:"replace"`
+}
+
+type CountedQuantity struct {
+	Code     string `json:"code"`
+	Quantity uint64 `json:"quantity"`
+}
+
+// PostCount posts the differences of the counted lines as adjustments of
+// available stock requested by User. Adjustments above ApprovalThreshold,
+// set by the service, wait for approval like the ones of Products.Adjust.
+type PostCount struct {
+	SessionID         int64  `json:"session_id"`
+	User              string `json:"user"`
+	RequestID         string `json:"request_id"`
+	ApprovalThreshold uint64 `json:"-"`
+}
+
+type CancelCount struct {
+	SessionID int64 `json:"session_id"`
+}
+
+func (oc *OpenCount) Validate() error {
+	if oc.WarehouseID <= 0 {
+		return fmt.Errorf("%w: warehouse_id must be positive", ErrValidationFailed)
+	}
+
+	for i, code := range oc.Codes {
+		switch {
+		case code == "":
+			return fmt.Errorf("%w: code %d is empty", ErrValidationFailed, i)
+		case slices.Contains(oc.Codes[:i], code):
+			return fmt.Errorf("%w: code %s is listed twice", ErrValidationFailed, code)
+		}
+	}
+
+	return nil
+}
+
+func (gc *GetCount) Validate() error {
+	if gc.ID <= 0 {
+		return fmt.Errorf("%w: id must be positive", ErrValidationFailed)
+	}
+
+	return nil
+}
+
+func (cs *CountSubmission) Validate() error {
+	switch {
+	case cs.SessionID <= 0:
+		return fmt.Errorf("%w: session_id must be positive", ErrValidationFailed)
+	case len(cs.Lines) == 0:
+		return fmt.Errorf("%w: lines are required", ErrValidationFailed)
+	}
+
+	for i, line := range cs.Lines {
+		if line.Code == "" {
+			return fmt.Errorf("%w: line %d: code is required", ErrValidationFailed, i)
+		}
+	}
+
+	return nil
+}
+
+func (pc *PostCount) Validate() error {
+	switch {
+	case pc.SessionID <= 0:
+		return fmt.Errorf("%w: session_id must be positive", ErrValidationFailed)
+	case pc.User == "":
+		return fmt.Errorf("%w: user is required", ErrValidationFailed)
+	}
+
+	return nil
+}
+
+func (cc *CancelCount) Validate() error {
+	if cc.SessionID <= 0 {
+		return fmt.Errorf("%w: session_id must be positive", ErrValidationFailed)
+	}
+
+	return nil
+}
+
+// NewCountSession opens a session with expected quantities by code. Products
+// of a limited session that are not in the warehouse are expected to be
+// missing.
+func NewCountSession(oc *OpenCount, expected map[string]uint64) CountSession {
+	s := CountSession{
+		WarehouseID: oc.WarehouseID,
+		Limited:     len(oc.Codes) > 0,
+		Status:      CountOpen,
+	}
+
+	codes := slices.Clone(oc.Codes)
+	if !s.Limited {
+		for code := range expected {
+			codes = append(codes, code)
+		}
+	}
+
+	slices.Sort(codes)
+
+	s.Lines = make([]CountLine, 0, len(codes))
+	for _, code := range codes {
+		s.Lines = append(s.Lines, CountLine{Code: code, Expected: expected[code]})
+	}
+
+	return s
+}
+
+// Review sets the difference of every counted line.
+func (s *CountSession) Review() {
+	for i := range s.Lines {
+		line := &s.Lines[i]
+
+		line.Difference = 0
+		if line.Counted != nil {
+			line.Difference = int64(*line.Counted) - int64(line.Expected) - line.Moved
+		}
+	}
+}
+
+func (s *CountSession) checkOpen() error {
+	if s.Status != CountOpen {
+		return fmt.Errorf("%w: count %d is %s", ErrValidationFailed, s.ID, s.Status)
+	}
+
+	return nil
+}
+
+// Submit counts the submitted quantities and returns the codes of the
+// changed lines. A product that is not expected in a session that is not
+// limited gets a line expecting none of it. Moved is the net change of the
+// available and reserved units by code since the session opened, it is
+// recorded in the changed lines.
+func (s *CountSession) Submit(cs *CountSubmission, moved map[string]int64) ([]string, error) {
+	if err := s.checkOpen(); err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, 0, len(cs.Lines))
+	for _, counted := range cs.Lines {
+		i := slices.IndexFunc(s.Lines, func(l CountLine) bool { return l.Code == counted.Code })
+		if i < 0 && s.Limited {
+			return nil, fmt.Errorf("%w: product %s is not in count %d", ErrValidationFailed, counted.Code, s.ID)
+		}
+
+		if i < 0 {
+			s.Lines = append(s.Lines, CountLine{Code: counted.Code})
+			i = len(s.Lines) - 1
+		}
+
+		quantity := counted.Quantity
+		if line := s.Lines[i]; line.Counted != nil && !cs.Replace {
+			quantity += *line.Counted
+		}
+
+		s.Lines[i].Counted = &quantity
+		s.Lines[i].Moved = moved[counted.Code]
+
+		if !slices.Contains(codes, counted.Code) {
+			codes = append(codes, counted.Code)
+		}
+	}
+
+	slices.SortFunc(s.Lines, func(a, b CountLine) int { return strings.Compare(a.Code, b.Code) })
+	s.Review()
+
+	return codes, nil
+}
+
+// Post closes the session and returns the adjustments of its counted lines
+// that differ from the expected quantity. Adjustments are held by the
+// approval threshold of pc.
+func (s *CountSession) Post(pc *PostCount) ([]Adjustment, error) {
+	if err := s.checkOpen(); err != nil {
+		return nil, err
+	}
+
+	s.Review()
+
+	adjustments := make([]Adjustment, 0, len(s.Lines))
+	for _, line := range s.Lines {
+		if line.Difference == 0 {
+			continue
+		}
+
+		a := Adjustment{
+			WarehouseID: s.WarehouseID,
+			Code:        line.Code,
+			Bucket:      BucketAvailable,
+			Quantity:    line.Difference,
+			Reason:      ReasonCountCorrection,
+			RequestedBy: pc.User,
+			RequestID:   pc.RequestID,
+		}
+
+		a.Hold(pc.ApprovalThreshold)
+		adjustments = append(adjustments, a)
+	}
+
+	s.Status = CountPosted
+
+	return adjustments, nil
+}
+
+func (s *CountSession) Cancel() error {
+	if err := s.checkOpen(); err != nil {
+		return err
+	}
+
+	s.Status = CountCanceled
+
+	return nil
+}
@@ -1,3 +1,8 @@
 package domain
 
 const BasicSliceLength = 10
+
+const (
+	defaultListLimit = 100
+	maxListLimit     = 1000
+)
@@ -1,5 +1,58 @@
 package domain
 
-import "errors"
+import (
+	"context"
+	"errors"
+)
 
 var ErrTest error = errors.New("some error")
+
+var (
+	ErrNotFound             = errors.New("not found")
+	ErrInsufficientStock    = errors.New("insufficient stock")
+	ErrWarehouseUnavailable = errors.New("warehouse unavailable")
+	ErrDuplicateCode        = errors.New("duplicate code")
+	ErrValidationFailed     = errors.New("validation failed")
+	ErrRolledBack           = errors.New("rolled back")
+	ErrOpenReservations     = errors.New("open reservations")
+)
+
+type ErrorCode string
+
+const (
+	CodeNotFound             ErrorCode = "NOT_FOUND"
+	CodeInsufficientStock    ErrorCode = "INSUFFICIENT_STOCK"
+	CodeWarehouseUnavailable ErrorCode = "WAREHOUSE_UNAVAILABLE"
+	CodeDuplicateCode        ErrorCode = "DUPLICATE_CODE"
+	CodeValidationFailed     ErrorCode = "VALIDATION_FAILED"
+	CodeRolledBack           ErrorCode = "ROLLED_BACK"
+	CodeOpenReservations     ErrorCode = "OPEN_RESERVATIONS"
+	CodeTimeout              ErrorCode = "TIMEOUT"
+	CodeCanceled             ErrorCode = "CANCELED"
+	CodeInternal             ErrorCode = "INTERNAL"
+)
+
+var errorCodes = []struct {
+	err  error
+	code ErrorCode
+}{
+	{ErrNotFound, CodeNotFound},
+	{ErrInsufficientStock, CodeInsufficientStock},
+	{ErrWarehouseUnavailable, CodeWarehouseUnavailable},
+	{ErrDuplicateCode, CodeDuplicateCode},
+	{ErrValidationFailed, CodeValidationFailed},
+	{ErrRolledBack, CodeRolledBack},
+	{ErrOpenReservations, CodeOpenReservations},
+	{context.DeadlineExceeded, CodeTimeout},
+	{context.Canceled, CodeCanceled},
+}
+
+func CodeOf(err error) ErrorCode {
+	for _, ec := range errorCodes {
+		if errors.Is(err, ec.err) {
+			return ec.code
+		}
+	}
+
+	return CodeInternal
+}
@@ -0,0 +1,91 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// Lot is a batch of a product, its units share manufacture and expiry dates.
+// Stock received without a lot goes to the default lot with an empty number
+// and no dates.
+type Lot struct {
+	Number         string     `json:"number"`
+	ManufacturedAt *time.Time `json:"manufactured_at,omitempty"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+}
+
+type LotQuantity struct {
+	Lot
+	Quantity uint64 `json:"quantity"`
+}
+
+func (l *Lot) Validate() error {
+	switch {
+	case l.Number == "":
+		return fmt.Errorf("%w: lot number is required", ErrValidationFailed)
+	case l.ManufacturedAt != nil && l.ExpiresAt != nil && !l.ManufacturedAt.Before(*l.ExpiresAt):
+		return fmt.Errorf("%w: lot %s expires before it is manufactured", ErrValidationFailed, l.Number)
+	}
+
+	return nil
+}
+
+// Expired tells if the lot can't be reserved at now anymore.
+func (l *Lot) Expired(now time.Time) bool {
+	return l.ExpiresAt != nil && !l.ExpiresAt.After(now)
+}
+
+// SameDates tells if a lot received again matches the lot already stored,
+// dates left empty match anything.
+func (l *Lot) SameDates(stored *Lot) bool {
+	same := func(a, b *time.Time) bool {
+		return a == nil || (b != nil && a.Equal(*b))
+	}
+
+	return same(l.ManufacturedAt, stored.ManufacturedAt) && same(l.ExpiresAt, stored.ExpiresAt)
+}
+
+// ExpiresBefore orders lots first-expired-first-out, lots without an expiry
+// date go last.
+func (l *Lot) ExpiresBefore(other *Lot) bool {
+	switch {
+	case l.ExpiresAt == nil:
+		return false
+	case other.ExpiresAt == nil:
+		return true
+	}
+
+	return l.ExpiresAt.Before(*other.ExpiresAt)
+}
+
+// PickLots takes quantity from lots in the order they are given, callers
+// sort them first-expired-first-out. The i-th picked lot is taken from the
+// i-th lot. It returns InsufficientStockError if lots don't have enough.
+func PickLots(warehouseID int64, code string, lots []LotQuantity, quantity uint64) ([]LotQuantity, error) {
+	picked := make([]LotQuantity, 0, len(lots))
+	left := quantity
+
+	for _, lot := range lots {
+		if left == 0 {
+			break
+		}
+
+		take := min(lot.Quantity, left)
+		left -= take
+
+		lot.Quantity = take
+		picked = append(picked, lot)
+	}
+
+	if left > 0 {
+		return nil, &InsufficientStockError{
+			WarehouseID: warehouseID,
+			Code:        code,
+			Bucket:      BucketAvailable,
+			Requested:   quantity,
+			Available:   quantity - left,
+		}
+	}
+
+	return picked, nil
+}
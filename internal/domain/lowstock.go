@@ -0,0 +1,135 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	LowStockReorder = "reorder"
+	LowStockMin     = "min"
+)
+
+// StockThreshold is the minimum level and the reorder point of a product in a
+// warehouse, both compare to available stock. Zero turns a threshold off.
+type StockThreshold struct {
+	WarehouseID  int64  `json:"warehouse_id"`
+	Code         string `json:"code"`
+	MinQuantity  uint64 `json:"min_quantity"`
+	ReorderPoint uint64 `json:"reorder_point"`
+}
+
+// LowStockEvent is written together with the stock change that took available
+// stock from Threshold or above to below it.
+type LowStockEvent struct {
+	ID          int64     `json:"id"`
+	WarehouseID int64     `json:"warehouse_id"`
+	Code        string    `json:"code"`
+	Level       string    `json:"level"`
+	Threshold   uint64    `json:"threshold"`
+	Available   uint64    `json:"available"`
+	RequestID   string    `json:"request_id"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// LowStockItem is a product whose available stock is below its reorder
+// point, Level is LowStockMin if it is below the minimum level too.
+type LowStockItem struct {
+	StockThreshold
+	Name      string `json:"name"`
+	Available uint64 `json:"available"`
+	Level     string `json:"level"`
+}
+
+// LowStockFilter lists every warehouse if WarehouseID is 0.
+type LowStockFilter struct {
+	WarehouseID int64 `json:"warehouse_id"`
+}
+
+// LowStockEventFilter returns events after AfterID, so consumers can poll it
+// with the last id they have seen.
+type LowStockEventFilter struct {
+	WarehouseID int64  `json:"warehouse_id"`
+	AfterID     int64  `json:"after_id"`
+	Limit       uint64 `json:"limit"`
+}
+
+func (t *StockThreshold) Validate() error {
+	switch {
+	case t.WarehouseID <= 0:
+		return fmt.Errorf("%w: warehouse_id must be positive", ErrValidationFailed)
+	case t.Code == "":
+		return fmt.Errorf("%w: code is required", ErrValidationFailed)
+	case t.MinQuantity > t.ReorderPoint:
+		return fmt.Errorf("%w: min_quantity must not exceed reorder_point", ErrValidationFailed)
+	}
+
+	return nil
+}
+
+func (f *LowStockFilter) Validate() error {
+	if f.WarehouseID < 0 {
+		return fmt.Errorf("%w: warehouse_id must be positive", ErrValidationFailed)
+	}
+
+	return nil
+}
+
+func (f *LowStockEventFilter) Validate() error {
+	switch {
+	case f.WarehouseID < 0:
+		return fmt.Errorf("%w: warehouse_id must be positive", ErrValidationFailed)
+	case f.AfterID < 0:
+		return fmt.Errorf("%w: after_id must not be negative", ErrValidationFailed)
+	case f.Limit > maxListLimit:
+		return fmt.Errorf("%w: limit must not exceed %d", ErrValidationFailed, maxListLimit)
+	}
+
+	if f.Limit == 0 {
+		f.Limit = defaultListLimit
+	}
+
+	return nil
+}
+
+// Crossed returns the levels available stock fell below when it changed from
+// before to after, the reorder point goes first.
+func (t *StockThreshold) Crossed(before, after uint64) []string {
+	levels := make([]string, 0, 2)
+
+	if crossed(t.ReorderPoint, before, after) {
+		levels = append(levels, LowStockReorder)
+	}
+
+	if crossed(t.MinQuantity, before, after) {
+		levels = append(levels, LowStockMin)
+	}
+
+	return levels
+}
+
+// Threshold is the quantity of the level.
+func (t *StockThreshold) Threshold(level string) uint64 {
+	if level == LowStockMin {
+		return t.MinQuantity
+	}
+
+	return t.ReorderPoint
+}
+
+// Level is the lowest level available stock is below, empty if it is not
+// below the reorder point.
+func (t *StockThreshold) Level(available uint64) string {
+	switch {
+	case available < t.MinQuantity:
+		return LowStockMin
+	case available < t.ReorderPoint:
+		return LowStockReorder
+	}
+
+	return ""
+}
+
+func crossed(threshold, before, after uint64) bool {
+	return threshold > 0 && before >= threshold && after < threshold
+}
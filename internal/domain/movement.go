@@ -0,0 +1,76 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	MovementReserve        = "reserve"
+	MovementRelease        = "release"
+	MovementExpire         = "expire"
+	MovementFulfill        = "fulfill"
+	MovementTransferOut    = "transfer_out"
+	MovementTransferIn     = "transfer_in"
+	MovementDispatch       = "dispatch"
+	MovementReceive        = "receive"
+	MovementDispatchCancel = "dispatch_cancel"
+	MovementReturn         = "return"
+	MovementWriteOff       = "write_off"
+	MovementBucketMove     = "bucket_move"
+	MovementAdjust         = "adjust"
+	MovementAdd            = "add"
+	MovementDelete         = "delete"
+)
+
+const (
+	BucketAvailable   = "available"
+	BucketReserved    = "reserved"
+	BucketQuarantined = "quarantined"
+	BucketDamaged     = "damaged"
+)
+
+// SweeperRequestID marks movements made by the background reservation
+// sweeper rather than by a client request.
+const SweeperRequestID = "reservation-sweeper"
+
+// Movement is an immutable ledger entry, one per changed stock bucket of a
+// product in a warehouse. Balance is the bucket quantity after the change,
+// Reason is the reason code of moves between buckets and adjustments.
+type Movement struct {
+	ID          int64     `json:"id"`
+	Type        string    `json:"type"`
+	WarehouseID int64     `json:"warehouse_id"`
+	Code        string    `json:"code"`
+	Bucket      string    `json:"bucket"`
+	Delta       int64     `json:"delta"`
+	Balance     uint64    `json:"balance"`
+	Reason      string    `json:"reason,omitempty"`
+	RequestID   string    `json:"request_id"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type MovementFilter struct {
+	Code        string    `json:"code"`
+	WarehouseID int64     `json:"warehouse_id"`
+	From        time.Time `json:"from"`
+	To          time.Time `json:"to"`
+	Limit       uint64    `json:"limit"`
+}
+
+func (f *MovementFilter) Validate() error {
+	switch {
+	case f.WarehouseID < 0:
+		return fmt.Errorf("%w: warehouse_id must be positive", ErrValidationFailed)
+	case !f.From.IsZero() && !f.To.IsZero() && !f.From.Before(f.To):
+		return fmt.Errorf("%w: from must be before to", ErrValidationFailed)
+	case f.Limit > maxListLimit:
+		return fmt.Errorf("%w: limit must not exceed %d", ErrValidationFailed, maxListLimit)
+	}
+
+	if f.Limit == 0 {
+		f.Limit = defaultListLimit
+	}
+
+	return nil
+}
@@ -1,32 +1,175 @@
 package domain
 
+import "fmt"
+
+// Product lists its Lots, Bins and Buckets only when leftovers are broken
+// down by them.
 type Product struct {
-	Name     string `json:"name"`
-	Size     string `json:"size"`
-	Code     string `json:"code"`
-	Quantity uint64 `json:"quantity"`
+	Name     string        `json:"name"`
+	Size     string        `json:"size"`
+	Code     string        `json:"code"`
+	Quantity uint64        `json:"quantity"`
+	Lots     []LotQuantity `json:"lots,omitempty"`
+	Bins     []BinStock    `json:"bins,omitempty"`
+	Buckets  *StockBuckets `json:"buckets,omitempty"`
 }
 
+// WarehouseProduct is reserved from lots first-expired-first-out, expired
+// lots are skipped. Lot restricts the reservation to one lot number.
 type WarehouseProduct struct {
 	WarehouseID int64  `json:"warehouse_id"`
 	Code        string `json:"code"`
 	Quantity    uint64 `json:"quantity"`
-	Status      string `json:"status"`
+	Lot         string `json:"lot"`
+	OrderRef    string `json:"order_ref"`
+	RequestID   string `json:"request_id"`
 }
 
+// TransferProduct moves lots first-expired-first-out, or only the Lot number
+// if it is set. Lots and Bins are where the moved units were taken from, they
+// arrive unplaced.
 type TransferProduct struct {
-	WarehouseFromID int64  `json:"warehouse_from_id"`
-	WarehouseToID   int64  `json:"warehouse_to_id"`
-	Code            string `json:"code"`
-	Quantity        uint64 `json:"quantity"`
+	WarehouseFromID int64         `json:"warehouse_from_id"`
+	WarehouseToID   int64         `json:"warehouse_to_id"`
+	Code            string        `json:"code"`
+	Quantity        uint64        `json:"quantity"`
+	Lot             string        `json:"lot"`
+	RequestID       string        `json:"request_id"`
+	Lots            []LotQuantity `json:"lots,omitempty"`
+	Bins            []BinQuantity `json:"bins,omitempty"`
 }
 
+// AddProduct receives stock into Lot, or into the default lot if it is not
+// set. Stock is put into BinID, or left unplaced if it is 0.
 type AddProduct struct {
 	Code        string `json:"code"`
 	Quantity    uint64 `json:"quantity"`
 	WarehouseID int64  `json:"warehouse_id"`
+	Lot         *Lot   `json:"lot,omitempty"`
+	BinID       int64  `json:"bin_id"`
+	RequestID   string `json:"request_id"`
 }
 
-type DeleteProduct struct {
+type GetProduct struct {
+	Code string `json:"code"`
+}
+
+// UpdateProduct changes catalog fields only, empty fields are left as they
+// are. Quantity is changed by stock operations.
+type UpdateProduct struct {
 	Code string `json:"code"`
+	Name string `json:"name"`
+	Size string `json:"size"`
+}
+
+// ProductFilter matches name as a case-insensitive substring, size and code
+// exactly.
+type ProductFilter struct {
+	Name   string `json:"name"`
+	Size   string `json:"size"`
+	Code   string `json:"code"`
+	Limit  uint64 `json:"limit"`
+	Offset uint64 `json:"offset"`
+}
+
+type DeleteProduct struct {
+	Code      string `json:"code"`
+	RequestID string `json:"request_id"`
+}
+
+func (p *Product) Validate() error {
+	switch {
+	case p.Name == "":
+		return fmt.Errorf("%w: name is required", ErrValidationFailed)
+	case p.Size == "":
+		return fmt.Errorf("%w: size is required", ErrValidationFailed)
+	case p.Code == "":
+		return fmt.Errorf("%w: code is required", ErrValidationFailed)
+	}
+
+	return nil
+}
+
+func (wp *WarehouseProduct) Validate() error {
+	switch {
+	case wp.WarehouseID <= 0:
+		return fmt.Errorf("%w: warehouse_id must be positive", ErrValidationFailed)
+	case wp.Code == "":
+		return fmt.Errorf("%w: code is required", ErrValidationFailed)
+	case wp.Quantity == 0:
+		return fmt.Errorf("%w: quantity must be positive", ErrValidationFailed)
+	}
+
+	return nil
+}
+
+func (td *TransferProduct) Validate() error {
+	switch {
+	case td.WarehouseFromID <= 0 || td.WarehouseToID <= 0:
+		return fmt.Errorf("%w: warehouse ids must be positive", ErrValidationFailed)
+	case td.WarehouseFromID == td.WarehouseToID:
+		return fmt.Errorf("%w: can't transfer to the same warehouse", ErrValidationFailed)
+	case td.Code == "":
+		return fmt.Errorf("%w: code is required", ErrValidationFailed)
+	case td.Quantity == 0:
+		return fmt.Errorf("%w: quantity must be positive", ErrValidationFailed)
+	}
+
+	return nil
+}
+
+func (ad *AddProduct) Validate() error {
+	switch {
+	case ad.WarehouseID <= 0:
+		return fmt.Errorf("%w: warehouse_id must be positive", ErrValidationFailed)
+	case ad.Code == "":
+		return fmt.Errorf("%w: code is required", ErrValidationFailed)
+	case ad.Quantity == 0:
+		return fmt.Errorf("%w: quantity must be positive", ErrValidationFailed)
+	case ad.BinID < 0:
+		return fmt.Errorf("%w: bin_id must not be negative", ErrValidationFailed)
+	case ad.Lot != nil:
+		return ad.Lot.Validate()
+	}
+
+	return nil
+}
+
+func (gp *GetProduct) Validate() error {
+	if gp.Code == "" {
+		return fmt.Errorf("%w: code is required", ErrValidationFailed)
+	}
+
+	return nil
+}
+
+func (up *UpdateProduct) Validate() error {
+	switch {
+	case up.Code == "":
+		return fmt.Errorf("%w: code is required", ErrValidationFailed)
+	case up.Name == "" && up.Size == "":
+		return fmt.Errorf("%w: name or size is required", ErrValidationFailed)
+	}
+
+	return nil
+}
+
+func (f *ProductFilter) Validate() error {
+	if f.Limit > maxListLimit {
+		return fmt.Errorf("%w: limit must not exceed %d", ErrValidationFailed, maxListLimit)
+	}
+
+	if f.Limit == 0 {
+		f.Limit = defaultListLimit
+	}
+
+	return nil
+}
+
+func (dp *DeleteProduct) Validate() error {
+	if dp.Code == "" {
+		return fmt.Errorf("%w: code is required", ErrValidationFailed)
+	}
+
+	return nil
 }
@@ -0,0 +1,260 @@
+package domain
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+const (
+	PurchaseOrderOpen     = "open"
+	PurchaseOrderReceived = "received"
+	PurchaseOrderClosed   = "closed"
+)
+
+type Supplier struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+type SupplierFilter struct {
+	Limit  uint64 `json:"limit"`
+	Offset uint64 `json:"offset"`
+}
+
+// PurchaseOrder expects its lines from the supplier. It is received once
+// every line is, closing it gives up on what is still outstanding.
+type PurchaseOrder struct {
+	ID         int64               `json:"id"`
+	SupplierID int64               `json:"supplier_id"`
+	Reference  string              `json:"reference"`
+	Status     string              `json:"status"`
+	CreatedAt  time.Time           `json:"created_at"`
+	ClosedAt   *time.Time          `json:"closed_at"`
+	Lines      []PurchaseOrderLine `json:"lines"`
+}
+
+// PurchaseOrderLine is the quantity of a product expected in a warehouse.
+type PurchaseOrderLine struct {
+	ID          int64  `json:"id"`
+	WarehouseID int64  `json:"warehouse_id"`
+	Code        string `json:"code"`
+	Ordered     uint64 `json:"ordered"`
+	Received    uint64 `json:"received"`
+}
+
+type GetPurchaseOrder struct {
+	ID int64 `json:"id"`
+}
+
+// PurchaseReceipt receives stock against lines of an open order, each line
+// goes through Products.Add with its Lot and BinID. More than a line has
+// outstanding can't be received.
+type PurchaseReceipt struct {
+	OrderID   int64         `json:"order_id"`
+	Lines     []ReceiptLine `json:"lines"`
+	RequestID string        `json:"request_id"`
+}
+
+type ReceiptLine struct {
+	LineID   int64  `json:"line_id"`
+	Quantity uint64 `json:"quantity"`
+	Lot      *Lot   `json:"lot,omitempty"`
+	BinID    int64  `json:"bin_id"`
+}
+
+type ClosePurchaseOrder struct {
+	OrderID int64 `json:"order_id"`
+}
+
+// OutstandingLine is a line of an open order that is not fully received.
+type OutstandingLine struct {
+	OrderID    int64  `json:"order_id"`
+	SupplierID int64  `json:"supplier_id"`
+	Reference  string `json:"reference"`
+	PurchaseOrderLine
+	Outstanding uint64 `json:"outstanding"`
+}
+
+// OutstandingFilter narrows outstanding lines down, zero values match
+// everything.
+type OutstandingFilter struct {
+	SupplierID  int64  `json:"supplier_id"`
+	WarehouseID int64  `json:"warehouse_id"`
+	Code        string `json:"code"`
+	Limit       uint64 `json:"limit"`
+	Offset      uint64 `json:"offset"`
+}
+
+func (s *Supplier) Validate() error {
+	if s.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrValidationFailed)
+	}
+
+	return nil
+}
+
+func (f *SupplierFilter) Validate() error {
+	if f.Limit > maxListLimit {
+		return fmt.Errorf("%w: limit must not exceed %d", ErrValidationFailed, maxListLimit)
+	}
+
+	if f.Limit == 0 {
+		f.Limit = defaultListLimit
+	}
+
+	return nil
+}
+
+func (po *PurchaseOrder) Validate() error {
+	switch {
+	case po.SupplierID <= 0:
+		return fmt.Errorf("%w: supplier_id must be positive", ErrValidationFailed)
+	case len(po.Lines) == 0:
+		return fmt.Errorf("%w: lines are required", ErrValidationFailed)
+	}
+
+	for i, line := range po.Lines {
+		switch {
+		case line.WarehouseID <= 0:
+			return fmt.Errorf("%w: line %d: warehouse_id must be positive", ErrValidationFailed, i)
+		case line.Code == "":
+			return fmt.Errorf("%w: line %d: code is required", ErrValidationFailed, i)
+		case line.Ordered == 0:
+			return fmt.Errorf("%w: line %d: ordered must be positive", ErrValidationFailed, i)
+		}
+
+		same := func(l PurchaseOrderLine) bool { return l.WarehouseID == line.WarehouseID && l.Code == line.Code }
+		if slices.ContainsFunc(po.Lines[:i], same) {
+			return fmt.Errorf("%w: line %d: %s in warehouse %d is listed twice",
+				ErrValidationFailed, i, line.Code, line.WarehouseID)
+		}
+	}
+
+	po.Status = PurchaseOrderOpen
+	for i := range po.Lines {
+		po.Lines[i].Received = 0
+	}
+
+	return nil
+}
+
+func (gp *GetPurchaseOrder) Validate() error {
+	if gp.ID <= 0 {
+		return fmt.Errorf("%w: id must be positive", ErrValidationFailed)
+	}
+
+	return nil
+}
+
+func (pr *PurchaseReceipt) Validate() error {
+	switch {
+	case pr.OrderID <= 0:
+		return fmt.Errorf("%w: order_id must be positive", ErrValidationFailed)
+	case len(pr.Lines) == 0:
+		return fmt.Errorf("%w: lines are required", ErrValidationFailed)
+	}
+
+	for i, line := range pr.Lines {
+		switch {
+		case line.LineID <= 0:
+			return fmt.Errorf("%w: line %d: line_id must be positive", ErrValidationFailed, i)
+		case line.Quantity == 0:
+			return fmt.Errorf("%w: line %d: quantity must be positive", ErrValidationFailed, i)
+		case line.BinID < 0:
+			return fmt.Errorf("%w: line %d: bin_id must not be negative", ErrValidationFailed, i)
+		case line.Lot != nil:
+			if err := line.Lot.Validate(); err != nil {
+				return fmt.Errorf("line %d: %w", i, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (cp *ClosePurchaseOrder) Validate() error {
+	if cp.OrderID <= 0 {
+		return fmt.Errorf("%w: order_id must be positive", ErrValidationFailed)
+	}
+
+	return nil
+}
+
+func (f *OutstandingFilter) Validate() error {
+	switch {
+	case f.SupplierID < 0:
+		return fmt.Errorf("%w: supplier_id must be positive", ErrValidationFailed)
+	case f.WarehouseID < 0:
+		return fmt.Errorf("%w: warehouse_id must be positive", ErrValidationFailed)
+	case f.Limit > maxListLimit:
+		return fmt.Errorf("%w: limit must not exceed %d", ErrValidationFailed, maxListLimit)
+	}
+
+	if f.Limit == 0 {
+		f.Limit = defaultListLimit
+	}
+
+	return nil
+}
+
+func (l *PurchaseOrderLine) Outstanding() uint64 {
+	return l.Ordered - l.Received
+}
+
+// Receive counts the receipt against the lines of the order and returns the
+// stock to add for it. The order is received when nothing is outstanding.
+// Lines are changed in place, callers that share them pass a copy.
+func (po *PurchaseOrder) Receive(pr *PurchaseReceipt) ([]AddProduct, error) {
+	if po.Status != PurchaseOrderOpen {
+		return nil, fmt.Errorf("%w: open purchase order %d", ErrNotFound, po.ID)
+	}
+
+	adds := make([]AddProduct, 0, len(pr.Lines))
+	for _, received := range pr.Lines {
+		i := slices.IndexFunc(po.Lines, func(l PurchaseOrderLine) bool { return l.ID == received.LineID })
+		if i < 0 {
+			return nil, fmt.Errorf("%w: line %d of purchase order %d", ErrNotFound, received.LineID, po.ID)
+		}
+
+		line := &po.Lines[i]
+		if received.Quantity > line.Outstanding() {
+			return nil, fmt.Errorf("%w: line %d has %d outstanding, %d received",
+				ErrValidationFailed, line.ID, line.Outstanding(), received.Quantity)
+		}
+
+		line.Received += received.Quantity
+		adds = append(adds, AddProduct{
+			Code:        line.Code,
+			Quantity:    received.Quantity,
+			WarehouseID: line.WarehouseID,
+			Lot:         received.Lot,
+			BinID:       received.BinID,
+			RequestID:   pr.RequestID,
+		})
+	}
+
+	if !slices.ContainsFunc(po.Lines, func(l PurchaseOrderLine) bool { return l.Outstanding() > 0 }) {
+		po.Status = PurchaseOrderReceived
+	}
+
+	return adds, nil
+}
+
+// Outstanding returns the lines that are not fully received yet.
+func (po *PurchaseOrder) Outstanding() []OutstandingLine {
+	lines := make([]OutstandingLine, 0, len(po.Lines))
+	for _, line := range po.Lines {
+		if line.Outstanding() > 0 {
+			lines = append(lines, OutstandingLine{
+				OrderID:           po.ID,
+				SupplierID:        po.SupplierID,
+				Reference:         po.Reference,
+				PurchaseOrderLine: line,
+				Outstanding:       line.Outstanding(),
+			})
+		}
+	}
+
+	return lines
+}
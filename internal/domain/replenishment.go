@@ -0,0 +1,141 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+)
+
+// StockLevel is available stock of a product in a warehouse with its
+// thresholds.
+type StockLevel struct {
+	StockThreshold
+	Available uint64 `json:"available"`
+}
+
+// SuggestTransfers restores the reorder point of every product below it
+// with stock that other available warehouses have above theirs. Code and
+// WarehouseID narrow it down to one product and one receiving warehouse.
+// Transfers are only suggested unless Execute is set.
+type SuggestTransfers struct {
+	Code        string `json:"code"`
+	WarehouseID int64  `json:"warehouse_id"`
+	Execute     bool   `json:"execute"`
+	RequestID   string `json:"request_id"`
+}
+
+// TransferSuggestion lists the transfers and what they can't restore, Executed
+// is set if the transfers were made.
+type TransferSuggestion struct {
+	Transfers  []TransferProduct `json:"transfers"`
+	Shortfalls []StockShortfall  `json:"shortfalls"`
+	Executed   bool              `json:"executed"`
+}
+
+// StockShortfall is the quantity a warehouse stays below its reorder point.
+type StockShortfall struct {
+	WarehouseID int64  `json:"warehouse_id"`
+	Code        string `json:"code"`
+	Quantity    uint64 `json:"quantity"`
+}
+
+func (st *SuggestTransfers) Validate() error {
+	if st.WarehouseID < 0 {
+		return fmt.Errorf("%w: warehouse_id must be positive", ErrValidationFailed)
+	}
+
+	return nil
+}
+
+// PlanTransfers fills the largest needs first from the largest surpluses, so
+// a need is split across as few warehouses as it can be. If receiverID is set,
+// only that warehouse receives stock.
+func PlanTransfers(levels []StockLevel, receiverID int64, requestID string) TransferSuggestion {
+	suggestion := TransferSuggestion{
+		Transfers:  make([]TransferProduct, 0, BasicSliceLength),
+		Shortfalls: make([]StockShortfall, 0, BasicSliceLength),
+	}
+
+	byCode := make(map[string][]StockLevel, BasicSliceLength)
+	codes := make([]string, 0, BasicSliceLength)
+
+	for _, level := range levels {
+		if _, ok := byCode[level.Code]; !ok {
+			codes = append(codes, level.Code)
+		}
+
+		byCode[level.Code] = append(byCode[level.Code], level)
+	}
+
+	sort.Strings(codes)
+
+	for _, code := range codes {
+		needs, surpluses := needsAndSurpluses(byCode[code], receiverID)
+
+		for _, need := range needs {
+			for i := range surpluses {
+				if need.Quantity == 0 {
+					break
+				}
+
+				take := min(need.Quantity, surpluses[i].Quantity)
+				if take == 0 {
+					continue
+				}
+
+				need.Quantity -= take
+				surpluses[i].Quantity -= take
+
+				suggestion.Transfers = append(suggestion.Transfers, TransferProduct{
+					WarehouseFromID: surpluses[i].WarehouseID,
+					WarehouseToID:   need.WarehouseID,
+					Code:            code,
+					Quantity:        take,
+					RequestID:       requestID,
+				})
+			}
+
+			if need.Quantity > 0 {
+				suggestion.Shortfalls = append(suggestion.Shortfalls, need)
+			}
+		}
+	}
+
+	return suggestion
+}
+
+// needsAndSurpluses returns what warehouses need to reach their reorder
+// points and what they have above them, both largest first. A warehouse
+// without a reorder point has no target to keep, so it gives nothing.
+func needsAndSurpluses(levels []StockLevel, receiverID int64) ([]StockShortfall, []StockShortfall) {
+	needs := make([]StockShortfall, 0, len(levels))
+	surpluses := make([]StockShortfall, 0, len(levels))
+
+	for _, level := range levels {
+		switch {
+		case level.Available < level.ReorderPoint && (receiverID == 0 || level.WarehouseID == receiverID):
+			needs = append(needs, StockShortfall{
+				WarehouseID: level.WarehouseID,
+				Code:        level.Code,
+				Quantity:    level.ReorderPoint - level.Available,
+			})
+		case level.ReorderPoint > 0 && level.Available > level.ReorderPoint:
+			surpluses = append(surpluses, StockShortfall{
+				WarehouseID: level.WarehouseID,
+				Code:        level.Code,
+				Quantity:    level.Available - level.ReorderPoint,
+			})
+		}
+	}
+
+	for _, s := range [][]StockShortfall{needs, surpluses} {
+		sort.Slice(s, func(i, j int) bool {
+			if s[i].Quantity != s[j].Quantity {
+				return s[i].Quantity > s[j].Quantity
+			}
+
+			return s[i].WarehouseID < s[j].WarehouseID
+		})
+	}
+
+	return needs, surpluses
+}
@@ -0,0 +1,26 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPlanTransfers(t *testing.T) {
+	level := func(warehouseID int64, reorderPoint, available uint64) StockLevel {
+		return StockLevel{
+			StockThreshold: StockThreshold{WarehouseID: warehouseID, Code: "test", ReorderPoint: reorderPoint},
+			Available:      available,
+		}
+	}
+
+	// Warehouse 3 has no reorder point, none of its stock is a surplus.
+	suggestion := PlanTransfers([]StockLevel{level(1, 10, 2), level(2, 4, 7), level(3, 0, 50)}, 0, "")
+
+	expectedTransfers := []TransferProduct{{WarehouseFromID: 2, WarehouseToID: 1, Code: "test", Quantity: 3}}
+	expectedShortfalls := []StockShortfall{{WarehouseID: 1, Code: "test", Quantity: 5}}
+
+	if !reflect.DeepEqual(suggestion.Transfers, expectedTransfers) ||
+		!reflect.DeepEqual(suggestion.Shortfalls, expectedShortfalls) {
+		t.Fatalf("expected: %v and %v, got: %+v", expectedTransfers, expectedShortfalls, suggestion)
+	}
+}
@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	ReservationActive    = "active"
+	ReservationCanceled  = "canceled"
+	ReservationExpired   = "expired"
+	ReservationFulfilled = "fulfilled"
+)
+
+// Reservation holds units of the Lots it was made from, Bins are where they
+// are, the rest is unplaced. Both are filled by operations that change the
+// reserved stock.
+type Reservation struct {
+	ID          int64         `json:"id"`
+	OrderRef    string        `json:"order_ref"`
+	WarehouseID int64         `json:"warehouse_id"`
+	Code        string        `json:"code"`
+	Quantity    uint64        `json:"quantity"`
+	Status      string        `json:"status"`
+	CreatedAt   time.Time     `json:"created_at"`
+	ExpiresAt   time.Time     `json:"expires_at"`
+	Lots        []LotQuantity `json:"lots,omitempty"`
+	Bins        []BinQuantity `json:"bins,omitempty"`
+	Lot         string        `json:"-"`
+	RequestID   string        `json:"-"`
+}
+
+type CancelReservation struct {
+	ReservationID int64  `json:"reservation_id"`
+	RequestID     string `json:"request_id"`
+}
+
+func NewReservation(wp *WarehouseProduct, expiresAt time.Time) Reservation {
+	return Reservation{
+		OrderRef:    wp.OrderRef,
+		WarehouseID: wp.WarehouseID,
+		Code:        wp.Code,
+		Quantity:    wp.Quantity,
+		Status:      ReservationActive,
+		ExpiresAt:   expiresAt,
+		Lot:         wp.Lot,
+		RequestID:   wp.RequestID,
+	}
+}
+
+func (cr *CancelReservation) Validate() error {
+	if cr.ReservationID <= 0 {
+		return fmt.Errorf("%w: reservation_id must be positive", ErrValidationFailed)
+	}
+
+	return nil
+}
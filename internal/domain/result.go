@@ -0,0 +1,25 @@
+package domain
+
+type ItemResult[T any] struct {
+	Index   int       `json:"index"`
+	Success bool      `json:"success"`
+	Item    *T        `json:"item,omitempty"`
+	Code    ErrorCode `json:"code,omitempty"`
+	Error   string    `json:"error,omitempty"`
+}
+
+func NewItemResult[T any](index int, item T) ItemResult[T] {
+	return ItemResult[T]{
+		Index:   index,
+		Success: true,
+		Item:    &item,
+	}
+}
+
+func NewItemError[T any](index int, err error) ItemResult[T] {
+	return ItemResult[T]{
+		Index: index,
+		Code:  CodeOf(err),
+		Error: err.Error(),
+	}
+}
@@ -0,0 +1,268 @@
+package domain
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+const (
+	ReturnOpen     = "open"
+	ReturnReceived = "received"
+)
+
+const (
+	DispositionRestock    = "restock"
+	DispositionQuarantine = "quarantine"
+	DispositionWriteOff   = "write_off"
+)
+
+// Return is a return merchandise authorization for lines of a shipment. It is
+// received once every line is.
+type Return struct {
+	ID         int64        `json:"id"`
+	ShipmentID int64        `json:"shipment_id"`
+	Reason     string       `json:"reason"`
+	Status     string       `json:"status"`
+	CreatedAt  time.Time    `json:"created_at"`
+	Lines      []ReturnLine `json:"lines"`
+}
+
+// ReturnLine is the quantity of a shipped reservation authorized for return,
+// and how much of it came back to each disposition.
+type ReturnLine struct {
+	ID            int64  `json:"id"`
+	ReservationID int64  `json:"reservation_id"`
+	WarehouseID   int64  `json:"warehouse_id"`
+	Code          string `json:"code"`
+	Quantity      uint64 `json:"quantity"`
+	Restocked     uint64 `json:"restocked"`
+	Quarantined   uint64 `json:"quarantined"`
+	WrittenOff    uint64 `json:"written_off"`
+}
+
+// CreateReturn authorizes a return against a shipment or a shipped
+// reservation. Without lines everything shipped that is not authorized yet
+// is, a line without quantity does the same for its reservation.
+type CreateReturn struct {
+	ShipmentID    int64        `json:"shipment_id"`
+	ReservationID int64        `json:"reservation_id"`
+	Reason        string       `json:"reason"`
+	Lines         []ReturnLine `json:"lines"`
+}
+
+type GetReturn struct {
+	ID int64 `json:"id"`
+}
+
+// ReturnReceipt receives returned units against lines of an open return,
+// each with its disposition. Restocked units go through Products.Add with
+// Lot and BinID, the other dispositions take neither.
+type ReturnReceipt struct {
+	ReturnID  int64               `json:"return_id"`
+	Lines     []ReturnReceiptLine `json:"lines"`
+	RequestID string              `json:"request_id"`
+}
+
+type ReturnReceiptLine struct {
+	LineID      int64  `json:"line_id"`
+	Quantity    uint64 `json:"quantity"`
+	Disposition string `json:"disposition"`
+	Lot         *Lot   `json:"lot,omitempty"`
+	BinID       int64  `json:"bin_id"`
+}
+
+// ReturnedStock is stock a return receipt brings back to the warehouse of
+// the shipment.
+type ReturnedStock struct {
+	AddProduct
+	Disposition string
+}
+
+func (cr *CreateReturn) Validate() error {
+	switch {
+	case cr.ShipmentID < 0, cr.ReservationID < 0:
+		return fmt.Errorf("%w: shipment_id and reservation_id must be positive", ErrValidationFailed)
+	case (cr.ShipmentID == 0) == (cr.ReservationID == 0):
+		return fmt.Errorf("%w: either shipment_id or reservation_id is required", ErrValidationFailed)
+	}
+
+	for i, line := range cr.Lines {
+		switch {
+		case line.ReservationID <= 0:
+			return fmt.Errorf("%w: line %d: reservation_id must be positive", ErrValidationFailed, i)
+		case cr.ReservationID != 0 && line.ReservationID != cr.ReservationID:
+			return fmt.Errorf("%w: line %d: reservation %d is not the returned one", ErrValidationFailed, i,
+				line.ReservationID)
+		}
+
+		same := func(l ReturnLine) bool { return l.ReservationID == line.ReservationID }
+		if slices.ContainsFunc(cr.Lines[:i], same) {
+			return fmt.Errorf("%w: line %d: reservation %d is listed twice", ErrValidationFailed, i, line.ReservationID)
+		}
+	}
+
+	return nil
+}
+
+func (gr *GetReturn) Validate() error {
+	if gr.ID <= 0 {
+		return fmt.Errorf("%w: id must be positive", ErrValidationFailed)
+	}
+
+	return nil
+}
+
+func (rr *ReturnReceipt) Validate() error {
+	switch {
+	case rr.ReturnID <= 0:
+		return fmt.Errorf("%w: return_id must be positive", ErrValidationFailed)
+	case len(rr.Lines) == 0:
+		return fmt.Errorf("%w: lines are required", ErrValidationFailed)
+	}
+
+	for i, line := range rr.Lines {
+		switch {
+		case line.LineID <= 0:
+			return fmt.Errorf("%w: line %d: line_id must be positive", ErrValidationFailed, i)
+		case line.Quantity == 0:
+			return fmt.Errorf("%w: line %d: quantity must be positive", ErrValidationFailed, i)
+		case line.BinID < 0:
+			return fmt.Errorf("%w: line %d: bin_id must not be negative", ErrValidationFailed, i)
+		}
+
+		switch line.Disposition {
+		case DispositionRestock:
+			if line.Lot == nil {
+				continue
+			}
+
+			if err := line.Lot.Validate(); err != nil {
+				return fmt.Errorf("line %d: %w", i, err)
+			}
+		case DispositionQuarantine, DispositionWriteOff:
+			if line.Lot != nil || line.BinID != 0 {
+				return fmt.Errorf("%w: line %d: lot and bin_id are for restock only", ErrValidationFailed, i)
+			}
+		default:
+			return fmt.Errorf("%w: line %d: disposition must be %s, %s or %s", ErrValidationFailed, i,
+				DispositionRestock, DispositionQuarantine, DispositionWriteOff)
+		}
+	}
+
+	return nil
+}
+
+func (l *ReturnLine) Received() uint64 {
+	return l.Restocked + l.Quarantined + l.WrittenOff
+}
+
+func (l *ReturnLine) Outstanding() uint64 {
+	return l.Quantity - l.Received()
+}
+
+// NewReturn authorizes the lines of cr against the shipped lines, returned is
+// the quantity of each reservation authorized by earlier returns.
+func NewReturn(cr *CreateReturn, shipmentID int64, shipped []ShipmentLine, returned map[int64]uint64) (Return, error) {
+	ret := Return{ShipmentID: shipmentID, Reason: cr.Reason, Status: ReturnOpen}
+
+	if len(shipped) == 0 {
+		if cr.ReservationID != 0 {
+			return ret, fmt.Errorf("%w: shipped reservation %d", ErrNotFound, cr.ReservationID)
+		}
+
+		return ret, fmt.Errorf("%w: shipment %d", ErrNotFound, cr.ShipmentID)
+	}
+
+	lines := cr.Lines
+	if len(lines) == 0 {
+		lines = make([]ReturnLine, 0, len(shipped))
+		for _, sl := range shipped {
+			if sl.Quantity > returned[sl.ReservationID] {
+				lines = append(lines, ReturnLine{ReservationID: sl.ReservationID})
+			}
+		}
+
+		if len(lines) == 0 {
+			return ret, fmt.Errorf("%w: everything shipped in shipment %d is returned already",
+				ErrValidationFailed, shipmentID)
+		}
+	}
+
+	ret.Lines = make([]ReturnLine, 0, len(lines))
+	for _, line := range lines {
+		i := slices.IndexFunc(shipped, func(sl ShipmentLine) bool { return sl.ReservationID == line.ReservationID })
+		if i < 0 {
+			return ret, fmt.Errorf("%w: reservation %d in shipment %d", ErrNotFound, line.ReservationID, shipmentID)
+		}
+
+		left := shipped[i].Quantity - min(returned[line.ReservationID], shipped[i].Quantity)
+		if line.Quantity == 0 {
+			line.Quantity = left
+		}
+
+		if line.Quantity == 0 || line.Quantity > left {
+			return ret, fmt.Errorf("%w: reservation %d has %d shipped and not returned, %d to return",
+				ErrValidationFailed, line.ReservationID, left, line.Quantity)
+		}
+
+		ret.Lines = append(ret.Lines, ReturnLine{
+			ReservationID: line.ReservationID,
+			WarehouseID:   shipped[i].WarehouseID,
+			Code:          shipped[i].Code,
+			Quantity:      line.Quantity,
+		})
+	}
+
+	return ret, nil
+}
+
+// Receive counts the receipt against the lines of the return and returns the
+// stock it brings back. The return is received when nothing is outstanding.
+// Lines are changed in place, callers that share them pass a copy.
+func (r *Return) Receive(rr *ReturnReceipt) ([]ReturnedStock, error) {
+	if r.Status != ReturnOpen {
+		return nil, fmt.Errorf("%w: open return %d", ErrNotFound, r.ID)
+	}
+
+	stock := make([]ReturnedStock, 0, len(rr.Lines))
+	for _, received := range rr.Lines {
+		i := slices.IndexFunc(r.Lines, func(l ReturnLine) bool { return l.ID == received.LineID })
+		if i < 0 {
+			return nil, fmt.Errorf("%w: line %d of return %d", ErrNotFound, received.LineID, r.ID)
+		}
+
+		line := &r.Lines[i]
+		if received.Quantity > line.Outstanding() {
+			return nil, fmt.Errorf("%w: line %d has %d outstanding, %d received",
+				ErrValidationFailed, line.ID, line.Outstanding(), received.Quantity)
+		}
+
+		switch received.Disposition {
+		case DispositionRestock:
+			line.Restocked += received.Quantity
+		case DispositionQuarantine:
+			line.Quarantined += received.Quantity
+		default:
+			line.WrittenOff += received.Quantity
+		}
+
+		stock = append(stock, ReturnedStock{
+			AddProduct: AddProduct{
+				Code:        line.Code,
+				Quantity:    received.Quantity,
+				WarehouseID: line.WarehouseID,
+				Lot:         received.Lot,
+				BinID:       received.BinID,
+				RequestID:   rr.RequestID,
+			},
+			Disposition: received.Disposition,
+		})
+	}
+
+	if !slices.ContainsFunc(r.Lines, func(l ReturnLine) bool { return l.Outstanding() > 0 }) {
+		r.Status = ReturnReceived
+	}
+
+	return stock, nil
+}
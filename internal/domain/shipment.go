@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+type Fulfillment struct {
+	ReservationIDs []int64 `json:"reservation_ids"`
+	RequestID      string  `json:"request_id"`
+}
+
+type Shipment struct {
+	ID        int64          `json:"id"`
+	CreatedAt time.Time      `json:"created_at"`
+	Lines     []ShipmentLine `json:"lines"`
+}
+
+type ShipmentLine struct {
+	ReservationID int64         `json:"reservation_id"`
+	OrderRef      string        `json:"order_ref"`
+	WarehouseID   int64         `json:"warehouse_id"`
+	Code          string        `json:"code"`
+	Quantity      uint64        `json:"quantity"`
+	Lots          []LotQuantity `json:"lots,omitempty"`
+	Bins          []BinQuantity `json:"bins,omitempty"`
+}
+
+func (f *Fulfillment) Validate() error {
+	if len(f.ReservationIDs) == 0 {
+		return fmt.Errorf("%w: reservation_ids are required", ErrValidationFailed)
+	}
+
+	seen := make(map[int64]struct{}, len(f.ReservationIDs))
+	for _, id := range f.ReservationIDs {
+		if id <= 0 {
+			return fmt.Errorf("%w: reservation_id must be positive", ErrValidationFailed)
+		}
+
+		if _, ok := seen[id]; ok {
+			return fmt.Errorf("%w: reservation %d is listed twice", ErrValidationFailed, id)
+		}
+		seen[id] = struct{}{}
+	}
+
+	return nil
+}
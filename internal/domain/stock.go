@@ -0,0 +1,117 @@
+package domain
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Reason codes of moves between stock buckets.
+const (
+	ReasonQAHold    = "qa_hold"
+	ReasonQARelease = "qa_release"
+	ReasonDamage    = "damage"
+	ReasonRecall    = "recall"
+	ReasonRepair    = "repair"
+)
+
+var bucketMoveReasons = []string{ReasonQAHold, ReasonQARelease, ReasonDamage, ReasonRecall, ReasonRepair}
+
+// movableBuckets are the buckets stock can be moved between. Reserved stock
+// belongs to reservations and is changed by them only.
+var movableBuckets = []string{BucketAvailable, BucketQuarantined, BucketDamaged}
+
+// StockBuckets is the stock of a product in a warehouse by bucket. Only
+// available stock can be reserved, quarantined and damaged stock is owned
+// but kept out of lots, bins and low stock.
+type StockBuckets struct {
+	Available   uint64 `json:"available"`
+	Reserved    uint64 `json:"reserved"`
+	Quarantined uint64 `json:"quarantined"`
+	Damaged     uint64 `json:"damaged"`
+}
+
+// BucketMove moves stock of a product in a warehouse from one bucket to
+// another. Stock leaves available like a transfer does, from lots
+// first-expired-first-out or only the Lot number if it is set, and from bins.
+// Lots and Bins are where it was taken from. Stock that comes back to
+// available goes unplaced into the default lot.
+type BucketMove struct {
+	WarehouseID int64         `json:"warehouse_id"`
+	Code        string        `json:"code"`
+	From        string        `json:"from"`
+	To          string        `json:"to"`
+	Quantity    uint64        `json:"quantity"`
+	Reason      string        `json:"reason"`
+	Lot         string        `json:"lot"`
+	RequestID   string        `json:"request_id"`
+	Lots        []LotQuantity `json:"lots,omitempty"`
+	Bins        []BinQuantity `json:"bins,omitempty"`
+}
+
+func (m *BucketMove) Validate() error {
+	switch {
+	case m.WarehouseID <= 0:
+		return fmt.Errorf("%w: warehouse_id must be positive", ErrValidationFailed)
+	case m.Code == "":
+		return fmt.Errorf("%w: code is required", ErrValidationFailed)
+	case m.Quantity == 0:
+		return fmt.Errorf("%w: quantity must be positive", ErrValidationFailed)
+	case !slices.Contains(movableBuckets, m.From), !slices.Contains(movableBuckets, m.To):
+		return fmt.Errorf("%w: from and to must be %s, %s or %s", ErrValidationFailed,
+			BucketAvailable, BucketQuarantined, BucketDamaged)
+	case m.From == m.To:
+		return fmt.Errorf("%w: can't move to the same bucket", ErrValidationFailed)
+	case !slices.Contains(bucketMoveReasons, m.Reason):
+		return fmt.Errorf("%w: reason must be one of %v", ErrValidationFailed, bucketMoveReasons)
+	case m.Lot != "" && m.From != BucketAvailable:
+		return fmt.Errorf("%w: lot is for moves from %s only", ErrValidationFailed, BucketAvailable)
+	}
+
+	return nil
+}
+
+// InsufficientStockError is returned when a stock bucket can't be decreased
+// by the requested quantity. It matches ErrInsufficientStock.
+type InsufficientStockError struct {
+	WarehouseID int64
+	Code        string
+	Bucket      string
+	Requested   uint64
+	Available   uint64
+}
+
+func (e *InsufficientStockError) Error() string {
+	return fmt.Sprintf("%s: not enough quantity: %d, in warehouse: %d. %s: %d",
+		ErrInsufficientStock, e.Requested, e.WarehouseID, e.Bucket, e.Available)
+}
+
+func (e *InsufficientStockError) Unwrap() error {
+	return ErrInsufficientStock
+}
+
+// StockShortage checks a change of stock buckets against the quantities
+// there are, it returns nil if neither bucket goes negative.
+func StockShortage(warehouseID int64, code string, available, reserved uint64, availableDelta, reservedDelta int64) error {
+	buckets := []struct {
+		name     string
+		quantity uint64
+		delta    int64
+	}{
+		{BucketAvailable, available, availableDelta},
+		{BucketReserved, reserved, reservedDelta},
+	}
+
+	for _, b := range buckets {
+		if b.delta < 0 && uint64(-b.delta) > b.quantity {
+			return &InsufficientStockError{
+				WarehouseID: warehouseID,
+				Code:        code,
+				Bucket:      b.name,
+				Requested:   uint64(-b.delta),
+				Available:   b.quantity,
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,121 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	TransferInTransit = "in_transit"
+	TransferReceived  = "received"
+	TransferCanceled  = "canceled"
+)
+
+// TransitTransfer is stock dispatched from WarehouseFromID that is in neither
+// warehouse until it is received or the transfer is canceled. Lots are the
+// lots still on the way, Bins are where Dispatch picked the units from.
+type TransitTransfer struct {
+	ID              int64         `json:"id"`
+	WarehouseFromID int64         `json:"warehouse_from_id"`
+	WarehouseToID   int64         `json:"warehouse_to_id"`
+	Code            string        `json:"code"`
+	Quantity        uint64        `json:"quantity"`
+	Received        uint64        `json:"received"`
+	Status          string        `json:"status"`
+	CreatedAt       time.Time     `json:"created_at"`
+	Lots            []LotQuantity `json:"lots,omitempty"`
+	Bins            []BinQuantity `json:"bins,omitempty"`
+}
+
+// ReceiveTransfer confirms the arrival of Quantity units, of everything still
+// in transit if it is 0. The transfer stays open until all units arrive.
+type ReceiveTransfer struct {
+	TransferID int64  `json:"transfer_id"`
+	Quantity   uint64 `json:"quantity"`
+	RequestID  string `json:"request_id"`
+}
+
+// CancelTransfer returns the units still in transit to the source warehouse.
+type CancelTransfer struct {
+	TransferID int64  `json:"transfer_id"`
+	RequestID  string `json:"request_id"`
+}
+
+// TransferFilter lists open transfers, WarehouseID matches either end.
+type TransferFilter struct {
+	WarehouseID int64  `json:"warehouse_id"`
+	Code        string `json:"code"`
+	Limit       uint64 `json:"limit"`
+	Offset      uint64 `json:"offset"`
+}
+
+func NewTransitTransfer(td *TransferProduct) TransitTransfer {
+	return TransitTransfer{
+		WarehouseFromID: td.WarehouseFromID,
+		WarehouseToID:   td.WarehouseToID,
+		Code:            td.Code,
+		Quantity:        td.Quantity,
+		Status:          TransferInTransit,
+	}
+}
+
+func (rt *ReceiveTransfer) Validate() error {
+	if rt.TransferID <= 0 {
+		return fmt.Errorf("%w: transfer_id must be positive", ErrValidationFailed)
+	}
+
+	return nil
+}
+
+func (ct *CancelTransfer) Validate() error {
+	if ct.TransferID <= 0 {
+		return fmt.Errorf("%w: transfer_id must be positive", ErrValidationFailed)
+	}
+
+	return nil
+}
+
+func (f *TransferFilter) Validate() error {
+	switch {
+	case f.WarehouseID < 0:
+		return fmt.Errorf("%w: warehouse_id must be positive", ErrValidationFailed)
+	case f.Limit > maxListLimit:
+		return fmt.Errorf("%w: limit must not exceed %d", ErrValidationFailed, maxListLimit)
+	}
+
+	if f.Limit == 0 {
+		f.Limit = defaultListLimit
+	}
+
+	return nil
+}
+
+// InTransit is the quantity that has neither arrived nor been canceled.
+func (t *TransitTransfer) InTransit() uint64 {
+	if t.Status != TransferInTransit {
+		return 0
+	}
+
+	return t.Quantity - t.Received
+}
+
+// Receive counts quantity as arrived, all units in transit if it is 0, and
+// closes the transfer when nothing is left on the way.
+func (t *TransitTransfer) Receive(quantity uint64) (uint64, error) {
+	inTransit := t.InTransit()
+	if quantity == 0 {
+		quantity = inTransit
+	}
+
+	if quantity > inTransit {
+		return 0, fmt.Errorf("%w: transfer %d has %d units in transit, %d received",
+			ErrValidationFailed, t.ID, inTransit, quantity)
+	}
+
+	t.Received += quantity
+	if t.Received == t.Quantity {
+		t.Status = TransferReceived
+	}
+
+	return quantity, nil
+}
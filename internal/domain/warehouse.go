@@ -1,10 +1,191 @@
 package domain
 
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Warehouse is archived instead of being deleted, so its stock history stays
+// consistent. Archived warehouses are unavailable and hidden from the list.
 type Warehouse struct {
-	Name         string `json:"name"`
-	Availability bool   `json:"availability"`
+	ID           int64      `json:"id"`
+	Name         string     `json:"name"`
+	Availability bool       `json:"availability"`
+	ArchivedAt   *time.Time `json:"archived_at,omitempty"`
 }
 
+// GetFromWarehouse breaks every product quantity down by lot if ByLot is
+// set, and by bin with the reserved units there if ByBin is set. AllBuckets
+// lists products with stock in any bucket, not only available, with every
+// bucket.
 type GetFromWarehouse struct {
 	WarehouseID int64 `json:"warehouse_id"`
+	ByLot       bool  `json:"by_lot"`
+	ByBin       bool  `json:"by_bin"`
+	AllBuckets  bool  `json:"all_buckets"`
+}
+
+type GetWarehouse struct {
+	ID int64 `json:"id"`
+}
+
+type WarehouseFilter struct {
+	IncludeArchived bool   `json:"include_archived"`
+	Limit           uint64 `json:"limit"`
+	Offset          uint64 `json:"offset"`
+}
+
+type UpdateWarehouse struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+// SetAvailability turns a warehouse on or off. Turning it off is refused while
+// it has active reservations, unless Force is set: the availability trigger
+// blocks every stock change there, so those reservations can't be fulfilled or
+// canceled until the warehouse is available again.
+type SetAvailability struct {
+	ID           int64 `json:"id"`
+	Availability bool  `json:"availability"`
+	Force        bool  `json:"force"`
+}
+
+type AvailabilityChange struct {
+	Warehouse        Warehouse     `json:"warehouse"`
+	Applied          bool          `json:"applied"`
+	OpenReservations []Reservation `json:"open_reservations"`
+}
+
+// Decommission empties a warehouse and archives it. Plan lines are
+// transferred first, the rest of available stock goes to TargetID.
+type Decommission struct {
+	WarehouseID int64              `json:"warehouse_id"`
+	TargetID    int64              `json:"target_id"`
+	Plan        []DecommissionLine `json:"plan"`
+	RequestID   string             `json:"request_id"`
+}
+
+type DecommissionLine struct {
+	WarehouseToID int64  `json:"warehouse_to_id"`
+	Code          string `json:"code"`
+	Quantity      uint64 `json:"quantity"`
+}
+
+type DecommissionResult struct {
+	Warehouse Warehouse         `json:"warehouse"`
+	Transfers []TransferProduct `json:"transfers"`
+}
+
+func (w *Warehouse) Validate() error {
+	if w.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrValidationFailed)
+	}
+
+	return nil
+}
+
+func (gw *GetWarehouse) Validate() error {
+	if gw.ID <= 0 {
+		return fmt.Errorf("%w: id must be positive", ErrValidationFailed)
+	}
+
+	return nil
+}
+
+func (f *WarehouseFilter) Validate() error {
+	if f.Limit > maxListLimit {
+		return fmt.Errorf("%w: limit must not exceed %d", ErrValidationFailed, maxListLimit)
+	}
+
+	if f.Limit == 0 {
+		f.Limit = defaultListLimit
+	}
+
+	return nil
+}
+
+func (uw *UpdateWarehouse) Validate() error {
+	switch {
+	case uw.ID <= 0:
+		return fmt.Errorf("%w: id must be positive", ErrValidationFailed)
+	case uw.Name == "":
+		return fmt.Errorf("%w: name is required", ErrValidationFailed)
+	}
+
+	return nil
+}
+
+func (sa *SetAvailability) Validate() error {
+	if sa.ID <= 0 {
+		return fmt.Errorf("%w: id must be positive", ErrValidationFailed)
+	}
+
+	return nil
+}
+
+func (d *Decommission) Validate() error {
+	switch {
+	case d.WarehouseID <= 0:
+		return fmt.Errorf("%w: warehouse_id must be positive", ErrValidationFailed)
+	case d.TargetID < 0:
+		return fmt.Errorf("%w: target_id must be positive", ErrValidationFailed)
+	case d.TargetID == d.WarehouseID:
+		return fmt.Errorf("%w: can't transfer to the same warehouse", ErrValidationFailed)
+	case d.TargetID == 0 && len(d.Plan) == 0:
+		return fmt.Errorf("%w: target_id or plan is required", ErrValidationFailed)
+	}
+
+	for i, line := range d.Plan {
+		td := line.Transfer(d)
+		if err := td.Validate(); err != nil {
+			return fmt.Errorf("plan line %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+func (line *DecommissionLine) Transfer(d *Decommission) TransferProduct {
+	return TransferProduct{
+		WarehouseFromID: d.WarehouseID,
+		WarehouseToID:   line.WarehouseToID,
+		Code:            line.Code,
+		Quantity:        line.Quantity,
+		RequestID:       d.RequestID,
+	}
+}
+
+// DecommissionBlockers are the ids of what refers to a warehouse and could
+// neither be finished nor undone once it is archived, because an archived
+// warehouse takes no stock changes.
+type DecommissionBlockers struct {
+	Transfers      []int64
+	PurchaseOrders []int64
+	Adjustments    []int64
+	Counts         []int64
+}
+
+// Err lists the blockers of the warehouse, it is nil if there are none.
+func (b *DecommissionBlockers) Err(warehouseID int64) error {
+	blockers := make([]string, 0, 4)
+	for _, kind := range []struct {
+		name string
+		ids  []int64
+	}{
+		{"transfers in transit", b.Transfers},
+		{"open purchase orders", b.PurchaseOrders},
+		{"pending adjustments", b.Adjustments},
+		{"open counts", b.Counts},
+	} {
+		if len(kind.ids) > 0 {
+			blockers = append(blockers, fmt.Sprintf("%s %v", kind.name, kind.ids))
+		}
+	}
+
+	if len(blockers) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("%w: warehouse %d has %s", ErrValidationFailed, warehouseID, strings.Join(blockers, ", "))
 }
@@ -0,0 +1,33 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// Args carries method params together with the context of the request,
+// net/rpc has no other way to hand a context to a method.
+type Args[T any] struct {
+	ctx    context.Context //nolint:containedctx
+	Params T
+}
+
+func (a *Args[T]) Context() context.Context {
+	if a.ctx == nil {
+		return context.Background()
+	}
+
+	return a.ctx
+}
+
+func (a *Args[T]) setContext(ctx context.Context) {
+	a.ctx = ctx
+}
+
+func (a *Args[T]) UnmarshalJSON(data []byte) error {
+	return json.Unmarshal(data, &a.Params)
+}
+
+type contextSetter interface {
+	setContext(ctx context.Context)
+}
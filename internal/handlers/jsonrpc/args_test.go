@@ -0,0 +1,29 @@
+package jsonrpc
+
+import (
+	"context"
+	"testing"
+)
+
+func argsOf[T any](params T) Args[T] {
+	return Args[T]{Params: params}
+}
+
+func TestArgsContext(t *testing.T) {
+	var args Args[int]
+	if args.Context() != context.Background() {
+		t.Fatalf("expected background context without a request context")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	codec := &serverCodec{ctx: ctx, request: &serverRequest{Params: []byte(`[7]`)}}
+	if err := codec.ReadRequestBody(&args); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if args.Context() != ctx || args.Params != 7 {
+		t.Fatalf("expected: %v and 7, got: %v and %d", ctx, args.Context(), args.Params)
+	}
+}
@@ -0,0 +1,209 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/rpc"
+
+	"github.com/akrovv/warehouse/internal/domain"
+)
+
+const version = "2.0"
+
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+	codeInternalError  = -32603
+	codeServerError    = -32000
+)
+
+var errorMessages = map[int]string{
+	codeParseError:     "Parse error",
+	codeInvalidRequest: "Invalid Request",
+	codeMethodNotFound: "Method not found",
+	codeInvalidParams:  "Invalid params",
+	codeInternalError:  "Internal error",
+}
+
+type serverRequest struct {
+	Version string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+	ID      json.RawMessage `json:"id"`
+}
+
+type serverError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    string `json:"data,omitempty"`
+}
+
+type serverResponse struct {
+	Version string          `json:"jsonrpc"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *serverError    `json:"error,omitempty"`
+	ID      json.RawMessage `json:"id"`
+}
+
+func newErrorResponse(id json.RawMessage, code int, data string) *serverResponse {
+	return &serverResponse{
+		Version: version,
+		Error: &serverError{
+			Code:    code,
+			Message: errorMessages[code],
+			Data:    data,
+		},
+		ID: id,
+	}
+}
+
+func (r *serverRequest) validate() error {
+	if r.Version != version {
+		return fmt.Errorf("unsupported jsonrpc version: %q", r.Version)
+	}
+
+	if r.Method == "" {
+		return errors.New("method is required")
+	}
+
+	if r.ID != nil {
+		switch r.ID[0] {
+		case '{', '[', 't', 'f':
+			return errors.New("id must be a string, number or null")
+		}
+	}
+
+	if params := bytes.TrimSpace(r.Params); len(params) > 0 {
+		switch params[0] {
+		case '[', '{', 'n':
+		default:
+			return errors.New("params must be an array or an object")
+		}
+	}
+
+	return nil
+}
+
+func (r *serverRequest) isNotification() bool {
+	return r.ID == nil
+}
+
+// decodeParams accepts both positional params, where the single argument of
+// the method is the first element of the array, and by-name params, where the
+// object itself is the argument.
+func (r *serverRequest) decodeParams(x any) error {
+	params := bytes.TrimSpace(r.Params)
+	if len(params) == 0 || bytes.Equal(params, []byte("null")) {
+		return nil
+	}
+
+	if params[0] == '{' {
+		return json.Unmarshal(params, x)
+	}
+
+	var positional []json.RawMessage
+	if err := json.Unmarshal(params, &positional); err != nil {
+		return err
+	}
+
+	if len(positional) != 1 {
+		return fmt.Errorf("expected 1 positional parameter, got %d", len(positional))
+	}
+
+	return json.Unmarshal(positional[0], x)
+}
+
+// serverCodec serves exactly one already parsed request through rpc.Server
+// and keeps the response instead of writing it to a connection.
+type serverCodec struct {
+	ctx            context.Context //nolint:containedctx
+	request        *serverRequest
+	response       *serverResponse
+	err            error
+	methodNotFound bool
+	invalidParams  bool
+}
+
+type codecKey struct{}
+
+// serviceError wraps the error of a method with a single result. rpc.Server
+// passes only the message of the error on, so the error itself is kept in
+// the codec of the request for its code.
+func serviceError(ctx context.Context, method string, err error) error {
+	if c, ok := ctx.Value(codecKey{}).(*serverCodec); ok {
+		c.err = err
+	}
+
+	return fmt.Errorf("service.%s returned: %w", method, err)
+}
+
+func (c *serverCodec) ReadRequestHeader(r *rpc.Request) error {
+	r.ServiceMethod = c.request.Method
+	r.Seq = 0
+	return nil
+}
+
+func (c *serverCodec) ReadRequestBody(x any) error {
+	// rpc.Server discards the body with a nil argument only when it can't
+	// find the requested method.
+	if x == nil {
+		c.methodNotFound = true
+		return nil
+	}
+
+	if args, ok := x.(contextSetter); ok {
+		args.setContext(c.ctx)
+	}
+
+	if err := c.request.decodeParams(x); err != nil {
+		c.invalidParams = true
+		return err
+	}
+
+	return nil
+}
+
+func (c *serverCodec) WriteResponse(r *rpc.Response, body any) error {
+	switch {
+	case c.methodNotFound:
+		c.response = newErrorResponse(c.request.ID, codeMethodNotFound, r.Error)
+		return nil
+	case c.invalidParams:
+		c.response = newErrorResponse(c.request.ID, codeInvalidParams, r.Error)
+		return nil
+	case r.Error != "":
+		c.response = &serverResponse{
+			Version: version,
+			Error: &serverError{
+				Code:    codeServerError,
+				Message: r.Error,
+				Data:    string(domain.CodeOf(c.err)),
+			},
+			ID: c.request.ID,
+		}
+		return nil
+	}
+
+	result, err := json.Marshal(body)
+	if err != nil {
+		c.response = newErrorResponse(c.request.ID, codeInternalError, err.Error())
+		return nil
+	}
+
+	c.response = &serverResponse{
+		Version: version,
+		Result:  result,
+		ID:      c.request.ID,
+	}
+
+	return nil
+}
+
+func (c *serverCodec) Close() error {
+	return nil
+}
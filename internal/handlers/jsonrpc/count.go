@@ -0,0 +1,100 @@
+package jsonrpc
+
+import (
+	"github.com/akrovv/warehouse/internal/domain"
+	"github.com/akrovv/warehouse/pkg/logger"
+)
+
+type countHandler struct {
+	service CountService
+	logger  logger.Logger
+}
+
+func NewCountHandler(service CountService, logger logger.Logger) *countHandler {
+	return &countHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func (h *countHandler) Open(in Args[[]domain.OpenCount], out *[]domain.ItemResult[domain.CountSession]) error {
+	results := make([]domain.ItemResult[domain.CountSession], 0, len(in.Params))
+
+	for i, value := range in.Params {
+		session, err := h.service.Open(in.Context(), &value)
+		if err != nil {
+			h.logger.Infof("can't open count: %v, got error: %s", value, err.Error())
+			results = append(results, domain.NewItemError[domain.CountSession](i, err))
+			continue
+		}
+
+		results = append(results, domain.NewItemResult(i, *session))
+	}
+
+	*out = results
+	return nil
+}
+
+func (h *countHandler) Get(in Args[domain.GetCount], out *domain.CountSession) error {
+	session, err := h.service.Get(in.Context(), &in.Params)
+	if err != nil {
+		return serviceError(in.Context(), "Get", err)
+	}
+
+	*out = *session
+	return nil
+}
+
+func (h *countHandler) Submit(in Args[[]domain.CountSubmission], out *[]domain.ItemResult[domain.CountSession]) error {
+	results := make([]domain.ItemResult[domain.CountSession], 0, len(in.Params))
+
+	for i, value := range in.Params {
+		session, err := h.service.Submit(in.Context(), &value)
+		if err != nil {
+			h.logger.Infof("can't submit count: %v, got error: %s", value, err.Error())
+			results = append(results, domain.NewItemError[domain.CountSession](i, err))
+			continue
+		}
+
+		results = append(results, domain.NewItemResult(i, *session))
+	}
+
+	*out = results
+	return nil
+}
+
+func (h *countHandler) Post(in Args[[]domain.PostCount], out *[]domain.ItemResult[domain.CountSession]) error {
+	results := make([]domain.ItemResult[domain.CountSession], 0, len(in.Params))
+
+	for i, value := range in.Params {
+		session, err := h.service.Post(in.Context(), &value)
+		if err != nil {
+			h.logger.Infof("can't post count: %v, got error: %s", value, err.Error())
+			results = append(results, domain.NewItemError[domain.CountSession](i, err))
+			continue
+		}
+
+		results = append(results, domain.NewItemResult(i, *session))
+	}
+
+	*out = results
+	return nil
+}
+
+func (h *countHandler) Cancel(in Args[[]domain.CancelCount], out *[]domain.ItemResult[domain.CountSession]) error {
+	results := make([]domain.ItemResult[domain.CountSession], 0, len(in.Params))
+
+	for i, value := range in.Params {
+		session, err := h.service.Cancel(in.Context(), &value)
+		if err != nil {
+			h.logger.Infof("can't cancel count: %v, got error: %s", value, err.Error())
+			results = append(results, domain.NewItemError[domain.CountSession](i, err))
+			continue
+		}
+
+		results = append(results, domain.NewItemResult(i, *session))
+	}
+
+	*out = results
+	return nil
+}
@@ -0,0 +1,287 @@
+package jsonrpc
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/akrovv/warehouse/internal/domain"
+	"github.com/akrovv/warehouse/internal/services/mocks"
+	"github.com/akrovv/warehouse/pkg/logger"
+	"github.com/golang/mock/gomock"
+)
+
+func getCountTestData(status string) []domain.CountSession {
+	counted := uint64(4)
+
+	return []domain.CountSession{
+		{
+			ID:          3,
+			WarehouseID: 1,
+			Status:      status,
+			Lines:       []domain.CountLine{{Code: "test", Expected: 5, Counted: &counted, Difference: -1}},
+		},
+		{
+			ID:          4,
+			WarehouseID: 2,
+			Status:      status,
+			Lines:       []domain.CountLine{{Code: "test", Expected: 4, Counted: &counted}},
+		},
+	}
+}
+
+func TestCountOpen(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cs := mocks.NewMockCountService(ctrl)
+	logger, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("can't create logger: %s", err)
+	}
+
+	in := []domain.OpenCount{
+		{
+			WarehouseID: 1,
+		},
+		{
+			WarehouseID: 2,
+			Codes:       []string{"test"},
+		},
+	}
+	sessions := getCountTestData(domain.CountOpen)
+
+	testCases := []itemTestCase{
+		{
+			errs:        []error{nil, nil},
+			expectCodes: []domain.ErrorCode{"", ""},
+		},
+		{
+			errs:        []error{nil, fmt.Errorf("%w: warehouse 2 has open count 4", domain.ErrValidationFailed)},
+			expectCodes: []domain.ErrorCode{"", domain.CodeValidationFailed},
+		},
+		{
+			errs:        []error{fmt.Errorf("%w: warehouse 1", domain.ErrNotFound), domain.ErrTest},
+			expectCodes: []domain.ErrorCode{domain.CodeNotFound, domain.CodeInternal},
+		},
+	}
+
+	handler := NewCountHandler(cs, logger)
+	for _, tc := range testCases {
+		for i := range in {
+			if tc.errs[i] != nil {
+				cs.EXPECT().Open(gomock.Any(), &in[i]).Return(nil, tc.errs[i])
+				continue
+			}
+
+			cs.EXPECT().Open(gomock.Any(), &in[i]).Return(&sessions[i], nil)
+		}
+
+		var out []domain.ItemResult[domain.CountSession]
+		if err = handler.Open(argsOf(in), &out); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		expectItemResults(t, tc, out, sessions)
+	}
+}
+
+func TestCountSubmit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cs := mocks.NewMockCountService(ctrl)
+	logger, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("can't create logger: %s", err)
+	}
+
+	in := []domain.CountSubmission{
+		{SessionID: 3, Lines: []domain.CountedQuantity{{Code: "test", Quantity: 4}}},
+		{SessionID: 4, Lines: []domain.CountedQuantity{{Code: "test", Quantity: 4}}},
+	}
+	sessions := getCountTestData(domain.CountOpen)
+
+	testCases := []itemTestCase{
+		{
+			errs:        []error{nil, nil},
+			expectCodes: []domain.ErrorCode{"", ""},
+		},
+		{
+			errs:        []error{fmt.Errorf("%w: product other", domain.ErrNotFound), nil},
+			expectCodes: []domain.ErrorCode{domain.CodeNotFound, ""},
+		},
+		{
+			errs: []error{
+				fmt.Errorf("%w: product test is not in count 3", domain.ErrValidationFailed),
+				fmt.Errorf("%w: count 4 is posted", domain.ErrValidationFailed),
+			},
+			expectCodes: []domain.ErrorCode{domain.CodeValidationFailed, domain.CodeValidationFailed},
+		},
+	}
+
+	handler := NewCountHandler(cs, logger)
+	for _, tc := range testCases {
+		for i := range in {
+			if tc.errs[i] != nil {
+				cs.EXPECT().Submit(gomock.Any(), &in[i]).Return(nil, tc.errs[i])
+				continue
+			}
+
+			cs.EXPECT().Submit(gomock.Any(), &in[i]).Return(&sessions[i], nil)
+		}
+
+		var out []domain.ItemResult[domain.CountSession]
+		if err = handler.Submit(argsOf(in), &out); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		expectItemResults(t, tc, out, sessions)
+	}
+}
+
+func TestCountPost(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cs := mocks.NewMockCountService(ctrl)
+	logger, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("can't create logger: %s", err)
+	}
+
+	in := []domain.PostCount{
+		{
+			SessionID: 3,
+			User:      "alice",
+		},
+		{
+			SessionID: 4,
+			User:      "alice",
+		},
+	}
+	sessions := getCountTestData(domain.CountPosted)
+
+	testCases := []itemTestCase{
+		{
+			errs:        []error{nil, nil},
+			expectCodes: []domain.ErrorCode{"", ""},
+		},
+		{
+			errs:        []error{nil, fmt.Errorf("product test: %w", domain.ErrInsufficientStock)},
+			expectCodes: []domain.ErrorCode{"", domain.CodeInsufficientStock},
+		},
+		{
+			errs: []error{
+				fmt.Errorf("product test: %w", domain.ErrWarehouseUnavailable),
+				fmt.Errorf("%w: count 4 is canceled", domain.ErrValidationFailed),
+			},
+			expectCodes: []domain.ErrorCode{domain.CodeWarehouseUnavailable, domain.CodeValidationFailed},
+		},
+	}
+
+	handler := NewCountHandler(cs, logger)
+	for _, tc := range testCases {
+		for i := range in {
+			if tc.errs[i] != nil {
+				cs.EXPECT().Post(gomock.Any(), &in[i]).Return(nil, tc.errs[i])
+				continue
+			}
+
+			cs.EXPECT().Post(gomock.Any(), &in[i]).Return(&sessions[i], nil)
+		}
+
+		var out []domain.ItemResult[domain.CountSession]
+		if err = handler.Post(argsOf(in), &out); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		expectItemResults(t, tc, out, sessions)
+	}
+}
+
+func TestCountCancel(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cs := mocks.NewMockCountService(ctrl)
+	logger, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("can't create logger: %s", err)
+	}
+
+	in := []domain.CancelCount{
+		{
+			SessionID: 3,
+		},
+		{
+			SessionID: 4,
+		},
+	}
+	sessions := getCountTestData(domain.CountCanceled)
+
+	testCases := []itemTestCase{
+		{
+			errs:        []error{nil, nil},
+			expectCodes: []domain.ErrorCode{"", ""},
+		},
+		{
+			errs:        []error{fmt.Errorf("%w: count 3 is posted", domain.ErrValidationFailed), nil},
+			expectCodes: []domain.ErrorCode{domain.CodeValidationFailed, ""},
+		},
+		{
+			errs:        []error{domain.ErrTest, fmt.Errorf("%w: count 4", domain.ErrNotFound)},
+			expectCodes: []domain.ErrorCode{domain.CodeInternal, domain.CodeNotFound},
+		},
+	}
+
+	handler := NewCountHandler(cs, logger)
+	for _, tc := range testCases {
+		for i := range in {
+			if tc.errs[i] != nil {
+				cs.EXPECT().Cancel(gomock.Any(), &in[i]).Return(nil, tc.errs[i])
+				continue
+			}
+
+			cs.EXPECT().Cancel(gomock.Any(), &in[i]).Return(&sessions[i], nil)
+		}
+
+		var out []domain.ItemResult[domain.CountSession]
+		if err = handler.Cancel(argsOf(in), &out); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		expectItemResults(t, tc, out, sessions)
+	}
+}
+
+func TestCountGet(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cs := mocks.NewMockCountService(ctrl)
+	logger, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("can't create logger: %s", err)
+	}
+
+	in := domain.GetCount{ID: 3}
+	session := getCountTestData(domain.CountOpen)[0]
+
+	handler := NewCountHandler(cs, logger)
+	for _, tc := range singleTestCases {
+		if tc.err != nil {
+			cs.EXPECT().Get(gomock.Any(), &in).Return(nil, tc.err)
+		} else {
+			cs.EXPECT().Get(gomock.Any(), &in).Return(&session, nil)
+		}
+
+		var out domain.CountSession
+		err = handler.Get(argsOf(in), &out)
+		expectSingleError(t, tc, err)
+
+		if tc.err == nil && !reflect.DeepEqual(out, session) {
+			t.Fatalf("expected: %v, got: %v", session, out)
+		}
+	}
+}
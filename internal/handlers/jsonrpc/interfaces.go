@@ -1,17 +1,77 @@
 package jsonrpc
 
-import "github.com/akrovv/warehouse/internal/domain"
+import (
+	"context"
+
+	"github.com/akrovv/warehouse/internal/domain"
+)
 
 type ProductService interface {
-	Create(product *domain.Product) error
-	Reserve(wp *domain.WarehouseProduct) error
-	CancelReservation(wp *domain.WarehouseProduct) error
-	Transfer(td *domain.TransferProduct) error
-	Add(ad *domain.AddProduct) error
-	Delete(dp *domain.DeleteProduct) (*domain.Product, error)
+	Create(ctx context.Context, product *domain.Product) error
+	Get(ctx context.Context, gp *domain.GetProduct) (*domain.Product, error)
+	List(ctx context.Context, f *domain.ProductFilter) ([]domain.Product, error)
+	Update(ctx context.Context, up *domain.UpdateProduct) (*domain.Product, error)
+	Reserve(ctx context.Context, wp *domain.WarehouseProduct) (*domain.Reservation, error)
+	ReserveBatch(ctx context.Context, wps []domain.WarehouseProduct) ([]domain.Reservation, error)
+	CancelReservation(ctx context.Context, cr *domain.CancelReservation) (*domain.Reservation, error)
+	Fulfill(ctx context.Context, f *domain.Fulfillment) (*domain.Shipment, error)
+	Transfer(ctx context.Context, td *domain.TransferProduct) error
+	TransferBatch(ctx context.Context, tds []domain.TransferProduct) error
+	Add(ctx context.Context, ad *domain.AddProduct) error
+	Delete(ctx context.Context, dp *domain.DeleteProduct) (*domain.Product, error)
+	Relocate(ctx context.Context, rl *domain.Relocation) error
+	MoveStock(ctx context.Context, m *domain.BucketMove) error
+	Adjust(ctx context.Context, a *domain.Adjustment) error
+	ApproveAdjustment(ctx context.Context, d *domain.DecideAdjustment) (*domain.Adjustment, error)
+	RejectAdjustment(ctx context.Context, d *domain.DecideAdjustment) (*domain.Adjustment, error)
+	ListAdjustments(ctx context.Context, f *domain.AdjustmentFilter) ([]domain.Adjustment, error)
+	SuggestTransfers(ctx context.Context, st *domain.SuggestTransfers) (*domain.TransferSuggestion, error)
+	Allocate(ctx context.Context, a *domain.Allocation) ([]domain.Reservation, error)
+	Dispatch(ctx context.Context, td *domain.TransferProduct) (*domain.TransitTransfer, error)
+	Receive(ctx context.Context, rt *domain.ReceiveTransfer) (*domain.TransitTransfer, error)
+	CancelTransfer(ctx context.Context, ct *domain.CancelTransfer) (*domain.TransitTransfer, error)
+	ListTransfers(ctx context.Context, f *domain.TransferFilter) ([]domain.TransitTransfer, error)
 }
 
 type WarehouseService interface {
-	Create(warehouse *domain.Warehouse) error
-	GetLeftOvers(gw *domain.GetFromWarehouse) ([]domain.Product, error)
+	Create(ctx context.Context, warehouse *domain.Warehouse) error
+	Get(ctx context.Context, gw *domain.GetWarehouse) (*domain.Warehouse, error)
+	List(ctx context.Context, f *domain.WarehouseFilter) ([]domain.Warehouse, error)
+	Update(ctx context.Context, uw *domain.UpdateWarehouse) (*domain.Warehouse, error)
+	SetAvailability(ctx context.Context, sa *domain.SetAvailability) (*domain.AvailabilityChange, error)
+	Decommission(ctx context.Context, d *domain.Decommission) (*domain.DecommissionResult, error)
+	GetLeftOvers(ctx context.Context, gw *domain.GetFromWarehouse) ([]domain.Product, error)
+	CreateBin(ctx context.Context, bin *domain.Bin) error
+	ListBins(ctx context.Context, lb *domain.ListBins) ([]domain.Bin, error)
+	SetThreshold(ctx context.Context, t *domain.StockThreshold) error
+	LowStock(ctx context.Context, f *domain.LowStockFilter) ([]domain.LowStockItem, error)
+	LowStockEvents(ctx context.Context, f *domain.LowStockEventFilter) ([]domain.LowStockEvent, error)
+}
+
+type MovementService interface {
+	List(ctx context.Context, f *domain.MovementFilter) ([]domain.Movement, error)
+}
+
+type PurchaseService interface {
+	CreateSupplier(ctx context.Context, supplier *domain.Supplier) error
+	ListSuppliers(ctx context.Context, f *domain.SupplierFilter) ([]domain.Supplier, error)
+	CreateOrder(ctx context.Context, po *domain.PurchaseOrder) error
+	GetOrder(ctx context.Context, gp *domain.GetPurchaseOrder) (*domain.PurchaseOrder, error)
+	Receive(ctx context.Context, pr *domain.PurchaseReceipt) (*domain.PurchaseOrder, error)
+	CloseOrder(ctx context.Context, cp *domain.ClosePurchaseOrder) (*domain.PurchaseOrder, error)
+	Outstanding(ctx context.Context, f *domain.OutstandingFilter) ([]domain.OutstandingLine, error)
+}
+
+type ReturnService interface {
+	Create(ctx context.Context, cr *domain.CreateReturn) (*domain.Return, error)
+	Get(ctx context.Context, gr *domain.GetReturn) (*domain.Return, error)
+	Receive(ctx context.Context, rr *domain.ReturnReceipt) (*domain.Return, error)
+}
+
+type CountService interface {
+	Open(ctx context.Context, oc *domain.OpenCount) (*domain.CountSession, error)
+	Get(ctx context.Context, gc *domain.GetCount) (*domain.CountSession, error)
+	Submit(ctx context.Context, cs *domain.CountSubmission) (*domain.CountSession, error)
+	Post(ctx context.Context, pc *domain.PostCount) (*domain.CountSession, error)
+	Cancel(ctx context.Context, cc *domain.CancelCount) (*domain.CountSession, error)
 }
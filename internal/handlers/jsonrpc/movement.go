@@ -0,0 +1,29 @@
+package jsonrpc
+
+import (
+	"github.com/akrovv/warehouse/internal/domain"
+	"github.com/akrovv/warehouse/pkg/logger"
+)
+
+type movementHandler struct {
+	service MovementService
+	logger  logger.Logger
+}
+
+func NewMovementHandler(service MovementService, logger logger.Logger) *movementHandler {
+	return &movementHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func (h *movementHandler) List(in Args[domain.MovementFilter], out *[]domain.Movement) error {
+	movements, err := h.service.List(in.Context(), &in.Params)
+
+	if err != nil {
+		return serviceError(in.Context(), "List", err)
+	}
+
+	*out = movements
+	return nil
+}
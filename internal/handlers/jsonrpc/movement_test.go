@@ -0,0 +1,73 @@
+package jsonrpc
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/akrovv/warehouse/internal/domain"
+	"github.com/akrovv/warehouse/internal/services/mocks"
+	"github.com/akrovv/warehouse/pkg/logger"
+	"github.com/golang/mock/gomock"
+)
+
+type movementTestCase struct {
+	in           domain.MovementFilter
+	out          []domain.Movement
+	movements    []domain.Movement
+	err          error
+	expectResult []domain.Movement
+}
+
+func TestMovementList(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ms := mocks.NewMockMovementService(ctrl)
+	logger, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("can't create logger: %s", err)
+	}
+
+	in := domain.MovementFilter{
+		Code:        "test",
+		WarehouseID: 1,
+	}
+	movements := []domain.Movement{
+		{
+			ID:          1,
+			Type:        domain.MovementAdd,
+			WarehouseID: 1,
+			Code:        "test",
+			Bucket:      domain.BucketAvailable,
+			Delta:       10,
+			Balance:     10,
+		},
+	}
+
+	testCases := []movementTestCase{
+		{
+			in:           in,
+			movements:    movements,
+			expectResult: movements,
+		},
+		{
+			in:  in,
+			err: domain.ErrTest,
+		},
+	}
+
+	handler := NewMovementHandler(ms, logger)
+	for _, tc := range testCases {
+		ms.EXPECT().List(gomock.Any(), &tc.in).Return(tc.movements, tc.err)
+
+		err = handler.List(argsOf(tc.in), &tc.out)
+		if !errors.Is(err, tc.err) {
+			t.Fatalf("expected error: %v, got: %v", tc.err, err)
+		}
+
+		if !reflect.DeepEqual(tc.out, tc.expectResult) {
+			t.Fatalf("expected: %v, got: %v", tc.expectResult, tc.out)
+		}
+	}
+}
@@ -1,7 +1,7 @@
 package jsonrpc
 
 import (
-	"fmt"
+	"context"
 
 	"github.com/akrovv/warehouse/internal/domain"
 	"github.com/akrovv/warehouse/pkg/logger"
@@ -19,142 +19,377 @@ func NewProductHandler(service ProductService, logger logger.Logger) *productHan
 	}
 }
 
-func (h *productHandler) Create(in []domain.Product, out *[]domain.Product) error {
-	var err error
-	total := 0
-	success := make([]domain.Product, 0, len(in))
+func (h *productHandler) Create(in Args[[]domain.Product], out *[]domain.ItemResult[domain.Product]) error {
+	results := make([]domain.ItemResult[domain.Product], 0, len(in.Params))
 
-	for _, value := range in {
-		if err = h.service.Create(&value); err != nil {
-			h.logger.Infof("error while creating product %v, error: %w\n", value, err)
-			total++
+	for i, value := range in.Params {
+		if err := h.service.Create(in.Context(), &value); err != nil {
+			h.logger.Infof("error while creating product %v, error: %s", value, err.Error())
+			results = append(results, domain.NewItemError[domain.Product](i, err))
 			continue
 		}
-		success = append(success, value)
+
+		results = append(results, domain.NewItemResult(i, value))
+	}
+
+	*out = results
+	return nil
+}
+
+func (h *productHandler) Get(in Args[domain.GetProduct], out *domain.Product) error {
+	product, err := h.service.Get(in.Context(), &in.Params)
+
+	if err != nil {
+		return serviceError(in.Context(), "Get", err)
+	}
+
+	*out = *product
+	return nil
+}
+
+func (h *productHandler) List(in Args[domain.ProductFilter], out *[]domain.Product) error {
+	products, err := h.service.List(in.Context(), &in.Params)
+
+	if err != nil {
+		return serviceError(in.Context(), "List", err)
+	}
+
+	*out = products
+	return nil
+}
+
+func (h *productHandler) Update(in Args[[]domain.UpdateProduct], out *[]domain.ItemResult[domain.Product]) error {
+	results := make([]domain.ItemResult[domain.Product], 0, len(in.Params))
+
+	for i, value := range in.Params {
+		product, err := h.service.Update(in.Context(), &value)
+		if err != nil {
+			h.logger.Infof("can't update product: %v, got error: %s", value, err.Error())
+			results = append(results, domain.NewItemError[domain.Product](i, err))
+			continue
+		}
+
+		results = append(results, domain.NewItemResult(i, *product))
+	}
+
+	*out = results
+	return nil
+}
+
+func (h *productHandler) Reserve(in Args[domain.Batch[domain.WarehouseProduct]], out *[]domain.ItemResult[domain.Reservation]) error {
+	if in.Params.Atomic {
+		*out = h.reserveAtomic(in.Context(), in.Params.Items)
+		return nil
+	}
+
+	results := make([]domain.ItemResult[domain.Reservation], 0, len(in.Params.Items))
+
+	for i, value := range in.Params.Items {
+		reservation, err := h.service.Reserve(in.Context(), &value)
+		if err != nil {
+			h.logger.Infof("can't reserve item: %v, got error: %s", value, err.Error())
+			results = append(results, domain.NewItemError[domain.Reservation](i, err))
+			continue
+		}
+
+		results = append(results, domain.NewItemResult(i, *reservation))
+	}
+
+	*out = results
+	return nil
+}
+
+func (h *productHandler) reserveAtomic(ctx context.Context, in []domain.WarehouseProduct) []domain.ItemResult[domain.Reservation] {
+	reservations, err := h.service.ReserveBatch(ctx, in)
+	if err != nil {
+		h.logger.Infof("can't reserve items atomically, got error: %s", err.Error())
+		return atomicErrorResults[domain.Reservation](len(in), err)
+	}
+
+	results := make([]domain.ItemResult[domain.Reservation], 0, len(reservations))
+	for i, reservation := range reservations {
+		results = append(results, domain.NewItemResult(i, reservation))
+	}
+
+	return results
+}
+
+func (h *productHandler) Allocate(in Args[domain.Allocation], out *[]domain.Reservation) error {
+	reservations, err := h.service.Allocate(in.Context(), &in.Params)
+	if err != nil {
+		return serviceError(in.Context(), "Allocate", err)
+	}
+
+	*out = reservations
+	return nil
+}
+
+func (h *productHandler) CancelReservation(in Args[[]domain.CancelReservation], out *[]domain.ItemResult[domain.Reservation]) error {
+	results := make([]domain.ItemResult[domain.Reservation], 0, len(in.Params))
+
+	for i, value := range in.Params {
+		reservation, err := h.service.CancelReservation(in.Context(), &value)
+		if err != nil {
+			h.logger.Infof("can't cancel reservation: %v, got error: %s", value, err.Error())
+			results = append(results, domain.NewItemError[domain.Reservation](i, err))
+			continue
+		}
+
+		results = append(results, domain.NewItemResult(i, *reservation))
+	}
+
+	*out = results
+	return nil
+}
+
+func (h *productHandler) Fulfill(in Args[domain.Fulfillment], out *domain.Shipment) error {
+	shipment, err := h.service.Fulfill(in.Context(), &in.Params)
+	if err != nil {
+		return serviceError(in.Context(), "Fulfill", err)
+	}
+
+	*out = *shipment
+	return nil
+}
+
+func (h *productHandler) SuggestTransfers(in Args[domain.SuggestTransfers], out *domain.TransferSuggestion) error {
+	suggestion, err := h.service.SuggestTransfers(in.Context(), &in.Params)
+	if err != nil {
+		return serviceError(in.Context(), "SuggestTransfers", err)
 	}
 
-	if total == len(in) {
-		return fmt.Errorf("all calls returned: %w", err)
+	*out = *suggestion
+	return nil
+}
+
+func (h *productHandler) Transfer(in Args[domain.Batch[domain.TransferProduct]], out *[]domain.ItemResult[domain.TransferProduct]) error {
+	if in.Params.Atomic {
+		*out = h.transferAtomic(in.Context(), in.Params.Items)
+		return nil
+	}
+
+	results := make([]domain.ItemResult[domain.TransferProduct], 0, len(in.Params.Items))
+
+	for i, value := range in.Params.Items {
+		if err := h.service.Transfer(in.Context(), &value); err != nil {
+			h.logger.Infof("can't transfer item: %v, got error: %s", value, err.Error())
+			results = append(results, domain.NewItemError[domain.TransferProduct](i, err))
+			continue
+		}
+
+		results = append(results, domain.NewItemResult(i, value))
+	}
+
+	*out = results
+	return nil
+}
+
+func (h *productHandler) transferAtomic(ctx context.Context, in []domain.TransferProduct) []domain.ItemResult[domain.TransferProduct] {
+	if err := h.service.TransferBatch(ctx, in); err != nil {
+		h.logger.Infof("can't transfer items atomically, got error: %s", err.Error())
+		return atomicErrorResults[domain.TransferProduct](len(in), err)
+	}
+
+	results := make([]domain.ItemResult[domain.TransferProduct], 0, len(in))
+	for i, value := range in {
+		results = append(results, domain.NewItemResult(i, value))
+	}
+
+	return results
+}
+
+func (h *productHandler) Dispatch(in Args[[]domain.TransferProduct], out *[]domain.ItemResult[domain.TransitTransfer]) error {
+	results := make([]domain.ItemResult[domain.TransitTransfer], 0, len(in.Params))
+
+	for i, value := range in.Params {
+		transfer, err := h.service.Dispatch(in.Context(), &value)
+		if err != nil {
+			h.logger.Infof("can't dispatch item: %v, got error: %s", value, err.Error())
+			results = append(results, domain.NewItemError[domain.TransitTransfer](i, err))
+			continue
+		}
+
+		results = append(results, domain.NewItemResult(i, *transfer))
+	}
+
+	*out = results
+	return nil
+}
+
+func (h *productHandler) Receive(in Args[[]domain.ReceiveTransfer], out *[]domain.ItemResult[domain.TransitTransfer]) error {
+	results := make([]domain.ItemResult[domain.TransitTransfer], 0, len(in.Params))
+
+	for i, value := range in.Params {
+		transfer, err := h.service.Receive(in.Context(), &value)
+		if err != nil {
+			h.logger.Infof("can't receive transfer: %v, got error: %s", value, err.Error())
+			results = append(results, domain.NewItemError[domain.TransitTransfer](i, err))
+			continue
+		}
+
+		results = append(results, domain.NewItemResult(i, *transfer))
 	}
 
-	*out = success
+	*out = results
 	return nil
 }
 
-func (h *productHandler) Reserve(in []domain.WarehouseProduct, out *[]domain.WarehouseProduct) error {
-	var err error
-	total := 0
-	reserved := make([]domain.WarehouseProduct, 0, len(in))
+func (h *productHandler) CancelTransfer(in Args[[]domain.CancelTransfer], out *[]domain.ItemResult[domain.TransitTransfer]) error {
+	results := make([]domain.ItemResult[domain.TransitTransfer], 0, len(in.Params))
 
-	for _, value := range in {
-		if err = h.service.Reserve(&value); err != nil {
-			h.logger.Infof("can't reserve item: %v, got error: %w", value, err)
-			total++
+	for i, value := range in.Params {
+		transfer, err := h.service.CancelTransfer(in.Context(), &value)
+		if err != nil {
+			h.logger.Infof("can't cancel transfer: %v, got error: %s", value, err.Error())
+			results = append(results, domain.NewItemError[domain.TransitTransfer](i, err))
 			continue
 		}
 
-		value.Status = "reserved"
-		reserved = append(reserved, value)
+		results = append(results, domain.NewItemResult(i, *transfer))
 	}
 
-	if total == len(in) {
-		return fmt.Errorf("all calls returned: %w", err)
+	*out = results
+	return nil
+}
+
+func (h *productHandler) ListTransfers(in Args[domain.TransferFilter], out *[]domain.TransitTransfer) error {
+	transfers, err := h.service.ListTransfers(in.Context(), &in.Params)
+	if err != nil {
+		return serviceError(in.Context(), "ListTransfers", err)
 	}
 
-	*out = reserved
+	*out = transfers
 	return nil
 }
 
-func (h *productHandler) CancelReservation(in []domain.WarehouseProduct, out *[]domain.WarehouseProduct) error {
-	var err error
-	total := 0
-	unreserved := make([]domain.WarehouseProduct, 0, len(in))
+func (h *productHandler) Add(in Args[[]domain.AddProduct], out *[]domain.ItemResult[domain.AddProduct]) error {
+	results := make([]domain.ItemResult[domain.AddProduct], 0, len(in.Params))
 
-	for _, value := range in {
-		if err = h.service.CancelReservation(&value); err != nil {
-			h.logger.Infof("can't cancel reservation with item: %v, got error: %w", value, err)
-			total++
+	for i, value := range in.Params {
+		if err := h.service.Add(in.Context(), &value); err != nil {
+			h.logger.Infof("can't add item: %v, got error: %s", value, err.Error())
+			results = append(results, domain.NewItemError[domain.AddProduct](i, err))
 			continue
 		}
 
-		value.Status = "canceled"
-		unreserved = append(unreserved, value)
+		results = append(results, domain.NewItemResult(i, value))
 	}
 
-	if total == len(in) {
-		return fmt.Errorf("all calls returned: %w", err)
+	*out = results
+	return nil
+}
+
+func (h *productHandler) Delete(in Args[[]domain.DeleteProduct], out *[]domain.ItemResult[domain.Product]) error {
+	results := make([]domain.ItemResult[domain.Product], 0, len(in.Params))
+
+	for i, value := range in.Params {
+		product, err := h.service.Delete(in.Context(), &value)
+		if err != nil {
+			h.logger.Infof("can't delete item: %v, got error: %s", value, err.Error())
+			results = append(results, domain.NewItemError[domain.Product](i, err))
+			continue
+		}
+
+		results = append(results, domain.NewItemResult(i, *product))
 	}
 
-	*out = unreserved
+	*out = results
 	return nil
 }
 
-func (h *productHandler) Transfer(in []domain.TransferProduct, out *[]domain.TransferProduct) error {
-	var err error
-	total := 0
-	transfered := make([]domain.TransferProduct, 0, len(in))
-	for _, value := range in {
-		if err = h.service.Transfer(&value); err != nil {
-			h.logger.Infof("can't transfer item: %v, got error: %w", value, err)
-			total++
+func (h *productHandler) Relocate(in Args[[]domain.Relocation], out *[]domain.ItemResult[domain.Relocation]) error {
+	results := make([]domain.ItemResult[domain.Relocation], 0, len(in.Params))
+
+	for i, value := range in.Params {
+		if err := h.service.Relocate(in.Context(), &value); err != nil {
+			h.logger.Infof("can't relocate item: %v, got error: %s", value, err.Error())
+			results = append(results, domain.NewItemError[domain.Relocation](i, err))
 			continue
 		}
 
-		transfered = append(transfered, value)
+		results = append(results, domain.NewItemResult(i, value))
 	}
 
-	if total == len(in) {
-		return fmt.Errorf("all calls returned: %w", err)
+	*out = results
+	return nil
+}
+
+func (h *productHandler) MoveStock(in Args[[]domain.BucketMove], out *[]domain.ItemResult[domain.BucketMove]) error {
+	results := make([]domain.ItemResult[domain.BucketMove], 0, len(in.Params))
+
+	for i, value := range in.Params {
+		if err := h.service.MoveStock(in.Context(), &value); err != nil {
+			h.logger.Infof("can't move stock: %v, got error: %s", value, err.Error())
+			results = append(results, domain.NewItemError[domain.BucketMove](i, err))
+			continue
+		}
+
+		results = append(results, domain.NewItemResult(i, value))
 	}
 
-	*out = transfered
+	*out = results
 	return nil
 }
 
-func (h *productHandler) Add(in []domain.AddProduct, out *[]domain.AddProduct) error {
-	var err error
-	total := 0
-	added := make([]domain.AddProduct, 0, len(in))
+func (h *productHandler) Adjust(in Args[[]domain.Adjustment], out *[]domain.ItemResult[domain.Adjustment]) error {
+	results := make([]domain.ItemResult[domain.Adjustment], 0, len(in.Params))
 
-	for _, value := range in {
-		if err = h.service.Add(&value); err != nil {
-			h.logger.Infof("can't add item: %v, got error: %w", value, err)
-			total++
+	for i, value := range in.Params {
+		if err := h.service.Adjust(in.Context(), &value); err != nil {
+			h.logger.Infof("can't adjust stock: %v, got error: %s", value, err.Error())
+			results = append(results, domain.NewItemError[domain.Adjustment](i, err))
 			continue
 		}
 
-		added = append(added, value)
+		results = append(results, domain.NewItemResult(i, value))
 	}
 
-	if total == len(in) {
-		return fmt.Errorf("all calls returned: %w", err)
+	*out = results
+	return nil
+}
+
+func (h *productHandler) ApproveAdjustment(in Args[[]domain.DecideAdjustment], out *[]domain.ItemResult[domain.Adjustment]) error {
+	results := make([]domain.ItemResult[domain.Adjustment], 0, len(in.Params))
+
+	for i, value := range in.Params {
+		adjustment, err := h.service.ApproveAdjustment(in.Context(), &value)
+		if err != nil {
+			h.logger.Infof("can't approve adjustment: %v, got error: %s", value, err.Error())
+			results = append(results, domain.NewItemError[domain.Adjustment](i, err))
+			continue
+		}
+
+		results = append(results, domain.NewItemResult(i, *adjustment))
 	}
 
-	*out = added
+	*out = results
 	return nil
 }
 
-func (h *productHandler) Delete(in []domain.DeleteProduct, out *[]domain.Product) error {
-	var err error
-	var product *domain.Product
-	total := 0
-	deleted := make([]domain.Product, 0, len(in))
+func (h *productHandler) RejectAdjustment(in Args[[]domain.DecideAdjustment], out *[]domain.ItemResult[domain.Adjustment]) error {
+	results := make([]domain.ItemResult[domain.Adjustment], 0, len(in.Params))
 
-	for _, value := range in {
-		product, err = h.service.Delete(&value)
+	for i, value := range in.Params {
+		adjustment, err := h.service.RejectAdjustment(in.Context(), &value)
 		if err != nil {
-			h.logger.Infof("can't delete item: %v, got error: %w", value, err)
-			total++
+			h.logger.Infof("can't reject adjustment: %v, got error: %s", value, err.Error())
+			results = append(results, domain.NewItemError[domain.Adjustment](i, err))
 			continue
 		}
 
-		deleted = append(deleted, *product)
+		results = append(results, domain.NewItemResult(i, *adjustment))
 	}
 
-	if total == len(in) {
-		return fmt.Errorf("all calls returned: %w", err)
+	*out = results
+	return nil
+}
+
+func (h *productHandler) ListAdjustments(in Args[domain.AdjustmentFilter], out *[]domain.Adjustment) error {
+	adjustments, err := h.service.ListAdjustments(in.Context(), &in.Params)
+	if err != nil {
+		return serviceError(in.Context(), "ListAdjustments", err)
 	}
 
-	*out = deleted
+	*out = adjustments
 	return nil
 }
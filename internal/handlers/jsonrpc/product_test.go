@@ -13,114 +13,129 @@ import (
 
 type productTestCase struct {
 	in           []domain.Product
-	out          []domain.Product
+	out          []domain.ItemResult[domain.Product]
 	err          error
 	repeat       uint8
 	repeatError  uint8
-	expectResult []domain.Product
+	expectResult []domain.ItemResult[domain.Product]
 }
 
-type warehouseProductTestCase struct {
+type reserveTestCase struct {
 	in           []domain.WarehouseProduct
-	out          []domain.WarehouseProduct
+	out          []domain.ItemResult[domain.Reservation]
+	err          error
+	repeat       uint8
+	repeatError  uint8
+	expectResult []domain.ItemResult[domain.Reservation]
+}
+
+type cancelReservationTestCase struct {
+	in           []domain.CancelReservation
+	out          []domain.ItemResult[domain.Reservation]
 	err          error
 	repeat       uint8
 	repeatError  uint8
-	expectResult []domain.WarehouseProduct
+	expectResult []domain.ItemResult[domain.Reservation]
 }
 
 type transferProductTestCase struct {
 	in           []domain.TransferProduct
-	out          []domain.TransferProduct
+	out          []domain.ItemResult[domain.TransferProduct]
 	err          error
 	repeat       uint8
 	repeatError  uint8
-	expectResult []domain.TransferProduct
+	expectResult []domain.ItemResult[domain.TransferProduct]
+}
+
+type fulfillTestCase struct {
+	in           domain.Fulfillment
+	out          domain.Shipment
+	shipment     *domain.Shipment
+	err          error
+	expectResult domain.Shipment
+}
+
+type atomicTestCase struct {
+	err          error
+	expectStatus []bool
+	expectCodes  []domain.ErrorCode
 }
 
 type addProductTestCase struct {
 	in           []domain.AddProduct
-	out          []domain.AddProduct
+	out          []domain.ItemResult[domain.AddProduct]
 	err          error
 	repeat       uint8
 	repeatError  uint8
-	expectResult []domain.AddProduct
+	expectResult []domain.ItemResult[domain.AddProduct]
+}
+
+type getProductTestCase struct {
+	in           domain.GetProduct
+	out          domain.Product
+	product      *domain.Product
+	err          error
+	expectResult domain.Product
+}
+
+type listProductTestCase struct {
+	in           domain.ProductFilter
+	out          []domain.Product
+	products     []domain.Product
+	err          error
+	expectResult []domain.Product
+}
+
+type updateProductTestCase struct {
+	in           []domain.UpdateProduct
+	out          []domain.ItemResult[domain.Product]
+	errs         []error
+	expectResult []domain.ItemResult[domain.Product]
 }
 
 type deleteProductTestCase struct {
 	in           []domain.DeleteProduct
-	out          []domain.Product
+	out          []domain.ItemResult[domain.Product]
 	err          error
 	repeat       uint8
 	repeatError  uint8
-	expectResult []domain.Product
+	expectResult []domain.ItemResult[domain.Product]
 }
 
-func getWarehouseProductTestData(status string) []warehouseProductTestCase {
-	in := []domain.WarehouseProduct{
+func getReservationTestData(status string) []domain.Reservation {
+	return []domain.Reservation{
 		{
+			ID:          1,
 			WarehouseID: 1,
 			Code:        "test",
 			Quantity:    1,
+			Status:      status,
 		},
 		{
+			ID:          2,
 			WarehouseID: 2,
 			Code:        "test",
 			Quantity:    2,
+			Status:      status,
 		},
 	}
+}
 
-	expectWarehouseProduct := [][]domain.WarehouseProduct{
-		{
-			{
-				WarehouseID: 1,
-				Code:        "test",
-				Quantity:    1,
-				Status:      status,
-			},
-			{
-				WarehouseID: 2,
-				Code:        "test",
-				Quantity:    2,
-				Status:      status,
-			},
-		},
-		{
-			{
-				WarehouseID: 2,
-				Code:        "test",
-				Quantity:    2,
-				Status:      status,
-			},
-		},
-	}
-
-	testCases := []warehouseProductTestCase{
+func getReservationExpectResults(reservations []domain.Reservation) [][]domain.ItemResult[domain.Reservation] {
+	return [][]domain.ItemResult[domain.Reservation]{
 		{
-			in:           in,
-			out:          nil,
-			err:          nil,
-			repeat:       2,
-			expectResult: expectWarehouseProduct[0],
+			domain.NewItemResult(0, reservations[0]),
+			domain.NewItemResult(1, reservations[1]),
 		},
 		{
-			in:           in,
-			out:          nil,
-			err:          domain.ErrTest,
-			repeat:       2,
-			repeatError:  1,
-			expectResult: expectWarehouseProduct[1],
+			domain.NewItemError[domain.Reservation](0, domain.ErrTest),
+			domain.NewItemResult(1, reservations[1]),
 		},
 		{
-			in:          in,
-			out:         nil,
-			err:         domain.ErrTest,
-			repeat:      2,
-			repeatError: 2,
+			domain.NewItemError[domain.Reservation](0, domain.ErrTest),
+			domain.NewItemError[domain.Reservation](1, domain.ErrTest),
 		},
 	}
-
-	return testCases
 }
 
 func TestProductCreate(t *testing.T) {
@@ -148,12 +163,19 @@ func TestProductCreate(t *testing.T) {
 		},
 	}
 
-	expectProduct := [][]domain.Product{
-		in,
+	expectProduct := [][]domain.ItemResult[domain.Product]{
+		{
+			domain.NewItemResult(0, in[0]),
+			domain.NewItemResult(1, in[1]),
+		},
+		{
+			domain.NewItemError[domain.Product](0, domain.ErrTest),
+			domain.NewItemResult(1, in[1]),
+		},
 		{
-			in[1],
+			domain.NewItemError[domain.Product](0, domain.ErrTest),
+			domain.NewItemError[domain.Product](1, domain.ErrTest),
 		},
-		nil,
 	}
 
 	testCases := []productTestCase{
@@ -187,18 +209,18 @@ func TestProductCreate(t *testing.T) {
 	for _, tc := range testCases {
 		for i := 0; i < int(tc.repeat); i++ {
 			if tc.repeatError > 0 {
-				ps.EXPECT().Create(&tc.in[i]).Return(tc.err)
+				ps.EXPECT().Create(gomock.Any(), &tc.in[i]).Return(tc.err)
 				tc.repeatError--
 				continue
 			} else {
 				tc.err = nil
 			}
-			ps.EXPECT().Create(&tc.in[i]).Return(tc.err)
+			ps.EXPECT().Create(gomock.Any(), &tc.in[i]).Return(tc.err)
 		}
 
-		err = handler.Create(tc.in, &tc.out)
-		if !errors.Is(err, tc.err) {
-			t.Fatalf("expected error: %v, got: %v", tc.err, err)
+		err = handler.Create(argsOf(tc.in), &tc.out)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
 		}
 
 		if !reflect.DeepEqual(tc.out, tc.expectResult) {
@@ -217,23 +239,62 @@ func TestProductReserve(t *testing.T) {
 		t.Fatalf("can't create logger: %s", err)
 	}
 
-	testCases := getWarehouseProductTestData("reserved")
+	in := []domain.WarehouseProduct{
+		{
+			WarehouseID: 1,
+			Code:        "test",
+			Quantity:    1,
+		},
+		{
+			WarehouseID: 2,
+			Code:        "test",
+			Quantity:    2,
+		},
+	}
+
+	reservations := getReservationTestData(domain.ReservationActive)
+	expectReservation := getReservationExpectResults(reservations)
+
+	testCases := []reserveTestCase{
+		{
+			in:           in,
+			out:          nil,
+			err:          nil,
+			repeat:       2,
+			expectResult: expectReservation[0],
+		},
+		{
+			in:           in,
+			out:          nil,
+			err:          domain.ErrTest,
+			repeat:       2,
+			repeatError:  1,
+			expectResult: expectReservation[1],
+		},
+		{
+			in:           in,
+			out:          nil,
+			err:          domain.ErrTest,
+			repeat:       2,
+			repeatError:  2,
+			expectResult: expectReservation[2],
+		},
+	}
+
 	handler := NewProductHandler(ps, logger)
 	for _, tc := range testCases {
 		for i := 0; i < int(tc.repeat); i++ {
 			if tc.repeatError > 0 {
-				ps.EXPECT().Reserve(&tc.in[i]).Return(tc.err)
+				ps.EXPECT().Reserve(gomock.Any(), &tc.in[i]).Return(nil, tc.err)
 				tc.repeatError--
 				continue
-			} else {
-				tc.err = nil
 			}
-			ps.EXPECT().Reserve(&tc.in[i]).Return(tc.err)
+			ps.EXPECT().Reserve(gomock.Any(), &tc.in[i]).Return(&reservations[i], nil)
 		}
 
-		err = handler.Reserve(tc.in, &tc.out)
-		if !errors.Is(err, tc.err) {
-			t.Fatalf("expected error: %v, got: %v", tc.err, err)
+		err = handler.Reserve(argsOf(domain.Batch[domain.WarehouseProduct]{Items: tc.in}), &tc.out)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
 		}
 
 		if !reflect.DeepEqual(tc.out, tc.expectResult) {
@@ -252,21 +313,114 @@ func TestProductCancelReservation(t *testing.T) {
 		t.Fatalf("can't create logger: %s", err)
 	}
 
-	testCases := getWarehouseProductTestData("canceled")
+	in := []domain.CancelReservation{
+		{
+			ReservationID: 1,
+		},
+		{
+			ReservationID: 2,
+		},
+	}
+
+	reservations := getReservationTestData(domain.ReservationCanceled)
+	expectReservation := getReservationExpectResults(reservations)
+
+	testCases := []cancelReservationTestCase{
+		{
+			in:           in,
+			out:          nil,
+			err:          nil,
+			repeat:       2,
+			expectResult: expectReservation[0],
+		},
+		{
+			in:           in,
+			out:          nil,
+			err:          domain.ErrTest,
+			repeat:       2,
+			repeatError:  1,
+			expectResult: expectReservation[1],
+		},
+		{
+			in:           in,
+			out:          nil,
+			err:          domain.ErrTest,
+			repeat:       2,
+			repeatError:  2,
+			expectResult: expectReservation[2],
+		},
+	}
+
 	handler := NewProductHandler(ps, logger)
 	for _, tc := range testCases {
 		for i := 0; i < int(tc.repeat); i++ {
 			if tc.repeatError > 0 {
-				ps.EXPECT().CancelReservation(&tc.in[i]).Return(tc.err)
+				ps.EXPECT().CancelReservation(gomock.Any(), &tc.in[i]).Return(nil, tc.err)
 				tc.repeatError--
 				continue
-			} else {
-				tc.err = nil
 			}
-			ps.EXPECT().CancelReservation(&tc.in[i]).Return(tc.err)
+			ps.EXPECT().CancelReservation(gomock.Any(), &tc.in[i]).Return(&reservations[i], nil)
 		}
 
-		err = handler.CancelReservation(tc.in, &tc.out)
+		err = handler.CancelReservation(argsOf(tc.in), &tc.out)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !reflect.DeepEqual(tc.out, tc.expectResult) {
+			t.Fatalf("expected: %v, got: %v", tc.expectResult, tc.out)
+		}
+	}
+}
+
+func TestProductFulfill(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ps := mocks.NewMockProductService(ctrl)
+	logger, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("can't create logger: %s", err)
+	}
+
+	in := domain.Fulfillment{
+		ReservationIDs: []int64{1, 2},
+	}
+	shipment := &domain.Shipment{
+		ID: 1,
+		Lines: []domain.ShipmentLine{
+			{
+				ReservationID: 1,
+				WarehouseID:   1,
+				Code:          "test",
+				Quantity:      1,
+			},
+			{
+				ReservationID: 2,
+				WarehouseID:   2,
+				Code:          "test",
+				Quantity:      2,
+			},
+		},
+	}
+
+	testCases := []fulfillTestCase{
+		{
+			in:           in,
+			shipment:     shipment,
+			expectResult: *shipment,
+		},
+		{
+			in:  in,
+			err: domain.ErrNotFound,
+		},
+	}
+
+	handler := NewProductHandler(ps, logger)
+	for _, tc := range testCases {
+		ps.EXPECT().Fulfill(gomock.Any(), &tc.in).Return(tc.shipment, tc.err)
+
+		err = handler.Fulfill(argsOf(tc.in), &tc.out)
 		if !errors.Is(err, tc.err) {
 			t.Fatalf("expected error: %v, got: %v", tc.err, err)
 		}
@@ -302,10 +456,18 @@ func TestProductTransfer(t *testing.T) {
 		},
 	}
 
-	expectWarehouseProduct := [][]domain.TransferProduct{
-		in,
+	expectWarehouseProduct := [][]domain.ItemResult[domain.TransferProduct]{
+		{
+			domain.NewItemResult(0, in[0]),
+			domain.NewItemResult(1, in[1]),
+		},
+		{
+			domain.NewItemError[domain.TransferProduct](0, domain.ErrTest),
+			domain.NewItemResult(1, in[1]),
+		},
 		{
-			in[1],
+			domain.NewItemError[domain.TransferProduct](0, domain.ErrTest),
+			domain.NewItemError[domain.TransferProduct](1, domain.ErrTest),
 		},
 	}
 
@@ -326,11 +488,12 @@ func TestProductTransfer(t *testing.T) {
 			expectResult: expectWarehouseProduct[1],
 		},
 		{
-			in:          in,
-			out:         nil,
-			err:         domain.ErrTest,
-			repeat:      2,
-			repeatError: 2,
+			in:           in,
+			out:          nil,
+			err:          domain.ErrTest,
+			repeat:       2,
+			repeatError:  2,
+			expectResult: expectWarehouseProduct[2],
 		},
 	}
 
@@ -338,18 +501,18 @@ func TestProductTransfer(t *testing.T) {
 	for _, tc := range testCases {
 		for i := 0; i < int(tc.repeat); i++ {
 			if tc.repeatError > 0 {
-				ps.EXPECT().Transfer(&tc.in[i]).Return(tc.err)
+				ps.EXPECT().Transfer(gomock.Any(), &tc.in[i]).Return(tc.err)
 				tc.repeatError--
 				continue
 			} else {
 				tc.err = nil
 			}
-			ps.EXPECT().Transfer(&tc.in[i]).Return(tc.err)
+			ps.EXPECT().Transfer(gomock.Any(), &tc.in[i]).Return(tc.err)
 		}
 
-		err = handler.Transfer(tc.in, &tc.out)
-		if !errors.Is(err, tc.err) {
-			t.Fatalf("expected error: %v, got: %v", tc.err, err)
+		err = handler.Transfer(argsOf(domain.Batch[domain.TransferProduct]{Items: tc.in}), &tc.out)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
 		}
 
 		if !reflect.DeepEqual(tc.out, tc.expectResult) {
@@ -358,7 +521,7 @@ func TestProductTransfer(t *testing.T) {
 	}
 }
 
-func TestProductAdd(t *testing.T) {
+func TestProductReserveAtomic(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
@@ -368,76 +531,70 @@ func TestProductAdd(t *testing.T) {
 		t.Fatalf("can't create logger: %s", err)
 	}
 
-	in := []domain.AddProduct{
+	in := []domain.WarehouseProduct{
 		{
-			Code:        "test",
-			Quantity:    10,
 			WarehouseID: 1,
-		},
-		{
 			Code:        "test",
-			Quantity:    5,
-			WarehouseID: 2,
+			Quantity:    1,
 		},
-	}
-
-	expectWarehouseProduct := [][]domain.AddProduct{
-		in,
 		{
-			in[1],
+			WarehouseID: 2,
+			Code:        "test",
+			Quantity:    2,
 		},
 	}
+	reservations := getReservationTestData(domain.ReservationActive)
 
-	testCases := []addProductTestCase{
+	testCases := []atomicTestCase{
 		{
-			in:           in,
-			out:          nil,
 			err:          nil,
-			repeat:       2,
-			expectResult: expectWarehouseProduct[0],
+			expectStatus: []bool{true, true},
+			expectCodes:  []domain.ErrorCode{"", ""},
 		},
 		{
-			in:           in,
-			out:          nil,
-			err:          domain.ErrTest,
-			repeat:       2,
-			repeatError:  1,
-			expectResult: expectWarehouseProduct[1],
+			err:          &domain.BatchError{Index: 1, Err: domain.ErrInsufficientStock},
+			expectStatus: []bool{false, false},
+			expectCodes:  []domain.ErrorCode{domain.CodeRolledBack, domain.CodeInsufficientStock},
 		},
 		{
-			in:          in,
-			out:         nil,
-			err:         domain.ErrTest,
-			repeat:      2,
-			repeatError: 2,
+			err:          domain.ErrTest,
+			expectStatus: []bool{false, false},
+			expectCodes:  []domain.ErrorCode{domain.CodeInternal, domain.CodeInternal},
 		},
 	}
 
 	handler := NewProductHandler(ps, logger)
 	for _, tc := range testCases {
-		for i := 0; i < int(tc.repeat); i++ {
-			if tc.repeatError > 0 {
-				ps.EXPECT().Add(&tc.in[i]).Return(tc.err)
-				tc.repeatError--
-				continue
-			} else {
-				tc.err = nil
-			}
-			ps.EXPECT().Add(&tc.in[i]).Return(tc.err)
+		var out []domain.ItemResult[domain.Reservation]
+
+		if tc.err != nil {
+			ps.EXPECT().ReserveBatch(gomock.Any(), in).Return(nil, tc.err)
+		} else {
+			ps.EXPECT().ReserveBatch(gomock.Any(), in).Return(reservations, nil)
 		}
 
-		err = handler.Add(tc.in, &tc.out)
-		if !errors.Is(err, tc.err) {
-			t.Fatalf("expected error: %v, got: %v", tc.err, err)
+		err = handler.Reserve(argsOf(domain.Batch[domain.WarehouseProduct]{Items: in, Atomic: true}), &out)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
 		}
 
-		if !reflect.DeepEqual(tc.out, tc.expectResult) {
-			t.Fatalf("expected: %v, got: %v", tc.expectResult, tc.out)
+		if len(out) != len(in) {
+			t.Fatalf("expected %d results, got: %d", len(in), len(out))
+		}
+
+		for i, result := range out {
+			if result.Index != i || result.Success != tc.expectStatus[i] || result.Code != tc.expectCodes[i] {
+				t.Fatalf("unexpected result %d: %+v", i, result)
+			}
+
+			if result.Success && result.Item.ID != reservations[i].ID {
+				t.Fatalf("expected reservation: %d, got: %d", reservations[i].ID, result.Item.ID)
+			}
 		}
 	}
 }
 
-func TestProductDelete(t *testing.T) {
+func TestProductTransferAtomic(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
@@ -447,39 +604,196 @@ func TestProductDelete(t *testing.T) {
 		t.Fatalf("can't create logger: %s", err)
 	}
 
-	in := []domain.DeleteProduct{
+	in := []domain.TransferProduct{
 		{
-			Code: "test-1",
+			WarehouseFromID: 1,
+			WarehouseToID:   2,
+			Code:            "test",
+			Quantity:        10,
 		},
 		{
-			Code: "test-2",
+			WarehouseFromID: 2,
+			WarehouseToID:   3,
+			Code:            "test",
+			Quantity:        10,
 		},
 	}
 
-	product := []domain.Product{
+	testCases := []atomicTestCase{
 		{
-			Name:     "test",
-			Size:     "test",
-			Code:     "test-1",
-			Quantity: 5,
+			err:          nil,
+			expectStatus: []bool{true, true},
+			expectCodes:  []domain.ErrorCode{"", ""},
 		},
 		{
-			Name:     "test",
-			Size:     "test",
-			Code:     "test-2",
-			Quantity: 1,
+			err:          &domain.BatchError{Index: 0, Err: domain.ErrWarehouseUnavailable},
+			expectStatus: []bool{false, false},
+			expectCodes:  []domain.ErrorCode{domain.CodeWarehouseUnavailable, domain.CodeRolledBack},
 		},
 	}
 
-	expectWarehouseProduct := [][]domain.Product{
-		product,
-		{
-			product[1],
-		},
+	handler := NewProductHandler(ps, logger)
+	for _, tc := range testCases {
+		var out []domain.ItemResult[domain.TransferProduct]
+
+		ps.EXPECT().TransferBatch(gomock.Any(), in).Return(tc.err)
+
+		err = handler.Transfer(argsOf(domain.Batch[domain.TransferProduct]{Items: in, Atomic: true}), &out)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(out) != len(in) {
+			t.Fatalf("expected %d results, got: %d", len(in), len(out))
+		}
+
+		for i, result := range out {
+			if result.Index != i || result.Success != tc.expectStatus[i] || result.Code != tc.expectCodes[i] {
+				t.Fatalf("unexpected result %d: %+v", i, result)
+			}
+		}
 	}
+}
 
-	testCases := []deleteProductTestCase{
-		{
+func TestProductAdd(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ps := mocks.NewMockProductService(ctrl)
+	logger, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("can't create logger: %s", err)
+	}
+
+	in := []domain.AddProduct{
+		{
+			Code:        "test",
+			Quantity:    10,
+			WarehouseID: 1,
+		},
+		{
+			Code:        "test",
+			Quantity:    5,
+			WarehouseID: 2,
+		},
+	}
+
+	expectWarehouseProduct := [][]domain.ItemResult[domain.AddProduct]{
+		{
+			domain.NewItemResult(0, in[0]),
+			domain.NewItemResult(1, in[1]),
+		},
+		{
+			domain.NewItemError[domain.AddProduct](0, domain.ErrTest),
+			domain.NewItemResult(1, in[1]),
+		},
+		{
+			domain.NewItemError[domain.AddProduct](0, domain.ErrTest),
+			domain.NewItemError[domain.AddProduct](1, domain.ErrTest),
+		},
+	}
+
+	testCases := []addProductTestCase{
+		{
+			in:           in,
+			out:          nil,
+			err:          nil,
+			repeat:       2,
+			expectResult: expectWarehouseProduct[0],
+		},
+		{
+			in:           in,
+			out:          nil,
+			err:          domain.ErrTest,
+			repeat:       2,
+			repeatError:  1,
+			expectResult: expectWarehouseProduct[1],
+		},
+		{
+			in:           in,
+			out:          nil,
+			err:          domain.ErrTest,
+			repeat:       2,
+			repeatError:  2,
+			expectResult: expectWarehouseProduct[2],
+		},
+	}
+
+	handler := NewProductHandler(ps, logger)
+	for _, tc := range testCases {
+		for i := 0; i < int(tc.repeat); i++ {
+			if tc.repeatError > 0 {
+				ps.EXPECT().Add(gomock.Any(), &tc.in[i]).Return(tc.err)
+				tc.repeatError--
+				continue
+			} else {
+				tc.err = nil
+			}
+			ps.EXPECT().Add(gomock.Any(), &tc.in[i]).Return(tc.err)
+		}
+
+		err = handler.Add(argsOf(tc.in), &tc.out)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !reflect.DeepEqual(tc.out, tc.expectResult) {
+			t.Fatalf("expected: %v, got: %v", tc.expectResult, tc.out)
+		}
+	}
+}
+
+func TestProductDelete(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ps := mocks.NewMockProductService(ctrl)
+	logger, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("can't create logger: %s", err)
+	}
+
+	in := []domain.DeleteProduct{
+		{
+			Code: "test-1",
+		},
+		{
+			Code: "test-2",
+		},
+	}
+
+	product := []domain.Product{
+		{
+			Name:     "test",
+			Size:     "test",
+			Code:     "test-1",
+			Quantity: 5,
+		},
+		{
+			Name:     "test",
+			Size:     "test",
+			Code:     "test-2",
+			Quantity: 1,
+		},
+	}
+
+	expectWarehouseProduct := [][]domain.ItemResult[domain.Product]{
+		{
+			domain.NewItemResult(0, product[0]),
+			domain.NewItemResult(1, product[1]),
+		},
+		{
+			domain.NewItemError[domain.Product](0, domain.ErrTest),
+			domain.NewItemResult(1, product[1]),
+		},
+		{
+			domain.NewItemError[domain.Product](0, domain.ErrTest),
+			domain.NewItemError[domain.Product](1, domain.ErrTest),
+		},
+	}
+
+	testCases := []deleteProductTestCase{
+		{
 			in:           in,
 			out:          nil,
 			err:          nil,
@@ -495,11 +809,12 @@ func TestProductDelete(t *testing.T) {
 			expectResult: expectWarehouseProduct[1],
 		},
 		{
-			in:          in,
-			out:         nil,
-			err:         domain.ErrTest,
-			repeat:      2,
-			repeatError: 2,
+			in:           in,
+			out:          nil,
+			err:          domain.ErrTest,
+			repeat:       2,
+			repeatError:  2,
+			expectResult: expectWarehouseProduct[2],
 		},
 	}
 
@@ -507,16 +822,61 @@ func TestProductDelete(t *testing.T) {
 	for _, tc := range testCases {
 		for i := 0; i < int(tc.repeat); i++ {
 			if tc.repeatError > 0 {
-				ps.EXPECT().Delete(&tc.in[i]).Return(&product[i], tc.err)
+				ps.EXPECT().Delete(gomock.Any(), &tc.in[i]).Return(&product[i], tc.err)
 				tc.repeatError--
 				continue
 			} else {
 				tc.err = nil
 			}
-			ps.EXPECT().Delete(&tc.in[i]).Return(&product[i], tc.err)
+			ps.EXPECT().Delete(gomock.Any(), &tc.in[i]).Return(&product[i], tc.err)
+		}
+
+		err = handler.Delete(argsOf(tc.in), &tc.out)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !reflect.DeepEqual(tc.out, tc.expectResult) {
+			t.Fatalf("expected: %v, got: %v", tc.expectResult, tc.out)
 		}
+	}
+}
+
+func TestProductGet(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ps := mocks.NewMockProductService(ctrl)
+	logger, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("can't create logger: %s", err)
+	}
+
+	in := domain.GetProduct{Code: "test"}
+	product := &domain.Product{
+		Name:     "test",
+		Size:     "test",
+		Code:     "test",
+		Quantity: 5,
+	}
 
-		err = handler.Delete(tc.in, &tc.out)
+	testCases := []getProductTestCase{
+		{
+			in:           in,
+			product:      product,
+			expectResult: *product,
+		},
+		{
+			in:  in,
+			err: domain.ErrNotFound,
+		},
+	}
+
+	handler := NewProductHandler(ps, logger)
+	for _, tc := range testCases {
+		ps.EXPECT().Get(gomock.Any(), &tc.in).Return(tc.product, tc.err)
+
+		err = handler.Get(argsOf(tc.in), &tc.out)
 		if !errors.Is(err, tc.err) {
 			t.Fatalf("expected error: %v, got: %v", tc.err, err)
 		}
@@ -526,3 +886,411 @@ func TestProductDelete(t *testing.T) {
 		}
 	}
 }
+
+func TestProductList(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ps := mocks.NewMockProductService(ctrl)
+	logger, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("can't create logger: %s", err)
+	}
+
+	in := domain.ProductFilter{
+		Name:  "test",
+		Limit: 10,
+	}
+	products := []domain.Product{
+		{
+			Name:     "test-1",
+			Size:     "test",
+			Code:     "test-1",
+			Quantity: 5,
+		},
+		{
+			Name:     "test-2",
+			Size:     "test",
+			Code:     "test-2",
+			Quantity: 1,
+		},
+	}
+
+	testCases := []listProductTestCase{
+		{
+			in:           in,
+			products:     products,
+			expectResult: products,
+		},
+		{
+			in:  in,
+			err: domain.ErrTest,
+		},
+	}
+
+	handler := NewProductHandler(ps, logger)
+	for _, tc := range testCases {
+		ps.EXPECT().List(gomock.Any(), &tc.in).Return(tc.products, tc.err)
+
+		err = handler.List(argsOf(tc.in), &tc.out)
+		if !errors.Is(err, tc.err) {
+			t.Fatalf("expected error: %v, got: %v", tc.err, err)
+		}
+
+		if !reflect.DeepEqual(tc.out, tc.expectResult) {
+			t.Fatalf("expected: %v, got: %v", tc.expectResult, tc.out)
+		}
+	}
+}
+
+func TestProductUpdate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ps := mocks.NewMockProductService(ctrl)
+	logger, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("can't create logger: %s", err)
+	}
+
+	in := []domain.UpdateProduct{
+		{
+			Code: "test-1",
+			Name: "new name",
+		},
+		{
+			Code: "test-2",
+			Size: "new size",
+		},
+	}
+
+	product := []domain.Product{
+		{
+			Name:     "new name",
+			Size:     "test",
+			Code:     "test-1",
+			Quantity: 5,
+		},
+		{
+			Name:     "test",
+			Size:     "new size",
+			Code:     "test-2",
+			Quantity: 1,
+		},
+	}
+
+	testCases := []updateProductTestCase{
+		{
+			in:   in,
+			errs: []error{nil, nil},
+			expectResult: []domain.ItemResult[domain.Product]{
+				domain.NewItemResult(0, product[0]),
+				domain.NewItemResult(1, product[1]),
+			},
+		},
+		{
+			in:   in,
+			errs: []error{domain.ErrNotFound, nil},
+			expectResult: []domain.ItemResult[domain.Product]{
+				domain.NewItemError[domain.Product](0, domain.ErrNotFound),
+				domain.NewItemResult(1, product[1]),
+			},
+		},
+	}
+
+	handler := NewProductHandler(ps, logger)
+	for _, tc := range testCases {
+		for i := range tc.in {
+			if tc.errs[i] != nil {
+				ps.EXPECT().Update(gomock.Any(), &tc.in[i]).Return(nil, tc.errs[i])
+				continue
+			}
+
+			ps.EXPECT().Update(gomock.Any(), &tc.in[i]).Return(&product[i], nil)
+		}
+
+		err = handler.Update(argsOf(tc.in), &tc.out)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !reflect.DeepEqual(tc.out, tc.expectResult) {
+			t.Fatalf("expected: %v, got: %v", tc.expectResult, tc.out)
+		}
+	}
+}
+
+func TestProductRelocate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ps := mocks.NewMockProductService(ctrl)
+	logger, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("can't create logger: %s", err)
+	}
+
+	in := []domain.Relocation{
+		{WarehouseID: 1, Code: "test", Quantity: 5, ToBinID: 7},
+		{WarehouseID: 1, Code: "test", Quantity: 5, FromBinID: 7, ToBinID: 8},
+	}
+
+	expectResult := []domain.ItemResult[domain.Relocation]{
+		domain.NewItemResult(0, in[0]),
+		domain.NewItemError[domain.Relocation](1, domain.ErrInsufficientStock),
+	}
+
+	ps.EXPECT().Relocate(gomock.Any(), &in[0]).Return(nil)
+	ps.EXPECT().Relocate(gomock.Any(), &in[1]).Return(domain.ErrInsufficientStock)
+
+	var out []domain.ItemResult[domain.Relocation]
+	if err = NewProductHandler(ps, logger).Relocate(argsOf(in), &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(out, expectResult) {
+		t.Fatalf("expected: %v, got: %v", expectResult, out)
+	}
+}
+
+func TestProductMoveStock(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ps := mocks.NewMockProductService(ctrl)
+	logger, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("can't create logger: %s", err)
+	}
+
+	in := []domain.BucketMove{
+		{WarehouseID: 1, Code: "test", From: domain.BucketAvailable, To: domain.BucketQuarantined, Quantity: 5,
+			Reason: domain.ReasonQAHold},
+		{WarehouseID: 1, Code: "test", From: domain.BucketDamaged, To: domain.BucketAvailable, Quantity: 5,
+			Reason: domain.ReasonRepair},
+	}
+
+	expectResult := []domain.ItemResult[domain.BucketMove]{
+		domain.NewItemResult(0, in[0]),
+		domain.NewItemError[domain.BucketMove](1, domain.ErrInsufficientStock),
+	}
+
+	ps.EXPECT().MoveStock(gomock.Any(), &in[0]).Return(nil)
+	ps.EXPECT().MoveStock(gomock.Any(), &in[1]).Return(domain.ErrInsufficientStock)
+
+	var out []domain.ItemResult[domain.BucketMove]
+	if err = NewProductHandler(ps, logger).MoveStock(argsOf(in), &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(out, expectResult) {
+		t.Fatalf("expected: %v, got: %v", expectResult, out)
+	}
+}
+
+func TestProductAdjust(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ps := mocks.NewMockProductService(ctrl)
+	logger, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("can't create logger: %s", err)
+	}
+
+	in := []domain.Adjustment{
+		{WarehouseID: 1, Code: "test", Quantity: -3, Reason: domain.ReasonDamage, RequestedBy: "alice"},
+		{WarehouseID: 1, Code: "test", Quantity: 50, Reason: domain.ReasonCountCorrection, RequestedBy: "alice"},
+	}
+
+	expectResult := []domain.ItemResult[domain.Adjustment]{
+		domain.NewItemResult(0, in[0]),
+		domain.NewItemError[domain.Adjustment](1, domain.ErrNotFound),
+	}
+
+	ps.EXPECT().Adjust(gomock.Any(), &in[0]).Return(nil)
+	ps.EXPECT().Adjust(gomock.Any(), &in[1]).Return(domain.ErrNotFound)
+
+	var out []domain.ItemResult[domain.Adjustment]
+	if err = NewProductHandler(ps, logger).Adjust(argsOf(in), &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(out, expectResult) {
+		t.Fatalf("expected: %v, got: %v", expectResult, out)
+	}
+}
+
+func TestProductApproveAdjustment(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ps := mocks.NewMockProductService(ctrl)
+	logger, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("can't create logger: %s", err)
+	}
+
+	in := []domain.DecideAdjustment{{ID: 1, User: "bob"}, {ID: 2, User: "alice"}}
+	approved := domain.Adjustment{ID: 1, Status: domain.AdjustmentApplied, DecidedBy: "bob"}
+
+	expectResult := []domain.ItemResult[domain.Adjustment]{
+		domain.NewItemResult(0, approved),
+		domain.NewItemError[domain.Adjustment](1, domain.ErrValidationFailed),
+	}
+
+	ps.EXPECT().ApproveAdjustment(gomock.Any(), &in[0]).Return(&approved, nil)
+	ps.EXPECT().ApproveAdjustment(gomock.Any(), &in[1]).Return(nil, domain.ErrValidationFailed)
+
+	var out []domain.ItemResult[domain.Adjustment]
+	if err = NewProductHandler(ps, logger).ApproveAdjustment(argsOf(in), &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(out, expectResult) {
+		t.Fatalf("expected: %v, got: %v", expectResult, out)
+	}
+}
+
+func TestProductSuggestTransfers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ps := mocks.NewMockProductService(ctrl)
+	logger, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("can't create logger: %s", err)
+	}
+
+	handler := NewProductHandler(ps, logger)
+
+	in := domain.SuggestTransfers{Code: "test", Execute: true}
+	suggestion := domain.TransferSuggestion{
+		Transfers: []domain.TransferProduct{{WarehouseFromID: 1, WarehouseToID: 2, Code: "test", Quantity: 4}},
+		Executed:  true,
+	}
+
+	ps.EXPECT().SuggestTransfers(gomock.Any(), &in).Return(&suggestion, nil)
+
+	var out domain.TransferSuggestion
+	if err = handler.SuggestTransfers(argsOf(in), &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(out, suggestion) {
+		t.Fatalf("expected: %v, got: %v", suggestion, out)
+	}
+
+	ps.EXPECT().SuggestTransfers(gomock.Any(), &in).Return(nil, domain.ErrInsufficientStock)
+
+	if err = handler.SuggestTransfers(argsOf(in), &out); !errors.Is(err, domain.ErrInsufficientStock) {
+		t.Fatalf("expected: %v, got: %v", domain.ErrInsufficientStock, err)
+	}
+}
+
+func TestProductAllocate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ps := mocks.NewMockProductService(ctrl)
+	logger, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("can't create logger: %s", err)
+	}
+
+	handler := NewProductHandler(ps, logger)
+
+	in := domain.Allocation{Code: "test", Quantity: 12, WarehouseIDs: []int64{2, 1}, Strategy: domain.AllocateMostStock}
+	reservations := []domain.Reservation{
+		{ID: 1, WarehouseID: 2, Code: "test", Quantity: 10, Status: domain.ReservationActive},
+		{ID: 2, WarehouseID: 1, Code: "test", Quantity: 2, Status: domain.ReservationActive},
+	}
+
+	ps.EXPECT().Allocate(gomock.Any(), &in).Return(reservations, nil)
+
+	var out []domain.Reservation
+	if err = handler.Allocate(argsOf(in), &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(out, reservations) {
+		t.Fatalf("expected: %v, got: %v", reservations, out)
+	}
+
+	ps.EXPECT().Allocate(gomock.Any(), &in).Return(nil, domain.ErrInsufficientStock)
+
+	if err = handler.Allocate(argsOf(in), &out); !errors.Is(err, domain.ErrInsufficientStock) {
+		t.Fatalf("expected: %v, got: %v", domain.ErrInsufficientStock, err)
+	}
+}
+
+func TestProductDispatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ps := mocks.NewMockProductService(ctrl)
+	logger, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("can't create logger: %s", err)
+	}
+
+	handler := NewProductHandler(ps, logger)
+
+	in := []domain.TransferProduct{
+		{WarehouseFromID: 1, WarehouseToID: 2, Code: "test", Quantity: 5},
+		{WarehouseFromID: 1, WarehouseToID: 2, Code: "test", Quantity: 50},
+	}
+	transfer := domain.NewTransitTransfer(&in[0])
+	transfer.ID = 7
+
+	ps.EXPECT().Dispatch(gomock.Any(), &in[0]).Return(&transfer, nil)
+	ps.EXPECT().Dispatch(gomock.Any(), &in[1]).Return(nil, domain.ErrInsufficientStock)
+
+	expected := []domain.ItemResult[domain.TransitTransfer]{
+		domain.NewItemResult(0, transfer),
+		domain.NewItemError[domain.TransitTransfer](1, domain.ErrInsufficientStock),
+	}
+
+	var out []domain.ItemResult[domain.TransitTransfer]
+	if err = handler.Dispatch(argsOf(in), &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(out, expected) {
+		t.Fatalf("expected: %v, got: %v", expected, out)
+	}
+}
+
+func TestProductReceive(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ps := mocks.NewMockProductService(ctrl)
+	logger, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("can't create logger: %s", err)
+	}
+
+	handler := NewProductHandler(ps, logger)
+
+	in := []domain.ReceiveTransfer{{TransferID: 7, Quantity: 3}, {TransferID: 8}}
+	transfer := domain.TransitTransfer{ID: 7, WarehouseFromID: 1, WarehouseToID: 2, Code: "test", Quantity: 5, Received: 3,
+		Status: domain.TransferInTransit}
+
+	ps.EXPECT().Receive(gomock.Any(), &in[0]).Return(&transfer, nil)
+	ps.EXPECT().Receive(gomock.Any(), &in[1]).Return(nil, domain.ErrNotFound)
+
+	expected := []domain.ItemResult[domain.TransitTransfer]{
+		domain.NewItemResult(0, transfer),
+		domain.NewItemError[domain.TransitTransfer](1, domain.ErrNotFound),
+	}
+
+	var out []domain.ItemResult[domain.TransitTransfer]
+	if err = handler.Receive(argsOf(in), &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(out, expected) {
+		t.Fatalf("expected: %v, got: %v", expected, out)
+	}
+}
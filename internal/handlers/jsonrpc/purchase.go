@@ -0,0 +1,118 @@
+package jsonrpc
+
+import (
+	"github.com/akrovv/warehouse/internal/domain"
+	"github.com/akrovv/warehouse/pkg/logger"
+)
+
+type purchaseHandler struct {
+	service PurchaseService
+	logger  logger.Logger
+}
+
+func NewPurchaseHandler(service PurchaseService, logger logger.Logger) *purchaseHandler {
+	return &purchaseHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func (h *purchaseHandler) CreateSupplier(in Args[[]domain.Supplier], out *[]domain.ItemResult[domain.Supplier]) error {
+	results := make([]domain.ItemResult[domain.Supplier], 0, len(in.Params))
+
+	for i, value := range in.Params {
+		if err := h.service.CreateSupplier(in.Context(), &value); err != nil {
+			h.logger.Infof("can't create supplier: %v, got error: %s", value, err.Error())
+			results = append(results, domain.NewItemError[domain.Supplier](i, err))
+			continue
+		}
+
+		results = append(results, domain.NewItemResult(i, value))
+	}
+
+	*out = results
+	return nil
+}
+
+func (h *purchaseHandler) ListSuppliers(in Args[domain.SupplierFilter], out *[]domain.Supplier) error {
+	suppliers, err := h.service.ListSuppliers(in.Context(), &in.Params)
+	if err != nil {
+		return serviceError(in.Context(), "ListSuppliers", err)
+	}
+
+	*out = suppliers
+	return nil
+}
+
+func (h *purchaseHandler) CreateOrder(in Args[[]domain.PurchaseOrder], out *[]domain.ItemResult[domain.PurchaseOrder]) error {
+	results := make([]domain.ItemResult[domain.PurchaseOrder], 0, len(in.Params))
+
+	for i, value := range in.Params {
+		if err := h.service.CreateOrder(in.Context(), &value); err != nil {
+			h.logger.Infof("can't create purchase order: %v, got error: %s", value, err.Error())
+			results = append(results, domain.NewItemError[domain.PurchaseOrder](i, err))
+			continue
+		}
+
+		results = append(results, domain.NewItemResult(i, value))
+	}
+
+	*out = results
+	return nil
+}
+
+func (h *purchaseHandler) GetOrder(in Args[domain.GetPurchaseOrder], out *domain.PurchaseOrder) error {
+	po, err := h.service.GetOrder(in.Context(), &in.Params)
+	if err != nil {
+		return serviceError(in.Context(), "GetOrder", err)
+	}
+
+	*out = *po
+	return nil
+}
+
+func (h *purchaseHandler) Receive(in Args[[]domain.PurchaseReceipt], out *[]domain.ItemResult[domain.PurchaseOrder]) error {
+	results := make([]domain.ItemResult[domain.PurchaseOrder], 0, len(in.Params))
+
+	for i, value := range in.Params {
+		po, err := h.service.Receive(in.Context(), &value)
+		if err != nil {
+			h.logger.Infof("can't receive purchase order: %v, got error: %s", value, err.Error())
+			results = append(results, domain.NewItemError[domain.PurchaseOrder](i, err))
+			continue
+		}
+
+		results = append(results, domain.NewItemResult(i, *po))
+	}
+
+	*out = results
+	return nil
+}
+
+func (h *purchaseHandler) CloseOrder(in Args[[]domain.ClosePurchaseOrder], out *[]domain.ItemResult[domain.PurchaseOrder]) error {
+	results := make([]domain.ItemResult[domain.PurchaseOrder], 0, len(in.Params))
+
+	for i, value := range in.Params {
+		po, err := h.service.CloseOrder(in.Context(), &value)
+		if err != nil {
+			h.logger.Infof("can't close purchase order: %v, got error: %s", value, err.Error())
+			results = append(results, domain.NewItemError[domain.PurchaseOrder](i, err))
+			continue
+		}
+
+		results = append(results, domain.NewItemResult(i, *po))
+	}
+
+	*out = results
+	return nil
+}
+
+func (h *purchaseHandler) Outstanding(in Args[domain.OutstandingFilter], out *[]domain.OutstandingLine) error {
+	lines, err := h.service.Outstanding(in.Context(), &in.Params)
+	if err != nil {
+		return serviceError(in.Context(), "Outstanding", err)
+	}
+
+	*out = lines
+	return nil
+}
@@ -0,0 +1,399 @@
+package jsonrpc
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/akrovv/warehouse/internal/domain"
+	"github.com/akrovv/warehouse/internal/services/mocks"
+	"github.com/akrovv/warehouse/pkg/logger"
+	"github.com/golang/mock/gomock"
+)
+
+// itemTestCase fails the items of a batch with errs, items with nil errors
+// succeed.
+type itemTestCase struct {
+	errs        []error
+	expectCodes []domain.ErrorCode
+}
+
+type singleTestCase struct {
+	err        error
+	expectCode domain.ErrorCode
+}
+
+var singleTestCases = []singleTestCase{
+	{
+		err:        nil,
+		expectCode: "",
+	},
+	{
+		err:        fmt.Errorf("%w: id 3", domain.ErrNotFound),
+		expectCode: domain.CodeNotFound,
+	},
+	{
+		err:        fmt.Errorf("%w: limit must not exceed 100", domain.ErrValidationFailed),
+		expectCode: domain.CodeValidationFailed,
+	},
+	{
+		err:        domain.ErrTest,
+		expectCode: domain.CodeInternal,
+	},
+}
+
+// expectItemResults checks that every item got its own result, succeeded
+// items carry what the service returned for them.
+func expectItemResults[T any](t *testing.T, tc itemTestCase, out []domain.ItemResult[T], items []T) {
+	t.Helper()
+
+	if len(out) != len(tc.errs) {
+		t.Fatalf("expected %d results, got: %d", len(tc.errs), len(out))
+	}
+
+	for i, result := range out {
+		if result.Index != i || result.Success != (tc.errs[i] == nil) || result.Code != tc.expectCodes[i] {
+			t.Fatalf("unexpected result %d: %+v, expected code: %q", i, result, tc.expectCodes[i])
+		}
+
+		if result.Success && !reflect.DeepEqual(*result.Item, items[i]) {
+			t.Fatalf("expected: %v, got: %v", items[i], *result.Item)
+		}
+	}
+}
+
+// expectSingleError checks that the error of a method that is not a batch
+// keeps its code.
+func expectSingleError(t *testing.T, tc singleTestCase, err error) {
+	t.Helper()
+
+	if !errors.Is(err, tc.err) {
+		t.Fatalf("expected error: %v, got: %v", tc.err, err)
+	}
+
+	if err != nil && domain.CodeOf(err) != tc.expectCode {
+		t.Fatalf("expected code: %s, got: %s", tc.expectCode, domain.CodeOf(err))
+	}
+}
+
+func TestPurchaseCreateSupplier(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ps := mocks.NewMockPurchaseService(ctrl)
+	logger, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("can't create logger: %s", err)
+	}
+
+	in := []domain.Supplier{
+		{
+			Name: "supplier-1",
+		},
+		{
+			Name: "",
+		},
+	}
+
+	testCases := []itemTestCase{
+		{
+			errs:        []error{nil, nil},
+			expectCodes: []domain.ErrorCode{"", ""},
+		},
+		{
+			errs:        []error{nil, domain.ErrValidationFailed},
+			expectCodes: []domain.ErrorCode{"", domain.CodeValidationFailed},
+		},
+		{
+			errs:        []error{domain.ErrTest, domain.ErrValidationFailed},
+			expectCodes: []domain.ErrorCode{domain.CodeInternal, domain.CodeValidationFailed},
+		},
+	}
+
+	handler := NewPurchaseHandler(ps, logger)
+	for _, tc := range testCases {
+		for i := range in {
+			ps.EXPECT().CreateSupplier(gomock.Any(), &in[i]).Return(tc.errs[i])
+		}
+
+		var out []domain.ItemResult[domain.Supplier]
+		if err = handler.CreateSupplier(argsOf(in), &out); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		expectItemResults(t, tc, out, in)
+	}
+}
+
+func TestPurchaseCreateOrder(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ps := mocks.NewMockPurchaseService(ctrl)
+	logger, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("can't create logger: %s", err)
+	}
+
+	in := []domain.PurchaseOrder{
+		{
+			SupplierID: 1,
+			Lines:      []domain.PurchaseOrderLine{{WarehouseID: 1, Code: "test", Ordered: 10}},
+		},
+		{
+			SupplierID: 7,
+			Lines:      []domain.PurchaseOrderLine{{WarehouseID: 1, Code: "test", Ordered: 10}},
+		},
+	}
+
+	testCases := []itemTestCase{
+		{
+			errs:        []error{nil, nil},
+			expectCodes: []domain.ErrorCode{"", ""},
+		},
+		{
+			errs:        []error{nil, fmt.Errorf("%w: supplier 7", domain.ErrNotFound)},
+			expectCodes: []domain.ErrorCode{"", domain.CodeNotFound},
+		},
+		{
+			errs:        []error{domain.ErrValidationFailed, domain.ErrTest},
+			expectCodes: []domain.ErrorCode{domain.CodeValidationFailed, domain.CodeInternal},
+		},
+	}
+
+	handler := NewPurchaseHandler(ps, logger)
+	for _, tc := range testCases {
+		for i := range in {
+			ps.EXPECT().CreateOrder(gomock.Any(), &in[i]).Return(tc.errs[i])
+		}
+
+		var out []domain.ItemResult[domain.PurchaseOrder]
+		if err = handler.CreateOrder(argsOf(in), &out); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		expectItemResults(t, tc, out, in)
+	}
+}
+
+func TestPurchaseReceive(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ps := mocks.NewMockPurchaseService(ctrl)
+	logger, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("can't create logger: %s", err)
+	}
+
+	in := []domain.PurchaseReceipt{
+		{OrderID: 3, Lines: []domain.ReceiptLine{{LineID: 5, Quantity: 4}}},
+		{OrderID: 3, Lines: []domain.ReceiptLine{{LineID: 5, Quantity: 10}}},
+	}
+	orders := []domain.PurchaseOrder{
+		{
+			ID:         3,
+			SupplierID: 1,
+			Status:     domain.PurchaseOrderOpen,
+			Lines:      []domain.PurchaseOrderLine{{ID: 5, WarehouseID: 1, Code: "test", Ordered: 10, Received: 4}},
+		},
+		{
+			ID:         3,
+			SupplierID: 1,
+			Status:     domain.PurchaseOrderReceived,
+			Lines:      []domain.PurchaseOrderLine{{ID: 5, WarehouseID: 1, Code: "test", Ordered: 10, Received: 10}},
+		},
+	}
+
+	testCases := []itemTestCase{
+		{
+			errs:        []error{nil, nil},
+			expectCodes: []domain.ErrorCode{"", ""},
+		},
+		{
+			errs:        []error{nil, fmt.Errorf("%w: line 5 has 6 outstanding", domain.ErrValidationFailed)},
+			expectCodes: []domain.ErrorCode{"", domain.CodeValidationFailed},
+		},
+		{
+			errs: []error{
+				fmt.Errorf("%w: order 3", domain.ErrNotFound),
+				fmt.Errorf("line 5: %w", domain.ErrWarehouseUnavailable),
+			},
+			expectCodes: []domain.ErrorCode{domain.CodeNotFound, domain.CodeWarehouseUnavailable},
+		},
+	}
+
+	handler := NewPurchaseHandler(ps, logger)
+	for _, tc := range testCases {
+		for i := range in {
+			if tc.errs[i] != nil {
+				ps.EXPECT().Receive(gomock.Any(), &in[i]).Return(nil, tc.errs[i])
+				continue
+			}
+
+			ps.EXPECT().Receive(gomock.Any(), &in[i]).Return(&orders[i], nil)
+		}
+
+		var out []domain.ItemResult[domain.PurchaseOrder]
+		if err = handler.Receive(argsOf(in), &out); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		expectItemResults(t, tc, out, orders)
+	}
+}
+
+func TestPurchaseCloseOrder(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ps := mocks.NewMockPurchaseService(ctrl)
+	logger, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("can't create logger: %s", err)
+	}
+
+	in := []domain.ClosePurchaseOrder{
+		{
+			OrderID: 3,
+		},
+		{
+			OrderID: 4,
+		},
+	}
+	orders := []domain.PurchaseOrder{
+		{
+			ID:     3,
+			Status: domain.PurchaseOrderClosed,
+		},
+		{
+			ID:     4,
+			Status: domain.PurchaseOrderClosed,
+		},
+	}
+
+	testCases := []itemTestCase{
+		{
+			errs:        []error{nil, nil},
+			expectCodes: []domain.ErrorCode{"", ""},
+		},
+		{
+			errs:        []error{fmt.Errorf("%w: order 3 is received", domain.ErrValidationFailed), nil},
+			expectCodes: []domain.ErrorCode{domain.CodeValidationFailed, ""},
+		},
+		{
+			errs:        []error{domain.ErrTest, fmt.Errorf("%w: order 4", domain.ErrNotFound)},
+			expectCodes: []domain.ErrorCode{domain.CodeInternal, domain.CodeNotFound},
+		},
+	}
+
+	handler := NewPurchaseHandler(ps, logger)
+	for _, tc := range testCases {
+		for i := range in {
+			if tc.errs[i] != nil {
+				ps.EXPECT().CloseOrder(gomock.Any(), &in[i]).Return(nil, tc.errs[i])
+				continue
+			}
+
+			ps.EXPECT().CloseOrder(gomock.Any(), &in[i]).Return(&orders[i], nil)
+		}
+
+		var out []domain.ItemResult[domain.PurchaseOrder]
+		if err = handler.CloseOrder(argsOf(in), &out); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		expectItemResults(t, tc, out, orders)
+	}
+}
+
+func TestPurchaseGetOrder(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ps := mocks.NewMockPurchaseService(ctrl)
+	logger, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("can't create logger: %s", err)
+	}
+
+	in := domain.GetPurchaseOrder{ID: 3}
+	po := domain.PurchaseOrder{ID: 3, SupplierID: 1, Status: domain.PurchaseOrderOpen}
+
+	handler := NewPurchaseHandler(ps, logger)
+	for _, tc := range singleTestCases {
+		if tc.err != nil {
+			ps.EXPECT().GetOrder(gomock.Any(), &in).Return(nil, tc.err)
+		} else {
+			ps.EXPECT().GetOrder(gomock.Any(), &in).Return(&po, nil)
+		}
+
+		var out domain.PurchaseOrder
+		err = handler.GetOrder(argsOf(in), &out)
+		expectSingleError(t, tc, err)
+
+		if tc.err == nil && !reflect.DeepEqual(out, po) {
+			t.Fatalf("expected: %v, got: %v", po, out)
+		}
+	}
+}
+
+func TestPurchaseListSuppliers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ps := mocks.NewMockPurchaseService(ctrl)
+	logger, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("can't create logger: %s", err)
+	}
+
+	in := domain.SupplierFilter{Limit: 10}
+	suppliers := []domain.Supplier{{ID: 1, Name: "supplier-1"}}
+
+	handler := NewPurchaseHandler(ps, logger)
+	for _, tc := range singleTestCases {
+		ps.EXPECT().ListSuppliers(gomock.Any(), &in).Return(suppliers, tc.err)
+
+		var out []domain.Supplier
+		err = handler.ListSuppliers(argsOf(in), &out)
+		expectSingleError(t, tc, err)
+
+		if tc.err == nil && !reflect.DeepEqual(out, suppliers) {
+			t.Fatalf("expected: %v, got: %v", suppliers, out)
+		}
+	}
+}
+
+func TestPurchaseOutstanding(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ps := mocks.NewMockPurchaseService(ctrl)
+	logger, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("can't create logger: %s", err)
+	}
+
+	in := domain.OutstandingFilter{WarehouseID: 1}
+	lines := []domain.OutstandingLine{{
+		OrderID:           3,
+		SupplierID:        1,
+		PurchaseOrderLine: domain.PurchaseOrderLine{ID: 5, WarehouseID: 1, Code: "test", Ordered: 10, Received: 4},
+		Outstanding:       6,
+	}}
+
+	handler := NewPurchaseHandler(ps, logger)
+	for _, tc := range singleTestCases {
+		ps.EXPECT().Outstanding(gomock.Any(), &in).Return(lines, tc.err)
+
+		var out []domain.OutstandingLine
+		err = handler.Outstanding(argsOf(in), &out)
+		expectSingleError(t, tc, err)
+
+		if tc.err == nil && !reflect.DeepEqual(out, lines) {
+			t.Fatalf("expected: %v, got: %v", lines, out)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package jsonrpc
+
+import (
+	"errors"
+
+	"github.com/akrovv/warehouse/internal/domain"
+)
+
+// atomicErrorResults marks the item that failed an atomic batch with its own
+// error and the rest of items as rolled back. Errors not bound to an item
+// are reported for every item.
+func atomicErrorResults[T any](total int, err error) []domain.ItemResult[T] {
+	results := make([]domain.ItemResult[T], 0, total)
+
+	var batchErr *domain.BatchError
+	if !errors.As(err, &batchErr) {
+		for i := 0; i < total; i++ {
+			results = append(results, domain.NewItemError[T](i, err))
+		}
+
+		return results
+	}
+
+	for i := 0; i < total; i++ {
+		if i == batchErr.Index {
+			results = append(results, domain.NewItemError[T](i, batchErr.Err))
+			continue
+		}
+
+		results = append(results, domain.NewItemError[T](i, domain.ErrRolledBack))
+	}
+
+	return results
+}
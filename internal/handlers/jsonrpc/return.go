@@ -0,0 +1,64 @@
+package jsonrpc
+
+import (
+	"github.com/akrovv/warehouse/internal/domain"
+	"github.com/akrovv/warehouse/pkg/logger"
+)
+
+type returnHandler struct {
+	service ReturnService
+	logger  logger.Logger
+}
+
+func NewReturnHandler(service ReturnService, logger logger.Logger) *returnHandler {
+	return &returnHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func (h *returnHandler) Create(in Args[[]domain.CreateReturn], out *[]domain.ItemResult[domain.Return]) error {
+	results := make([]domain.ItemResult[domain.Return], 0, len(in.Params))
+
+	for i, value := range in.Params {
+		ret, err := h.service.Create(in.Context(), &value)
+		if err != nil {
+			h.logger.Infof("can't create return: %v, got error: %s", value, err.Error())
+			results = append(results, domain.NewItemError[domain.Return](i, err))
+			continue
+		}
+
+		results = append(results, domain.NewItemResult(i, *ret))
+	}
+
+	*out = results
+	return nil
+}
+
+func (h *returnHandler) Get(in Args[domain.GetReturn], out *domain.Return) error {
+	ret, err := h.service.Get(in.Context(), &in.Params)
+	if err != nil {
+		return serviceError(in.Context(), "Get", err)
+	}
+
+	*out = *ret
+	return nil
+}
+
+func (h *returnHandler) Receive(in Args[[]domain.ReturnReceipt], out *[]domain.ItemResult[domain.Return]) error {
+	results := make([]domain.ItemResult[domain.Return], 0, len(in.Params))
+
+	for i, value := range in.Params {
+		ret, err := h.service.Receive(in.Context(), &value)
+		if err != nil {
+			h.logger.Infof("can't receive return: %v, got error: %s", value, err.Error())
+			results = append(results, domain.NewItemError[domain.Return](i, err))
+			continue
+		}
+
+		results = append(results, domain.NewItemResult(i, *ret))
+	}
+
+	*out = results
+	return nil
+}
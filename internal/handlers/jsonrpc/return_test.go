@@ -0,0 +1,174 @@
+package jsonrpc
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/akrovv/warehouse/internal/domain"
+	"github.com/akrovv/warehouse/internal/services/mocks"
+	"github.com/akrovv/warehouse/pkg/logger"
+	"github.com/golang/mock/gomock"
+)
+
+func getReturnTestData(status string) []domain.Return {
+	return []domain.Return{
+		{
+			ID:         4,
+			ShipmentID: 2,
+			Status:     status,
+			Lines:      []domain.ReturnLine{{ID: 9, ReservationID: 7, WarehouseID: 1, Code: "test", Quantity: 4}},
+		},
+		{
+			ID:         5,
+			ShipmentID: 2,
+			Status:     status,
+			Lines:      []domain.ReturnLine{{ID: 10, ReservationID: 8, WarehouseID: 2, Code: "test", Quantity: 1}},
+		},
+	}
+}
+
+func TestReturnCreate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	rs := mocks.NewMockReturnService(ctrl)
+	logger, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("can't create logger: %s", err)
+	}
+
+	in := []domain.CreateReturn{
+		{
+			ShipmentID: 2,
+			Lines:      []domain.ReturnLine{{ReservationID: 7, Quantity: 4}},
+		},
+		{
+			ShipmentID: 2,
+			Lines:      []domain.ReturnLine{{ReservationID: 8, Quantity: 1}},
+		},
+	}
+	returns := getReturnTestData(domain.ReturnOpen)
+
+	testCases := []itemTestCase{
+		{
+			errs:        []error{nil, nil},
+			expectCodes: []domain.ErrorCode{"", ""},
+		},
+		{
+			errs:        []error{fmt.Errorf("%w: shipment 2", domain.ErrNotFound), nil},
+			expectCodes: []domain.ErrorCode{domain.CodeNotFound, ""},
+		},
+		{
+			errs: []error{
+				fmt.Errorf("%w: reservation 7 shipped 3 units", domain.ErrValidationFailed),
+				domain.ErrTest,
+			},
+			expectCodes: []domain.ErrorCode{domain.CodeValidationFailed, domain.CodeInternal},
+		},
+	}
+
+	handler := NewReturnHandler(rs, logger)
+	for _, tc := range testCases {
+		for i := range in {
+			if tc.errs[i] != nil {
+				rs.EXPECT().Create(gomock.Any(), &in[i]).Return(nil, tc.errs[i])
+				continue
+			}
+
+			rs.EXPECT().Create(gomock.Any(), &in[i]).Return(&returns[i], nil)
+		}
+
+		var out []domain.ItemResult[domain.Return]
+		if err = handler.Create(argsOf(in), &out); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		expectItemResults(t, tc, out, returns)
+	}
+}
+
+func TestReturnReceive(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	rs := mocks.NewMockReturnService(ctrl)
+	logger, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("can't create logger: %s", err)
+	}
+
+	in := []domain.ReturnReceipt{
+		{ReturnID: 4, Lines: []domain.ReturnReceiptLine{{LineID: 9, Quantity: 4, Disposition: domain.DispositionRestock}}},
+		{ReturnID: 5, Lines: []domain.ReturnReceiptLine{{LineID: 10, Quantity: 1, Disposition: domain.DispositionWriteOff}}},
+	}
+	returns := getReturnTestData(domain.ReturnReceived)
+
+	testCases := []itemTestCase{
+		{
+			errs:        []error{nil, nil},
+			expectCodes: []domain.ErrorCode{"", ""},
+		},
+		{
+			errs:        []error{fmt.Errorf("line 9: %w", domain.ErrWarehouseUnavailable), nil},
+			expectCodes: []domain.ErrorCode{domain.CodeWarehouseUnavailable, ""},
+		},
+		{
+			errs: []error{
+				fmt.Errorf("%w: line 9 has 2 to receive", domain.ErrValidationFailed),
+				fmt.Errorf("%w: return 5", domain.ErrNotFound),
+			},
+			expectCodes: []domain.ErrorCode{domain.CodeValidationFailed, domain.CodeNotFound},
+		},
+	}
+
+	handler := NewReturnHandler(rs, logger)
+	for _, tc := range testCases {
+		for i := range in {
+			if tc.errs[i] != nil {
+				rs.EXPECT().Receive(gomock.Any(), &in[i]).Return(nil, tc.errs[i])
+				continue
+			}
+
+			rs.EXPECT().Receive(gomock.Any(), &in[i]).Return(&returns[i], nil)
+		}
+
+		var out []domain.ItemResult[domain.Return]
+		if err = handler.Receive(argsOf(in), &out); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		expectItemResults(t, tc, out, returns)
+	}
+}
+
+func TestReturnGet(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	rs := mocks.NewMockReturnService(ctrl)
+	logger, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("can't create logger: %s", err)
+	}
+
+	in := domain.GetReturn{ID: 4}
+	ret := getReturnTestData(domain.ReturnOpen)[0]
+
+	handler := NewReturnHandler(rs, logger)
+	for _, tc := range singleTestCases {
+		if tc.err != nil {
+			rs.EXPECT().Get(gomock.Any(), &in).Return(nil, tc.err)
+		} else {
+			rs.EXPECT().Get(gomock.Any(), &in).Return(&ret, nil)
+		}
+
+		var out domain.Return
+		err = handler.Get(argsOf(in), &out)
+		expectSingleError(t, tc, err)
+
+		if tc.err == nil && !reflect.DeepEqual(out, ret) {
+			t.Fatalf("expected: %v, got: %v", ret, out)
+		}
+	}
+}
@@ -1,28 +1,27 @@
 package jsonrpc
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"net/rpc"
-	"net/rpc/jsonrpc"
+	"time"
 
 	"github.com/akrovv/warehouse/pkg/logger"
 )
 
 type server struct {
-	server *rpc.Server
+	server  *rpc.Server
+	logger  logger.Logger
+	timeout time.Duration
 }
 
-type HTTPConn struct {
-	in  io.Reader
-	out io.Writer
-}
-
-func (c *HTTPConn) Read(p []byte) (n int, err error)  { return c.in.Read(p) }
-func (c *HTTPConn) Write(d []byte) (n int, err error) { return c.out.Write(d) }
-func (c *HTTPConn) Close() error                      { return nil }
-
-func NewServer(productService ProductService, warehouseService WarehouseService, logger logger.Logger) (*server, error) {
+func NewServer(productService ProductService, warehouseService WarehouseService, movementService MovementService,
+	purchaseService PurchaseService, returnService ReturnService, countService CountService, logger logger.Logger,
+	timeout time.Duration) (*server, error) {
 	r := rpc.NewServer()
 
 	if err := r.RegisterName("Products", NewProductHandler(productService, logger)); err != nil {
@@ -33,21 +32,126 @@ func NewServer(productService ProductService, warehouseService WarehouseService,
 		return nil, err
 	}
 
+	if err := r.RegisterName("Movements", NewMovementHandler(movementService, logger)); err != nil {
+		return nil, err
+	}
+
+	if err := r.RegisterName("Purchases", NewPurchaseHandler(purchaseService, logger)); err != nil {
+		return nil, err
+	}
+
+	if err := r.RegisterName("Returns", NewReturnHandler(returnService, logger)); err != nil {
+		return nil, err
+	}
+
+	if err := r.RegisterName("Counts", NewCountHandler(countService, logger)); err != nil {
+		return nil, err
+	}
+
 	return &server{
-		server: r,
+		server:  r,
+		logger:  logger,
+		timeout: timeout,
 	}, nil
 }
 
 func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	serverCodec := jsonrpc.NewServerCodec(&HTTPConn{
-		in:  r.Body,
-		out: w,
-	})
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, `{"error":"cant read request"}`, http.StatusBadRequest)
+		return
+	}
+
+	// The timeout covers the whole HTTP request, a batch shares it between
+	// all of its calls.
+	ctx := r.Context()
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+
+	var payload any
+	body = bytes.TrimSpace(body)
+
+	switch {
+	case !json.Valid(body):
+		payload = newErrorResponse(nil, codeParseError, "")
+	case body[0] == '[':
+		payload = s.serveBatch(ctx, body)
+	default:
+		if response := s.serveRequest(ctx, body); response != nil {
+			payload = response
+		}
+	}
+
+	if payload == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
 
 	w.Header().Set("Content-type", "application/json")
-	err := s.server.ServeRequest(serverCodec)
-	if err != nil {
-		http.Error(w, `{"error":"cant serve request"}`, http.StatusInternalServerError)
+	if err = json.NewEncoder(w).Encode(payload); err != nil {
+		s.logger.Infof("can't write response, error: %s", err.Error())
+	}
+}
+
+// serveBatch returns nil if the batch has notifications only. An empty or
+// broken batch gets a single response, not an array.
+func (s *server) serveBatch(ctx context.Context, body []byte) any {
+	var batch []json.RawMessage
+	if err := json.Unmarshal(body, &batch); err != nil || len(batch) == 0 {
+		return newErrorResponse(nil, codeInvalidRequest, "batch must be a non-empty array")
+	}
+
+	responses := make([]*serverResponse, 0, len(batch))
+	for _, raw := range batch {
+		if response := s.serveRequest(ctx, raw); response != nil {
+			responses = append(responses, response)
+		}
+	}
+
+	if len(responses) == 0 {
+		return nil
+	}
+
+	return responses
+}
+
+// serveRequest returns nil for notifications, the rest of requests always
+// get a response, even if they can't be parsed.
+func (s *server) serveRequest(ctx context.Context, raw []byte) *serverResponse {
+	request := new(serverRequest)
+	if err := json.Unmarshal(raw, request); err != nil {
+		return newErrorResponse(nil, codeInvalidRequest, err.Error())
+	}
+
+	if err := request.validate(); err != nil {
+		return newErrorResponse(nil, codeInvalidRequest, err.Error())
+	}
+
+	codec := &serverCodec{request: request}
+	codec.ctx = context.WithValue(ctx, codecKey{}, codec)
+	s.call(codec)
+
+	if request.isNotification() {
+		return nil
+	}
+
+	return codec.response
+}
+
+func (s *server) call(codec *serverCodec) {
+	defer func() {
+		if r := recover(); r != nil {
+			s.logger.Infof("panic while serving %s: %v", codec.request.Method, r)
+			codec.response = newErrorResponse(codec.request.ID, codeInternalError, fmt.Sprint(r))
+		}
+	}()
+
+	err := s.server.ServeRequest(codec)
+	if err != nil && codec.response == nil {
+		codec.response = newErrorResponse(codec.request.ID, codeInternalError, err.Error())
 	}
 }
 
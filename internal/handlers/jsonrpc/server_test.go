@@ -0,0 +1,286 @@
+package jsonrpc
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/akrovv/warehouse/internal/domain"
+	"github.com/akrovv/warehouse/internal/services/mocks"
+	"github.com/akrovv/warehouse/pkg/logger"
+	"github.com/golang/mock/gomock"
+)
+
+type serverTestCase struct {
+	name         string
+	body         string
+	prepare      func(ps *mocks.MockProductService, ws *mocks.MockWarehouseService)
+	expectStatus int
+	expectResult string
+}
+
+func TestServerServeHTTP(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ps := mocks.NewMockProductService(ctrl)
+	ws := mocks.NewMockWarehouseService(ctrl)
+	ms := mocks.NewMockMovementService(ctrl)
+	logger, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("can't create logger: %s", err)
+	}
+
+	server, err := NewServer(ps, ws, ms, mocks.NewMockPurchaseService(ctrl), mocks.NewMockReturnService(ctrl),
+		mocks.NewMockCountService(ctrl), logger, 0)
+	if err != nil {
+		t.Fatalf("can't create server: %s", err)
+	}
+
+	gw := domain.GetFromWarehouse{WarehouseID: 1}
+	products := []domain.Product{
+		{
+			Name:     "test",
+			Size:     "test",
+			Code:     "test",
+			Quantity: 10,
+		},
+	}
+	leftOvers := `[{"name":"test","size":"test","code":"test","quantity":10}]`
+
+	reserve := []domain.WarehouseProduct{
+		{
+			WarehouseID: 1,
+			Code:        "test",
+			Quantity:    1,
+		},
+	}
+	reservations := []domain.Reservation{
+		{
+			ID:          1,
+			WarehouseID: 1,
+			Code:        "test",
+			Quantity:    1,
+			Status:      domain.ReservationActive,
+			CreatedAt:   time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			ExpiresAt:   time.Date(2024, 1, 1, 0, 30, 0, 0, time.UTC),
+		},
+	}
+	reserved := `[{"index":0,"success":true,"item":{"id":1,"order_ref":"","warehouse_id":1,"code":"test","quantity":1,` +
+		`"status":"active","created_at":"2024-01-01T00:00:00Z","expires_at":"2024-01-01T00:30:00Z"}}]`
+
+	testCases := []serverTestCase{
+		{
+			name: "positional params",
+			body: `{"jsonrpc":"2.0","id":1,"method":"Warehouses.GetLeftOvers","params":[{"warehouse_id":1}]}`,
+			prepare: func(_ *mocks.MockProductService, ws *mocks.MockWarehouseService) {
+				ws.EXPECT().GetLeftOvers(gomock.Any(), &gw).Return(products, nil)
+			},
+			expectStatus: http.StatusOK,
+			expectResult: `{"jsonrpc":"2.0","id":1,"result":` + leftOvers + `}`,
+		},
+		{
+			name: "by-name params",
+			body: `{"jsonrpc":"2.0","id":"abc","method":"Warehouses.GetLeftOvers","params":{"warehouse_id":1}}`,
+			prepare: func(_ *mocks.MockProductService, ws *mocks.MockWarehouseService) {
+				ws.EXPECT().GetLeftOvers(gomock.Any(), &gw).Return(products, nil)
+			},
+			expectStatus: http.StatusOK,
+			expectResult: `{"jsonrpc":"2.0","id":"abc","result":` + leftOvers + `}`,
+		},
+		{
+			name: "notification",
+			body: `{"jsonrpc":"2.0","method":"Warehouses.GetLeftOvers","params":[{"warehouse_id":1}]}`,
+			prepare: func(_ *mocks.MockProductService, ws *mocks.MockWarehouseService) {
+				ws.EXPECT().GetLeftOvers(gomock.Any(), &gw).Return(products, nil)
+			},
+			expectStatus: http.StatusNoContent,
+		},
+		{
+			name: "batch",
+			body: `[
+				{"jsonrpc":"2.0","id":1,"method":"Warehouses.GetLeftOvers","params":[{"warehouse_id":1}]},
+				{"jsonrpc":"2.0","method":"Warehouses.GetLeftOvers","params":[{"warehouse_id":1}]},
+				{"jsonrpc":"2.0","id":2,"method":"Warehouses.Unknown","params":[]},
+				1
+			]`,
+			prepare: func(_ *mocks.MockProductService, ws *mocks.MockWarehouseService) {
+				ws.EXPECT().GetLeftOvers(gomock.Any(), &gw).Return(products, nil).Times(2)
+			},
+			expectStatus: http.StatusOK,
+			expectResult: `[
+				{"jsonrpc":"2.0","id":1,"result":` + leftOvers + `},
+				{"jsonrpc":"2.0","id":2,"error":{"code":-32601,"message":"Method not found","data":"rpc: can't find method Warehouses.Unknown"}},
+				{"jsonrpc":"2.0","id":null,"error":{"code":-32600,"message":"Invalid Request","data":"json: cannot unmarshal number into Go value of type jsonrpc.serverRequest"}}
+			]`,
+		},
+		{
+			name:         "batch of notifications",
+			body:         `[{"jsonrpc":"2.0","method":"Warehouses.Unknown"}]`,
+			expectStatus: http.StatusNoContent,
+		},
+		{
+			name:         "empty batch",
+			body:         `[]`,
+			expectStatus: http.StatusOK,
+			expectResult: `{"jsonrpc":"2.0","id":null,"error":{"code":-32600,"message":"Invalid Request","data":"batch must be a non-empty array"}}`,
+		},
+		{
+			name: "legacy batch params",
+			body: `{"jsonrpc":"2.0","id":1,"method":"Products.Reserve","params":[[{"warehouse_id":1,"code":"test","quantity":1}]]}`,
+			prepare: func(ps *mocks.MockProductService, _ *mocks.MockWarehouseService) {
+				ps.EXPECT().Reserve(gomock.Any(), &reserve[0]).Return(&reservations[0], nil)
+			},
+			expectStatus: http.StatusOK,
+			expectResult: `{"jsonrpc":"2.0","id":1,"result":` + reserved + `}`,
+		},
+		{
+			name: "atomic batch params",
+			body: `{"jsonrpc":"2.0","id":1,"method":"Products.Reserve","params":{"items":[{"warehouse_id":1,"code":"test","quantity":1}],"atomic":true}}`,
+			prepare: func(ps *mocks.MockProductService, _ *mocks.MockWarehouseService) {
+				ps.EXPECT().ReserveBatch(gomock.Any(), reserve).Return(reservations, nil)
+			},
+			expectStatus: http.StatusOK,
+			expectResult: `{"jsonrpc":"2.0","id":1,"result":` + reserved + `}`,
+		},
+		{
+			name:         "parse error",
+			body:         `{"jsonrpc":"2.0","id":1,"method"`,
+			expectStatus: http.StatusOK,
+			expectResult: `{"jsonrpc":"2.0","id":null,"error":{"code":-32700,"message":"Parse error"}}`,
+		},
+		{
+			name:         "wrong version",
+			body:         `{"jsonrpc":"1.0","id":1,"method":"Warehouses.GetLeftOvers","params":[{"warehouse_id":1}]}`,
+			expectStatus: http.StatusOK,
+			expectResult: `{"jsonrpc":"2.0","id":null,"error":{"code":-32600,"message":"Invalid Request","data":"unsupported jsonrpc version: \"1.0\""}}`,
+		},
+		{
+			name:         "invalid params",
+			body:         `{"jsonrpc":"2.0","id":1,"method":"Warehouses.GetLeftOvers","params":[{"warehouse_id":"one"}]}`,
+			expectStatus: http.StatusOK,
+			expectResult: `{"jsonrpc":"2.0","id":1,"error":{"code":-32602,"message":"Invalid params","data":"json: cannot unmarshal string into Go struct field GetFromWarehouse.warehouse_id of type int64"}}`,
+		},
+		{
+			name: "server error",
+			body: `{"jsonrpc":"2.0","id":1,"method":"Warehouses.GetLeftOvers","params":[{"warehouse_id":1}]}`,
+			prepare: func(_ *mocks.MockProductService, ws *mocks.MockWarehouseService) {
+				ws.EXPECT().GetLeftOvers(gomock.Any(), &gw).Return(nil, domain.ErrTest)
+			},
+			expectStatus: http.StatusOK,
+			expectResult: `{"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":"service.GetLeftOvers returned: some error","data":"INTERNAL"}}`,
+		},
+		{
+			// The error of the README example, as the PostgreSQL storage returns it.
+			name: "server error code",
+			body: `{"jsonrpc":"2.0","id":1,"method":"Warehouses.GetLeftOvers","params":[{"warehouse_id":1}]}`,
+			prepare: func(_ *mocks.MockProductService, ws *mocks.MockWarehouseService) {
+				err := fmt.Errorf("warehouse 1 has no available products: %w", fmt.Errorf("%w: %w", domain.ErrNotFound, sql.ErrNoRows))
+				ws.EXPECT().GetLeftOvers(gomock.Any(), &gw).Return(nil, err)
+			},
+			expectStatus: http.StatusOK,
+			expectResult: `{"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":"service.GetLeftOvers returned: warehouse 1 has no available products: not found: sql: no rows in result set","data":"NOT_FOUND"}}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		if tc.prepare != nil {
+			tc.prepare(ps, ws)
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tc.body))
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+
+		if w.Code != tc.expectStatus {
+			t.Fatalf("%s: expected status: %d, got: %d", tc.name, tc.expectStatus, w.Code)
+		}
+
+		if tc.expectResult == "" {
+			if w.Body.Len() != 0 {
+				t.Fatalf("%s: expected empty body, got: %s", tc.name, w.Body.String())
+			}
+			continue
+		}
+
+		var expected, got any
+		if err = json.Unmarshal([]byte(tc.expectResult), &expected); err != nil {
+			t.Fatalf("%s: can't unmarshal expected result: %s", tc.name, err)
+		}
+
+		if err = json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+			t.Fatalf("%s: can't unmarshal response: %s", tc.name, err)
+		}
+
+		if !reflect.DeepEqual(got, expected) {
+			t.Fatalf("%s: expected: %v, got: %v", tc.name, expected, got)
+		}
+	}
+}
+
+func TestServerRequestContext(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ws := mocks.NewMockWarehouseService(ctrl)
+	logger, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("can't create logger: %s", err)
+	}
+
+	server, err := NewServer(mocks.NewMockProductService(ctrl), ws, mocks.NewMockMovementService(ctrl),
+		mocks.NewMockPurchaseService(ctrl), mocks.NewMockReturnService(ctrl), mocks.NewMockCountService(ctrl), logger,
+		10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("can't create server: %s", err)
+	}
+
+	// The service gives up only when the request context is done.
+	ws.EXPECT().GetLeftOvers(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, _ *domain.GetFromWarehouse) ([]domain.Product, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}).Times(2)
+
+	body := `{"jsonrpc":"2.0","id":1,"method":"Warehouses.GetLeftOvers","params":[{"warehouse_id":1}]}`
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	testCases := []struct {
+		name          string
+		req           *http.Request
+		expectMessage string
+	}{
+		{
+			name:          "timeout",
+			req:           httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)),
+			expectMessage: "service.GetLeftOvers returned: context deadline exceeded",
+		},
+		{
+			name:          "client gone",
+			req:           httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)).WithContext(canceled),
+			expectMessage: "service.GetLeftOvers returned: context canceled",
+		},
+	}
+
+	for _, tc := range testCases {
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, tc.req)
+
+		var response serverResponse
+		if err = json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("%s: can't unmarshal response: %s", tc.name, err)
+		}
+
+		if response.Error == nil || response.Error.Message != tc.expectMessage {
+			t.Fatalf("%s: expected: %s, got: %s", tc.name, tc.expectMessage, w.Body.String())
+		}
+	}
+}
@@ -1,8 +1,6 @@
 package jsonrpc
 
 import (
-	"fmt"
-
 	"github.com/akrovv/warehouse/internal/domain"
 	"github.com/akrovv/warehouse/pkg/logger"
 )
@@ -19,35 +17,164 @@ func NewWarehouseHandler(service WarehouseService, logger logger.Logger) *wareho
 	}
 }
 
-func (h *warehouseHandler) Create(in []domain.Warehouse, out *[]domain.Warehouse) error {
-	var err error
-	total := 0
-	success := make([]domain.Warehouse, 0, len(in))
+func (h *warehouseHandler) Create(in Args[[]domain.Warehouse], out *[]domain.ItemResult[domain.Warehouse]) error {
+	results := make([]domain.ItemResult[domain.Warehouse], 0, len(in.Params))
 
-	for _, value := range in {
-		if err = h.service.Create(&value); err != nil {
-			h.logger.Infof("error while creating product %v, error: %s\n", value, err.Error())
-			total++
+	for i, value := range in.Params {
+		if err := h.service.Create(in.Context(), &value); err != nil {
+			h.logger.Infof("error while creating warehouse %v, error: %s", value, err.Error())
+			results = append(results, domain.NewItemError[domain.Warehouse](i, err))
 			continue
 		}
-		success = append(success, value)
+
+		results = append(results, domain.NewItemResult(i, value))
+	}
+
+	*out = results
+	return nil
+}
+
+func (h *warehouseHandler) Get(in Args[domain.GetWarehouse], out *domain.Warehouse) error {
+	warehouse, err := h.service.Get(in.Context(), &in.Params)
+
+	if err != nil {
+		return serviceError(in.Context(), "Get", err)
+	}
+
+	*out = *warehouse
+	return nil
+}
+
+func (h *warehouseHandler) List(in Args[domain.WarehouseFilter], out *[]domain.Warehouse) error {
+	warehouses, err := h.service.List(in.Context(), &in.Params)
+
+	if err != nil {
+		return serviceError(in.Context(), "List", err)
 	}
 
-	if total == len(in) {
-		return fmt.Errorf("all calls returned: %w", err)
+	*out = warehouses
+	return nil
+}
+
+func (h *warehouseHandler) Update(in Args[[]domain.UpdateWarehouse], out *[]domain.ItemResult[domain.Warehouse]) error {
+	results := make([]domain.ItemResult[domain.Warehouse], 0, len(in.Params))
+
+	for i, value := range in.Params {
+		warehouse, err := h.service.Update(in.Context(), &value)
+		if err != nil {
+			h.logger.Infof("can't update warehouse: %v, got error: %s", value, err.Error())
+			results = append(results, domain.NewItemError[domain.Warehouse](i, err))
+			continue
+		}
+
+		results = append(results, domain.NewItemResult(i, *warehouse))
+	}
+
+	*out = results
+	return nil
+}
+
+func (h *warehouseHandler) SetAvailability(in Args[domain.SetAvailability], out *domain.AvailabilityChange) error {
+	change, err := h.service.SetAvailability(in.Context(), &in.Params)
+
+	if err != nil {
+		return serviceError(in.Context(), "SetAvailability", err)
+	}
+
+	if !change.Applied {
+		h.logger.Infof("availability of warehouse %d is not changed, open reservations: %d",
+			in.Params.ID, len(change.OpenReservations))
+	}
+
+	*out = *change
+	return nil
+}
+
+func (h *warehouseHandler) Decommission(in Args[domain.Decommission], out *domain.DecommissionResult) error {
+	result, err := h.service.Decommission(in.Context(), &in.Params)
+
+	if err != nil {
+		return serviceError(in.Context(), "Decommission", err)
 	}
 
-	*out = success
+	*out = *result
 	return nil
 }
 
-func (h *warehouseHandler) GetLeftOvers(in domain.GetFromWarehouse, out *[]domain.Product) error {
-	products, err := h.service.GetLeftOvers(&in)
+func (h *warehouseHandler) GetLeftOvers(in Args[domain.GetFromWarehouse], out *[]domain.Product) error {
+	products, err := h.service.GetLeftOvers(in.Context(), &in.Params)
 
 	if err != nil {
-		return fmt.Errorf("service.GetLeftOvers returned: %w", err)
+		return serviceError(in.Context(), "GetLeftOvers", err)
 	}
 
 	*out = products
 	return nil
 }
+
+func (h *warehouseHandler) CreateBin(in Args[[]domain.Bin], out *[]domain.ItemResult[domain.Bin]) error {
+	results := make([]domain.ItemResult[domain.Bin], 0, len(in.Params))
+
+	for i, value := range in.Params {
+		if err := h.service.CreateBin(in.Context(), &value); err != nil {
+			h.logger.Infof("error while creating bin %v, error: %s", value, err.Error())
+			results = append(results, domain.NewItemError[domain.Bin](i, err))
+			continue
+		}
+
+		results = append(results, domain.NewItemResult(i, value))
+	}
+
+	*out = results
+	return nil
+}
+
+func (h *warehouseHandler) ListBins(in Args[domain.ListBins], out *[]domain.Bin) error {
+	bins, err := h.service.ListBins(in.Context(), &in.Params)
+
+	if err != nil {
+		return serviceError(in.Context(), "ListBins", err)
+	}
+
+	*out = bins
+	return nil
+}
+
+func (h *warehouseHandler) SetThreshold(in Args[[]domain.StockThreshold], out *[]domain.ItemResult[domain.StockThreshold]) error {
+	results := make([]domain.ItemResult[domain.StockThreshold], 0, len(in.Params))
+
+	for i, value := range in.Params {
+		if err := h.service.SetThreshold(in.Context(), &value); err != nil {
+			h.logger.Infof("error while setting threshold %v, error: %s", value, err.Error())
+			results = append(results, domain.NewItemError[domain.StockThreshold](i, err))
+			continue
+		}
+
+		results = append(results, domain.NewItemResult(i, value))
+	}
+
+	*out = results
+	return nil
+}
+
+func (h *warehouseHandler) LowStock(in Args[domain.LowStockFilter], out *[]domain.LowStockItem) error {
+	items, err := h.service.LowStock(in.Context(), &in.Params)
+
+	if err != nil {
+		return serviceError(in.Context(), "LowStock", err)
+	}
+
+	*out = items
+	return nil
+}
+
+func (h *warehouseHandler) LowStockEvents(in Args[domain.LowStockEventFilter], out *[]domain.LowStockEvent) error {
+	events, err := h.service.LowStockEvents(in.Context(), &in.Params)
+
+	if err != nil {
+		return serviceError(in.Context(), "LowStockEvents", err)
+	}
+
+	*out = events
+	return nil
+}
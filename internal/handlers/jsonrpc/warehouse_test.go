@@ -1,6 +1,7 @@
 package jsonrpc
 
 import (
+	"context"
 	"errors"
 	"reflect"
 	"testing"
@@ -13,11 +14,27 @@ import (
 
 type warehouseTestCase struct {
 	in           []domain.Warehouse
-	out          []domain.Warehouse
+	out          []domain.ItemResult[domain.Warehouse]
 	err          error
 	repeat       uint8
 	repeatError  uint8
-	expectResult []domain.Warehouse
+	expectResult []domain.ItemResult[domain.Warehouse]
+}
+
+type getWarehouseTestCase struct {
+	in           domain.GetWarehouse
+	out          domain.Warehouse
+	warehouse    *domain.Warehouse
+	err          error
+	expectResult domain.Warehouse
+}
+
+type setAvailabilityTestCase struct {
+	in           domain.SetAvailability
+	out          domain.AvailabilityChange
+	change       *domain.AvailabilityChange
+	err          error
+	expectResult domain.AvailabilityChange
 }
 
 type getLeftOversTestCase struct {
@@ -48,12 +65,19 @@ func TestWarehouseCreate(t *testing.T) {
 		},
 	}
 
-	expectWarehouse := [][]domain.Warehouse{
-		in,
+	expectWarehouse := [][]domain.ItemResult[domain.Warehouse]{
+		{
+			domain.NewItemResult(0, in[0]),
+			domain.NewItemResult(1, in[1]),
+		},
 		{
-			in[1],
+			domain.NewItemError[domain.Warehouse](0, domain.ErrTest),
+			domain.NewItemResult(1, in[1]),
+		},
+		{
+			domain.NewItemError[domain.Warehouse](0, domain.ErrTest),
+			domain.NewItemError[domain.Warehouse](1, domain.ErrTest),
 		},
-		nil,
 	}
 
 	testCases := []warehouseTestCase{
@@ -87,18 +111,18 @@ func TestWarehouseCreate(t *testing.T) {
 	for index, tc := range testCases {
 		for i := 0; i < int(tc.repeat); i++ {
 			if tc.repeatError > 0 {
-				wh.EXPECT().Create(&tc.in[i]).Return(tc.err)
+				wh.EXPECT().Create(gomock.Any(), &tc.in[i]).Return(tc.err)
 				tc.repeatError--
 				continue
 			} else {
 				tc.err = nil
 			}
-			wh.EXPECT().Create(&tc.in[i]).Return(tc.err)
+			wh.EXPECT().Create(gomock.Any(), &tc.in[i]).Return(tc.err)
 		}
 
-		err = handler.Create(tc.in, &tc.out)
-		if !errors.Is(err, tc.err) {
-			t.Fatalf("expected error: %v, got: %v", tc.err, err)
+		err = handler.Create(argsOf(tc.in), &tc.out)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
 		}
 
 		if !reflect.DeepEqual(tc.out, expectWarehouse[index]) {
@@ -150,9 +174,9 @@ func TestWarehouseGetLeftOvers(t *testing.T) {
 	handler := NewWarehouseHandler(wh, logger)
 
 	for _, tc := range testCases {
-		wh.EXPECT().GetLeftOvers(&tc.in).Return(tc.expectResult, tc.err)
+		wh.EXPECT().GetLeftOvers(gomock.Any(), &tc.in).Return(tc.expectResult, tc.err)
 
-		err = handler.GetLeftOvers(tc.in, &tc.out)
+		err = handler.GetLeftOvers(argsOf(tc.in), &tc.out)
 
 		if !errors.Is(err, tc.err) {
 			t.Fatalf("expected error: %v, got: %v", tc.err, err)
@@ -163,3 +187,363 @@ func TestWarehouseGetLeftOvers(t *testing.T) {
 		}
 	}
 }
+
+func TestWarehouseCreateReturnsID(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	wh := mocks.NewMockWarehouseService(ctrl)
+	logger, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("can't create logger: %s", err)
+	}
+
+	in := []domain.Warehouse{
+		{
+			Name:         "test-1",
+			Availability: true,
+		},
+	}
+	expectResult := []domain.ItemResult[domain.Warehouse]{
+		domain.NewItemResult(0, domain.Warehouse{ID: 7, Name: "test-1", Availability: true}),
+	}
+
+	wh.EXPECT().Create(gomock.Any(), &in[0]).DoAndReturn(func(_ context.Context, w *domain.Warehouse) error {
+		w.ID = 7
+		return nil
+	})
+
+	var out []domain.ItemResult[domain.Warehouse]
+	if err = NewWarehouseHandler(wh, logger).Create(argsOf(in), &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(out, expectResult) {
+		t.Fatalf("expected: %v, got: %v", expectResult, out)
+	}
+}
+
+func TestWarehouseGet(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	wh := mocks.NewMockWarehouseService(ctrl)
+	logger, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("can't create logger: %s", err)
+	}
+
+	in := domain.GetWarehouse{ID: 1}
+	warehouse := &domain.Warehouse{
+		ID:           1,
+		Name:         "test",
+		Availability: true,
+	}
+
+	testCases := []getWarehouseTestCase{
+		{
+			in:           in,
+			warehouse:    warehouse,
+			expectResult: *warehouse,
+		},
+		{
+			in:  in,
+			err: domain.ErrNotFound,
+		},
+	}
+
+	handler := NewWarehouseHandler(wh, logger)
+	for _, tc := range testCases {
+		wh.EXPECT().Get(gomock.Any(), &tc.in).Return(tc.warehouse, tc.err)
+
+		err = handler.Get(argsOf(tc.in), &tc.out)
+		if !errors.Is(err, tc.err) {
+			t.Fatalf("expected error: %v, got: %v", tc.err, err)
+		}
+
+		if !reflect.DeepEqual(tc.out, tc.expectResult) {
+			t.Fatalf("expected: %v, got: %v", tc.expectResult, tc.out)
+		}
+	}
+}
+
+func TestWarehouseList(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	wh := mocks.NewMockWarehouseService(ctrl)
+	logger, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("can't create logger: %s", err)
+	}
+
+	in := domain.WarehouseFilter{Limit: 10}
+	warehouses := []domain.Warehouse{
+		{
+			ID:           1,
+			Name:         "test-1",
+			Availability: true,
+		},
+		{
+			ID:   2,
+			Name: "test-2",
+		},
+	}
+
+	wh.EXPECT().List(gomock.Any(), &in).Return(warehouses, nil)
+
+	var out []domain.Warehouse
+	if err = NewWarehouseHandler(wh, logger).List(argsOf(in), &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(out, warehouses) {
+		t.Fatalf("expected: %v, got: %v", warehouses, out)
+	}
+}
+
+func TestWarehouseUpdate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	wh := mocks.NewMockWarehouseService(ctrl)
+	logger, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("can't create logger: %s", err)
+	}
+
+	in := []domain.UpdateWarehouse{
+		{
+			ID:   1,
+			Name: "new name",
+		},
+		{
+			ID:   2,
+			Name: "new name",
+		},
+	}
+	warehouse := domain.Warehouse{
+		ID:           1,
+		Name:         "new name",
+		Availability: true,
+	}
+	expectResult := []domain.ItemResult[domain.Warehouse]{
+		domain.NewItemResult(0, warehouse),
+		domain.NewItemError[domain.Warehouse](1, domain.ErrNotFound),
+	}
+
+	wh.EXPECT().Update(gomock.Any(), &in[0]).Return(&warehouse, nil)
+	wh.EXPECT().Update(gomock.Any(), &in[1]).Return(nil, domain.ErrNotFound)
+
+	var out []domain.ItemResult[domain.Warehouse]
+	if err = NewWarehouseHandler(wh, logger).Update(argsOf(in), &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(out, expectResult) {
+		t.Fatalf("expected: %v, got: %v", expectResult, out)
+	}
+}
+
+func TestWarehouseSetAvailability(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	wh := mocks.NewMockWarehouseService(ctrl)
+	logger, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("can't create logger: %s", err)
+	}
+
+	in := domain.SetAvailability{ID: 1}
+	refused := &domain.AvailabilityChange{
+		Warehouse: domain.Warehouse{
+			ID:           1,
+			Name:         "test",
+			Availability: true,
+		},
+		OpenReservations: []domain.Reservation{
+			{
+				ID:          1,
+				WarehouseID: 1,
+				Code:        "test",
+				Quantity:    1,
+				Status:      domain.ReservationActive,
+			},
+		},
+	}
+
+	testCases := []setAvailabilityTestCase{
+		{
+			in:           in,
+			change:       refused,
+			expectResult: *refused,
+		},
+		{
+			in:  in,
+			err: domain.ErrNotFound,
+		},
+	}
+
+	handler := NewWarehouseHandler(wh, logger)
+	for _, tc := range testCases {
+		wh.EXPECT().SetAvailability(gomock.Any(), &tc.in).Return(tc.change, tc.err)
+
+		err = handler.SetAvailability(argsOf(tc.in), &tc.out)
+		if !errors.Is(err, tc.err) {
+			t.Fatalf("expected error: %v, got: %v", tc.err, err)
+		}
+
+		if !reflect.DeepEqual(tc.out, tc.expectResult) {
+			t.Fatalf("expected: %v, got: %v", tc.expectResult, tc.out)
+		}
+	}
+}
+
+func TestWarehouseDecommission(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	wh := mocks.NewMockWarehouseService(ctrl)
+	logger, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("can't create logger: %s", err)
+	}
+
+	in := domain.Decommission{
+		WarehouseID: 1,
+		TargetID:    2,
+	}
+	result := &domain.DecommissionResult{
+		Warehouse: domain.Warehouse{
+			ID:   1,
+			Name: "test",
+		},
+		Transfers: []domain.TransferProduct{
+			{
+				WarehouseFromID: 1,
+				WarehouseToID:   2,
+				Code:            "test",
+				Quantity:        10,
+			},
+		},
+	}
+
+	handler := NewWarehouseHandler(wh, logger)
+
+	wh.EXPECT().Decommission(gomock.Any(), &in).Return(result, nil)
+
+	var out domain.DecommissionResult
+	if err = handler.Decommission(argsOf(in), &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(out, *result) {
+		t.Fatalf("expected: %v, got: %v", *result, out)
+	}
+
+	wh.EXPECT().Decommission(gomock.Any(), &in).Return(nil, domain.ErrOpenReservations)
+
+	if err = handler.Decommission(argsOf(in), &out); !errors.Is(err, domain.ErrOpenReservations) {
+		t.Fatalf("expected error: %v, got: %v", domain.ErrOpenReservations, err)
+	}
+}
+
+func TestWarehouseBins(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	wh := mocks.NewMockWarehouseService(ctrl)
+	logger, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("can't create logger: %s", err)
+	}
+
+	in := []domain.Bin{
+		{WarehouseID: 1, Zone: "A", Aisle: "01", Rack: "R1", Bin: "B1"},
+		{WarehouseID: 1, Zone: "A", Aisle: "01", Rack: "R1", Bin: "B1"},
+	}
+
+	handler := NewWarehouseHandler(wh, logger)
+
+	wh.EXPECT().CreateBin(gomock.Any(), &in[0]).DoAndReturn(func(_ context.Context, bin *domain.Bin) error {
+		bin.ID = 7
+		return nil
+	})
+	wh.EXPECT().CreateBin(gomock.Any(), &in[1]).Return(domain.ErrDuplicateCode)
+
+	var created []domain.ItemResult[domain.Bin]
+	if err = handler.CreateBin(argsOf(in), &created); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(created) != 2 || created[0].Item == nil || created[0].Item.ID != 7 || created[1].Success {
+		t.Fatalf("unexpected result: %+v", created)
+	}
+
+	lb := domain.ListBins{WarehouseID: 1}
+	bins := []domain.Bin{{ID: 7, WarehouseID: 1, Zone: "A", Aisle: "01", Rack: "R1", Bin: "B1"}}
+
+	wh.EXPECT().ListBins(gomock.Any(), &lb).Return(bins, nil)
+
+	var out []domain.Bin
+	if err = handler.ListBins(argsOf(lb), &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(out, bins) {
+		t.Fatalf("expected: %v, got: %v", bins, out)
+	}
+}
+
+func TestWarehouseLowStock(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	wh := mocks.NewMockWarehouseService(ctrl)
+	logger, err := logger.NewLogger()
+	if err != nil {
+		t.Fatalf("can't create logger: %s", err)
+	}
+
+	in := []domain.StockThreshold{
+		{WarehouseID: 1, Code: "test-1", MinQuantity: 2, ReorderPoint: 5},
+		{WarehouseID: 1, Code: "test-2", MinQuantity: 5, ReorderPoint: 2},
+	}
+
+	handler := NewWarehouseHandler(wh, logger)
+
+	wh.EXPECT().SetThreshold(gomock.Any(), &in[0]).Return(nil)
+	wh.EXPECT().SetThreshold(gomock.Any(), &in[1]).Return(domain.ErrValidationFailed)
+
+	var set []domain.ItemResult[domain.StockThreshold]
+	if err = handler.SetThreshold(argsOf(in), &set); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(set) != 2 || !set[0].Success || set[1].Success {
+		t.Fatalf("unexpected result: %+v", set)
+	}
+
+	f := domain.LowStockFilter{WarehouseID: 1}
+	items := []domain.LowStockItem{{StockThreshold: in[0], Name: "test", Available: 1, Level: domain.LowStockMin}}
+
+	wh.EXPECT().LowStock(gomock.Any(), &f).Return(items, nil)
+
+	var out []domain.LowStockItem
+	if err = handler.LowStock(argsOf(f), &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(out, items) {
+		t.Fatalf("expected: %v, got: %v", items, out)
+	}
+
+	ef := domain.LowStockEventFilter{AfterID: 3}
+	wh.EXPECT().LowStockEvents(gomock.Any(), &ef).Return(nil, domain.ErrTest)
+
+	var events []domain.LowStockEvent
+	if err = handler.LowStockEvents(argsOf(ef), &events); !errors.Is(err, domain.ErrTest) {
+		t.Fatalf("expected: %v, got: %v", domain.ErrTest, err)
+	}
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"context"
+
+	"github.com/akrovv/warehouse/internal/domain"
+)
+
+type countService struct {
+	storage           CountStorage
+	approvalThreshold uint64
+}
+
+// NewCountService holds count corrections above approvalThreshold like
+// the product service holds adjustments, 0 applies them right away.
+func NewCountService(storage CountStorage, approvalThreshold uint64) *countService {
+	return &countService{
+		storage:           storage,
+		approvalThreshold: approvalThreshold,
+	}
+}
+
+func (s *countService) Open(ctx context.Context, oc *domain.OpenCount) (*domain.CountSession, error) {
+	if err := oc.Validate(); err != nil {
+		return nil, err
+	}
+
+	return s.storage.Open(ctx, oc)
+}
+
+// Get returns the session with the differences to review.
+func (s *countService) Get(ctx context.Context, gc *domain.GetCount) (*domain.CountSession, error) {
+	if err := gc.Validate(); err != nil {
+		return nil, err
+	}
+
+	session, err := s.storage.Get(ctx, gc)
+	if err != nil {
+		return nil, err
+	}
+
+	session.Review()
+
+	return session, nil
+}
+
+func (s *countService) Submit(ctx context.Context, cs *domain.CountSubmission) (*domain.CountSession, error) {
+	if err := cs.Validate(); err != nil {
+		return nil, err
+	}
+
+	return s.storage.Submit(ctx, cs)
+}
+
+func (s *countService) Post(ctx context.Context, pc *domain.PostCount) (*domain.CountSession, error) {
+	if err := pc.Validate(); err != nil {
+		return nil, err
+	}
+
+	pc.ApprovalThreshold = s.approvalThreshold
+
+	return s.storage.Post(ctx, pc)
+}
+
+func (s *countService) Cancel(ctx context.Context, cc *domain.CancelCount) (*domain.CountSession, error) {
+	if err := cc.Validate(); err != nil {
+		return nil, err
+	}
+
+	session, err := s.storage.Cancel(ctx, cc)
+	if err != nil {
+		return nil, err
+	}
+
+	session.Review()
+
+	return session, nil
+}
@@ -0,0 +1,111 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: interfaces.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	domain "github.com/akrovv/warehouse/internal/domain"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockCountService is a mock of CountService interface.
+type MockCountService struct {
+	ctrl     *gomock.Controller
+	recorder *MockCountServiceMockRecorder
+}
+
+// MockCountServiceMockRecorder is the mock recorder for MockCountService.
+type MockCountServiceMockRecorder struct {
+	mock *MockCountService
+}
+
+// NewMockCountService creates a new mock instance.
+func NewMockCountService(ctrl *gomock.Controller) *MockCountService {
+	mock := &MockCountService{ctrl: ctrl}
+	mock.recorder = &MockCountServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCountService) EXPECT() *MockCountServiceMockRecorder {
+	return m.recorder
+}
+
+// Cancel mocks base method.
+func (m *MockCountService) Cancel(ctx context.Context, cc *domain.CancelCount) (*domain.CountSession, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Cancel", ctx, cc)
+	ret0, _ := ret[0].(*domain.CountSession)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Cancel indicates an expected call of Cancel.
+func (mr *MockCountServiceMockRecorder) Cancel(ctx, cc interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Cancel", reflect.TypeOf((*MockCountService)(nil).Cancel), ctx, cc)
+}
+
+// Get mocks base method.
+func (m *MockCountService) Get(ctx context.Context, gc *domain.GetCount) (*domain.CountSession, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, gc)
+	ret0, _ := ret[0].(*domain.CountSession)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockCountServiceMockRecorder) Get(ctx, gc interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockCountService)(nil).Get), ctx, gc)
+}
+
+// Open mocks base method.
+func (m *MockCountService) Open(ctx context.Context, oc *domain.OpenCount) (*domain.CountSession, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Open", ctx, oc)
+	ret0, _ := ret[0].(*domain.CountSession)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Open indicates an expected call of Open.
+func (mr *MockCountServiceMockRecorder) Open(ctx, oc interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Open", reflect.TypeOf((*MockCountService)(nil).Open), ctx, oc)
+}
+
+// Post mocks base method.
+func (m *MockCountService) Post(ctx context.Context, pc *domain.PostCount) (*domain.CountSession, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Post", ctx, pc)
+	ret0, _ := ret[0].(*domain.CountSession)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Post indicates an expected call of Post.
+func (mr *MockCountServiceMockRecorder) Post(ctx, pc interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Post", reflect.TypeOf((*MockCountService)(nil).Post), ctx, pc)
+}
+
+// Submit mocks base method.
+func (m *MockCountService) Submit(ctx context.Context, cs *domain.CountSubmission) (*domain.CountSession, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Submit", ctx, cs)
+	ret0, _ := ret[0].(*domain.CountSession)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Submit indicates an expected call of Submit.
+func (mr *MockCountServiceMockRecorder) Submit(ctx, cs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Submit", reflect.TypeOf((*MockCountService)(nil).Submit), ctx, cs)
+}
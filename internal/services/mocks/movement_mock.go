@@ -0,0 +1,51 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: interfaces.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	domain "github.com/akrovv/warehouse/internal/domain"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockMovementService is a mock of MovementService interface.
+type MockMovementService struct {
+	ctrl     *gomock.Controller
+	recorder *MockMovementServiceMockRecorder
+}
+
+// MockMovementServiceMockRecorder is the mock recorder for MockMovementService.
+type MockMovementServiceMockRecorder struct {
+	mock *MockMovementService
+}
+
+// NewMockMovementService creates a new mock instance.
+func NewMockMovementService(ctrl *gomock.Controller) *MockMovementService {
+	mock := &MockMovementService{ctrl: ctrl}
+	mock.recorder = &MockMovementServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMovementService) EXPECT() *MockMovementServiceMockRecorder {
+	return m.recorder
+}
+
+// List mocks base method.
+func (m *MockMovementService) List(ctx context.Context, f *domain.MovementFilter) ([]domain.Movement, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, f)
+	ret0, _ := ret[0].([]domain.Movement)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockMovementServiceMockRecorder) List(ctx, f interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockMovementService)(nil).List), ctx, f)
+}
@@ -5,6 +5,7 @@
 package mocks
 
 import (
+	context "context"
 	reflect "reflect"
 
 	domain "github.com/akrovv/warehouse/internal/domain"
@@ -35,86 +36,354 @@ func (m *MockProductService) EXPECT() *MockProductServiceMockRecorder {
 }
 
 // Add mocks base method.
-func (m *MockProductService) Add(ad *domain.AddProduct) error {
+func (m *MockProductService) Add(ctx context.Context, ad *domain.AddProduct) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Add", ad)
+	ret := m.ctrl.Call(m, "Add", ctx, ad)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Add indicates an expected call of Add.
-func (mr *MockProductServiceMockRecorder) Add(ad interface{}) *gomock.Call {
+func (mr *MockProductServiceMockRecorder) Add(ctx, ad interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Add", reflect.TypeOf((*MockProductService)(nil).Add), ad)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Add", reflect.TypeOf((*MockProductService)(nil).Add), ctx, ad)
 }
 
-// CancelReservation mocks base method.
-func (m *MockProductService) CancelReservation(wp *domain.WarehouseProduct) error {
+// Adjust mocks base method.
+func (m *MockProductService) Adjust(ctx context.Context, a *domain.Adjustment) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CancelReservation", wp)
+	ret := m.ctrl.Call(m, "Adjust", ctx, a)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
+// Adjust indicates an expected call of Adjust.
+func (mr *MockProductServiceMockRecorder) Adjust(ctx, a interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Adjust", reflect.TypeOf((*MockProductService)(nil).Adjust), ctx, a)
+}
+
+// Allocate mocks base method.
+func (m *MockProductService) Allocate(ctx context.Context, a *domain.Allocation) ([]domain.Reservation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Allocate", ctx, a)
+	ret0, _ := ret[0].([]domain.Reservation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Allocate indicates an expected call of Allocate.
+func (mr *MockProductServiceMockRecorder) Allocate(ctx, a interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Allocate", reflect.TypeOf((*MockProductService)(nil).Allocate), ctx, a)
+}
+
+// ApproveAdjustment mocks base method.
+func (m *MockProductService) ApproveAdjustment(ctx context.Context, d *domain.DecideAdjustment) (*domain.Adjustment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ApproveAdjustment", ctx, d)
+	ret0, _ := ret[0].(*domain.Adjustment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ApproveAdjustment indicates an expected call of ApproveAdjustment.
+func (mr *MockProductServiceMockRecorder) ApproveAdjustment(ctx, d interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApproveAdjustment", reflect.TypeOf((*MockProductService)(nil).ApproveAdjustment), ctx, d)
+}
+
+// CancelReservation mocks base method.
+func (m *MockProductService) CancelReservation(ctx context.Context, cr *domain.CancelReservation) (*domain.Reservation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelReservation", ctx, cr)
+	ret0, _ := ret[0].(*domain.Reservation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
 // CancelReservation indicates an expected call of CancelReservation.
-func (mr *MockProductServiceMockRecorder) CancelReservation(wp interface{}) *gomock.Call {
+func (mr *MockProductServiceMockRecorder) CancelReservation(ctx, cr interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelReservation", reflect.TypeOf((*MockProductService)(nil).CancelReservation), wp)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelReservation", reflect.TypeOf((*MockProductService)(nil).CancelReservation), ctx, cr)
+}
+
+// CancelTransfer mocks base method.
+func (m *MockProductService) CancelTransfer(ctx context.Context, ct *domain.CancelTransfer) (*domain.TransitTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelTransfer", ctx, ct)
+	ret0, _ := ret[0].(*domain.TransitTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CancelTransfer indicates an expected call of CancelTransfer.
+func (mr *MockProductServiceMockRecorder) CancelTransfer(ctx, ct interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelTransfer", reflect.TypeOf((*MockProductService)(nil).CancelTransfer), ctx, ct)
 }
 
 // Create mocks base method.
-func (m *MockProductService) Create(product *domain.Product) error {
+func (m *MockProductService) Create(ctx context.Context, product *domain.Product) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Create", product)
+	ret := m.ctrl.Call(m, "Create", ctx, product)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Create indicates an expected call of Create.
-func (mr *MockProductServiceMockRecorder) Create(product interface{}) *gomock.Call {
+func (mr *MockProductServiceMockRecorder) Create(ctx, product interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockProductService)(nil).Create), product)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockProductService)(nil).Create), ctx, product)
 }
 
 // Delete mocks base method.
-func (m *MockProductService) Delete(dp *domain.DeleteProduct) (*domain.Product, error) {
+func (m *MockProductService) Delete(ctx context.Context, dp *domain.DeleteProduct) (*domain.Product, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Delete", dp)
+	ret := m.ctrl.Call(m, "Delete", ctx, dp)
 	ret0, _ := ret[0].(*domain.Product)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Delete indicates an expected call of Delete.
-func (mr *MockProductServiceMockRecorder) Delete(dp interface{}) *gomock.Call {
+func (mr *MockProductServiceMockRecorder) Delete(ctx, dp interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockProductService)(nil).Delete), dp)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockProductService)(nil).Delete), ctx, dp)
 }
 
-// Reserve mocks base method.
-func (m *MockProductService) Reserve(wp *domain.WarehouseProduct) error {
+// Dispatch mocks base method.
+func (m *MockProductService) Dispatch(ctx context.Context, td *domain.TransferProduct) (*domain.TransitTransfer, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Reserve", wp)
+	ret := m.ctrl.Call(m, "Dispatch", ctx, td)
+	ret0, _ := ret[0].(*domain.TransitTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Dispatch indicates an expected call of Dispatch.
+func (mr *MockProductServiceMockRecorder) Dispatch(ctx, td interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Dispatch", reflect.TypeOf((*MockProductService)(nil).Dispatch), ctx, td)
+}
+
+// Fulfill mocks base method.
+func (m *MockProductService) Fulfill(ctx context.Context, f *domain.Fulfillment) (*domain.Shipment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Fulfill", ctx, f)
+	ret0, _ := ret[0].(*domain.Shipment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Fulfill indicates an expected call of Fulfill.
+func (mr *MockProductServiceMockRecorder) Fulfill(ctx, f interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Fulfill", reflect.TypeOf((*MockProductService)(nil).Fulfill), ctx, f)
+}
+
+// Get mocks base method.
+func (m *MockProductService) Get(ctx context.Context, gp *domain.GetProduct) (*domain.Product, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, gp)
+	ret0, _ := ret[0].(*domain.Product)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockProductServiceMockRecorder) Get(ctx, gp interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockProductService)(nil).Get), ctx, gp)
+}
+
+// List mocks base method.
+func (m *MockProductService) List(ctx context.Context, f *domain.ProductFilter) ([]domain.Product, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, f)
+	ret0, _ := ret[0].([]domain.Product)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockProductServiceMockRecorder) List(ctx, f interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockProductService)(nil).List), ctx, f)
+}
+
+// ListAdjustments mocks base method.
+func (m *MockProductService) ListAdjustments(ctx context.Context, f *domain.AdjustmentFilter) ([]domain.Adjustment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAdjustments", ctx, f)
+	ret0, _ := ret[0].([]domain.Adjustment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAdjustments indicates an expected call of ListAdjustments.
+func (mr *MockProductServiceMockRecorder) ListAdjustments(ctx, f interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAdjustments", reflect.TypeOf((*MockProductService)(nil).ListAdjustments), ctx, f)
+}
+
+// ListTransfers mocks base method.
+func (m *MockProductService) ListTransfers(ctx context.Context, f *domain.TransferFilter) ([]domain.TransitTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTransfers", ctx, f)
+	ret0, _ := ret[0].([]domain.TransitTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTransfers indicates an expected call of ListTransfers.
+func (mr *MockProductServiceMockRecorder) ListTransfers(ctx, f interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTransfers", reflect.TypeOf((*MockProductService)(nil).ListTransfers), ctx, f)
+}
+
+// MoveStock mocks base method.
+func (m_2 *MockProductService) MoveStock(ctx context.Context, m *domain.BucketMove) error {
+	m_2.ctrl.T.Helper()
+	ret := m_2.ctrl.Call(m_2, "MoveStock", ctx, m)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
+// MoveStock indicates an expected call of MoveStock.
+func (mr *MockProductServiceMockRecorder) MoveStock(ctx, m interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MoveStock", reflect.TypeOf((*MockProductService)(nil).MoveStock), ctx, m)
+}
+
+// Receive mocks base method.
+func (m *MockProductService) Receive(ctx context.Context, rt *domain.ReceiveTransfer) (*domain.TransitTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Receive", ctx, rt)
+	ret0, _ := ret[0].(*domain.TransitTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Receive indicates an expected call of Receive.
+func (mr *MockProductServiceMockRecorder) Receive(ctx, rt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Receive", reflect.TypeOf((*MockProductService)(nil).Receive), ctx, rt)
+}
+
+// RejectAdjustment mocks base method.
+func (m *MockProductService) RejectAdjustment(ctx context.Context, d *domain.DecideAdjustment) (*domain.Adjustment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RejectAdjustment", ctx, d)
+	ret0, _ := ret[0].(*domain.Adjustment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RejectAdjustment indicates an expected call of RejectAdjustment.
+func (mr *MockProductServiceMockRecorder) RejectAdjustment(ctx, d interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RejectAdjustment", reflect.TypeOf((*MockProductService)(nil).RejectAdjustment), ctx, d)
+}
+
+// Relocate mocks base method.
+func (m *MockProductService) Relocate(ctx context.Context, rl *domain.Relocation) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Relocate", ctx, rl)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Relocate indicates an expected call of Relocate.
+func (mr *MockProductServiceMockRecorder) Relocate(ctx, rl interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Relocate", reflect.TypeOf((*MockProductService)(nil).Relocate), ctx, rl)
+}
+
+// Reserve mocks base method.
+func (m *MockProductService) Reserve(ctx context.Context, wp *domain.WarehouseProduct) (*domain.Reservation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reserve", ctx, wp)
+	ret0, _ := ret[0].(*domain.Reservation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
 // Reserve indicates an expected call of Reserve.
-func (mr *MockProductServiceMockRecorder) Reserve(wp interface{}) *gomock.Call {
+func (mr *MockProductServiceMockRecorder) Reserve(ctx, wp interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reserve", reflect.TypeOf((*MockProductService)(nil).Reserve), wp)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reserve", reflect.TypeOf((*MockProductService)(nil).Reserve), ctx, wp)
+}
+
+// ReserveBatch mocks base method.
+func (m *MockProductService) ReserveBatch(ctx context.Context, wps []domain.WarehouseProduct) ([]domain.Reservation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReserveBatch", ctx, wps)
+	ret0, _ := ret[0].([]domain.Reservation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReserveBatch indicates an expected call of ReserveBatch.
+func (mr *MockProductServiceMockRecorder) ReserveBatch(ctx, wps interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReserveBatch", reflect.TypeOf((*MockProductService)(nil).ReserveBatch), ctx, wps)
+}
+
+// SuggestTransfers mocks base method.
+func (m *MockProductService) SuggestTransfers(ctx context.Context, st *domain.SuggestTransfers) (*domain.TransferSuggestion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SuggestTransfers", ctx, st)
+	ret0, _ := ret[0].(*domain.TransferSuggestion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SuggestTransfers indicates an expected call of SuggestTransfers.
+func (mr *MockProductServiceMockRecorder) SuggestTransfers(ctx, st interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SuggestTransfers", reflect.TypeOf((*MockProductService)(nil).SuggestTransfers), ctx, st)
 }
 
 // Transfer mocks base method.
-func (m *MockProductService) Transfer(td *domain.TransferProduct) error {
+func (m *MockProductService) Transfer(ctx context.Context, td *domain.TransferProduct) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Transfer", td)
+	ret := m.ctrl.Call(m, "Transfer", ctx, td)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Transfer indicates an expected call of Transfer.
-func (mr *MockProductServiceMockRecorder) Transfer(td interface{}) *gomock.Call {
+func (mr *MockProductServiceMockRecorder) Transfer(ctx, td interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Transfer", reflect.TypeOf((*MockProductService)(nil).Transfer), ctx, td)
+}
+
+// TransferBatch mocks base method.
+func (m *MockProductService) TransferBatch(ctx context.Context, tds []domain.TransferProduct) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TransferBatch", ctx, tds)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TransferBatch indicates an expected call of TransferBatch.
+func (mr *MockProductServiceMockRecorder) TransferBatch(ctx, tds interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TransferBatch", reflect.TypeOf((*MockProductService)(nil).TransferBatch), ctx, tds)
+}
+
+// Update mocks base method.
+func (m *MockProductService) Update(ctx context.Context, up *domain.UpdateProduct) (*domain.Product, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, up)
+	ret0, _ := ret[0].(*domain.Product)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockProductServiceMockRecorder) Update(ctx, up interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Transfer", reflect.TypeOf((*MockProductService)(nil).Transfer), td)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockProductService)(nil).Update), ctx, up)
 }
@@ -0,0 +1,139 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: interfaces.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	domain "github.com/akrovv/warehouse/internal/domain"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockPurchaseService is a mock of PurchaseService interface.
+type MockPurchaseService struct {
+	ctrl     *gomock.Controller
+	recorder *MockPurchaseServiceMockRecorder
+}
+
+// MockPurchaseServiceMockRecorder is the mock recorder for MockPurchaseService.
+type MockPurchaseServiceMockRecorder struct {
+	mock *MockPurchaseService
+}
+
+// NewMockPurchaseService creates a new mock instance.
+func NewMockPurchaseService(ctrl *gomock.Controller) *MockPurchaseService {
+	mock := &MockPurchaseService{ctrl: ctrl}
+	mock.recorder = &MockPurchaseServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPurchaseService) EXPECT() *MockPurchaseServiceMockRecorder {
+	return m.recorder
+}
+
+// CloseOrder mocks base method.
+func (m *MockPurchaseService) CloseOrder(ctx context.Context, cp *domain.ClosePurchaseOrder) (*domain.PurchaseOrder, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CloseOrder", ctx, cp)
+	ret0, _ := ret[0].(*domain.PurchaseOrder)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CloseOrder indicates an expected call of CloseOrder.
+func (mr *MockPurchaseServiceMockRecorder) CloseOrder(ctx, cp interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloseOrder", reflect.TypeOf((*MockPurchaseService)(nil).CloseOrder), ctx, cp)
+}
+
+// CreateOrder mocks base method.
+func (m *MockPurchaseService) CreateOrder(ctx context.Context, po *domain.PurchaseOrder) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateOrder", ctx, po)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateOrder indicates an expected call of CreateOrder.
+func (mr *MockPurchaseServiceMockRecorder) CreateOrder(ctx, po interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrder", reflect.TypeOf((*MockPurchaseService)(nil).CreateOrder), ctx, po)
+}
+
+// CreateSupplier mocks base method.
+func (m *MockPurchaseService) CreateSupplier(ctx context.Context, supplier *domain.Supplier) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSupplier", ctx, supplier)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateSupplier indicates an expected call of CreateSupplier.
+func (mr *MockPurchaseServiceMockRecorder) CreateSupplier(ctx, supplier interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSupplier", reflect.TypeOf((*MockPurchaseService)(nil).CreateSupplier), ctx, supplier)
+}
+
+// GetOrder mocks base method.
+func (m *MockPurchaseService) GetOrder(ctx context.Context, gp *domain.GetPurchaseOrder) (*domain.PurchaseOrder, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrder", ctx, gp)
+	ret0, _ := ret[0].(*domain.PurchaseOrder)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrder indicates an expected call of GetOrder.
+func (mr *MockPurchaseServiceMockRecorder) GetOrder(ctx, gp interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrder", reflect.TypeOf((*MockPurchaseService)(nil).GetOrder), ctx, gp)
+}
+
+// ListSuppliers mocks base method.
+func (m *MockPurchaseService) ListSuppliers(ctx context.Context, f *domain.SupplierFilter) ([]domain.Supplier, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSuppliers", ctx, f)
+	ret0, _ := ret[0].([]domain.Supplier)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSuppliers indicates an expected call of ListSuppliers.
+func (mr *MockPurchaseServiceMockRecorder) ListSuppliers(ctx, f interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSuppliers", reflect.TypeOf((*MockPurchaseService)(nil).ListSuppliers), ctx, f)
+}
+
+// Outstanding mocks base method.
+func (m *MockPurchaseService) Outstanding(ctx context.Context, f *domain.OutstandingFilter) ([]domain.OutstandingLine, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Outstanding", ctx, f)
+	ret0, _ := ret[0].([]domain.OutstandingLine)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Outstanding indicates an expected call of Outstanding.
+func (mr *MockPurchaseServiceMockRecorder) Outstanding(ctx, f interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Outstanding", reflect.TypeOf((*MockPurchaseService)(nil).Outstanding), ctx, f)
+}
+
+// Receive mocks base method.
+func (m *MockPurchaseService) Receive(ctx context.Context, pr *domain.PurchaseReceipt) (*domain.PurchaseOrder, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Receive", ctx, pr)
+	ret0, _ := ret[0].(*domain.PurchaseOrder)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Receive indicates an expected call of Receive.
+func (mr *MockPurchaseServiceMockRecorder) Receive(ctx, pr interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Receive", reflect.TypeOf((*MockPurchaseService)(nil).Receive), ctx, pr)
+}
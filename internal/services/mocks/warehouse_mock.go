@@ -5,6 +5,7 @@
 package mocks
 
 import (
+	context "context"
 	reflect "reflect"
 
 	domain "github.com/akrovv/warehouse/internal/domain"
@@ -35,30 +36,178 @@ func (m *MockWarehouseService) EXPECT() *MockWarehouseServiceMockRecorder {
 }
 
 // Create mocks base method.
-func (m *MockWarehouseService) Create(warehouse *domain.Warehouse) error {
+func (m *MockWarehouseService) Create(ctx context.Context, warehouse *domain.Warehouse) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Create", warehouse)
+	ret := m.ctrl.Call(m, "Create", ctx, warehouse)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Create indicates an expected call of Create.
-func (mr *MockWarehouseServiceMockRecorder) Create(warehouse interface{}) *gomock.Call {
+func (mr *MockWarehouseServiceMockRecorder) Create(ctx, warehouse interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockWarehouseService)(nil).Create), warehouse)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockWarehouseService)(nil).Create), ctx, warehouse)
+}
+
+// CreateBin mocks base method.
+func (m *MockWarehouseService) CreateBin(ctx context.Context, bin *domain.Bin) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateBin", ctx, bin)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateBin indicates an expected call of CreateBin.
+func (mr *MockWarehouseServiceMockRecorder) CreateBin(ctx, bin interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateBin", reflect.TypeOf((*MockWarehouseService)(nil).CreateBin), ctx, bin)
+}
+
+// Decommission mocks base method.
+func (m *MockWarehouseService) Decommission(ctx context.Context, d *domain.Decommission) (*domain.DecommissionResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Decommission", ctx, d)
+	ret0, _ := ret[0].(*domain.DecommissionResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Decommission indicates an expected call of Decommission.
+func (mr *MockWarehouseServiceMockRecorder) Decommission(ctx, d interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Decommission", reflect.TypeOf((*MockWarehouseService)(nil).Decommission), ctx, d)
+}
+
+// Get mocks base method.
+func (m *MockWarehouseService) Get(ctx context.Context, gw *domain.GetWarehouse) (*domain.Warehouse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, gw)
+	ret0, _ := ret[0].(*domain.Warehouse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockWarehouseServiceMockRecorder) Get(ctx, gw interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockWarehouseService)(nil).Get), ctx, gw)
 }
 
 // GetLeftOvers mocks base method.
-func (m *MockWarehouseService) GetLeftOvers(gw *domain.GetFromWarehouse) ([]domain.Product, error) {
+func (m *MockWarehouseService) GetLeftOvers(ctx context.Context, gw *domain.GetFromWarehouse) ([]domain.Product, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetLeftOvers", gw)
+	ret := m.ctrl.Call(m, "GetLeftOvers", ctx, gw)
 	ret0, _ := ret[0].([]domain.Product)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetLeftOvers indicates an expected call of GetLeftOvers.
-func (mr *MockWarehouseServiceMockRecorder) GetLeftOvers(gw interface{}) *gomock.Call {
+func (mr *MockWarehouseServiceMockRecorder) GetLeftOvers(ctx, gw interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLeftOvers", reflect.TypeOf((*MockWarehouseService)(nil).GetLeftOvers), ctx, gw)
+}
+
+// List mocks base method.
+func (m *MockWarehouseService) List(ctx context.Context, f *domain.WarehouseFilter) ([]domain.Warehouse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, f)
+	ret0, _ := ret[0].([]domain.Warehouse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockWarehouseServiceMockRecorder) List(ctx, f interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockWarehouseService)(nil).List), ctx, f)
+}
+
+// ListBins mocks base method.
+func (m *MockWarehouseService) ListBins(ctx context.Context, lb *domain.ListBins) ([]domain.Bin, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListBins", ctx, lb)
+	ret0, _ := ret[0].([]domain.Bin)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListBins indicates an expected call of ListBins.
+func (mr *MockWarehouseServiceMockRecorder) ListBins(ctx, lb interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBins", reflect.TypeOf((*MockWarehouseService)(nil).ListBins), ctx, lb)
+}
+
+// LowStock mocks base method.
+func (m *MockWarehouseService) LowStock(ctx context.Context, f *domain.LowStockFilter) ([]domain.LowStockItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LowStock", ctx, f)
+	ret0, _ := ret[0].([]domain.LowStockItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LowStock indicates an expected call of LowStock.
+func (mr *MockWarehouseServiceMockRecorder) LowStock(ctx, f interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LowStock", reflect.TypeOf((*MockWarehouseService)(nil).LowStock), ctx, f)
+}
+
+// LowStockEvents mocks base method.
+func (m *MockWarehouseService) LowStockEvents(ctx context.Context, f *domain.LowStockEventFilter) ([]domain.LowStockEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LowStockEvents", ctx, f)
+	ret0, _ := ret[0].([]domain.LowStockEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LowStockEvents indicates an expected call of LowStockEvents.
+func (mr *MockWarehouseServiceMockRecorder) LowStockEvents(ctx, f interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LowStockEvents", reflect.TypeOf((*MockWarehouseService)(nil).LowStockEvents), ctx, f)
+}
+
+// SetAvailability mocks base method.
+func (m *MockWarehouseService) SetAvailability(ctx context.Context, sa *domain.SetAvailability) (*domain.AvailabilityChange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetAvailability", ctx, sa)
+	ret0, _ := ret[0].(*domain.AvailabilityChange)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetAvailability indicates an expected call of SetAvailability.
+func (mr *MockWarehouseServiceMockRecorder) SetAvailability(ctx, sa interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetAvailability", reflect.TypeOf((*MockWarehouseService)(nil).SetAvailability), ctx, sa)
+}
+
+// SetThreshold mocks base method.
+func (m *MockWarehouseService) SetThreshold(ctx context.Context, t *domain.StockThreshold) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetThreshold", ctx, t)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetThreshold indicates an expected call of SetThreshold.
+func (mr *MockWarehouseServiceMockRecorder) SetThreshold(ctx, t interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetThreshold", reflect.TypeOf((*MockWarehouseService)(nil).SetThreshold), ctx, t)
+}
+
+// Update mocks base method.
+func (m *MockWarehouseService) Update(ctx context.Context, uw *domain.UpdateWarehouse) (*domain.Warehouse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, uw)
+	ret0, _ := ret[0].(*domain.Warehouse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockWarehouseServiceMockRecorder) Update(ctx, uw interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLeftOvers", reflect.TypeOf((*MockWarehouseService)(nil).GetLeftOvers), gw)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockWarehouseService)(nil).Update), ctx, uw)
 }
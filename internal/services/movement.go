@@ -0,0 +1,25 @@
+package services
+
+import (
+	"context"
+
+	"github.com/akrovv/warehouse/internal/domain"
+)
+
+type movementService struct {
+	storage MovementStorage
+}
+
+func NewMovementService(storage MovementStorage) *movementService {
+	return &movementService{
+		storage: storage,
+	}
+}
+
+func (s *movementService) List(ctx context.Context, f *domain.MovementFilter) ([]domain.Movement, error) {
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+
+	return s.storage.List(ctx, f)
+}
@@ -1,37 +1,255 @@
 package services
 
-import "github.com/akrovv/warehouse/internal/domain"
+import (
+	"context"
+	"time"
+
+	"github.com/akrovv/warehouse/internal/domain"
+)
 
 type productService struct {
-	storage ProductStorage
+	storage           ProductStorage
+	reservationTTL    time.Duration
+	approvalThreshold uint64
 }
 
-func NewProductService(storage ProductStorage) *productService {
+func NewProductService(storage ProductStorage, reservationTTL time.Duration, approvalThreshold uint64) *productService {
 	return &productService{
-		storage: storage,
+		storage:           storage,
+		reservationTTL:    reservationTTL,
+		approvalThreshold: approvalThreshold,
+	}
+}
+
+func (s *productService) Create(ctx context.Context, product *domain.Product) error {
+	if err := product.Validate(); err != nil {
+		return err
+	}
+
+	return s.storage.Create(ctx, product)
+}
+
+func (s *productService) Get(ctx context.Context, gp *domain.GetProduct) (*domain.Product, error) {
+	if err := gp.Validate(); err != nil {
+		return nil, err
+	}
+
+	return s.storage.Get(ctx, gp)
+}
+
+func (s *productService) List(ctx context.Context, f *domain.ProductFilter) ([]domain.Product, error) {
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+
+	return s.storage.List(ctx, f)
+}
+
+func (s *productService) Update(ctx context.Context, up *domain.UpdateProduct) (*domain.Product, error) {
+	if err := up.Validate(); err != nil {
+		return nil, err
+	}
+
+	return s.storage.Update(ctx, up)
+}
+
+func (s *productService) Reserve(ctx context.Context, wp *domain.WarehouseProduct) (*domain.Reservation, error) {
+	if err := wp.Validate(); err != nil {
+		return nil, err
+	}
+
+	reservation := domain.NewReservation(wp, time.Now().Add(s.reservationTTL))
+	if err := s.storage.Reserve(ctx, &reservation); err != nil {
+		return nil, err
+	}
+
+	return &reservation, nil
+}
+
+func (s *productService) ReserveBatch(ctx context.Context, wps []domain.WarehouseProduct) ([]domain.Reservation, error) {
+	expiresAt := time.Now().Add(s.reservationTTL)
+	reservations := make([]domain.Reservation, 0, len(wps))
+
+	for i := range wps {
+		if err := wps[i].Validate(); err != nil {
+			return nil, &domain.BatchError{Index: i, Err: err}
+		}
+
+		reservations = append(reservations, domain.NewReservation(&wps[i], expiresAt))
+	}
+
+	if err := s.storage.ReserveBatch(ctx, reservations); err != nil {
+		return nil, err
+	}
+
+	return reservations, nil
+}
+
+func (s *productService) Allocate(ctx context.Context, a *domain.Allocation) ([]domain.Reservation, error) {
+	if err := a.Validate(); err != nil {
+		return nil, err
+	}
+
+	a.ExpiresAt = time.Now().Add(s.reservationTTL)
+
+	return s.storage.Allocate(ctx, a)
+}
+
+func (s *productService) CancelReservation(ctx context.Context, cr *domain.CancelReservation) (*domain.Reservation, error) {
+	if err := cr.Validate(); err != nil {
+		return nil, err
+	}
+
+	return s.storage.CancelReservation(ctx, cr)
+}
+
+func (s *productService) Fulfill(ctx context.Context, f *domain.Fulfillment) (*domain.Shipment, error) {
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+
+	return s.storage.Fulfill(ctx, f)
+}
+
+func (s *productService) Transfer(ctx context.Context, td *domain.TransferProduct) error {
+	if err := td.Validate(); err != nil {
+		return err
+	}
+
+	return s.storage.Transfer(ctx, td)
+}
+
+func (s *productService) TransferBatch(ctx context.Context, tds []domain.TransferProduct) error {
+	for i := range tds {
+		if err := tds[i].Validate(); err != nil {
+			return &domain.BatchError{Index: i, Err: err}
+		}
+	}
+
+	return s.storage.TransferBatch(ctx, tds)
+}
+
+func (s *productService) Add(ctx context.Context, ad *domain.AddProduct) error {
+	if err := ad.Validate(); err != nil {
+		return err
+	}
+
+	return s.storage.Add(ctx, ad)
+}
+
+func (s *productService) Delete(ctx context.Context, dp *domain.DeleteProduct) (*domain.Product, error) {
+	if err := dp.Validate(); err != nil {
+		return nil, err
+	}
+
+	return s.storage.Delete(ctx, dp)
+}
+
+func (s *productService) Relocate(ctx context.Context, rl *domain.Relocation) error {
+	if err := rl.Validate(); err != nil {
+		return err
+	}
+
+	return s.storage.Relocate(ctx, rl)
+}
+
+func (s *productService) MoveStock(ctx context.Context, m *domain.BucketMove) error {
+	if err := m.Validate(); err != nil {
+		return err
+	}
+
+	return s.storage.MoveStock(ctx, m)
+}
+
+func (s *productService) Adjust(ctx context.Context, a *domain.Adjustment) error {
+	if err := a.Validate(); err != nil {
+		return err
+	}
+
+	a.Hold(s.approvalThreshold)
+
+	return s.storage.Adjust(ctx, a)
+}
+
+func (s *productService) ApproveAdjustment(ctx context.Context, d *domain.DecideAdjustment) (*domain.Adjustment, error) {
+	if err := d.Validate(); err != nil {
+		return nil, err
 	}
+
+	return s.storage.ApproveAdjustment(ctx, d)
 }
 
-func (s *productService) Create(product *domain.Product) error {
-	return s.storage.Create(product)
+func (s *productService) RejectAdjustment(ctx context.Context, d *domain.DecideAdjustment) (*domain.Adjustment, error) {
+	if err := d.Validate(); err != nil {
+		return nil, err
+	}
+
+	return s.storage.RejectAdjustment(ctx, d)
 }
 
-func (s *productService) Reserve(wp *domain.WarehouseProduct) error {
-	return s.storage.Reserve(wp)
+func (s *productService) ListAdjustments(ctx context.Context, f *domain.AdjustmentFilter) ([]domain.Adjustment, error) {
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+
+	return s.storage.ListAdjustments(ctx, f)
 }
 
-func (s *productService) CancelReservation(wp *domain.WarehouseProduct) error {
-	return s.storage.CancelReservation(wp)
+// SuggestTransfers reads stock levels outside of the transfer transaction.
+// Executed transfers run with TransferBatch, so they fail as a whole if stock
+// changed in between.
+func (s *productService) SuggestTransfers(ctx context.Context, st *domain.SuggestTransfers) (*domain.TransferSuggestion, error) {
+	if err := st.Validate(); err != nil {
+		return nil, err
+	}
+
+	levels, err := s.storage.StockLevels(ctx, st)
+	if err != nil {
+		return nil, err
+	}
+
+	suggestion := domain.PlanTransfers(levels, st.WarehouseID, st.RequestID)
+	if !st.Execute {
+		return &suggestion, nil
+	}
+
+	if err = s.TransferBatch(ctx, suggestion.Transfers); err != nil {
+		return nil, err
+	}
+
+	suggestion.Executed = true
+
+	return &suggestion, nil
+}
+
+func (s *productService) Dispatch(ctx context.Context, td *domain.TransferProduct) (*domain.TransitTransfer, error) {
+	if err := td.Validate(); err != nil {
+		return nil, err
+	}
+
+	return s.storage.Dispatch(ctx, td)
 }
 
-func (s *productService) Transfer(td *domain.TransferProduct) error {
-	return s.storage.Transfer(td)
+func (s *productService) Receive(ctx context.Context, rt *domain.ReceiveTransfer) (*domain.TransitTransfer, error) {
+	if err := rt.Validate(); err != nil {
+		return nil, err
+	}
+
+	return s.storage.Receive(ctx, rt)
 }
 
-func (s *productService) Add(ad *domain.AddProduct) error {
-	return s.storage.Add(ad)
+func (s *productService) CancelTransfer(ctx context.Context, ct *domain.CancelTransfer) (*domain.TransitTransfer, error) {
+	if err := ct.Validate(); err != nil {
+		return nil, err
+	}
+
+	return s.storage.CancelTransfer(ctx, ct)
 }
 
-func (s *productService) Delete(dp *domain.DeleteProduct) (*domain.Product, error) {
-	return s.storage.Delete(dp)
+func (s *productService) ListTransfers(ctx context.Context, f *domain.TransferFilter) ([]domain.TransitTransfer, error) {
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+
+	return s.storage.ListTransfers(ctx, f)
 }
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"context"
+
+	"github.com/akrovv/warehouse/internal/domain"
+)
+
+type purchaseService struct {
+	storage PurchaseStorage
+}
+
+func NewPurchaseService(storage PurchaseStorage) *purchaseService {
+	return &purchaseService{
+		storage: storage,
+	}
+}
+
+func (s *purchaseService) CreateSupplier(ctx context.Context, supplier *domain.Supplier) error {
+	if err := supplier.Validate(); err != nil {
+		return err
+	}
+
+	return s.storage.CreateSupplier(ctx, supplier)
+}
+
+func (s *purchaseService) ListSuppliers(ctx context.Context, f *domain.SupplierFilter) ([]domain.Supplier, error) {
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+
+	return s.storage.ListSuppliers(ctx, f)
+}
+
+func (s *purchaseService) CreateOrder(ctx context.Context, po *domain.PurchaseOrder) error {
+	if err := po.Validate(); err != nil {
+		return err
+	}
+
+	return s.storage.CreateOrder(ctx, po)
+}
+
+func (s *purchaseService) GetOrder(ctx context.Context, gp *domain.GetPurchaseOrder) (*domain.PurchaseOrder, error) {
+	if err := gp.Validate(); err != nil {
+		return nil, err
+	}
+
+	return s.storage.GetOrder(ctx, gp)
+}
+
+func (s *purchaseService) Receive(ctx context.Context, pr *domain.PurchaseReceipt) (*domain.PurchaseOrder, error) {
+	if err := pr.Validate(); err != nil {
+		return nil, err
+	}
+
+	return s.storage.Receive(ctx, pr)
+}
+
+func (s *purchaseService) CloseOrder(ctx context.Context, cp *domain.ClosePurchaseOrder) (*domain.PurchaseOrder, error) {
+	if err := cp.Validate(); err != nil {
+		return nil, err
+	}
+
+	return s.storage.CloseOrder(ctx, cp)
+}
+
+func (s *purchaseService) Outstanding(ctx context.Context, f *domain.OutstandingFilter) ([]domain.OutstandingLine, error) {
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+
+	return s.storage.Outstanding(ctx, f)
+}
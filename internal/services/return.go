@@ -0,0 +1,41 @@
+package services
+
+import (
+	"context"
+
+	"github.com/akrovv/warehouse/internal/domain"
+)
+
+type returnService struct {
+	storage ReturnStorage
+}
+
+func NewReturnService(storage ReturnStorage) *returnService {
+	return &returnService{
+		storage: storage,
+	}
+}
+
+func (s *returnService) Create(ctx context.Context, cr *domain.CreateReturn) (*domain.Return, error) {
+	if err := cr.Validate(); err != nil {
+		return nil, err
+	}
+
+	return s.storage.Create(ctx, cr)
+}
+
+func (s *returnService) Get(ctx context.Context, gr *domain.GetReturn) (*domain.Return, error) {
+	if err := gr.Validate(); err != nil {
+		return nil, err
+	}
+
+	return s.storage.Get(ctx, gr)
+}
+
+func (s *returnService) Receive(ctx context.Context, rr *domain.ReturnReceipt) (*domain.Return, error) {
+	if err := rr.Validate(); err != nil {
+		return nil, err
+	}
+
+	return s.storage.Receive(ctx, rr)
+}
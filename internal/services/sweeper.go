@@ -0,0 +1,54 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/akrovv/warehouse/pkg/logger"
+)
+
+type reservationSweeper struct {
+	storage  ProductStorage
+	interval time.Duration
+	logger   logger.Logger
+}
+
+func NewReservationSweeper(storage ProductStorage, interval time.Duration, logger logger.Logger) *reservationSweeper {
+	return &reservationSweeper{
+		storage:  storage,
+		interval: interval,
+		logger:   logger,
+	}
+}
+
+// Run releases expired reservations every interval until ctx is done.
+func (s *reservationSweeper) Run(ctx context.Context) {
+	if s.interval <= 0 {
+		s.logger.Infof("reservation sweeper is disabled, interval: %s", s.interval)
+		return
+	}
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.sweep(ctx, now)
+		}
+	}
+}
+
+func (s *reservationSweeper) sweep(ctx context.Context, now time.Time) {
+	released, err := s.storage.ReleaseExpired(ctx, now)
+	if err != nil {
+		s.logger.Infof("can't release expired reservations, got error: %s", err.Error())
+		return
+	}
+
+	if len(released) > 0 {
+		s.logger.Infof("released %d expired reservations", len(released))
+	}
+}
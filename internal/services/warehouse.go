@@ -1,6 +1,10 @@
 package services
 
-import "github.com/akrovv/warehouse/internal/domain"
+import (
+	"context"
+
+	"github.com/akrovv/warehouse/internal/domain"
+)
 
 type warehouseService struct {
 	storage WarehouseStorage
@@ -12,10 +16,94 @@ func NewWarehouseService(storage WarehouseStorage) *warehouseService {
 	}
 }
 
-func (s *warehouseService) Create(warehouse *domain.Warehouse) error {
-	return s.storage.Create(warehouse)
+func (s *warehouseService) Create(ctx context.Context, warehouse *domain.Warehouse) error {
+	if err := warehouse.Validate(); err != nil {
+		return err
+	}
+
+	return s.storage.Create(ctx, warehouse)
+}
+
+func (s *warehouseService) Get(ctx context.Context, gw *domain.GetWarehouse) (*domain.Warehouse, error) {
+	if err := gw.Validate(); err != nil {
+		return nil, err
+	}
+
+	return s.storage.Get(ctx, gw)
+}
+
+func (s *warehouseService) List(ctx context.Context, f *domain.WarehouseFilter) ([]domain.Warehouse, error) {
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+
+	return s.storage.List(ctx, f)
+}
+
+func (s *warehouseService) Update(ctx context.Context, uw *domain.UpdateWarehouse) (*domain.Warehouse, error) {
+	if err := uw.Validate(); err != nil {
+		return nil, err
+	}
+
+	return s.storage.Update(ctx, uw)
 }
 
-func (s *warehouseService) GetLeftOvers(gw *domain.GetFromWarehouse) ([]domain.Product, error) {
-	return s.storage.GetLeftOvers(gw)
+func (s *warehouseService) SetAvailability(ctx context.Context, sa *domain.SetAvailability) (*domain.AvailabilityChange, error) {
+	if err := sa.Validate(); err != nil {
+		return nil, err
+	}
+
+	return s.storage.SetAvailability(ctx, sa)
+}
+
+func (s *warehouseService) Decommission(ctx context.Context, d *domain.Decommission) (*domain.DecommissionResult, error) {
+	if err := d.Validate(); err != nil {
+		return nil, err
+	}
+
+	return s.storage.Decommission(ctx, d)
+}
+
+func (s *warehouseService) GetLeftOvers(ctx context.Context, gw *domain.GetFromWarehouse) ([]domain.Product, error) {
+	return s.storage.GetLeftOvers(ctx, gw)
+}
+
+func (s *warehouseService) CreateBin(ctx context.Context, bin *domain.Bin) error {
+	if err := bin.Validate(); err != nil {
+		return err
+	}
+
+	return s.storage.CreateBin(ctx, bin)
+}
+
+func (s *warehouseService) ListBins(ctx context.Context, lb *domain.ListBins) ([]domain.Bin, error) {
+	if err := lb.Validate(); err != nil {
+		return nil, err
+	}
+
+	return s.storage.ListBins(ctx, lb)
+}
+
+func (s *warehouseService) SetThreshold(ctx context.Context, t *domain.StockThreshold) error {
+	if err := t.Validate(); err != nil {
+		return err
+	}
+
+	return s.storage.SetThreshold(ctx, t)
+}
+
+func (s *warehouseService) LowStock(ctx context.Context, f *domain.LowStockFilter) ([]domain.LowStockItem, error) {
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+
+	return s.storage.LowStock(ctx, f)
+}
+
+func (s *warehouseService) LowStockEvents(ctx context.Context, f *domain.LowStockEventFilter) ([]domain.LowStockEvent, error) {
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+
+	return s.storage.LowStockEvents(ctx, f)
 }